@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/kirsle/configdir"
 )
@@ -17,6 +18,73 @@ type Config struct {
 
 type Settings struct {
 	Connections []S3Config `json:"connections"`
+	// TransferWorkers is the number of uploads and downloads that run in
+	// parallel. Zero means the default.
+	TransferWorkers int `json:"transferWorkers,omitempty"`
+	// UploadFilters are the glob patterns last used for folder uploads. Nil
+	// means the defaults.
+	UploadFilters *UploadFilters `json:"uploadFilters,omitempty"`
+	// Sorting holds the object table order per connection name.
+	Sorting map[string]Sorting `json:"sorting,omitempty"`
+	// GridView marks the connections whose objects are shown as thumbnails.
+	GridView map[string]bool `json:"gridView,omitempty"`
+	// Jobs are syncs that run repeatedly while the app is open.
+	Jobs []SyncJob `json:"jobs,omitempty"`
+}
+
+// Job modes say which way a sync job copies files.
+const (
+	JobUpload   = "upload"   // copy new and changed local files to the bucket
+	JobDownload = "download" // copy new and changed objects to the local directory
+	JobMirror   = "mirror"   // make the prefix match the local directory, deleting extraneous objects
+)
+
+// JobModes lists the job modes in the order they are offered.
+var JobModes = []string{JobUpload, JobDownload, JobMirror}
+
+// SyncJob syncs a local directory with a prefix of a bucket of a saved
+// connection every Interval minutes.
+type SyncJob struct {
+	Name       string   `json:"name"`
+	Connection string   `json:"connection"`
+	Bucket     string   `json:"bucket"`
+	Prefix     string   `json:"prefix,omitempty"`
+	LocalDir   string   `json:"localDir"`
+	Mode       string   `json:"mode"`
+	Interval   int      `json:"intervalMinutes"`
+	Enabled    bool     `json:"enabled"`
+	History    []JobRun `json:"history,omitempty"` // newest first
+}
+
+// LastRun returns the most recent run of the job.
+func (j SyncJob) LastRun() (JobRun, bool) {
+	if len(j.History) == 0 {
+		return JobRun{}, false
+	}
+	return j.History[0], true
+}
+
+// JobRun is the outcome of one run of a sync job.
+type JobRun struct {
+	Started  time.Time `json:"started"`
+	Finished time.Time `json:"finished"`
+	Files    int       `json:"files"`
+	Deleted  int       `json:"deleted,omitempty"`
+	Bytes    int64     `json:"bytes"`
+	Errors   []string  `json:"errors,omitempty"`
+	Canceled bool      `json:"canceled,omitempty"`
+}
+
+// Sorting is the column the object table is sorted by and its direction.
+type Sorting struct {
+	Column     string `json:"column"`
+	Descending bool   `json:"descending,omitempty"`
+}
+
+// UploadFilters select the files of a folder upload.
+type UploadFilters struct {
+	Include []string `json:"include,omitempty"`
+	Exclude []string `json:"exclude,omitempty"`
 }
 
 func New() (*Config, error) {
@@ -48,7 +116,9 @@ func New() (*Config, error) {
 		if err := json.NewDecoder(f).Decode(&stored); err != nil {
 			return cfg, err
 		}
-		cfg.Settings.Connections = append(cfg.Settings.Connections, stored.Connections...)
+		transient := cfg.Settings.Connections
+		cfg.Settings = stored
+		cfg.Settings.Connections = append(transient, stored.Connections...)
 	}
 
 	if cfg.loadSecrets() {
@@ -121,5 +191,7 @@ func (c *Config) Save() error {
 		}
 	}
 
-	return json.NewEncoder(f).Encode(Settings{Connections: serialized})
+	settings := c.Settings
+	settings.Connections = serialized
+	return json.NewEncoder(f).Encode(settings)
 }
@@ -22,7 +22,15 @@ func TestSaveFiltersTransientAndRoundTrips(t *testing.T) {
 	c := &Config{
 		filepath: settingsPath,
 		Settings: Settings{
-			Connections: []S3Config{normal1, transient, normal2},
+			Connections:     []S3Config{normal1, transient, normal2},
+			TransferWorkers: 5,
+			UploadFilters:   &UploadFilters{Exclude: []string{"node_modules"}},
+			Sorting:         map[string]Sorting{"prod": {Column: "size", Descending: true}},
+			Jobs: []SyncJob{{
+				Name: "exports", Connection: "prod", Bucket: "my-bucket", Prefix: "exports/", LocalDir: "~/exports",
+				Mode: JobMirror, Interval: 30, Enabled: true,
+				History: []JobRun{{Files: 3, Bytes: 1024, Errors: []string{"a.csv: denied"}}},
+			}},
 		},
 	}
 
@@ -58,6 +66,21 @@ func TestSaveFiltersTransientAndRoundTrips(t *testing.T) {
 	if loaded.Connections[1].Endpoint != normal2.Endpoint {
 		t.Errorf("Connections[1].Endpoint = %q, want %q", loaded.Connections[1].Endpoint, normal2.Endpoint)
 	}
+	if loaded.TransferWorkers != 5 {
+		t.Errorf("TransferWorkers = %d, want 5", loaded.TransferWorkers)
+	}
+	if loaded.UploadFilters == nil || len(loaded.UploadFilters.Exclude) != 1 || loaded.UploadFilters.Exclude[0] != "node_modules" {
+		t.Errorf("UploadFilters = %+v, want exclude node_modules", loaded.UploadFilters)
+	}
+	if got := loaded.Sorting["prod"]; got.Column != "size" || !got.Descending {
+		t.Errorf("Sorting[prod] = %+v, want size descending", got)
+	}
+	if len(loaded.Jobs) != 1 || loaded.Jobs[0].Interval != 30 || loaded.Jobs[0].Mode != JobMirror {
+		t.Fatalf("Jobs = %+v, want the mirror job", loaded.Jobs)
+	}
+	if run, ok := loaded.Jobs[0].LastRun(); !ok || run.Files != 3 || len(run.Errors) != 1 {
+		t.Errorf("LastRun() = %+v, %v", run, ok)
+	}
 }
 
 func TestSaveMovesSecretToKeychain(t *testing.T) {
@@ -168,3 +191,16 @@ func TestSavePermissions(t *testing.T) {
 		}
 	}
 }
+
+func TestTrashFolder(t *testing.T) {
+	for prefix, want := range map[string]string{
+		"":           DefaultTrashPrefix,
+		" / ":        DefaultTrashPrefix,
+		"deleted":    "deleted/",
+		"/bin/old/ ": "bin/old/",
+	} {
+		if got := (S3Config{TrashPrefix: prefix}).TrashFolder(); got != want {
+			t.Errorf("TrashFolder() with prefix %q = %q, want %q", prefix, got, want)
+		}
+	}
+}
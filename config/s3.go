@@ -1,6 +1,8 @@
 package config
 
 import (
+	"strings"
+
 	"github.com/pteich/configstruct"
 )
 
@@ -15,6 +17,23 @@ type S3Config struct {
 	Prefix    string `json:"prefix" cli:"prefix" env:"PREFIX"`
 	Region    string `json:"region" cli:"region" env:"REGION"`
 	UseSSL    bool   `json:"usessl" cli:"usessl" env:"USE_SSL"`
+	// Trash moves deleted objects below TrashPrefix, or keeps them as
+	// versions in versioned buckets, instead of deleting them for good.
+	Trash       bool   `json:"trash,omitempty"`
+	TrashPrefix string `json:"trashPrefix,omitempty"`
+}
+
+// DefaultTrashPrefix is the trash folder of connections that do not set one.
+const DefaultTrashPrefix = ".trash/"
+
+// TrashFolder returns the prefix deleted objects are moved to in trash mode,
+// always ending with a slash.
+func (c S3Config) TrashFolder() string {
+	prefix := strings.Trim(strings.TrimSpace(c.TrashPrefix), "/")
+	if prefix == "" {
+		return DefaultTrashPrefix
+	}
+	return prefix + "/"
 }
 
 func NewS3Config() (S3Config, error) {
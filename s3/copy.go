@@ -0,0 +1,79 @@
+package s3
+
+import (
+	"context"
+	"net/url"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/s3utils"
+)
+
+const (
+	// maxCopySize is the largest object S3 copies in a single request.
+	// ComposeObject copies larger objects in parts and drops their content
+	// headers on the way.
+	maxCopySize = 5 << 30
+	// copyPartSize keeps objects of the maximum size of 5 TiB below the
+	// limit of 10000 parts.
+	copyPartSize = 1 << 30
+)
+
+// copyObject copies src to dstObject in dstBucket on the server, keeping
+// its headers, metadata and tags. Objects up to 5 GiB take a single request
+// that copies all of them; larger ones are copied in parts with the
+// properties read from the source.
+func (s *Service) copyObject(ctx context.Context, src minio.CopySrcOptions, dstBucket, dstObject string) error {
+	info, err := s.client.StatObject(ctx, src.Bucket, src.Object, minio.StatObjectOptions{VersionID: src.VersionID})
+	if err != nil {
+		return err
+	}
+	if info.Size <= maxCopySize {
+		_, err = s.client.CopyObject(ctx, minio.CopyDestOptions{Bucket: dstBucket, Object: dstObject}, src)
+		return err
+	}
+
+	props, err := s.objectProperties(ctx, src.Bucket, src.Object, src.VersionID)
+	if err != nil {
+		return err
+	}
+	src.MatchETag = props.Info.ETag
+	return s.copyInParts(ctx, src, props.Info.Size, dstBucket, dstObject, props)
+}
+
+// copyInParts copies size bytes of src to dstObject in dstBucket with a
+// multipart upload whose parts are copied on the server. The copy gets the
+// headers, user metadata, tags and storage class in props.
+func (s *Service) copyInParts(ctx context.Context, src minio.CopySrcOptions, size int64, dstBucket, dstObject string, props ObjectProperties) error {
+	core := s.core()
+	uploadID, err := core.NewMultipartUpload(ctx, dstBucket, dstObject, putOptions(props))
+	if err != nil {
+		return err
+	}
+	abort := func(err error) error {
+		_ = core.AbortMultipartUpload(context.WithoutCancel(ctx), dstBucket, dstObject, uploadID)
+		return err
+	}
+
+	// CopyObjectPart names no version and checks no ETag on its own.
+	source := s3utils.EncodePath(src.Bucket + "/" + src.Object)
+	if src.VersionID != "" {
+		source += "?versionId=" + url.QueryEscape(src.VersionID)
+	}
+	header := map[string]string{"x-amz-copy-source": source}
+	if src.MatchETag != "" {
+		header["x-amz-copy-source-if-match"] = src.MatchETag
+	}
+
+	var parts []minio.CompletePart
+	for offset, number := int64(0), 1; offset < size; offset, number = offset+copyPartSize, number+1 {
+		part, err := core.CopyObjectPart(ctx, src.Bucket, src.Object, dstBucket, dstObject, uploadID, number, offset, min(copyPartSize, size-offset), header)
+		if err != nil {
+			return abort(err)
+		}
+		parts = append(parts, part)
+	}
+	if _, err := core.CompleteMultipartUpload(ctx, dstBucket, dstObject, uploadID, parts, minio.PutObjectOptions{}); err != nil {
+		return abort(err)
+	}
+	return nil
+}
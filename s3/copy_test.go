@@ -0,0 +1,131 @@
+package s3
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/pteich/us3ui/config"
+)
+
+// fakeS3 answers the requests of a multipart copy of a single large object
+// and records them.
+type fakeS3 struct {
+	size int64
+
+	mu       sync.Mutex
+	initiate http.Header
+	parts    []http.Header
+	complete bool
+}
+
+func (f *fakeS3) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	query := r.URL.Query()
+	switch {
+	case r.Method == http.MethodHead:
+		w.Header().Set("Content-Length", fmt.Sprint(f.size))
+		w.Header().Set("Content-Type", "video/mp4")
+		w.Header().Set("ETag", `"d41d8cd98f00b204e9800998ecf8427e-640"`)
+		w.Header().Set("Last-Modified", "Sat, 17 Oct 2026 12:00:00 GMT")
+		w.Header().Set("Cache-Control", "max-age=60")
+		w.Header().Set("X-Amz-Meta-Owner", "video")
+	case r.Method == http.MethodGet && query.Has("tagging"):
+		fmt.Fprint(w, `<Tagging><TagSet><Tag><Key>team</Key><Value>video</Value></Tag></TagSet></Tagging>`)
+	case r.Method == http.MethodPost && query.Has("uploads"):
+		f.initiate = r.Header.Clone()
+		fmt.Fprint(w, `<InitiateMultipartUploadResult><Bucket>bucket</Bucket><Key>video.mp4</Key><UploadId>upload-1</UploadId></InitiateMultipartUploadResult>`)
+	case r.Method == http.MethodPut && query.Get("uploadId") == "upload-1":
+		f.parts = append(f.parts, r.Header.Clone())
+		fmt.Fprintf(w, `<CopyPartResult><LastModified>2026-10-17T12:00:00.000Z</LastModified><ETag>"part-%s"</ETag></CopyPartResult>`, query.Get("partNumber"))
+	case r.Method == http.MethodPost && query.Get("uploadId") == "upload-1":
+		f.complete = true
+		fmt.Fprint(w, `<CompleteMultipartUploadResult><Bucket>bucket</Bucket><Key>video.mp4</Key><ETag>"done-6"</ETag></CompleteMultipartUploadResult>`)
+	default:
+		http.Error(w, "unexpected request "+r.Method+" "+r.URL.String(), http.StatusBadRequest)
+	}
+}
+
+func newFakeS3Service(t *testing.T, fake *fakeS3) *Service {
+	t.Helper()
+	server := httptest.NewServer(fake)
+	t.Cleanup(server.Close)
+
+	s, err := New(config.S3Config{
+		Endpoint:  strings.TrimPrefix(server.URL, "http://"),
+		Region:    "us-east-1",
+		AccessKey: "key",
+		SecretKey: "secret",
+		Bucket:    "bucket",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestUpdateObjectPropertiesCopiesLargeObjectsInParts(t *testing.T) {
+	fake := &fakeS3{size: 6<<30 + 1}
+	s := newFakeS3Service(t, fake)
+
+	headers := ObjectHeaders{ContentType: "video/mp4", CacheControl: "max-age=3600", ContentDisposition: "inline"}
+	err := s.UpdateObjectProperties(context.Background(), "video.mp4", headers, map[string]string{"owner": "cdn"}, map[string]string{"team": "video"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if !fake.complete {
+		t.Fatalf("the upload was not completed")
+	}
+	for name, want := range map[string]string{
+		"Content-Type":        "video/mp4",
+		"Cache-Control":       "max-age=3600",
+		"Content-Disposition": "inline",
+		"X-Amz-Meta-Owner":    "cdn",
+		"X-Amz-Tagging":       "team=video",
+	} {
+		if got := fake.initiate.Get(name); got != want {
+			t.Errorf("upload started with %s = %q, want %q", name, got, want)
+		}
+	}
+	if len(fake.parts) != 7 {
+		t.Fatalf("copied %d parts, want 7", len(fake.parts))
+	}
+	last := fake.parts[len(fake.parts)-1]
+	if got, want := last.Get("X-Amz-Copy-Source-Range"), "bytes=6442450944-6442450944"; got != want {
+		t.Errorf("last part range = %q, want %q", got, want)
+	}
+	if got := last.Get("X-Amz-Copy-Source-If-Match"); got != "d41d8cd98f00b204e9800998ecf8427e-640" {
+		t.Errorf("parts copied without the ETag check: %q", got)
+	}
+}
+
+func TestCopyLargeObjectKeepsHeaders(t *testing.T) {
+	fake := &fakeS3{size: 6 << 30}
+	s := newFakeS3Service(t, fake)
+
+	if err := s.RestoreObjectVersion(context.Background(), "video.mp4", "v1"); err != nil {
+		t.Fatal(err)
+	}
+	for name, want := range map[string]string{
+		"Content-Type":     "video/mp4",
+		"Cache-Control":    "max-age=60",
+		"X-Amz-Meta-Owner": "video",
+		"X-Amz-Tagging":    "team=video",
+	} {
+		if got := fake.initiate.Get(name); got != want {
+			t.Errorf("upload started with %s = %q, want %q", name, got, want)
+		}
+	}
+	if len(fake.parts) != 6 || !fake.complete {
+		t.Fatalf("copied %d parts, completed %v", len(fake.parts), fake.complete)
+	}
+	if got := fake.parts[0].Get("X-Amz-Copy-Source"); got != "bucket/video.mp4?versionId=v1" {
+		t.Errorf("copy source = %q", got)
+	}
+}
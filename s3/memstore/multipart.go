@@ -0,0 +1,156 @@
+package memstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"maps"
+	"sort"
+
+	"github.com/minio/minio-go/v7"
+
+	"github.com/pteich/us3ui/s3"
+)
+
+func (s *Store) NewMultipartUpload(ctx context.Context, objectName string, mimeType string) (string, error) {
+	return s.NewMultipartUploadWithProperties(ctx, objectName, s3.ObjectProperties{Headers: s3.ObjectHeaders{ContentType: mimeType}})
+}
+
+func (s *Store) NewMultipartUploadWithProperties(ctx context.Context, objectName string, props s3.ObjectProperties) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.currentBucket(); err != nil {
+		return "", err
+	}
+	s.nextID++
+	id := fmt.Sprintf("upload-%d", s.nextID)
+	s.uploads[id] = &upload{key: objectName, props: props, parts: make(map[int]*object)}
+	return id, nil
+}
+
+func (s *Store) UploadPart(ctx context.Context, objectName, uploadID string, partNumber int, r io.Reader, size int64) (s3.Part, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return s3.Part{}, err
+	}
+	if err := ctx.Err(); err != nil {
+		return s3.Part{}, err
+	}
+	if int64(len(data)) != size {
+		return s3.Part{}, fmt.Errorf("short part %d for %s: got %d bytes, want %d", partNumber, objectName, len(data), size)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, err := s.uploadLocked(objectName, uploadID)
+	if err != nil {
+		return s3.Part{}, err
+	}
+	part := s.newObject(data, "")
+	u.parts[partNumber] = part
+	return s3.Part{Number: partNumber, ETag: part.etag, Size: size}, nil
+}
+
+func (s *Store) ListParts(ctx context.Context, objectName, uploadID string) ([]s3.Part, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, err := s.uploadLocked(objectName, uploadID)
+	if err != nil {
+		return nil, err
+	}
+	parts := make([]s3.Part, 0, len(u.parts))
+	for number, part := range u.parts {
+		parts = append(parts, s3.Part{Number: number, ETag: part.etag, Size: int64(len(part.data))})
+	}
+	sort.Slice(parts, func(i, j int) bool {
+		return parts[i].Number < parts[j].Number
+	})
+	return parts, nil
+}
+
+func (s *Store) CompleteMultipartUpload(ctx context.Context, objectName, uploadID string, parts []s3.Part) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	u, err := s.uploadLocked(objectName, uploadID)
+	if err != nil {
+		return err
+	}
+
+	sorted := make([]s3.Part, len(parts))
+	copy(sorted, parts)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Number < sorted[j].Number
+	})
+
+	var data bytes.Buffer
+	etags := md5.New()
+	for _, p := range sorted {
+		stored, ok := u.parts[p.Number]
+		if !ok || stored.etag != p.ETag {
+			return minio.ErrorResponse{Code: "InvalidPart", Key: objectName, Message: fmt.Sprintf("part %d not found or etag mismatch", p.Number)}
+		}
+		data.Write(stored.data)
+		raw, _ := hex.DecodeString(stored.etag)
+		etags.Write(raw)
+	}
+
+	completed := s.newObject(data.Bytes(), u.props.Headers.ContentType)
+	completed.headers = u.props.Headers
+	completed.metadata = maps.Clone(u.props.Metadata)
+	completed.tags = maps.Clone(u.props.Tags)
+	// Mirror the S3 multipart ETag format so callers can tell both kinds apart.
+	completed.etag = fmt.Sprintf("%s-%d", hex.EncodeToString(etags.Sum(nil)), len(sorted))
+	b, err := s.currentBucket()
+	if err != nil {
+		return err
+	}
+	s.writeLocked(b, objectName, completed)
+	delete(s.uploads, uploadID)
+	return nil
+}
+
+func (s *Store) AbortMultipartUpload(ctx context.Context, objectName, uploadID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, err := s.uploadLocked(objectName, uploadID); err != nil {
+		return err
+	}
+	delete(s.uploads, uploadID)
+	return nil
+}
+
+// PendingUploads returns the number of multipart uploads that were started but
+// neither completed nor aborted.
+func (s *Store) PendingUploads() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.uploads)
+}
+
+func (s *Store) uploadLocked(objectName, uploadID string) (*upload, error) {
+	u, ok := s.uploads[uploadID]
+	if !ok || u.key != objectName {
+		return nil, minio.ErrorResponse{Code: "NoSuchUpload", Key: objectName, Message: "upload does not exist"}
+	}
+	return u, nil
+}
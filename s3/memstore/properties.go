@@ -0,0 +1,113 @@
+package memstore
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"maps"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/tags"
+
+	"github.com/pteich/us3ui/s3"
+)
+
+func (s *Store) GetObjectProperties(ctx context.Context, objectName string) (s3.ObjectProperties, error) {
+	if err := ctx.Err(); err != nil {
+		return s3.ObjectProperties{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, err := s.currentBucket()
+	if err != nil {
+		return s3.ObjectProperties{}, err
+	}
+	obj, ok := b.objects[objectName]
+	if !ok {
+		return s3.ObjectProperties{}, noSuchKey(objectName)
+	}
+
+	props := s3.ObjectProperties{
+		Info:     objectInfo(objectName, obj),
+		Headers:  obj.headers,
+		Metadata: maps.Clone(obj.metadata),
+		Tags:     maps.Clone(obj.tags),
+	}
+	if props.Metadata == nil {
+		props.Metadata = map[string]string{}
+	}
+	if props.Tags == nil {
+		props.Tags = map[string]string{}
+	}
+	return props, nil
+}
+
+func (s *Store) UpdateObjectProperties(ctx context.Context, objectName string, headers s3.ObjectHeaders, metadata, objectTags map[string]string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if _, err := tags.NewTags(objectTags, true); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, err := s.currentBucket()
+	if err != nil {
+		return err
+	}
+	obj, ok := b.objects[objectName]
+	if !ok {
+		return noSuchKey(objectName)
+	}
+
+	// Like a copy in place, the result is a new object with the same data.
+	updated := s.newObject(bytes.Clone(obj.data), headers.ContentType)
+	updated.etag = obj.etag
+	updated.headers = headers
+	updated.metadata = maps.Clone(metadata)
+	updated.tags = maps.Clone(objectTags)
+	s.writeLocked(b, objectName, updated)
+	return nil
+}
+
+func (s *Store) ReplaceObject(ctx context.Context, objectName string, r io.Reader, length int64, props s3.ObjectProperties, matchETag string) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if length >= 0 && int64(len(data)) != length {
+		return fmt.Errorf("short upload for %s: got %d bytes, want %d", objectName, len(data), length)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, err := s.currentBucket()
+	if err != nil {
+		return err
+	}
+	if matchETag != "" {
+		obj, ok := b.objects[objectName]
+		if !ok {
+			return noSuchKey(objectName)
+		}
+		if obj.etag != matchETag {
+			return minio.ErrorResponse{Code: "PreconditionFailed", Key: objectName, Message: "etag does not match", StatusCode: 412}
+		}
+	}
+
+	replaced := s.newObject(data, props.Headers.ContentType)
+	replaced.headers = props.Headers
+	replaced.metadata = maps.Clone(props.Metadata)
+	replaced.tags = maps.Clone(props.Tags)
+	s.writeLocked(b, objectName, replaced)
+	return nil
+}
@@ -0,0 +1,462 @@
+// Package memstore provides an in-memory s3.ObjectStore for tests and demo
+// mode. It mimics the S3 semantics the UI relies on (lexically ordered
+// listings, StartAfter paging, idempotent deletes) without any network access.
+package memstore
+
+import (
+	"bytes"
+	"context"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+
+	"github.com/pteich/us3ui/s3"
+)
+
+type object struct {
+	data         []byte
+	headers      s3.ObjectHeaders
+	metadata     map[string]string
+	tags         map[string]string
+	modified     time.Time
+	etag         string
+	versionID    string
+	deleteMarker bool
+}
+
+type bucket struct {
+	created    time.Time
+	objects    map[string]*object // latest version of every live key
+	versioning string
+	versions   map[string][]*object // newest first, once versioning was enabled
+}
+
+type upload struct {
+	key   string
+	props s3.ObjectProperties // headers, metadata and tags of the object
+	parts map[int]*object
+}
+
+// Store is an in-memory object store bound to a single bucket, like
+// s3.Service. It is safe for concurrent use.
+type Store struct {
+	mu         sync.Mutex
+	bucketName string
+	buckets    map[string]*bucket
+	uploads    map[string]*upload
+	nextID     int
+	now        func() time.Time
+}
+
+var _ s3.ObjectStore = (*Store)(nil)
+
+// New creates a store with an empty bucket named bucketName.
+func New(bucketName string) *Store {
+	s := &Store{
+		bucketName: bucketName,
+		buckets:    make(map[string]*bucket),
+		uploads:    make(map[string]*upload),
+		now:        time.Now,
+	}
+	s.buckets[bucketName] = &bucket{created: s.now(), objects: make(map[string]*object)}
+	return s
+}
+
+// Put stores data under key in the current bucket. It is meant for seeding
+// test and demo content.
+func (s *Store) Put(key string, data []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.putLocked(key, data, "application/octet-stream")
+}
+
+// PutModified stores data under key like Put, last modified at modified.
+func (s *Store) PutModified(key string, data []byte, modified time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.putLocked(key, data, "application/octet-stream")
+	s.buckets[s.bucketName].objects[key].modified = modified
+}
+
+// Get returns the content stored under key in the current bucket.
+func (s *Store) Get(key string) ([]byte, bool) {
+	return s.GetFromBucket(s.bucketName, key)
+}
+
+// GetFromBucket returns the content stored under key in bucketName.
+func (s *Store) GetFromBucket(bucketName, key string) ([]byte, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.buckets[bucketName]
+	if !ok {
+		return nil, false
+	}
+	obj, ok := b.objects[key]
+	if !ok {
+		return nil, false
+	}
+	return bytes.Clone(obj.data), true
+}
+
+func (s *Store) putLocked(key string, data []byte, contentType string) {
+	b, ok := s.buckets[s.bucketName]
+	if !ok {
+		b = &bucket{created: s.now(), objects: make(map[string]*object)}
+		s.buckets[s.bucketName] = b
+	}
+	s.writeLocked(b, key, s.newObject(data, contentType))
+}
+
+func (s *Store) newObject(data []byte, contentType string) *object {
+	sum := md5.Sum(data)
+	return &object{
+		data:     data,
+		headers:  s3.ObjectHeaders{ContentType: contentType},
+		modified: s.now(),
+		etag:     hex.EncodeToString(sum[:]),
+	}
+}
+
+func (s *Store) currentBucket() (*bucket, error) {
+	b, ok := s.buckets[s.bucketName]
+	if !ok {
+		return nil, noSuchBucket(s.bucketName)
+	}
+	return b, nil
+}
+
+func (s *Store) ListObjectsBatch(ctx context.Context, startAfter, prefix string, batchSize int) ([]minio.ObjectInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, err := s.currentBucket()
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make([]string, 0, len(b.objects))
+	for key := range b.objects {
+		if strings.HasPrefix(key, prefix) && key > startAfter {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	if batchSize > 0 && len(keys) > batchSize {
+		keys = keys[:batchSize]
+	}
+
+	objects := make([]minio.ObjectInfo, 0, len(keys))
+	for _, key := range keys {
+		objects = append(objects, objectInfo(key, b.objects[key]))
+	}
+	return objects, nil
+}
+
+func (s *Store) ListFolderBatch(ctx context.Context, startAfter, prefix string, batchSize int) ([]minio.ObjectInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, err := s.currentBucket()
+	if err != nil {
+		return nil, err
+	}
+
+	startAfter = s3.FolderStartAfter(prefix, startAfter)
+	keys := make([]string, 0, len(b.objects))
+	for key := range b.objects {
+		if strings.HasPrefix(key, prefix) && key > startAfter {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	// Keys below a subfolder collapse into one common prefix entry, like S3
+	// does for a delimiter listing.
+	entries := make([]minio.ObjectInfo, 0)
+	for _, key := range keys {
+		if batchSize > 0 && len(entries) >= batchSize {
+			break
+		}
+		rest := key[len(prefix):]
+		if i := strings.Index(rest, s3.Delimiter); i >= 0 {
+			folder := prefix + rest[:i+1]
+			if n := len(entries); n > 0 && entries[n-1].Key == folder {
+				continue
+			}
+			entries = append(entries, minio.ObjectInfo{Key: folder})
+			continue
+		}
+		entries = append(entries, objectInfo(key, b.objects[key]))
+	}
+	return entries, nil
+}
+
+func (s *Store) UploadObjectReader(ctx context.Context, filePath string, objectName string, r io.Reader, length int64, mimeType string) error {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if length >= 0 && int64(len(data)) != length {
+		return fmt.Errorf("short upload for %s: got %d bytes, want %d", objectName, len(data), length)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.currentBucket(); err != nil {
+		return err
+	}
+	s.putLocked(objectName, data, mimeType)
+	return nil
+}
+
+func (s *Store) DownloadObject(ctx context.Context, objectName string) (io.ReadCloser, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, err := s.currentBucket()
+	if err != nil {
+		return nil, err
+	}
+	obj, ok := b.objects[objectName]
+	if !ok {
+		return nil, noSuchKey(objectName)
+	}
+	return io.NopCloser(bytes.NewReader(obj.data)), nil
+}
+
+func (s *Store) DownloadObjectRange(ctx context.Context, objectName, etag string, offset, length int64) (io.ReadCloser, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, err := s.currentBucket()
+	if err != nil {
+		return nil, err
+	}
+	obj, ok := b.objects[objectName]
+	if !ok {
+		return nil, noSuchKey(objectName)
+	}
+	if etag != "" && etag != obj.etag {
+		return nil, minio.ErrorResponse{Code: "PreconditionFailed", Key: objectName, Message: "etag does not match", StatusCode: 412}
+	}
+
+	// Like S3, a range that starts at or after the end is not satisfiable,
+	// which includes every range of an empty object.
+	size := int64(len(obj.data))
+	ranged := offset > 0 || length > 0
+	if offset < 0 || ranged && offset >= size {
+		return nil, minio.ErrorResponse{Code: "InvalidRange", Key: objectName, Message: "requested range is not satisfiable", StatusCode: 416}
+	}
+	end := size
+	if length > 0 && offset+length < size {
+		end = offset + length
+	}
+	return io.NopCloser(bytes.NewReader(obj.data[offset:end])), nil
+}
+
+func (s *Store) StatObject(ctx context.Context, objectName string) (minio.ObjectInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return minio.ObjectInfo{}, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, err := s.currentBucket()
+	if err != nil {
+		return minio.ObjectInfo{}, err
+	}
+	obj, ok := b.objects[objectName]
+	if !ok {
+		return minio.ObjectInfo{}, noSuchKey(objectName)
+	}
+	return objectInfo(objectName, obj), nil
+}
+
+func (s *Store) DeleteObject(ctx context.Context, objectName string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, err := s.currentBucket()
+	if err != nil {
+		return err
+	}
+	if b.versioning != s3.VersioningOff {
+		s.writeLocked(b, objectName, &object{deleteMarker: true, modified: s.now()})
+		return nil
+	}
+	// Like S3, deleting a missing key is not an error.
+	delete(b.objects, objectName)
+	return nil
+}
+
+// DeleteObjects removes each of objectNames like DeleteObject.
+func (s *Store) DeleteObjects(ctx context.Context, objectNames []string) []s3.DeleteError {
+	var failed []s3.DeleteError
+	for _, name := range objectNames {
+		if err := s.DeleteObject(ctx, name); err != nil {
+			failed = append(failed, s3.DeleteError{Key: name, Err: err})
+		}
+	}
+	return failed
+}
+
+func (s *Store) CopyObject(ctx context.Context, srcObject, dstObject string) error {
+	return s.CopyObjectToBucket(ctx, srcObject, s.bucketName, dstObject)
+}
+
+func (s *Store) CopyObjectToBucket(ctx context.Context, srcObject, dstBucket, dstObject string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, err := s.currentBucket()
+	if err != nil {
+		return err
+	}
+	src, ok := b.objects[srcObject]
+	if !ok {
+		return noSuchKey(srcObject)
+	}
+	dstB, ok := s.buckets[dstBucket]
+	if !ok {
+		return noSuchBucket(dstBucket)
+	}
+	dst := s.newObject(bytes.Clone(src.data), src.headers.ContentType)
+	dst.etag = src.etag
+	dst.headers = src.headers
+	dst.metadata = maps.Clone(src.metadata)
+	dst.tags = maps.Clone(src.tags)
+	s.writeLocked(dstB, dstObject, dst)
+	return nil
+}
+
+// PresignURL returns a fake link that carries the method, expiry and
+// response overrides in its query.
+func (s *Store) PresignURL(ctx context.Context, objectName string, opts s3.PresignOptions) (*url.URL, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	u := &url.URL{
+		Scheme: "memory",
+		Host:   s.bucketName,
+		Path:   "/" + objectName,
+	}
+	q := url.Values{}
+	if opts.Method == http.MethodGet {
+		q = opts.ResponseParams()
+	}
+	q.Set("X-Amz-Expires", fmt.Sprintf("%d", int64(opts.Expires.Seconds())))
+	q.Set("X-Method", opts.Method)
+	u.RawQuery = q.Encode()
+	return u, nil
+}
+
+// PresignPost returns a fake form whose policy field holds the unsigned
+// policy in base64.
+func (s *Store) PresignPost(ctx context.Context, opts s3.PostPolicyOptions) (*url.URL, map[string]string, error) {
+	policy, err := opts.Policy(s.bucketName, time.Now())
+	if err != nil {
+		return nil, nil, err
+	}
+	fields := opts.FormFields()
+	fields["bucket"] = s.bucketName
+	fields["policy"] = base64.StdEncoding.EncodeToString([]byte(policy.String()))
+	fields["x-amz-signature"] = "memory"
+	return &url.URL{Scheme: "memory", Host: s.bucketName, Path: "/"}, fields, nil
+}
+
+func (s *Store) ListBuckets(ctx context.Context) ([]minio.BucketInfo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	buckets := make([]minio.BucketInfo, 0, len(s.buckets))
+	for name, b := range s.buckets {
+		buckets = append(buckets, minio.BucketInfo{Name: name, CreationDate: b.created})
+	}
+	sort.Slice(buckets, func(i, j int) bool {
+		return buckets[i].Name < buckets[j].Name
+	})
+	return buckets, nil
+}
+
+func (s *Store) CreateBucket(ctx context.Context, bucketName string, region string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.buckets[bucketName]; ok {
+		return minio.ErrorResponse{Code: "BucketAlreadyOwnedByYou", BucketName: bucketName, Message: "bucket already exists"}
+	}
+	s.buckets[bucketName] = &bucket{created: s.now(), objects: make(map[string]*object)}
+	return nil
+}
+
+func (s *Store) DeleteBucket(ctx context.Context, bucketName string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.buckets[bucketName]
+	if !ok {
+		return noSuchBucket(bucketName)
+	}
+	if len(b.objects) > 0 || len(b.versions) > 0 {
+		return minio.ErrorResponse{Code: "BucketNotEmpty", BucketName: bucketName, Message: "bucket is not empty"}
+	}
+	delete(s.buckets, bucketName)
+	return nil
+}
+
+func objectInfo(key string, obj *object) minio.ObjectInfo {
+	return minio.ObjectInfo{
+		Key:          key,
+		Size:         int64(len(obj.data)),
+		ETag:         obj.etag,
+		ContentType:  obj.headers.ContentType,
+		LastModified: obj.modified,
+	}
+}
+
+func noSuchBucket(name string) error {
+	return minio.ErrorResponse{Code: "NoSuchBucket", BucketName: name, Message: "bucket does not exist"}
+}
+
+func noSuchKey(key string) error {
+	return minio.ErrorResponse{Code: "NoSuchKey", Key: key, Message: "key does not exist"}
+}
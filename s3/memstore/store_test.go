@@ -0,0 +1,431 @@
+package memstore
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+
+	"github.com/pteich/us3ui/s3"
+)
+
+func TestListObjectsBatchPagesInKeyOrder(t *testing.T) {
+	s := New("bucket")
+	for _, key := range []string{"b/2", "a/1", "b/1", "c"} {
+		s.Put(key, []byte(key))
+	}
+	ctx := context.Background()
+
+	first, err := s.ListObjectsBatch(ctx, "", "", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first) != 2 || first[0].Key != "a/1" || first[1].Key != "b/1" {
+		t.Fatalf("first batch = %v", first)
+	}
+
+	rest, err := s.ListObjectsBatch(ctx, first[1].Key, "", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rest) != 2 || rest[0].Key != "b/2" || rest[1].Key != "c" {
+		t.Fatalf("second batch = %v", rest)
+	}
+
+	prefixed, err := s.ListObjectsBatch(ctx, "", "b/", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(prefixed) != 2 {
+		t.Fatalf("prefixed batch = %v, want 2 objects", prefixed)
+	}
+}
+
+func TestListFolderBatchGroupsSubfolders(t *testing.T) {
+	s := New("bucket")
+	for _, key := range []string{"a/1", "a/b/2", "a/b/3", "a/c/4", "a/d", "e"} {
+		s.Put(key, []byte(key))
+	}
+	ctx := context.Background()
+
+	first, err := s.ListFolderBatch(ctx, "", "a/", 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(first) != 2 || first[0].Key != "a/1" || first[1].Key != "a/b/" || !s3.IsFolder(first[1]) {
+		t.Fatalf("first batch = %v", first)
+	}
+
+	rest, err := s.ListFolderBatch(ctx, first[1].Key, "a/", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rest) != 2 || rest[0].Key != "a/c/" || rest[1].Key != "a/d" || s3.IsFolder(rest[1]) {
+		t.Fatalf("second batch = %v", rest)
+	}
+
+	root, err := s.ListFolderBatch(ctx, "", "", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(root) != 2 || root[0].Key != "a/" || root[1].Key != "e" {
+		t.Fatalf("root listing = %v", root)
+	}
+}
+
+func TestUploadDownloadDelete(t *testing.T) {
+	s := New("bucket")
+	ctx := context.Background()
+	content := []byte("hello world")
+
+	if err := s.UploadObjectReader(ctx, "", "greeting.txt", bytes.NewReader(content), int64(len(content)), "text/plain"); err != nil {
+		t.Fatal(err)
+	}
+
+	rc, err := s.DownloadObject(ctx, "greeting.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, content) {
+		t.Errorf("downloaded %q, want %q", got, content)
+	}
+
+	if err := s.DeleteObject(ctx, "greeting.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.DownloadObject(ctx, "greeting.txt"); err == nil {
+		t.Errorf("DownloadObject after delete returned no error")
+	}
+	if err := s.DeleteObject(ctx, "greeting.txt"); err != nil {
+		t.Errorf("deleting a missing key returned %v, want nil", err)
+	}
+}
+
+func TestDownloadObjectRange(t *testing.T) {
+	s := New("bucket")
+	ctx := context.Background()
+	s.Put("digits", []byte("0123456789"))
+	s.Put("empty", nil)
+
+	for _, tt := range []struct {
+		key            string
+		offset, length int64
+		want           string
+	}{
+		{"digits", 2, 3, "234"},
+		{"digits", 8, 5, "89"},
+		{"digits", 4, 0, "456789"},
+		{"digits", 0, 0, "0123456789"},
+		{"empty", 0, 0, ""},
+	} {
+		rc, err := s.DownloadObjectRange(ctx, tt.key, "", tt.offset, tt.length)
+		if err != nil {
+			t.Errorf("range %d+%d of %s: %v", tt.offset, tt.length, tt.key, err)
+			continue
+		}
+		got, _ := io.ReadAll(rc)
+		rc.Close()
+		if string(got) != tt.want {
+			t.Errorf("range %d+%d of %s = %q, want %q", tt.offset, tt.length, tt.key, got, tt.want)
+		}
+	}
+
+	for _, tt := range []struct {
+		key            string
+		offset, length int64
+	}{
+		{"digits", 10, 1},
+		{"digits", 10, 0},
+		{"empty", 0, 1},
+	} {
+		_, err := s.DownloadObjectRange(ctx, tt.key, "", tt.offset, tt.length)
+		if resp := minio.ToErrorResponse(err); resp.Code != "InvalidRange" || resp.StatusCode != 416 {
+			t.Errorf("range %d+%d of %s = %v, want InvalidRange", tt.offset, tt.length, tt.key, err)
+		}
+	}
+}
+
+func TestDeleteObjects(t *testing.T) {
+	s := New("bucket")
+	for _, key := range []string{"a", "b", "c"} {
+		s.Put(key, []byte(key))
+	}
+
+	if failed := s.DeleteObjects(context.Background(), []string{"a", "c", "missing"}); len(failed) != 0 {
+		t.Fatalf("DeleteObjects failed for %v", failed)
+	}
+	for key, want := range map[string]bool{"a": false, "b": true, "c": false} {
+		if _, ok := s.Get(key); ok != want {
+			t.Errorf("%s present = %v, want %v", key, ok, want)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if failed := s.DeleteObjects(ctx, []string{"b"}); len(failed) != 1 || failed[0].Key != "b" {
+		t.Errorf("DeleteObjects with a canceled context = %v", failed)
+	}
+}
+
+func TestBuckets(t *testing.T) {
+	s := New("main")
+	ctx := context.Background()
+
+	if err := s.CreateBucket(ctx, "extra", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CreateBucket(ctx, "extra", ""); err == nil {
+		t.Errorf("creating an existing bucket returned no error")
+	}
+
+	buckets, err := s.ListBuckets(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(buckets) != 2 || buckets[0].Name != "extra" || buckets[1].Name != "main" {
+		t.Fatalf("ListBuckets() = %v", buckets)
+	}
+
+	s.Put("file", []byte("x"))
+	if err := s.DeleteBucket(ctx, "main"); err == nil {
+		t.Errorf("deleting a non-empty bucket returned no error")
+	}
+	if err := s.DeleteBucket(ctx, "extra"); err != nil {
+		t.Errorf("DeleteBucket(extra) = %v", err)
+	}
+}
+
+func TestObjectVersions(t *testing.T) {
+	s := New("bucket")
+	ctx := context.Background()
+	s.Put("doc.txt", []byte("v0"))
+
+	if err := s.SetBucketVersioning(ctx, "bucket", true); err != nil {
+		t.Fatal(err)
+	}
+	s.Put("doc.txt", []byte("v1"))
+	if err := s.DeleteObject(ctx, "doc.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.Get("doc.txt"); ok {
+		t.Fatalf("deleted object is still listed")
+	}
+
+	versions, err := s.ListObjectVersions(ctx, "doc.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 3 || !versions[0].IsDeleteMarker || !versions[0].IsLatest || versions[2].VersionID != s3.NullVersion {
+		t.Fatalf("versions = %+v", versions)
+	}
+
+	rc, err := s.DownloadObjectVersion(ctx, "doc.txt", s3.NullVersion)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := io.ReadAll(rc)
+	if string(data) != "v0" {
+		t.Errorf("null version = %q, want v0", data)
+	}
+
+	if err := s.RestoreObjectVersion(ctx, "doc.txt", versions[1].VersionID); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := s.Get("doc.txt"); string(data) != "v1" {
+		t.Errorf("restored content = %q, want v1", data)
+	}
+
+	// Removing the restored copy uncovers the delete marker again.
+	versions, _ = s.ListObjectVersions(ctx, "doc.txt")
+	if err := s.DeleteObjectVersion(ctx, "doc.txt", versions[0].VersionID); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := s.Get("doc.txt"); ok {
+		t.Errorf("object visible after removing the latest version")
+	}
+	if err := s.DeleteObjectVersion(ctx, "doc.txt", versions[1].VersionID); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := s.Get("doc.txt"); string(data) != "v1" {
+		t.Errorf("content after removing the delete marker = %q, want v1", data)
+	}
+
+	status, err := s.GetBucketVersioning(ctx, "bucket")
+	if err != nil || status != s3.VersioningEnabled {
+		t.Errorf("GetBucketVersioning = %q, %v", status, err)
+	}
+}
+
+func TestObjectProperties(t *testing.T) {
+	s := New("bucket")
+	ctx := context.Background()
+	s.Put("site/index.html", []byte("<html>"))
+
+	headers := s3.ObjectHeaders{ContentType: "text/html; charset=utf-8", CacheControl: "max-age=300"}
+	metadata := map[string]string{"owner": "cdn"}
+	objectTags := map[string]string{"team": "web"}
+	if err := s.UpdateObjectProperties(ctx, "site/index.html", headers, metadata, objectTags); err != nil {
+		t.Fatal(err)
+	}
+	metadata["owner"] = "changed after the call"
+
+	props, err := s.GetObjectProperties(ctx, "site/index.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if props.Headers != headers || props.Info.ContentType != headers.ContentType {
+		t.Errorf("headers = %+v", props.Headers)
+	}
+	if props.Metadata["owner"] != "cdn" || props.Tags["team"] != "web" {
+		t.Errorf("metadata = %v, tags = %v", props.Metadata, props.Tags)
+	}
+	if data, _ := s.Get("site/index.html"); string(data) != "<html>" {
+		t.Errorf("content changed to %q", data)
+	}
+
+	if err := s.UpdateObjectProperties(ctx, "site/index.html", headers, nil, map[string]string{"": "x"}); err == nil {
+		t.Errorf("an empty tag key was accepted")
+	}
+}
+
+func TestReplaceObject(t *testing.T) {
+	s := New("bucket")
+	ctx := context.Background()
+	s.Put("config/app.json", []byte(`{"debug":false}`))
+	headers := s3.ObjectHeaders{ContentType: "application/json", CacheControl: "no-cache"}
+	if err := s.UpdateObjectProperties(ctx, "config/app.json", headers, map[string]string{"owner": "ops"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	props, err := s.GetObjectProperties(ctx, "config/app.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	content := `{"debug":true}`
+	if err := s.ReplaceObject(ctx, "config/app.json", strings.NewReader(content), int64(len(content)), props, props.Info.ETag); err != nil {
+		t.Fatal(err)
+	}
+	replaced, err := s.GetObjectProperties(ctx, "config/app.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := s.Get("config/app.json"); string(data) != content {
+		t.Errorf("content = %q", data)
+	}
+	if replaced.Headers != headers || replaced.Metadata["owner"] != "ops" || replaced.Info.ETag == props.Info.ETag {
+		t.Errorf("replaced properties = %+v", replaced)
+	}
+
+	// The old ETag no longer matches.
+	err = s.ReplaceObject(ctx, "config/app.json", strings.NewReader("{}"), 2, props, props.Info.ETag)
+	if minio.ToErrorResponse(err).Code != "PreconditionFailed" {
+		t.Errorf("ReplaceObject with a stale ETag = %v", err)
+	}
+	if err := s.ReplaceObject(ctx, "config/app.json", strings.NewReader("{}"), 2, props, ""); err != nil {
+		t.Errorf("unconditional ReplaceObject = %v", err)
+	}
+}
+
+func TestCopyObject(t *testing.T) {
+	s := New("bucket")
+	ctx := context.Background()
+	s.Put("docs/a.txt", []byte("alpha"))
+	headers := s3.ObjectHeaders{ContentType: "text/plain"}
+	if err := s.UpdateObjectProperties(ctx, "docs/a.txt", headers, map[string]string{"owner": "ops"}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.CopyObject(ctx, "docs/a.txt", "archive/a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if data, ok := s.Get("archive/a.txt"); !ok || string(data) != "alpha" {
+		t.Fatalf("copy = %q, %v", data, ok)
+	}
+	if _, ok := s.Get("docs/a.txt"); !ok {
+		t.Errorf("source was removed by the copy")
+	}
+	props, err := s.GetObjectProperties(ctx, "archive/a.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if props.Headers != headers || props.Metadata["owner"] != "ops" {
+		t.Errorf("copy lost its properties: %+v", props)
+	}
+
+	if err := s.CopyObject(ctx, "missing.txt", "other.txt"); err == nil {
+		t.Errorf("copying a missing object succeeded")
+	}
+
+	if err := s.CopyObjectToBucket(ctx, "docs/a.txt", "backup", "a.txt"); err == nil {
+		t.Errorf("copying into a missing bucket succeeded")
+	}
+	if err := s.CreateBucket(ctx, "backup", ""); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.CopyObjectToBucket(ctx, "docs/a.txt", "backup", "a.txt"); err != nil {
+		t.Fatal(err)
+	}
+	if data, ok := s.GetFromBucket("backup", "a.txt"); !ok || string(data) != "alpha" {
+		t.Errorf("backup/a.txt = %q, %v", data, ok)
+	}
+	if _, ok := s.Get("a.txt"); ok {
+		t.Errorf("the copy landed in the current bucket")
+	}
+}
+
+func TestPresignPost(t *testing.T) {
+	s := New("bucket")
+	opts := s3.PostPolicyOptions{
+		KeyPrefix:         "incoming/",
+		Expires:           time.Hour,
+		MaxSize:           10 << 20,
+		ContentType:       "image/",
+		ContentTypePrefix: true,
+		SuccessStatus:     "201",
+		Metadata:          map[string]string{"customer": "42"},
+	}
+	u, fields, err := s.PresignPost(context.Background(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.String() != "memory://bucket/" || fields["key"] != "incoming/${filename}" || fields["Content-Type"] != "image/" ||
+		fields["success_action_status"] != "201" || fields["x-amz-meta-customer"] != "42" {
+		t.Errorf("form = %v %v", u, fields)
+	}
+	policy, err := base64.StdEncoding.DecodeString(fields["policy"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, cond := range []string{
+		`["starts-with","$key","incoming/"]`,
+		`["starts-with","$Content-Type","image/"]`,
+		`["content-length-range", 0, 10485760]`,
+		`["eq","$x-amz-meta-customer","42"]`,
+	} {
+		if !strings.Contains(string(policy), cond) {
+			t.Errorf("policy %s lacks %s", policy, cond)
+		}
+	}
+
+	for _, bad := range []s3.PostPolicyOptions{
+		{Expires: 8 * 24 * time.Hour},
+		{Expires: time.Hour, MinSize: 10, MaxSize: 5},
+		{Expires: time.Hour, MaxSize: s3.MaxPostObjectSize + 1},
+		{Expires: time.Hour, SuccessStatus: "302"},
+		{Expires: time.Hour, Metadata: map[string]string{"": "x"}},
+	} {
+		if _, _, err := s.PresignPost(context.Background(), bad); err == nil {
+			t.Errorf("PresignPost accepted %+v", bad)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package memstore
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+
+	"github.com/minio/minio-go/v7"
+
+	"github.com/pteich/us3ui/s3"
+)
+
+// writeLocked makes obj the latest version of key. With versioning the
+// previous versions are kept; a suspended bucket replaces the null version
+// like S3 does.
+func (s *Store) writeLocked(b *bucket, key string, obj *object) {
+	if b.versioning != s3.VersioningOff {
+		history := b.versions[key]
+		if len(history) == 0 {
+			// An object written before versioning was enabled becomes the
+			// null version.
+			if current, ok := b.objects[key]; ok {
+				current.versionID = s3.NullVersion
+				history = []*object{current}
+			}
+		}
+
+		if b.versioning == s3.VersioningEnabled {
+			s.nextID++
+			obj.versionID = fmt.Sprintf("version-%d", s.nextID)
+		} else {
+			obj.versionID = s3.NullVersion
+			history = slices.DeleteFunc(history, func(v *object) bool {
+				return v.versionID == s3.NullVersion
+			})
+		}
+
+		if b.versions == nil {
+			b.versions = make(map[string][]*object)
+		}
+		b.versions[key] = append([]*object{obj}, history...)
+	}
+
+	if obj.deleteMarker {
+		delete(b.objects, key)
+		return
+	}
+	b.objects[key] = obj
+}
+
+// versionLocked finds a version of key. Keys that were never versioned only
+// have the null version.
+func (s *Store) versionLocked(b *bucket, key, versionID string) (*object, error) {
+	history := b.versions[key]
+	if len(history) == 0 {
+		if obj, ok := b.objects[key]; ok && versionID == s3.NullVersion {
+			return obj, nil
+		}
+		return nil, noSuchVersion(key, versionID)
+	}
+	for _, v := range history {
+		if v.versionID == versionID {
+			return v, nil
+		}
+	}
+	return nil, noSuchVersion(key, versionID)
+}
+
+func (s *Store) GetBucketVersioning(ctx context.Context, bucketName string) (string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.buckets[bucketName]
+	if !ok {
+		return "", noSuchBucket(bucketName)
+	}
+	return b.versioning, nil
+}
+
+func (s *Store) SetBucketVersioning(ctx context.Context, bucketName string, enabled bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, ok := s.buckets[bucketName]
+	if !ok {
+		return noSuchBucket(bucketName)
+	}
+	if enabled {
+		b.versioning = s3.VersioningEnabled
+	} else {
+		b.versioning = s3.VersioningSuspended
+	}
+	return nil
+}
+
+func (s *Store) ListObjectVersions(ctx context.Context, objectName string) ([]minio.ObjectInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, err := s.currentBucket()
+	if err != nil {
+		return nil, err
+	}
+
+	history := b.versions[objectName]
+	if len(history) == 0 {
+		obj, ok := b.objects[objectName]
+		if !ok {
+			return []minio.ObjectInfo{}, nil
+		}
+		info := objectInfo(objectName, obj)
+		info.VersionID = s3.NullVersion
+		info.IsLatest = true
+		return []minio.ObjectInfo{info}, nil
+	}
+
+	versions := make([]minio.ObjectInfo, 0, len(history))
+	for i, v := range history {
+		info := objectInfo(objectName, v)
+		if v.deleteMarker {
+			info = minio.ObjectInfo{Key: objectName, LastModified: v.modified, IsDeleteMarker: true}
+		}
+		info.VersionID = v.versionID
+		info.IsLatest = i == 0
+		versions = append(versions, info)
+	}
+	return versions, nil
+}
+
+func (s *Store) DownloadObjectVersion(ctx context.Context, objectName, versionID string) (io.ReadCloser, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, err := s.currentBucket()
+	if err != nil {
+		return nil, err
+	}
+	v, err := s.versionLocked(b, objectName, versionID)
+	if err != nil {
+		return nil, err
+	}
+	if v.deleteMarker {
+		return nil, deleteMarkerError(objectName)
+	}
+	return io.NopCloser(bytes.NewReader(v.data)), nil
+}
+
+func (s *Store) RestoreObjectVersion(ctx context.Context, objectName, versionID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, err := s.currentBucket()
+	if err != nil {
+		return err
+	}
+	v, err := s.versionLocked(b, objectName, versionID)
+	if err != nil {
+		return err
+	}
+	if v.deleteMarker {
+		return deleteMarkerError(objectName)
+	}
+	restored := s.newObject(bytes.Clone(v.data), v.headers.ContentType)
+	restored.headers = v.headers
+	restored.metadata = maps.Clone(v.metadata)
+	restored.tags = maps.Clone(v.tags)
+	s.writeLocked(b, objectName, restored)
+	return nil
+}
+
+func (s *Store) DeleteObjectVersion(ctx context.Context, objectName, versionID string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	b, err := s.currentBucket()
+	if err != nil {
+		return err
+	}
+
+	history := b.versions[objectName]
+	if len(history) == 0 {
+		if versionID == s3.NullVersion {
+			delete(b.objects, objectName)
+		}
+		return nil
+	}
+
+	idx := slices.IndexFunc(history, func(v *object) bool {
+		return v.versionID == versionID
+	})
+	if idx < 0 {
+		return nil
+	}
+	history = slices.Delete(history, idx, idx+1)
+	if len(history) == 0 {
+		delete(b.versions, objectName)
+	} else {
+		b.versions[objectName] = history
+	}
+
+	// Removing the latest version uncovers the one before it.
+	if idx == 0 {
+		if len(history) == 0 || history[0].deleteMarker {
+			delete(b.objects, objectName)
+		} else {
+			b.objects[objectName] = history[0]
+		}
+	}
+	return nil
+}
+
+func noSuchVersion(key, versionID string) error {
+	return minio.ErrorResponse{Code: "NoSuchVersion", Key: key, Message: fmt.Sprintf("version %s does not exist", versionID), StatusCode: 404}
+}
+
+func deleteMarkerError(key string) error {
+	return minio.ErrorResponse{Code: "MethodNotAllowed", Key: key, Message: "the version is a delete marker", StatusCode: 405}
+}
@@ -0,0 +1,89 @@
+package s3
+
+import (
+	"context"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// Part is a single uploaded part of a multipart upload.
+type Part struct {
+	Number int    `json:"number"`
+	ETag   string `json:"etag"`
+	Size   int64  `json:"size"`
+}
+
+func (s *Service) core() minio.Core {
+	return minio.Core{Client: s.client}
+}
+
+func (s *Service) NewMultipartUpload(ctx context.Context, objectName string, mimeType string) (string, error) {
+	return s.core().NewMultipartUpload(ctx, s.bucketName, objectName, minio.PutObjectOptions{
+		ContentType: mimeType,
+	})
+}
+
+// NewMultipartUploadWithProperties starts a multipart upload of an object
+// with the headers, user metadata, tags and storage class in props.
+func (s *Service) NewMultipartUploadWithProperties(ctx context.Context, objectName string, props ObjectProperties) (string, error) {
+	return s.core().NewMultipartUpload(ctx, s.bucketName, objectName, putOptions(props))
+}
+
+func (s *Service) UploadPart(ctx context.Context, objectName, uploadID string, partNumber int, r io.Reader, size int64) (Part, error) {
+	part, err := s.core().PutObjectPart(ctx, s.bucketName, objectName, uploadID, partNumber, r, size, minio.PutObjectPartOptions{})
+	if err != nil {
+		return Part{}, err
+	}
+	return Part{Number: part.PartNumber, ETag: trimETag(part.ETag), Size: part.Size}, nil
+}
+
+// ListParts returns all parts the server has stored for an unfinished upload,
+// ordered by part number.
+func (s *Service) ListParts(ctx context.Context, objectName, uploadID string) ([]Part, error) {
+	parts := make([]Part, 0)
+	marker := 0
+	for {
+		result, err := s.core().ListObjectParts(ctx, s.bucketName, objectName, uploadID, marker, 1000)
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range result.ObjectParts {
+			parts = append(parts, Part{Number: p.PartNumber, ETag: trimETag(p.ETag), Size: p.Size})
+		}
+		if !result.IsTruncated {
+			break
+		}
+		marker = result.NextPartNumberMarker
+	}
+
+	sort.Slice(parts, func(i, j int) bool {
+		return parts[i].Number < parts[j].Number
+	})
+	return parts, nil
+}
+
+func (s *Service) CompleteMultipartUpload(ctx context.Context, objectName, uploadID string, parts []Part) error {
+	complete := make([]minio.CompletePart, len(parts))
+	for i, p := range parts {
+		complete[i] = minio.CompletePart{PartNumber: p.Number, ETag: p.ETag}
+	}
+	sort.Slice(complete, func(i, j int) bool {
+		return complete[i].PartNumber < complete[j].PartNumber
+	})
+
+	_, err := s.core().CompleteMultipartUpload(ctx, s.bucketName, objectName, uploadID, complete, minio.PutObjectOptions{})
+	return err
+}
+
+func (s *Service) AbortMultipartUpload(ctx context.Context, objectName, uploadID string) error {
+	return s.core().AbortMultipartUpload(ctx, s.bucketName, objectName, uploadID)
+}
+
+// trimETag strips the double quotes S3 puts around ETags in XML responses so
+// values from different calls compare equal.
+func trimETag(etag string) string {
+	return strings.Trim(etag, "\"")
+}
@@ -0,0 +1,199 @@
+package s3
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// MaxPresignExpiry is the longest validity of a presigned link that S3
+// signature version 4 allows.
+const MaxPresignExpiry = 7 * 24 * time.Hour
+
+// MaxPostObjectSize is the largest object a browser upload with a POST form
+// may send.
+const MaxPostObjectSize = 5 << 30
+
+// PostFileName is replaced with the name of the uploaded file in the key of a
+// POST form upload.
+const PostFileName = "${filename}"
+
+// PresignOptions describe a presigned link.
+type PresignOptions struct {
+	Method  string // http.MethodGet to download, http.MethodPut to upload
+	Expires time.Duration
+	// ContentDisposition and ContentType override the response headers of a
+	// download link.
+	ContentDisposition string
+	ContentType        string
+}
+
+// Validate checks the method and expiry of a link.
+func (o PresignOptions) Validate() error {
+	if o.Method != http.MethodGet && o.Method != http.MethodPut {
+		return fmt.Errorf("unsupported link method %q", o.Method)
+	}
+	if o.Expires < time.Second || o.Expires > MaxPresignExpiry {
+		return fmt.Errorf("links must expire within %d days", MaxPresignExpiry/(24*time.Hour))
+	}
+	return nil
+}
+
+// ResponseParams returns the query parameters that override the response
+// headers of a download.
+func (o PresignOptions) ResponseParams() url.Values {
+	params := url.Values{}
+	if o.ContentDisposition != "" {
+		params.Set("response-content-disposition", o.ContentDisposition)
+	}
+	if o.ContentType != "" {
+		params.Set("response-content-type", o.ContentType)
+	}
+	return params
+}
+
+// PresignURL returns a link that downloads or uploads objectName without
+// credentials until it expires.
+func (s *Service) PresignURL(ctx context.Context, objectName string, opts PresignOptions) (*url.URL, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if opts.Method == http.MethodPut {
+		return s.client.PresignedPutObject(ctx, s.bucketName, objectName, opts.Expires)
+	}
+	return s.client.PresignedGetObject(ctx, s.bucketName, objectName, opts.Expires, opts.ResponseParams())
+}
+
+// PostPolicyOptions describe a presigned POST form that lets browsers upload
+// below a key prefix without credentials.
+type PostPolicyOptions struct {
+	KeyPrefix string
+	Expires   time.Duration
+	// MinSize and MaxSize limit the size of an upload in bytes. Without
+	// MaxSize, uploads may be as large as MaxPostObjectSize.
+	MinSize int64
+	MaxSize int64
+	// ContentType is the type an upload must declare. With
+	// ContentTypePrefix set it is a prefix like "image/" instead.
+	ContentType        string
+	ContentTypePrefix  bool
+	ContentDisposition string
+	// SuccessStatus is the status of a successful upload: 200, 201 or 204.
+	SuccessStatus   string
+	SuccessRedirect string
+	Metadata        map[string]string
+}
+
+// Validate checks the expiry, size range and success status of a form.
+func (o PostPolicyOptions) Validate() error {
+	if o.Expires < time.Second || o.Expires > MaxPresignExpiry {
+		return fmt.Errorf("upload forms must expire within %d days", MaxPresignExpiry/(24*time.Hour))
+	}
+	if o.MinSize < 0 || o.MaxSize < 0 || o.MaxSize > MaxPostObjectSize {
+		return fmt.Errorf("upload sizes must be between 0 and %d bytes", int64(MaxPostObjectSize))
+	}
+	if o.MaxSize > 0 && o.MinSize > o.MaxSize {
+		return errors.New("the minimum upload size is larger than the maximum")
+	}
+	switch o.SuccessStatus {
+	case "", "200", "201", "204":
+	default:
+		return fmt.Errorf("unsupported success status %q", o.SuccessStatus)
+	}
+	return nil
+}
+
+// Policy builds the POST policy of a form that uploads into bucket and
+// expires o.Expires after now.
+func (o PostPolicyOptions) Policy(bucket string, now time.Time) (*minio.PostPolicy, error) {
+	if err := o.Validate(); err != nil {
+		return nil, err
+	}
+	p := minio.NewPostPolicy()
+	if err := p.SetBucket(bucket); err != nil {
+		return nil, err
+	}
+	if err := p.SetKeyStartsWith(o.KeyPrefix); err != nil {
+		return nil, err
+	}
+	if err := p.SetExpires(now.Add(o.Expires).UTC()); err != nil {
+		return nil, err
+	}
+	if o.MinSize > 0 || o.MaxSize > 0 {
+		maxSize := o.MaxSize
+		if maxSize == 0 {
+			maxSize = MaxPostObjectSize
+		}
+		if err := p.SetContentLengthRange(o.MinSize, maxSize); err != nil {
+			return nil, err
+		}
+	}
+
+	var err error
+	switch {
+	case o.ContentType == "":
+	case o.ContentTypePrefix:
+		err = p.SetContentTypeStartsWith(o.ContentType)
+	default:
+		err = p.SetContentType(o.ContentType)
+	}
+	if err == nil && o.ContentDisposition != "" {
+		err = p.SetContentDisposition(o.ContentDisposition)
+	}
+	if err == nil && o.SuccessStatus != "" {
+		err = p.SetSuccessStatusAction(o.SuccessStatus)
+	}
+	if err == nil && o.SuccessRedirect != "" {
+		err = p.SetSuccessActionRedirect(o.SuccessRedirect)
+	}
+	for key, value := range o.Metadata {
+		if err != nil {
+			break
+		}
+		err = p.SetUserMetadata(key, value)
+	}
+	return p, err
+}
+
+// FormFields returns the form fields the conditions of the policy fix. The
+// key names each upload after its file below KeyPrefix.
+func (o PostPolicyOptions) FormFields() map[string]string {
+	fields := map[string]string{"key": o.KeyPrefix + PostFileName}
+	if o.ContentType != "" {
+		fields["Content-Type"] = o.ContentType
+	}
+	if o.ContentDisposition != "" {
+		fields["Content-Disposition"] = o.ContentDisposition
+	}
+	if o.SuccessStatus != "" {
+		fields["success_action_status"] = o.SuccessStatus
+	}
+	if o.SuccessRedirect != "" {
+		fields["success_action_redirect"] = o.SuccessRedirect
+	}
+	for key, value := range o.Metadata {
+		fields["x-amz-meta-"+key] = value
+	}
+	return fields
+}
+
+// PresignPost signs a POST form that uploads into the bucket. It returns the
+// URL the form posts to and the fields it must send along with the file.
+func (s *Service) PresignPost(ctx context.Context, opts PostPolicyOptions) (*url.URL, map[string]string, error) {
+	policy, err := opts.Policy(s.bucketName, time.Now())
+	if err != nil {
+		return nil, nil, err
+	}
+	u, fields, err := s.client.PresignedPostPolicy(ctx, policy)
+	if err != nil {
+		return nil, nil, err
+	}
+	maps.Copy(fields, opts.FormFields())
+	return u, fields, nil
+}
@@ -0,0 +1,157 @@
+package s3
+
+import (
+	"context"
+	"io"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/tags"
+)
+
+// ObjectHeaders are the content headers S3 stores with an object and returns
+// on every download.
+type ObjectHeaders struct {
+	ContentType        string
+	CacheControl       string
+	ContentDisposition string
+	ContentEncoding    string
+	ContentLanguage    string
+}
+
+// ObjectProperties describes an object beyond what a listing returns. Info
+// is read-only; headers, user metadata and tags can be changed with
+// UpdateObjectProperties.
+type ObjectProperties struct {
+	Info     minio.ObjectInfo
+	Headers  ObjectHeaders
+	Metadata map[string]string // user metadata without the x-amz-meta- prefix
+	Tags     map[string]string
+}
+
+// GetObjectProperties stats objectName and reads its tags.
+func (s *Service) GetObjectProperties(ctx context.Context, objectName string) (ObjectProperties, error) {
+	return s.objectProperties(ctx, s.bucketName, objectName, "")
+}
+
+// objectProperties stats a version of objectName in bucket, the latest if
+// versionID is empty, and reads its tags.
+func (s *Service) objectProperties(ctx context.Context, bucket, objectName, versionID string) (ObjectProperties, error) {
+	info, err := s.client.StatObject(ctx, bucket, objectName, minio.StatObjectOptions{VersionID: versionID})
+	if err != nil {
+		return ObjectProperties{}, err
+	}
+	info.ETag = trimETag(info.ETag)
+
+	// Some S3 compatible servers have no tagging; their objects have no tags.
+	tagMap := map[string]string{}
+	objectTags, err := s.client.GetObjectTagging(ctx, bucket, objectName, minio.GetObjectTaggingOptions{VersionID: versionID})
+	switch {
+	case err == nil:
+		tagMap = objectTags.ToMap()
+	case minio.ToErrorResponse(err).Code != "NotImplemented":
+		return ObjectProperties{}, err
+	}
+
+	metadata := make(map[string]string, len(info.UserMetadata))
+	for k, v := range info.UserMetadata {
+		metadata[strings.ToLower(k)] = v
+	}
+
+	return ObjectProperties{
+		Info: info,
+		Headers: ObjectHeaders{
+			ContentType:        info.ContentType,
+			CacheControl:       info.Metadata.Get("Cache-Control"),
+			ContentDisposition: info.Metadata.Get("Content-Disposition"),
+			ContentEncoding:    info.Metadata.Get("Content-Encoding"),
+			ContentLanguage:    info.Metadata.Get("Content-Language"),
+		},
+		Metadata: metadata,
+		Tags:     tagMap,
+	}, nil
+}
+
+// UpdateObjectProperties replaces the headers, user metadata and tags of
+// objectName by copying the object onto itself on the server. Headers left
+// empty are removed. In a versioned bucket the copy becomes a new version.
+// Objects over 5 GiB are copied in parts.
+func (s *Service) UpdateObjectProperties(ctx context.Context, objectName string, headers ObjectHeaders, metadata, objectTags map[string]string) error {
+	if _, err := tags.NewTags(objectTags, true); err != nil {
+		return err
+	}
+
+	info, err := s.StatObject(ctx, objectName)
+	if err != nil {
+		return err
+	}
+
+	userMetadata := make(map[string]string, len(metadata)+1)
+	for k, v := range metadata {
+		userMetadata[k] = v
+	}
+	// A copy is written with the default storage class unless it is given
+	// again.
+	if info.StorageClass != "" && info.StorageClass != "STANDARD" {
+		userMetadata["X-Amz-Storage-Class"] = info.StorageClass
+	}
+
+	if info.Size > maxCopySize {
+		props := ObjectProperties{Info: info, Headers: headers, Metadata: metadata, Tags: objectTags}
+		src := minio.CopySrcOptions{Bucket: s.bucketName, Object: objectName, MatchETag: info.ETag}
+		return s.copyInParts(ctx, src, info.Size, s.bucketName, objectName, props)
+	}
+
+	_, err = s.client.ComposeObject(ctx,
+		minio.CopyDestOptions{
+			Bucket:             s.bucketName,
+			Object:             objectName,
+			ReplaceMetadata:    true,
+			UserMetadata:       userMetadata,
+			ReplaceTags:        true,
+			UserTags:           objectTags,
+			ContentType:        headers.ContentType,
+			CacheControl:       headers.CacheControl,
+			ContentDisposition: headers.ContentDisposition,
+			ContentEncoding:    headers.ContentEncoding,
+			ContentLanguage:    headers.ContentLanguage,
+		},
+		minio.CopySrcOptions{
+			Bucket:    s.bucketName,
+			Object:    objectName,
+			MatchETag: info.ETag,
+		},
+	)
+	return err
+}
+
+// ReplaceObject writes new content to objectName and keeps the headers, user
+// metadata, tags and storage class in props. If matchETag is set, the write
+// fails with PreconditionFailed when the object changed since it had that
+// ETag.
+func (s *Service) ReplaceObject(ctx context.Context, objectName string, r io.Reader, length int64, props ObjectProperties, matchETag string) error {
+	opts := putOptions(props)
+	if matchETag != "" {
+		opts.SetMatchETag(matchETag)
+	}
+	_, err := s.client.PutObject(ctx, s.bucketName, objectName, r, length, opts)
+	return err
+}
+
+// putOptions writes an object with the headers, user metadata, tags and
+// storage class in props.
+func putOptions(props ObjectProperties) minio.PutObjectOptions {
+	opts := minio.PutObjectOptions{
+		ContentType:        props.Headers.ContentType,
+		CacheControl:       props.Headers.CacheControl,
+		ContentDisposition: props.Headers.ContentDisposition,
+		ContentEncoding:    props.Headers.ContentEncoding,
+		ContentLanguage:    props.Headers.ContentLanguage,
+		UserMetadata:       props.Metadata,
+		UserTags:           props.Tags,
+	}
+	if props.Info.StorageClass != "STANDARD" {
+		opts.StorageClass = props.Info.StorageClass
+	}
+	return opts
+}
@@ -4,8 +4,6 @@ import (
 	"context"
 	"fmt"
 	"io"
-	"net/url"
-	"time"
 
 	"github.com/minio/minio-go/v7"
 	"github.com/minio/minio-go/v7/pkg/credentials"
@@ -34,6 +32,35 @@ func (s *Service) DeleteObject(ctx context.Context, objectName string) error {
 	return s.client.RemoveObject(ctx, s.bucketName, objectName, minio.RemoveObjectOptions{})
 }
 
+// DeleteObjects removes objectNames with multi-object delete requests of up
+// to MaxDeleteBatch keys each and returns the objects that were not removed.
+func (s *Service) DeleteObjects(ctx context.Context, objectNames []string) []DeleteError {
+	objects := make(chan minio.ObjectInfo, len(objectNames))
+	for _, name := range objectNames {
+		objects <- minio.ObjectInfo{Key: name}
+	}
+	close(objects)
+
+	var failed []DeleteError
+	for e := range s.client.RemoveObjects(ctx, s.bucketName, objects, minio.RemoveObjectsOptions{}) {
+		failed = append(failed, DeleteError{Key: e.ObjectName, Err: e.Err})
+	}
+	return failed
+}
+
+// CopyObject copies srcObject to dstObject on the server, keeping its
+// headers, metadata and tags.
+func (s *Service) CopyObject(ctx context.Context, srcObject, dstObject string) error {
+	return s.copyObject(ctx, minio.CopySrcOptions{Bucket: s.bucketName, Object: srcObject}, s.bucketName, dstObject)
+}
+
+// CopyObjectToBucket copies srcObject to dstObject in the bucket dstBucket
+// of the same server, keeping its headers, metadata and tags. The copy is
+// done on the server.
+func (s *Service) CopyObjectToBucket(ctx context.Context, srcObject, dstBucket, dstObject string) error {
+	return s.copyObject(ctx, minio.CopySrcOptions{Bucket: s.bucketName, Object: srcObject}, dstBucket, dstObject)
+}
+
 func (s *Service) UploadObjectReader(ctx context.Context, filePath string, objectName string, r io.Reader, length int64, mimeType string) error {
 	_, err := s.client.PutObject(ctx, s.bucketName, objectName,
 		r,
@@ -48,20 +75,61 @@ func (s *Service) DownloadObject(ctx context.Context, objectName string) (io.Rea
 	return s.client.GetObject(ctx, s.bucketName, objectName, minio.GetObjectOptions{})
 }
 
-func (s *Service) GetPresignedURL(ctx context.Context, objectName string, expires time.Duration) (*url.URL, error) {
-	return s.client.PresignedGetObject(ctx, s.bucketName, objectName, expires, nil)
+// DownloadObjectRange reads length bytes starting at offset. A length of zero
+// or less reads to the end of the object. If etag is set the request fails
+// when the object was replaced in the meantime.
+func (s *Service) DownloadObjectRange(ctx context.Context, objectName, etag string, offset, length int64) (io.ReadCloser, error) {
+	opts := minio.GetObjectOptions{}
+	if etag != "" {
+		if err := opts.SetMatchETag(etag); err != nil {
+			return nil, err
+		}
+	}
+
+	end := int64(0)
+	if length > 0 {
+		end = offset + length - 1
+	}
+	if offset > 0 || end > 0 {
+		if err := opts.SetRange(offset, end); err != nil {
+			return nil, err
+		}
+	}
+
+	rc, _, _, err := s.core().GetObject(ctx, s.bucketName, objectName, opts)
+	return rc, err
+}
+
+func (s *Service) StatObject(ctx context.Context, objectName string) (minio.ObjectInfo, error) {
+	info, err := s.client.StatObject(ctx, s.bucketName, objectName, minio.StatObjectOptions{})
+	info.ETag = trimETag(info.ETag)
+	return info, err
 }
 
 func (s *Service) ListObjectsBatch(ctx context.Context, startAfter, prefix string, batchSize int) ([]minio.ObjectInfo, error) {
-	opts := minio.ListObjectsOptions{
+	return s.listBatch(ctx, minio.ListObjectsOptions{
 		WithVersions: false,
 		WithMetadata: false,
 		MaxKeys:      batchSize,
 		Prefix:       prefix,
 		Recursive:    true,
 		StartAfter:   startAfter,
-	}
+	}, batchSize)
+}
+
+// ListFolderBatch lists the objects and subfolders directly below prefix.
+// Subfolders are returned as entries whose key ends with the delimiter, see
+// IsFolder.
+func (s *Service) ListFolderBatch(ctx context.Context, startAfter, prefix string, batchSize int) ([]minio.ObjectInfo, error) {
+	return s.listBatch(ctx, minio.ListObjectsOptions{
+		MaxKeys:    batchSize,
+		Prefix:     prefix,
+		Recursive:  false,
+		StartAfter: FolderStartAfter(prefix, startAfter),
+	}, batchSize)
+}
 
+func (s *Service) listBatch(ctx context.Context, opts minio.ListObjectsOptions, batchSize int) ([]minio.ObjectInfo, error) {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
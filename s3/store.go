@@ -0,0 +1,84 @@
+package s3
+
+import (
+	"context"
+	"io"
+	"net/url"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// ObjectStore is the set of bucket operations the UI depends on. *Service
+// implements it against a live S3 endpoint, memstore.Store keeps everything in
+// memory for tests and demo mode.
+type ObjectStore interface {
+	ListObjectsBatch(ctx context.Context, startAfter, prefix string, batchSize int) ([]minio.ObjectInfo, error)
+	ListFolderBatch(ctx context.Context, startAfter, prefix string, batchSize int) ([]minio.ObjectInfo, error)
+	UploadObjectReader(ctx context.Context, filePath string, objectName string, r io.Reader, length int64, mimeType string) error
+	DownloadObject(ctx context.Context, objectName string) (io.ReadCloser, error)
+	DownloadObjectRange(ctx context.Context, objectName, etag string, offset, length int64) (io.ReadCloser, error)
+	StatObject(ctx context.Context, objectName string) (minio.ObjectInfo, error)
+	GetObjectProperties(ctx context.Context, objectName string) (ObjectProperties, error)
+	UpdateObjectProperties(ctx context.Context, objectName string, headers ObjectHeaders, metadata, tags map[string]string) error
+	ReplaceObject(ctx context.Context, objectName string, r io.Reader, length int64, props ObjectProperties, matchETag string) error
+	DeleteObject(ctx context.Context, objectName string) error
+	DeleteObjects(ctx context.Context, objectNames []string) []DeleteError
+	CopyObject(ctx context.Context, srcObject, dstObject string) error
+	CopyObjectToBucket(ctx context.Context, srcObject, dstBucket, dstObject string) error
+	PresignURL(ctx context.Context, objectName string, opts PresignOptions) (*url.URL, error)
+	PresignPost(ctx context.Context, opts PostPolicyOptions) (*url.URL, map[string]string, error)
+
+	NewMultipartUpload(ctx context.Context, objectName string, mimeType string) (string, error)
+	NewMultipartUploadWithProperties(ctx context.Context, objectName string, props ObjectProperties) (string, error)
+	UploadPart(ctx context.Context, objectName, uploadID string, partNumber int, r io.Reader, size int64) (Part, error)
+	ListParts(ctx context.Context, objectName, uploadID string) ([]Part, error)
+	CompleteMultipartUpload(ctx context.Context, objectName, uploadID string, parts []Part) error
+	AbortMultipartUpload(ctx context.Context, objectName, uploadID string) error
+
+	ListObjectVersions(ctx context.Context, objectName string) ([]minio.ObjectInfo, error)
+	DownloadObjectVersion(ctx context.Context, objectName, versionID string) (io.ReadCloser, error)
+	RestoreObjectVersion(ctx context.Context, objectName, versionID string) error
+	DeleteObjectVersion(ctx context.Context, objectName, versionID string) error
+
+	ListBuckets(ctx context.Context) ([]minio.BucketInfo, error)
+	CreateBucket(ctx context.Context, bucketName string, region string) error
+	DeleteBucket(ctx context.Context, bucketName string) error
+	GetBucketVersioning(ctx context.Context, bucketName string) (string, error)
+	SetBucketVersioning(ctx context.Context, bucketName string, enabled bool) error
+}
+
+var _ ObjectStore = (*Service)(nil)
+
+// Delimiter separates folders in object keys.
+const Delimiter = "/"
+
+// MaxDeleteBatch is the most keys one multi-object delete request may name.
+const MaxDeleteBatch = 1000
+
+// DeleteError reports an object that DeleteObjects could not remove.
+type DeleteError struct {
+	Key string
+	Err error
+}
+
+func (e DeleteError) Error() string {
+	return e.Key + ": " + e.Err.Error()
+}
+
+// IsFolder reports whether obj is a common prefix returned by a folder
+// listing rather than an object.
+func IsFolder(obj minio.ObjectInfo) bool {
+	return strings.HasSuffix(obj.Key, Delimiter) && obj.ETag == "" && obj.Size == 0
+}
+
+// FolderStartAfter returns the StartAfter value that continues a folder
+// listing of prefix behind last, the final entry of the previous batch. When
+// last is a subfolder, all keys inside it are skipped; otherwise S3 would
+// report the same common prefix again.
+func FolderStartAfter(prefix, last string) string {
+	if last == "" || last == prefix || !strings.HasSuffix(last, Delimiter) {
+		return last
+	}
+	return last + string(rune(0x10FFFF))
+}
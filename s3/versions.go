@@ -0,0 +1,79 @@
+package s3
+
+import (
+	"context"
+	"io"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// Bucket versioning states as reported by GetBucketVersioning. A bucket that
+// never had versioning enabled reports VersioningOff.
+const (
+	VersioningOff       = ""
+	VersioningEnabled   = minio.Enabled
+	VersioningSuspended = minio.Suspended
+)
+
+// NullVersion is the version ID of objects written while versioning was off
+// or suspended.
+const NullVersion = "null"
+
+func (s *Service) GetBucketVersioning(ctx context.Context, bucketName string) (string, error) {
+	cfg, err := s.client.GetBucketVersioning(ctx, bucketName)
+	if err != nil {
+		return "", err
+	}
+	return cfg.Status, nil
+}
+
+// SetBucketVersioning enables versioning or suspends it. Once enabled,
+// versioning can only be suspended, never turned off again.
+func (s *Service) SetBucketVersioning(ctx context.Context, bucketName string, enabled bool) error {
+	if enabled {
+		return s.client.EnableVersioning(ctx, bucketName)
+	}
+	return s.client.SuspendVersioning(ctx, bucketName)
+}
+
+// ListObjectVersions returns all versions and delete markers of objectName,
+// newest first.
+func (s *Service) ListObjectVersions(ctx context.Context, objectName string) ([]minio.ObjectInfo, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	versions := make([]minio.ObjectInfo, 0)
+	for obj := range s.client.ListObjects(ctx, s.bucketName, minio.ListObjectsOptions{
+		WithVersions: true,
+		Prefix:       objectName,
+		Recursive:    true,
+	}) {
+		if obj.Err != nil {
+			return nil, obj.Err
+		}
+		// The prefix also matches longer keys like "name.bak".
+		if obj.Key != objectName {
+			continue
+		}
+		obj.ETag = trimETag(obj.ETag)
+		versions = append(versions, obj)
+	}
+	return versions, nil
+}
+
+func (s *Service) DownloadObjectVersion(ctx context.Context, objectName, versionID string) (io.ReadCloser, error) {
+	rc, _, _, err := s.core().GetObject(ctx, s.bucketName, objectName, minio.GetObjectOptions{VersionID: versionID})
+	return rc, err
+}
+
+// RestoreObjectVersion makes a copy of the version the latest version of
+// objectName, with the headers, metadata and tags of the version. The copy
+// is done on the server.
+func (s *Service) RestoreObjectVersion(ctx context.Context, objectName, versionID string) error {
+	return s.copyObject(ctx, minio.CopySrcOptions{Bucket: s.bucketName, Object: objectName, VersionID: versionID}, s.bucketName, objectName)
+}
+
+// DeleteObjectVersion removes a single version or delete marker for good.
+func (s *Service) DeleteObjectVersion(ctx context.Context, objectName, versionID string) error {
+	return s.client.RemoveObject(ctx, s.bucketName, objectName, minio.RemoveObjectOptions{VersionID: versionID})
+}
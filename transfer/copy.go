@@ -0,0 +1,109 @@
+package transfer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pteich/us3ui/s3"
+)
+
+// Copier streams objects from one store into another, e.g. between two
+// connections. Nothing is written to disk: small objects are piped from a
+// GET into a PUT, larger ones are sent as a multipart upload with one ranged
+// GET per part.
+type Copier struct {
+	src      s3.ObjectStore
+	dst      s3.ObjectStore
+	PartSize int64
+}
+
+func NewCopier(src, dst s3.ObjectStore) *Copier {
+	return &Copier{
+		src:      src,
+		dst:      dst,
+		PartSize: DefaultPartSize,
+	}
+}
+
+// Copy copies srcKey of the source store to dstKey of the destination with
+// its headers, user metadata, tags and storage class, like a copy on the
+// server. The source is read with its ETag, so an object replaced during the
+// copy fails the copy instead of mixing two versions.
+func (c *Copier) Copy(ctx context.Context, srcKey, dstKey string, onProgress func(Progress)) error {
+	if onProgress == nil {
+		onProgress = func(Progress) {}
+	}
+
+	props, err := c.src.GetObjectProperties(ctx, srcKey)
+	if err != nil {
+		return err
+	}
+	info := props.Info
+
+	partSize := partSizeFor(c.PartSize, info.Size)
+	if info.Size <= partSize {
+		rc, err := c.src.DownloadObjectRange(ctx, srcKey, info.ETag, 0, 0)
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+
+		pr := &ProgressReader{
+			Reader: rc,
+			Total:  info.Size,
+			OnProgress: func(read int64) {
+				onProgress(Progress{Bytes: read, Total: info.Size})
+			},
+		}
+		return c.dst.ReplaceObject(ctx, dstKey, pr, info.Size, props, "")
+	}
+
+	uploadID, err := c.dst.NewMultipartUploadWithProperties(ctx, dstKey, props)
+	if err != nil {
+		return err
+	}
+	if err := c.copyParts(ctx, srcKey, info.ETag, dstKey, uploadID, info.Size, partSize, onProgress); err != nil {
+		// The parts are useless without the rest; the upload is dropped
+		// even if the copy was canceled.
+		_ = c.dst.AbortMultipartUpload(context.WithoutCancel(ctx), dstKey, uploadID)
+		return err
+	}
+	return nil
+}
+
+func (c *Copier) copyParts(ctx context.Context, srcKey, etag, dstKey, uploadID string, size, partSize int64, onProgress func(Progress)) error {
+	numParts := int((size + partSize - 1) / partSize)
+	parts := make([]s3.Part, 0, numParts)
+
+	for n := 1; n <= numParts; n++ {
+		offset := int64(n-1) * partSize
+		length := min(partSize, size-offset)
+
+		rc, err := c.src.DownloadObjectRange(ctx, srcKey, etag, offset, length)
+		if err != nil {
+			return fmt.Errorf("part %d of %d: %w", n, numParts, err)
+		}
+		pr := &ProgressReader{
+			Reader: rc,
+			Total:  length,
+			OnProgress: func(read int64) {
+				onProgress(Progress{
+					Bytes:     offset + read,
+					Total:     size,
+					Part:      n,
+					Parts:     numParts,
+					PartBytes: read,
+					PartSize:  length,
+				})
+			},
+		}
+		part, err := c.dst.UploadPart(ctx, dstKey, uploadID, n, pr, length)
+		rc.Close()
+		if err != nil {
+			return fmt.Errorf("part %d of %d: %w", n, numParts, err)
+		}
+		parts = append(parts, part)
+	}
+
+	return c.dst.CompleteMultipartUpload(ctx, dstKey, uploadID, parts)
+}
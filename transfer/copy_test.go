@@ -0,0 +1,103 @@
+package transfer
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/pteich/us3ui/s3"
+	"github.com/pteich/us3ui/s3/memstore"
+)
+
+func TestCopySmallObject(t *testing.T) {
+	src := memstore.New("source")
+	src.Put("docs/a.txt", []byte("alpha"))
+	dst := &flakyStore{Store: memstore.New("target")}
+
+	var last Progress
+	if err := NewCopier(src, dst).Copy(context.Background(), "docs/a.txt", "backup/a.txt", func(p Progress) { last = p }); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := dst.Get("backup/a.txt"); string(got) != "alpha" {
+		t.Errorf("copied %q, want %q", got, "alpha")
+	}
+	if len(dst.sent) != 0 {
+		t.Errorf("sent parts %v for an object below the part size", dst.sent)
+	}
+	if last.Fraction() != 1 {
+		t.Errorf("last progress = %+v", last)
+	}
+}
+
+func TestCopyMultipart(t *testing.T) {
+	content := strings.Repeat("0123456789", 3)
+	src := memstore.New("source")
+	src.Put("big.bin", []byte(content))
+	dst := &flakyStore{Store: memstore.New("target")}
+
+	c := NewCopier(src, dst)
+	c.PartSize = 8
+	var last Progress
+	if err := c.Copy(context.Background(), "big.bin", "big.bin", func(p Progress) { last = p }); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := dst.Get("big.bin"); string(got) != content {
+		t.Errorf("copied %q, want %q", got, content)
+	}
+	if len(dst.sent) != 4 {
+		t.Errorf("sent parts %v, want 4", dst.sent)
+	}
+	if last.Bytes != int64(len(content)) || last.Part != 4 {
+		t.Errorf("last progress = %+v", last)
+	}
+}
+
+func TestCopyAbortsFailedMultipart(t *testing.T) {
+	src := memstore.New("source")
+	src.Put("big.bin", []byte(strings.Repeat("x", 20)))
+	dst := &flakyStore{Store: memstore.New("target"), failParts: map[int]bool{2: true}}
+
+	c := NewCopier(src, dst)
+	c.PartSize = 8
+	if err := c.Copy(context.Background(), "big.bin", "big.bin", nil); err == nil {
+		t.Fatal("copy succeeded although a part failed")
+	}
+	if _, ok := dst.Get("big.bin"); ok {
+		t.Errorf("a partial object was stored")
+	}
+	if n := dst.PendingUploads(); n != 0 {
+		t.Errorf("%d multipart uploads left open", n)
+	}
+}
+
+func TestCopyKeepsProperties(t *testing.T) {
+	ctx := context.Background()
+	headers := s3.ObjectHeaders{ContentType: "text/csv", CacheControl: "max-age=60", ContentDisposition: "attachment"}
+	src := memstore.New("source")
+	for _, key := range []string{"small.csv", "big.csv"} {
+		src.Put(key, []byte(strings.Repeat("a,b\n", 5)))
+		if err := src.UpdateObjectProperties(ctx, key, headers, map[string]string{"owner": "ops"}, map[string]string{"team": "data"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	dst := memstore.New("target")
+
+	c := NewCopier(src, dst)
+	c.PartSize = 8
+	if err := c.Copy(ctx, "big.csv", "big.csv", nil); err != nil {
+		t.Fatal(err)
+	}
+	c.PartSize = 64
+	if err := c.Copy(ctx, "small.csv", "small.csv", nil); err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"small.csv", "big.csv"} {
+		got, err := dst.GetObjectProperties(ctx, key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got.Headers != headers || got.Metadata["owner"] != "ops" || got.Tags["team"] != "data" {
+			t.Errorf("%s copied with %+v", key, got)
+		}
+	}
+}
@@ -0,0 +1,321 @@
+package transfer
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/pteich/us3ui/s3"
+)
+
+const (
+	DefaultChunkSize         = 8 << 20  // 8 MiB per range request
+	DefaultParallel          = 4        // concurrent range requests for large objects
+	DefaultParallelThreshold = 64 << 20 // objects from this size on are fetched in parallel
+	partFileSuffix           = ".part"
+)
+
+// Downloader fetches objects into local files. Data is written to a ".part"
+// file next to the destination and renamed once complete; an interrupted
+// download continues with range requests as long as the object's ETag is
+// unchanged.
+type Downloader struct {
+	store             s3.ObjectStore
+	state             *StateStore
+	ChunkSize         int64
+	Parallel          int
+	ParallelThreshold int64
+}
+
+// downloadState records which chunks of the ".part" file are complete.
+type downloadState struct {
+	Key       string `json:"key"`
+	ETag      string `json:"etag"`
+	Size      int64  `json:"size"`
+	ChunkSize int64  `json:"chunkSize"`
+	Done      []bool `json:"done"`
+}
+
+func NewDownloader(store s3.ObjectStore, state *StateStore) *Downloader {
+	return &Downloader{
+		store:             store,
+		state:             state,
+		ChunkSize:         DefaultChunkSize,
+		Parallel:          DefaultParallel,
+		ParallelThreshold: DefaultParallelThreshold,
+	}
+}
+
+// PartPath returns the temporary file a download into destPath writes to.
+func PartPath(destPath string) string {
+	return destPath + partFileSuffix
+}
+
+// Download stores objectName at destPath, creating missing parent
+// directories.
+func (d *Downloader) Download(ctx context.Context, objectName, destPath string, onProgress func(Progress)) error {
+	if onProgress == nil {
+		onProgress = func(Progress) {}
+	}
+
+	info, err := d.store.StatObject(ctx, objectName)
+	if err != nil {
+		return err
+	}
+
+	absPath, err := filepath.Abs(destPath)
+	if err != nil {
+		absPath = destPath
+	}
+	id := stateID("download", objectName, absPath)
+	partPath := PartPath(destPath)
+
+	chunkSize := d.ChunkSize
+	if chunkSize <= 0 {
+		chunkSize = DefaultChunkSize
+	}
+	numChunks := int((info.Size + chunkSize - 1) / chunkSize)
+
+	st, resumed := d.resumeState(id, partPath, objectName, info.ETag, info.Size, chunkSize, numChunks)
+
+	if err := os.MkdirAll(filepath.Dir(destPath), 0o755); err != nil {
+		return err
+	}
+
+	flags := os.O_RDWR | os.O_CREATE
+	if !resumed {
+		flags |= os.O_TRUNC
+	}
+	f, err := os.OpenFile(partPath, flags, 0o644)
+	if err != nil {
+		return err
+	}
+	if err := f.Truncate(info.Size); err != nil {
+		f.Close()
+		return err
+	}
+	if err := d.state.Save(id, st); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := d.fetchChunks(ctx, f, id, st, onProgress); err != nil {
+		f.Close()
+		return err
+	}
+
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(partPath, destPath); err != nil {
+		return err
+	}
+	onProgress(Progress{Bytes: info.Size, Total: info.Size})
+	return d.state.Delete(id)
+}
+
+// DownloadVersion stores a specific version of objectName at destPath.
+// Versions are fetched in a single request and start over when interrupted.
+func (d *Downloader) DownloadVersion(ctx context.Context, objectName, versionID string, size int64, destPath string, onProgress func(Progress)) error {
+	if onProgress == nil {
+		onProgress = func(Progress) {}
+	}
+
+	rc, err := d.store.DownloadObjectVersion(ctx, objectName, versionID)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	if err := os.MkdirAll(filepath.Dir(destPath), 0o755); err != nil {
+		return err
+	}
+	partPath := PartPath(destPath)
+	f, err := os.Create(partPath)
+	if err != nil {
+		return err
+	}
+
+	pr := &ProgressReader{
+		Reader: rc,
+		Total:  size,
+		OnProgress: func(read int64) {
+			onProgress(Progress{Bytes: read, Total: size})
+		},
+	}
+	if _, err := io.Copy(f, pr); err != nil {
+		f.Close()
+		os.Remove(partPath)
+		return err
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(partPath)
+		return err
+	}
+	return os.Rename(partPath, destPath)
+}
+
+// resumeState returns the saved state if the partial file belongs to the
+// same version of the object, or a fresh state otherwise.
+func (d *Downloader) resumeState(id, partPath, objectName, etag string, size, chunkSize int64, numChunks int) (*downloadState, bool) {
+	fresh := &downloadState{
+		Key:       objectName,
+		ETag:      etag,
+		Size:      size,
+		ChunkSize: chunkSize,
+		Done:      make([]bool, numChunks),
+	}
+
+	var st downloadState
+	if !d.state.Load(id, &st) {
+		return fresh, false
+	}
+	if st.Key != objectName || st.ETag != etag || etag == "" || st.Size != size || st.ChunkSize != chunkSize || len(st.Done) != numChunks {
+		return fresh, false
+	}
+	if fi, err := os.Stat(partPath); err != nil || fi.Size() != size {
+		return fresh, false
+	}
+	return &st, true
+}
+
+func (d *Downloader) fetchChunks(ctx context.Context, f *os.File, id string, st *downloadState, onProgress func(Progress)) error {
+	pending := make([]int, 0, len(st.Done))
+	var received int64
+	for i, done := range st.Done {
+		if done {
+			received += d.chunkLength(st, i)
+			continue
+		}
+		pending = append(pending, i)
+	}
+	if len(pending) == 0 {
+		return nil
+	}
+
+	workers := 1
+	if st.Size >= d.ParallelThreshold && d.Parallel > 1 {
+		workers = min(d.Parallel, len(pending))
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		firstErr error
+		wg       sync.WaitGroup
+	)
+	chunks := make(chan int)
+
+	report := func(delta int64) {
+		mu.Lock()
+		defer mu.Unlock()
+		received += delta
+		onProgress(Progress{Bytes: received, Total: st.Size})
+	}
+
+	fail := func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if firstErr == nil {
+			firstErr = err
+			cancel()
+		}
+	}
+
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range chunks {
+				if err := d.fetchChunk(ctx, f, st, i, report); err != nil {
+					fail(err)
+					continue
+				}
+
+				mu.Lock()
+				st.Done[i] = true
+				err := d.state.Save(id, st)
+				mu.Unlock()
+				if err != nil {
+					fail(err)
+				}
+			}
+		}()
+	}
+
+	for _, i := range pending {
+		select {
+		case chunks <- i:
+		case <-ctx.Done():
+		}
+		if ctx.Err() != nil {
+			break
+		}
+	}
+	close(chunks)
+	wg.Wait()
+
+	if firstErr != nil {
+		return firstErr
+	}
+	return ctx.Err()
+}
+
+func (d *Downloader) fetchChunk(ctx context.Context, f *os.File, st *downloadState, i int, report func(int64)) error {
+	offset := int64(i) * st.ChunkSize
+	length := d.chunkLength(st, i)
+
+	rc, err := d.store.DownloadObjectRange(ctx, st.Key, st.ETag, offset, length)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+
+	var written int64
+	pr := &ProgressReader{
+		Reader: io.LimitReader(rc, length),
+		Total:  length,
+		OnProgress: func(read int64) {
+			report(read - written)
+			written = read
+		},
+	}
+	n, err := io.Copy(io.NewOffsetWriter(f, offset), pr)
+	if err != nil {
+		report(-written)
+		return err
+	}
+	if n != length {
+		report(-written)
+		return fmt.Errorf("chunk at offset %d: %w", offset, io.ErrUnexpectedEOF)
+	}
+	return nil
+}
+
+func (d *Downloader) chunkLength(st *downloadState, i int) int64 {
+	offset := int64(i) * st.ChunkSize
+	return min(st.ChunkSize, st.Size-offset)
+}
+
+// Discard removes the partial file and saved state of an unfinished download.
+func (d *Downloader) Discard(objectName, destPath string) error {
+	absPath, err := filepath.Abs(destPath)
+	if err != nil {
+		absPath = destPath
+	}
+	err = os.Remove(PartPath(destPath))
+	if errors.Is(err, os.ErrNotExist) {
+		err = nil
+	}
+	return errors.Join(err, d.state.Delete(stateID("download", objectName, absPath)))
+}
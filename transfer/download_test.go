@@ -0,0 +1,202 @@
+package transfer
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/pteich/us3ui/s3/memstore"
+)
+
+// rangeStore records requested offsets and fails reads at the offsets in
+// failAt.
+type rangeStore struct {
+	*memstore.Store
+	mu        sync.Mutex
+	failAt    map[int64]bool
+	requested []int64
+}
+
+func (r *rangeStore) DownloadObjectRange(ctx context.Context, objectName, etag string, offset, length int64) (io.ReadCloser, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.failAt[offset] {
+		return nil, errors.New("connection reset")
+	}
+	r.requested = append(r.requested, offset)
+	return r.Store.DownloadObjectRange(ctx, objectName, etag, offset, length)
+}
+
+func (r *rangeStore) offsets() []int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	offsets := append([]int64(nil), r.requested...)
+	sort.Slice(offsets, func(i, j int) bool { return offsets[i] < offsets[j] })
+	return offsets
+}
+
+func TestDownloadWritesFileAtomically(t *testing.T) {
+	store := &rangeStore{Store: memstore.New("bucket")}
+	store.Put("docs/readme.txt", []byte("hello world"))
+	stateDir := t.TempDir()
+	d := NewDownloader(store, NewStateStore(stateDir))
+	d.ChunkSize = 4
+
+	dest := filepath.Join(t.TempDir(), "readme.txt")
+	var last Progress
+	if err := d.Download(context.Background(), "docs/readme.txt", dest, func(p Progress) { last = p }); err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "hello world" {
+		t.Errorf("downloaded %q", data)
+	}
+	if _, err := os.Stat(PartPath(dest)); !os.IsNotExist(err) {
+		t.Errorf("part file still exists: %v", err)
+	}
+	if last.Fraction() != 1 {
+		t.Errorf("final progress = %+v", last)
+	}
+	if entries, _ := os.ReadDir(stateDir); len(entries) != 0 {
+		t.Errorf("state left behind: %v", entries)
+	}
+}
+
+func TestDownloadResumesMissingChunks(t *testing.T) {
+	content := "aaaabbbbccccdddde"
+	store := &rangeStore{Store: memstore.New("bucket"), failAt: map[int64]bool{8: true}}
+	store.Put("big.bin", []byte(content))
+	state := NewStateStore(t.TempDir())
+	dest := filepath.Join(t.TempDir(), "big.bin")
+
+	d := NewDownloader(store, state)
+	d.ChunkSize = 4
+	if err := d.Download(context.Background(), "big.bin", dest, nil); err == nil {
+		t.Fatal("expected first attempt to fail")
+	}
+	if _, err := os.Stat(dest); !os.IsNotExist(err) {
+		t.Fatalf("destination exists after failed download")
+	}
+	if _, err := os.Stat(PartPath(dest)); err != nil {
+		t.Fatalf("part file missing after failed download: %v", err)
+	}
+
+	store.failAt = nil
+	store.requested = nil
+	d = NewDownloader(store, state)
+	d.ChunkSize = 4
+	if err := d.Download(context.Background(), "big.bin", dest, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := store.offsets(); len(got) != 3 || got[0] != 8 {
+		t.Errorf("resumed download requested offsets %v, want [8 12 16]", got)
+	}
+	if data, _ := os.ReadFile(dest); string(data) != content {
+		t.Errorf("downloaded %q, want %q", data, content)
+	}
+}
+
+func TestDownloadRestartsWhenObjectChanged(t *testing.T) {
+	store := &rangeStore{Store: memstore.New("bucket"), failAt: map[int64]bool{4: true}}
+	store.Put("file.txt", []byte("11112222"))
+	state := NewStateStore(t.TempDir())
+	dest := filepath.Join(t.TempDir(), "file.txt")
+
+	d := NewDownloader(store, state)
+	d.ChunkSize = 4
+	if err := d.Download(context.Background(), "file.txt", dest, nil); err == nil {
+		t.Fatal("expected first attempt to fail")
+	}
+
+	store.Put("file.txt", []byte("33334444"))
+	store.failAt = nil
+	store.requested = nil
+	if err := d.Download(context.Background(), "file.txt", dest, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := store.offsets(); len(got) != 2 || got[0] != 0 {
+		t.Errorf("requested offsets %v, want a full download", got)
+	}
+	if data, _ := os.ReadFile(dest); string(data) != "33334444" {
+		t.Errorf("downloaded %q", data)
+	}
+}
+
+func TestDownloadParallelRanges(t *testing.T) {
+	content := strings.Repeat("0123456789", 50)
+	store := &rangeStore{Store: memstore.New("bucket")}
+	store.Put("large.bin", []byte(content))
+
+	d := NewDownloader(store, NewStateStore(t.TempDir()))
+	d.ChunkSize = 16
+	d.Parallel = 4
+	d.ParallelThreshold = 100
+	dest := filepath.Join(t.TempDir(), "large.bin")
+
+	if err := d.Download(context.Background(), "large.bin", dest, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if data, _ := os.ReadFile(dest); string(data) != content {
+		t.Errorf("parallel download corrupted the file")
+	}
+	if got := len(store.offsets()); got != 32 {
+		t.Errorf("requested %d ranges, want 32", got)
+	}
+}
+
+func TestDownloadEmptyObject(t *testing.T) {
+	store := &rangeStore{Store: memstore.New("bucket")}
+	store.Put("empty", nil)
+
+	d := NewDownloader(store, NewStateStore(t.TempDir()))
+	dest := filepath.Join(t.TempDir(), "empty")
+	if err := d.Download(context.Background(), "empty", dest, nil); err != nil {
+		t.Fatal(err)
+	}
+	if fi, err := os.Stat(dest); err != nil || fi.Size() != 0 {
+		t.Errorf("empty download: %v, %v", fi, err)
+	}
+}
+
+func TestDownloadVersion(t *testing.T) {
+	store := memstore.New("bucket")
+	ctx := context.Background()
+	if err := store.SetBucketVersioning(ctx, "bucket", true); err != nil {
+		t.Fatal(err)
+	}
+	store.Put("notes.txt", []byte("first"))
+	store.Put("notes.txt", []byte("second"))
+	versions, err := store.ListObjectVersions(ctx, "notes.txt")
+	if err != nil || len(versions) != 2 {
+		t.Fatalf("versions = %v, %v", versions, err)
+	}
+
+	dest := filepath.Join(t.TempDir(), "old", "notes.txt")
+	d := NewDownloader(store, NewStateStore(t.TempDir()))
+	if err := d.DownloadVersion(ctx, "notes.txt", versions[1].VersionID, versions[1].Size, dest, nil); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "first" {
+		t.Errorf("downloaded %q, want the older version", data)
+	}
+	if _, err := os.Stat(PartPath(dest)); !os.IsNotExist(err) {
+		t.Errorf("part file left behind")
+	}
+}
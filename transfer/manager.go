@@ -0,0 +1,502 @@
+package transfer
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+)
+
+const (
+	DefaultWorkers = 3
+	notifyInterval = 250 * time.Millisecond // minimum gap between progress notifications
+	speedWindow    = time.Second            // interval speed samples are taken over
+)
+
+// Kind tells what a queued transfer does.
+type Kind int
+
+const (
+	KindUpload Kind = iota
+	KindDownload
+	KindCopy
+)
+
+func (k Kind) String() string {
+	switch k {
+	case KindUpload:
+		return "Upload"
+	case KindDownload:
+		return "Download"
+	case KindCopy:
+		return "Copy"
+	default:
+		return "Transfer"
+	}
+}
+
+// Status is the lifecycle state of a queued transfer.
+type Status int
+
+const (
+	StatusQueued Status = iota
+	StatusRunning
+	StatusPaused
+	StatusDone
+	StatusFailed
+	StatusCanceled
+)
+
+func (s Status) String() string {
+	switch s {
+	case StatusQueued:
+		return "Queued"
+	case StatusRunning:
+		return "Running"
+	case StatusPaused:
+		return "Paused"
+	case StatusDone:
+		return "Done"
+	case StatusFailed:
+		return "Failed"
+	case StatusCanceled:
+		return "Canceled"
+	default:
+		return "Unknown"
+	}
+}
+
+// Finished reports whether the transfer reached a terminal state.
+func (s Status) Finished() bool {
+	return s == StatusDone || s == StatusFailed || s == StatusCanceled
+}
+
+// Task describes a transfer to queue. Run may be called several times for
+// the same task (resume after pause, retry after failure) and should pick up
+// where the previous attempt stopped.
+type Task struct {
+	Kind   Kind
+	Name   string
+	Source string
+	Target string
+	Size   int64
+
+	Run func(ctx context.Context, onProgress func(Progress)) error
+	// Cleanup runs after the task was canceled, e.g. to drop partial data.
+	Cleanup func()
+	// OnFinish runs every time the task reaches a terminal state. err is nil
+	// on success and context.Canceled when the user canceled it.
+	OnFinish func(err error)
+}
+
+// JobInfo is a snapshot of a queued transfer.
+type JobInfo struct {
+	ID       int
+	Kind     Kind
+	Name     string
+	Source   string
+	Target   string
+	Status   Status
+	Err      error
+	Progress Progress
+	Speed    float64 // bytes per second
+	ETA      time.Duration
+}
+
+type job struct {
+	id       int
+	task     Task
+	status   Status
+	err      error
+	progress Progress
+	cancel   context.CancelFunc
+	active   bool // Run has not returned yet
+
+	speed       float64
+	sampleAt    time.Time
+	sampleBytes int64
+}
+
+// Manager runs queued transfers with a limited number of parallel workers.
+type Manager struct {
+	mu         sync.Mutex
+	workers    int
+	running    int
+	finishing  int // jobs whose hooks have not returned yet
+	nextID     int
+	jobs       []*job
+	listeners  map[int]func()
+	nextListen int
+	lastNotify time.Time
+	idle       *sync.Cond
+}
+
+func NewManager(workers int) *Manager {
+	if workers <= 0 {
+		workers = DefaultWorkers
+	}
+	m := &Manager{
+		workers:   workers,
+		listeners: make(map[int]func()),
+	}
+	m.idle = sync.NewCond(&m.mu)
+	return m
+}
+
+// Workers returns the number of transfers that may run at the same time.
+func (m *Manager) Workers() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.workers
+}
+
+// SetWorkers changes the number of parallel transfers. Running transfers
+// are not interrupted when the limit is lowered.
+func (m *Manager) SetWorkers(n int) {
+	if n <= 0 {
+		n = DefaultWorkers
+	}
+	m.mu.Lock()
+	m.workers = n
+	m.scheduleLocked()
+	m.mu.Unlock()
+	m.notify(true)
+}
+
+// Subscribe registers fn to be called whenever the queue changes. Progress
+// updates are throttled. fn is called from arbitrary goroutines. The returned
+// function removes the subscription.
+func (m *Manager) Subscribe(fn func()) func() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	id := m.nextListen
+	m.nextListen++
+	m.listeners[id] = fn
+	return func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		delete(m.listeners, id)
+	}
+}
+
+// Add queues a task and returns its job ID.
+func (m *Manager) Add(task Task) int {
+	m.mu.Lock()
+	m.nextID++
+	j := &job{id: m.nextID, task: task, status: StatusQueued}
+	j.progress.Total = task.Size
+	m.jobs = append(m.jobs, j)
+	m.scheduleLocked()
+	id := j.id
+	m.mu.Unlock()
+	m.notify(true)
+	return id
+}
+
+// Jobs returns a snapshot of all transfers in queue order.
+func (m *Manager) Jobs() []JobInfo {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	infos := make([]JobInfo, len(m.jobs))
+	for i, j := range m.jobs {
+		infos[i] = j.info()
+	}
+	return infos
+}
+
+// Counts returns how many transfers are waiting and running.
+func (m *Manager) Counts() (queued, running int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, j := range m.jobs {
+		switch j.status {
+		case StatusQueued:
+			queued++
+		case StatusRunning:
+			running++
+		}
+	}
+	return queued, running
+}
+
+// Pause stops a queued or running transfer so it can be resumed later.
+func (m *Manager) Pause(id int) {
+	m.mu.Lock()
+	j := m.findLocked(id)
+	if j == nil || (j.status != StatusQueued && j.status != StatusRunning) {
+		m.mu.Unlock()
+		return
+	}
+	j.status = StatusPaused
+	if j.cancel != nil {
+		j.cancel()
+	}
+	if !m.busyLocked() {
+		m.idle.Broadcast()
+	}
+	m.mu.Unlock()
+	m.notify(true)
+}
+
+// Resume queues a paused transfer again.
+func (m *Manager) Resume(id int) {
+	m.requeue(id, StatusPaused)
+}
+
+// Retry queues a failed or canceled transfer again.
+func (m *Manager) Retry(id int) {
+	m.requeue(id, StatusFailed, StatusCanceled)
+}
+
+// Cancel aborts a transfer. Its Cleanup runs once it has stopped.
+func (m *Manager) Cancel(id int) {
+	m.mu.Lock()
+	j := m.findLocked(id)
+	if j == nil || j.status.Finished() {
+		m.mu.Unlock()
+		return
+	}
+
+	j.status = StatusCanceled
+	j.err = context.Canceled
+	if j.active {
+		// The worker finishes the job once Run returns.
+		j.cancel()
+		m.mu.Unlock()
+		m.notify(true)
+		return
+	}
+	m.finishing++
+	m.mu.Unlock()
+
+	m.finish(j, context.Canceled)
+}
+
+// CancelAll aborts every transfer that has not finished yet.
+func (m *Manager) CancelAll() {
+	for _, info := range m.Jobs() {
+		if !info.Status.Finished() {
+			m.Cancel(info.ID)
+		}
+	}
+}
+
+// ClearFinished removes completed, failed and canceled transfers from the
+// list.
+func (m *Manager) ClearFinished() {
+	m.mu.Lock()
+	jobs := m.jobs[:0]
+	for _, j := range m.jobs {
+		if !j.status.Finished() {
+			jobs = append(jobs, j)
+		}
+	}
+	clear(m.jobs[len(jobs):])
+	m.jobs = jobs
+	m.mu.Unlock()
+	m.notify(true)
+}
+
+// Wait blocks until no transfer is queued or running.
+func (m *Manager) Wait() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for m.busyLocked() {
+		m.idle.Wait()
+	}
+}
+
+func (m *Manager) busyLocked() bool {
+	for _, j := range m.jobs {
+		if j.status == StatusQueued || j.status == StatusRunning {
+			return true
+		}
+	}
+	return m.running > 0 || m.finishing > 0
+}
+
+func (m *Manager) requeue(id int, from ...Status) {
+	m.mu.Lock()
+	j := m.findLocked(id)
+	if j == nil || !statusIn(j.status, from) {
+		m.mu.Unlock()
+		return
+	}
+	j.status = StatusQueued
+	j.err = nil
+	j.speed = 0
+	m.scheduleLocked()
+	m.mu.Unlock()
+	m.notify(true)
+}
+
+func (m *Manager) findLocked(id int) *job {
+	for _, j := range m.jobs {
+		if j.id == id {
+			return j
+		}
+	}
+	return nil
+}
+
+// scheduleLocked starts queued jobs while workers are free.
+func (m *Manager) scheduleLocked() {
+	for _, j := range m.jobs {
+		if m.running >= m.workers {
+			return
+		}
+		if j.status != StatusQueued || j.active {
+			// An active job was resumed before its previous run returned;
+			// it is started again once that happened.
+			continue
+		}
+
+		ctx, cancel := context.WithCancel(context.Background())
+		j.status = StatusRunning
+		j.cancel = cancel
+		j.active = true
+		j.sampleAt = time.Now()
+		j.sampleBytes = j.progress.Bytes
+		m.running++
+		go m.run(ctx, j)
+	}
+}
+
+func (m *Manager) run(ctx context.Context, j *job) {
+	err := j.task.Run(ctx, func(p Progress) {
+		m.updateProgress(j, p)
+	})
+
+	m.mu.Lock()
+	m.running--
+	if j.cancel != nil {
+		j.cancel()
+	}
+	j.cancel = nil
+	j.active = false
+	j.speed = 0
+
+	var finishErr error
+	finished := true
+	switch {
+	case j.status == StatusPaused || j.status == StatusQueued:
+		// Paused, or paused and resumed again while Run was stopping.
+		finished = false
+	case j.status == StatusCanceled:
+		finishErr = context.Canceled
+	case err != nil:
+		j.status = StatusFailed
+		j.err = err
+		finishErr = err
+	default:
+		j.status = StatusDone
+		j.progress.Bytes = j.progress.Total
+	}
+	if finished {
+		m.finishing++
+	}
+	m.scheduleLocked()
+	if !m.busyLocked() {
+		m.idle.Broadcast()
+	}
+	m.mu.Unlock()
+
+	if finished {
+		m.finish(j, finishErr)
+		return
+	}
+	m.notify(true)
+}
+
+// finish runs the task's hooks for a job that reached a terminal state.
+func (m *Manager) finish(j *job, err error) {
+	if errors.Is(err, context.Canceled) && j.task.Cleanup != nil {
+		j.task.Cleanup()
+	}
+	if j.task.OnFinish != nil {
+		j.task.OnFinish(err)
+	}
+
+	m.mu.Lock()
+	m.finishing--
+	if !m.busyLocked() {
+		m.idle.Broadcast()
+	}
+	m.mu.Unlock()
+	m.notify(true)
+}
+
+func (m *Manager) updateProgress(j *job, p Progress) {
+	m.mu.Lock()
+	if p.Total <= 0 {
+		p.Total = j.progress.Total
+	}
+	j.progress = p
+
+	now := time.Now()
+	if elapsed := now.Sub(j.sampleAt); elapsed >= speedWindow {
+		current := float64(p.Bytes-j.sampleBytes) / elapsed.Seconds()
+		if current < 0 {
+			current = 0
+		}
+		if j.speed == 0 {
+			j.speed = current
+		} else {
+			j.speed = 0.7*j.speed + 0.3*current
+		}
+		j.sampleAt = now
+		j.sampleBytes = p.Bytes
+	}
+	m.mu.Unlock()
+
+	m.notify(false)
+}
+
+// notify calls the listeners. Progress updates (force == false) are
+// throttled to notifyInterval.
+func (m *Manager) notify(force bool) {
+	m.mu.Lock()
+	now := time.Now()
+	if !force && now.Sub(m.lastNotify) < notifyInterval {
+		m.mu.Unlock()
+		return
+	}
+	m.lastNotify = now
+	listeners := make([]func(), 0, len(m.listeners))
+	for _, fn := range m.listeners {
+		listeners = append(listeners, fn)
+	}
+	m.mu.Unlock()
+
+	for _, fn := range listeners {
+		fn()
+	}
+}
+
+func (j *job) info() JobInfo {
+	info := JobInfo{
+		ID:       j.id,
+		Kind:     j.task.Kind,
+		Name:     j.task.Name,
+		Source:   j.task.Source,
+		Target:   j.task.Target,
+		Status:   j.status,
+		Err:      j.err,
+		Progress: j.progress,
+		Speed:    j.speed,
+	}
+	if j.status == StatusRunning && j.speed > 0 && j.progress.Total > j.progress.Bytes {
+		info.ETA = time.Duration(float64(j.progress.Total-j.progress.Bytes) / j.speed * float64(time.Second))
+	}
+	return info
+}
+
+func statusIn(status Status, list []Status) bool {
+	for _, s := range list {
+		if status == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,191 @@
+package transfer
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("condition not met in time")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func statusOf(m *Manager, id int) Status {
+	for _, info := range m.Jobs() {
+		if info.ID == id {
+			return info.Status
+		}
+	}
+	return -1
+}
+
+func TestManagerLimitsParallelWorkers(t *testing.T) {
+	m := NewManager(2)
+
+	var running, peak atomic.Int32
+	release := make(chan struct{})
+	for range 5 {
+		m.Add(Task{Run: func(ctx context.Context, _ func(Progress)) error {
+			n := running.Add(1)
+			for {
+				p := peak.Load()
+				if n <= p || peak.CompareAndSwap(p, n) {
+					break
+				}
+			}
+			<-release
+			running.Add(-1)
+			return nil
+		}})
+	}
+
+	waitFor(t, func() bool { return running.Load() == 2 })
+	if queued, active := m.Counts(); queued != 3 || active != 2 {
+		t.Errorf("Counts() = %d queued, %d running, want 3 and 2", queued, active)
+	}
+	close(release)
+	m.Wait()
+
+	if peak.Load() != 2 {
+		t.Errorf("peak parallel transfers = %d, want 2", peak.Load())
+	}
+	for _, info := range m.Jobs() {
+		if info.Status != StatusDone {
+			t.Errorf("job %d status = %v, want Done", info.ID, info.Status)
+		}
+	}
+}
+
+func TestManagerPauseResume(t *testing.T) {
+	m := NewManager(1)
+
+	var attempts atomic.Int32
+	started := make(chan struct{}, 2)
+	id := m.Add(Task{Run: func(ctx context.Context, _ func(Progress)) error {
+		if attempts.Add(1) == 1 {
+			started <- struct{}{}
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		return nil
+	}})
+
+	<-started
+	m.Pause(id)
+	waitFor(t, func() bool {
+		_, running := m.Counts()
+		return running == 0
+	})
+	if got := statusOf(m, id); got != StatusPaused {
+		t.Fatalf("status after pause = %v, want Paused", got)
+	}
+
+	m.Resume(id)
+	m.Wait()
+	if got := statusOf(m, id); got != StatusDone {
+		t.Errorf("status after resume = %v, want Done", got)
+	}
+	if attempts.Load() != 2 {
+		t.Errorf("Run called %d times, want 2", attempts.Load())
+	}
+}
+
+func TestManagerCancelRunsCleanup(t *testing.T) {
+	m := NewManager(1)
+
+	started := make(chan struct{})
+	var cleaned atomic.Bool
+	var finishErr error
+	var mu sync.Mutex
+	id := m.Add(Task{
+		Run: func(ctx context.Context, _ func(Progress)) error {
+			close(started)
+			<-ctx.Done()
+			return ctx.Err()
+		},
+		Cleanup: func() { cleaned.Store(true) },
+		OnFinish: func(err error) {
+			mu.Lock()
+			finishErr = err
+			mu.Unlock()
+		},
+	})
+	queuedID := m.Add(Task{Run: func(ctx context.Context, _ func(Progress)) error { return nil }})
+
+	<-started
+	m.Cancel(queuedID)
+	m.Cancel(id)
+	m.Wait()
+
+	if !cleaned.Load() {
+		t.Errorf("Cleanup was not called")
+	}
+	mu.Lock()
+	if !errors.Is(finishErr, context.Canceled) {
+		t.Errorf("OnFinish err = %v, want context.Canceled", finishErr)
+	}
+	mu.Unlock()
+	if got := statusOf(m, queuedID); got != StatusCanceled {
+		t.Errorf("queued job status = %v, want Canceled", got)
+	}
+}
+
+func TestManagerRetryFailed(t *testing.T) {
+	m := NewManager(1)
+
+	var attempts atomic.Int32
+	id := m.Add(Task{Run: func(ctx context.Context, _ func(Progress)) error {
+		if attempts.Add(1) == 1 {
+			return errors.New("boom")
+		}
+		return nil
+	}})
+	m.Wait()
+
+	jobs := m.Jobs()
+	if jobs[0].Status != StatusFailed || jobs[0].Err == nil {
+		t.Fatalf("job = %+v, want failed with error", jobs[0])
+	}
+
+	m.Retry(id)
+	m.Wait()
+	if got := statusOf(m, id); got != StatusDone {
+		t.Errorf("status after retry = %v, want Done", got)
+	}
+
+	m.ClearFinished()
+	if len(m.Jobs()) != 0 {
+		t.Errorf("ClearFinished left %d jobs", len(m.Jobs()))
+	}
+}
+
+func TestManagerNotifiesSubscribers(t *testing.T) {
+	m := NewManager(1)
+
+	var calls atomic.Int32
+	unsubscribe := m.Subscribe(func() { calls.Add(1) })
+	m.Add(Task{Run: func(ctx context.Context, _ func(Progress)) error { return nil }})
+	m.Wait()
+
+	if calls.Load() == 0 {
+		t.Errorf("subscriber was not notified")
+	}
+
+	unsubscribe()
+	before := calls.Load()
+	m.Add(Task{Run: func(ctx context.Context, _ func(Progress)) error { return nil }})
+	m.Wait()
+	if calls.Load() != before {
+		t.Errorf("subscriber notified after unsubscribe")
+	}
+}
@@ -0,0 +1,87 @@
+package transfer
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/kirsle/configdir"
+
+	"github.com/pteich/us3ui/config"
+)
+
+// StateStore persists the progress of interrupted transfers as small JSON
+// files so they can be resumed after a crash or restart.
+type StateStore struct {
+	dir string
+}
+
+// DefaultStateDir returns the per-user directory transfer state is kept in.
+func DefaultStateDir() string {
+	return configdir.LocalCache(config.Name, "transfers")
+}
+
+// NewStateStore returns a store writing to dir. The directory is created on
+// the first save.
+func NewStateStore(dir string) *StateStore {
+	return &StateStore{dir: dir}
+}
+
+// stateID derives a stable file name from the parts identifying a transfer.
+func stateID(kind string, parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(parts, "\x00")))
+	return kind + "-" + hex.EncodeToString(sum[:16])
+}
+
+func (s *StateStore) path(id string) string {
+	return filepath.Join(s.dir, id+".json")
+}
+
+// Load decodes the state saved under id into v. It reports false when nothing
+// (or nothing readable) is stored.
+func (s *StateStore) Load(id string, v any) bool {
+	if s == nil {
+		return false
+	}
+	data, err := os.ReadFile(s.path(id))
+	if err != nil {
+		return false
+	}
+	return json.Unmarshal(data, v) == nil
+}
+
+// Save writes v under id, replacing the previous state atomically.
+func (s *StateStore) Save(id string, v any) error {
+	if s == nil {
+		return nil
+	}
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	tmp := s.path(id) + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path(id))
+}
+
+// Delete removes the state saved under id.
+func (s *StateStore) Delete(id string) error {
+	if s == nil {
+		return nil
+	}
+	err := os.Remove(s.path(id))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,600 @@
+package transfer
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gabriel-vasile/mimetype"
+
+	"github.com/pteich/us3ui/s3"
+)
+
+const (
+	syncListBatch = 1000
+	// syncTimeSlack absorbs file systems that store modification times with
+	// a precision of one or two seconds.
+	syncTimeSlack = 2 * time.Second
+)
+
+// SyncEndpoint is one side of a sync: a prefix of a bucket or a local
+// directory.
+type SyncEndpoint struct {
+	Store s3.ObjectStore // nil for a local directory
+	Path  string         // key prefix ending with the delimiter, or a local directory
+}
+
+// Local reports whether e is a local directory.
+func (e SyncEndpoint) Local() bool {
+	return e.Store == nil
+}
+
+// location returns the key or local path of rel, a slash separated path
+// below e.
+func (e SyncEndpoint) location(rel string) string {
+	if e.Local() {
+		return filepath.Join(e.Path, filepath.FromSlash(rel))
+	}
+	return e.Path + rel
+}
+
+// SyncFile is a file or object found below an endpoint.
+type SyncFile struct {
+	Size    int64
+	ModTime time.Time
+	ETag    string // empty for local files
+}
+
+// SyncDirection says which way data flows.
+type SyncDirection int
+
+const (
+	SyncToTarget SyncDirection = iota // make the target match the source
+	SyncToSource                      // make the source match the target
+	SyncBoth                          // copy new and newer files both ways
+)
+
+// SyncStatus describes a file from the side data flows to: it is missing
+// there, different, only there or identical.
+type SyncStatus int
+
+const (
+	SyncIdentical SyncStatus = iota
+	SyncNew
+	SyncChanged
+	SyncDeleted
+)
+
+func (s SyncStatus) String() string {
+	switch s {
+	case SyncIdentical:
+		return "Identical"
+	case SyncNew:
+		return "New"
+	case SyncChanged:
+		return "Changed"
+	case SyncDeleted:
+		return "Deleted"
+	}
+	return "Unknown"
+}
+
+// SyncAction is what applying a sync does with a file.
+type SyncAction int
+
+const (
+	SyncSkip SyncAction = iota
+	SyncCopyToTarget
+	SyncCopyToSource
+	SyncDeleteTarget
+	SyncDeleteSource
+)
+
+func (a SyncAction) String() string {
+	switch a {
+	case SyncSkip:
+		return "Skip"
+	case SyncCopyToTarget:
+		return "Copy to target"
+	case SyncCopyToSource:
+		return "Copy to source"
+	case SyncDeleteTarget:
+		return "Delete from target"
+	case SyncDeleteSource:
+		return "Delete from source"
+	}
+	return "Unknown"
+}
+
+// SyncItem is a file of the dry-run diff and what applying it does.
+type SyncItem struct {
+	Rel     string // slash separated path below both endpoints
+	Status  SyncStatus
+	Action  SyncAction
+	Size    int64     // size of the copied file
+	ModTime time.Time // modification time of the copied file
+}
+
+// SyncOptions control how endpoints are compared and synced.
+type SyncOptions struct {
+	Direction SyncDirection
+	// Delete removes files on the side data flows to that are missing on the
+	// other side. It has no effect when syncing both ways, as there is no way
+	// to tell a deleted file from a new one.
+	Delete bool
+	Filter Filter
+}
+
+// SyncProgress reports how far applying a sync has come.
+type SyncProgress struct {
+	Rel        string // file being transferred
+	Done       int
+	Total      int
+	Bytes      int64
+	TotalBytes int64
+}
+
+// SyncResult summarizes an applied sync.
+type SyncResult struct {
+	Copied  int
+	Deleted int
+	Bytes   int64
+	Errors  []string // one line per failed file
+}
+
+// Syncer compares two endpoints and copies what differs.
+type Syncer struct {
+	Source  SyncEndpoint
+	Target  SyncEndpoint
+	Options SyncOptions
+	state   *StateStore
+}
+
+func NewSyncer(source, target SyncEndpoint, opts SyncOptions, state *StateStore) *Syncer {
+	return &Syncer{Source: source, Target: target, Options: opts, state: state}
+}
+
+// Plan lists both endpoints and returns the dry-run diff sorted by path.
+// Files of the same size are compared by ETag or MD5 where both sides have
+// one, otherwise by modification time. Files the last sync copied count as
+// identical as long as neither side changed.
+func (s *Syncer) Plan(ctx context.Context) ([]SyncItem, error) {
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+	source, err := ListSyncFiles(ctx, s.Source, s.Options.Filter, s.Target.Local())
+	if err != nil {
+		return nil, err
+	}
+	target, err := ListSyncFiles(ctx, s.Target, s.Options.Filter, s.Source.Local())
+	if err != nil {
+		return nil, err
+	}
+
+	rels := make([]string, 0, len(source)+len(target))
+	for rel := range source {
+		rels = append(rels, rel)
+	}
+	for rel := range target {
+		if _, ok := source[rel]; !ok {
+			rels = append(rels, rel)
+		}
+	}
+	sort.Strings(rels)
+
+	items := make([]SyncItem, 0, len(rels))
+	for _, rel := range rels {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		src, inSource := source[rel]
+		dst, inTarget := target[rel]
+		item := SyncItem{Rel: rel}
+		switch {
+		case !inTarget:
+			item = s.onlyOnOneSide(rel, src, true)
+		case !inSource:
+			item = s.onlyOnOneSide(rel, dst, false)
+		default:
+			same, err := s.sameContent(ctx, rel, src, dst)
+			if err != nil {
+				return nil, err
+			}
+			if !same {
+				item = s.changed(rel, src, dst)
+			}
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func (s *Syncer) validate() error {
+	src, dst := s.Source, s.Target
+	switch {
+	case src.Local() && dst.Local():
+		return errors.New("one side of a sync has to be a bucket")
+	case src.Local() || dst.Local():
+		return nil
+	case src.Store == dst.Store && (strings.HasPrefix(src.Path, dst.Path) || strings.HasPrefix(dst.Path, src.Path)):
+		return errors.New("the prefixes of a sync within one bucket must not contain each other")
+	}
+	return nil
+}
+
+// onlyOnOneSide handles a file found in the source or in the target only.
+func (s *Syncer) onlyOnOneSide(rel string, f SyncFile, inSource bool) SyncItem {
+	item := SyncItem{Rel: rel, Size: f.Size, ModTime: f.ModTime}
+	switch dir := s.Options.Direction; {
+	case dir == SyncBoth:
+		item.Status = SyncNew
+		item.Action = SyncCopyToTarget
+		if !inSource {
+			item.Action = SyncCopyToSource
+		}
+	case (dir == SyncToTarget) == inSource:
+		item.Status = SyncNew
+		item.Action = SyncCopyToTarget
+		if dir == SyncToSource {
+			item.Action = SyncCopyToSource
+		}
+	default:
+		item.Status = SyncDeleted
+		if s.Options.Delete {
+			item.Action = SyncDeleteTarget
+			if dir == SyncToSource {
+				item.Action = SyncDeleteSource
+			}
+		}
+	}
+	return item
+}
+
+// changed handles a file that differs between both sides.
+func (s *Syncer) changed(rel string, src, dst SyncFile) SyncItem {
+	toTarget := s.Options.Direction == SyncToTarget ||
+		s.Options.Direction == SyncBoth && !dst.ModTime.After(src.ModTime)
+	if toTarget {
+		return SyncItem{Rel: rel, Status: SyncChanged, Action: SyncCopyToTarget, Size: src.Size, ModTime: src.ModTime}
+	}
+	return SyncItem{Rel: rel, Status: SyncChanged, Action: SyncCopyToSource, Size: dst.Size, ModTime: dst.ModTime}
+}
+
+// sameContent reports whether src and dst hold the same data.
+func (s *Syncer) sameContent(ctx context.Context, rel string, src, dst SyncFile) (bool, error) {
+	if src.Size != dst.Size {
+		return false, nil
+	}
+
+	srcSum, err := s.checksum(ctx, s.Source, rel, src, dst)
+	if err != nil {
+		return false, err
+	}
+	dstSum, err := s.checksum(ctx, s.Target, rel, dst, src)
+	if err != nil {
+		return false, err
+	}
+	if srcSum != "" && dstSum != "" {
+		return srcSum == dstSum, nil
+	}
+	if s.syncedBefore(rel, src, dst) {
+		return true, nil
+	}
+
+	// Without comparable checksums the side data flows from wins if it is
+	// newer.
+	diff := src.ModTime.Sub(dst.ModTime)
+	switch s.Options.Direction {
+	case SyncToTarget:
+		return diff <= syncTimeSlack, nil
+	case SyncToSource:
+		return diff >= -syncTimeSlack, nil
+	}
+	return diff.Abs() <= syncTimeSlack, nil
+}
+
+// syncRecord remembers both sides of a file right after the sync copied it.
+// Copies get a new modification time and objects uploaded in parts have no
+// MD5, so without it the next compare would copy the file back.
+type syncRecord struct {
+	Source SyncFile `json:"source"`
+	Target SyncFile `json:"target"`
+}
+
+// recordID identifies the record of rel. Keys carry only the prefix of an
+// endpoint, the recorded files make sure a record belongs to both sides.
+func (s *Syncer) recordID(rel string) string {
+	id := func(e SyncEndpoint) string {
+		if e.Local() {
+			if abs, err := filepath.Abs(e.Path); err == nil {
+				return abs
+			}
+		}
+		return e.Path
+	}
+	return stateID("sync", id(s.Source), id(s.Target), rel)
+}
+
+// syncedBefore reports whether src and dst are still the files the last sync
+// of rel left behind.
+func (s *Syncer) syncedBefore(rel string, src, dst SyncFile) bool {
+	var rec syncRecord
+	if !s.state.Load(s.recordID(rel), &rec) {
+		return false
+	}
+	return rec.Source.same(src) && rec.Target.same(dst)
+}
+
+// remember records both sides of rel after it was copied.
+func (s *Syncer) remember(ctx context.Context, rel string) error {
+	src, err := statSyncFile(ctx, s.Source, rel)
+	if err != nil {
+		return err
+	}
+	dst, err := statSyncFile(ctx, s.Target, rel)
+	if err != nil {
+		return err
+	}
+	return s.state.Save(s.recordID(rel), syncRecord{Source: src, Target: dst})
+}
+
+// same reports whether f and other describe the same version of a file.
+// Modification times may differ by the slack, as listing and stat report
+// them with a different precision.
+func (f SyncFile) same(other SyncFile) bool {
+	return f.Size == other.Size && f.ETag == other.ETag && f.ModTime.Sub(other.ModTime).Abs() <= syncTimeSlack
+}
+
+func statSyncFile(ctx context.Context, e SyncEndpoint, rel string) (SyncFile, error) {
+	if e.Local() {
+		info, err := os.Stat(e.location(rel))
+		if err != nil {
+			return SyncFile{}, err
+		}
+		return SyncFile{Size: info.Size(), ModTime: info.ModTime()}, nil
+	}
+	info, err := e.Store.StatObject(ctx, e.location(rel))
+	if err != nil {
+		return SyncFile{}, err
+	}
+	return SyncFile{Size: info.Size, ModTime: info.LastModified, ETag: strings.Trim(info.ETag, `"`)}, nil
+}
+
+// checksum returns the MD5 of f at e, hashing local files only if other
+// has an MD5 to compare with. It is empty if there is none, as for objects
+// uploaded in parts.
+func (s *Syncer) checksum(ctx context.Context, e SyncEndpoint, rel string, f, other SyncFile) (string, error) {
+	if !e.Local() {
+		if isMD5(f.ETag) {
+			return strings.ToLower(f.ETag), nil
+		}
+		return "", nil
+	}
+	if !isMD5(other.ETag) {
+		return "", nil
+	}
+	return fileMD5(ctx, e.location(rel))
+}
+
+// isMD5 reports whether etag is the MD5 of the data. ETags of multipart
+// uploads end in the number of parts.
+func isMD5(etag string) bool {
+	if len(etag) != 32 {
+		return false
+	}
+	_, err := hex.DecodeString(etag)
+	return err == nil
+}
+
+// fileMD5 hashes the file at path. It stops early when ctx is canceled, as
+// large files take a while.
+func fileMD5(ctx context.Context, path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := md5.New()
+	if _, err := io.Copy(h, contextReader{ctx: ctx, r: f}); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// contextReader fails reads once ctx is canceled.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
+
+// ListSyncFiles returns the files below e by their slash separated path.
+// Folder markers and files the filter excludes are left out, as are partial
+// downloads. With forLocal, keys that cannot be stored as a local path are
+// left out too.
+func ListSyncFiles(ctx context.Context, e SyncEndpoint, filter Filter, forLocal bool) (map[string]SyncFile, error) {
+	files := make(map[string]SyncFile)
+	if e.Local() {
+		root := filepath.Clean(e.Path)
+		err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			if p == root {
+				return nil
+			}
+			rel, err := filepath.Rel(root, p)
+			if err != nil {
+				return err
+			}
+			rel = filepath.ToSlash(rel)
+			if d.IsDir() {
+				if filter.Excluded(rel) {
+					return fs.SkipDir
+				}
+				return nil
+			}
+			if !d.Type().IsRegular() || strings.HasSuffix(rel, partFileSuffix) || !filter.Included(rel) {
+				return nil
+			}
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			files[rel] = SyncFile{Size: info.Size(), ModTime: info.ModTime()}
+			return nil
+		})
+		return files, err
+	}
+
+	lastKey := ""
+	for {
+		batch, err := e.Store.ListObjectsBatch(ctx, lastKey, e.Path, syncListBatch)
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range batch {
+			rel := strings.TrimPrefix(obj.Key, e.Path)
+			if strings.HasSuffix(obj.Key, s3.Delimiter) || !filter.Included(rel) || forLocal && !localSafe(rel) {
+				continue
+			}
+			files[rel] = SyncFile{Size: obj.Size, ModTime: obj.LastModified, ETag: strings.Trim(obj.ETag, `"`)}
+		}
+		if len(batch) < syncListBatch {
+			return files, nil
+		}
+		lastKey = batch[len(batch)-1].Key
+	}
+}
+
+// localSafe reports whether rel can be stored below a local directory
+// without escaping it.
+func localSafe(rel string) bool {
+	for _, segment := range strings.Split(rel, s3.Delimiter) {
+		if segment == "" || segment == "." || segment == ".." || strings.ContainsRune(segment, '\\') {
+			return false
+		}
+	}
+	return true
+}
+
+// Apply carries out the actions of items one after another. It stops early
+// when ctx is canceled; what was done so far is in the result.
+func (s *Syncer) Apply(ctx context.Context, items []SyncItem, onProgress func(SyncProgress)) SyncResult {
+	var (
+		result SyncResult
+		todo   []SyncItem
+		total  int64
+	)
+	for _, item := range items {
+		if item.Action == SyncSkip {
+			continue
+		}
+		todo = append(todo, item)
+		if item.Action == SyncCopyToTarget || item.Action == SyncCopyToSource {
+			total += item.Size
+		}
+	}
+
+	report := func(rel string, done int, bytes int64) {
+		if onProgress != nil {
+			onProgress(SyncProgress{Rel: rel, Done: done, Total: len(todo), Bytes: bytes, TotalBytes: total})
+		}
+	}
+	for i, item := range todo {
+		if ctx.Err() != nil {
+			break
+		}
+		report(item.Rel, i, result.Bytes)
+
+		var err error
+		switch item.Action {
+		case SyncCopyToTarget:
+			err = s.copy(ctx, s.Source, s.Target, item, func(p Progress) { report(item.Rel, i, result.Bytes+p.Bytes) })
+		case SyncCopyToSource:
+			err = s.copy(ctx, s.Target, s.Source, item, func(p Progress) { report(item.Rel, i, result.Bytes+p.Bytes) })
+		case SyncDeleteTarget:
+			err = remove(ctx, s.Target, item.Rel)
+		case SyncDeleteSource:
+			err = remove(ctx, s.Source, item.Rel)
+		}
+		if err == nil {
+			err = s.record(ctx, item)
+		}
+
+		switch {
+		case errors.Is(err, context.Canceled):
+		case err != nil:
+			result.Errors = append(result.Errors, fmt.Sprintf("%s: %v", item.Rel, err))
+		case item.Action == SyncDeleteTarget || item.Action == SyncDeleteSource:
+			result.Deleted++
+		default:
+			result.Copied++
+			result.Bytes += item.Size
+		}
+	}
+	return result
+}
+
+// copy transfers item from one endpoint to the other. Downloaded files get
+// the modification time of the object so later comparisons see them as
+// identical.
+func (s *Syncer) copy(ctx context.Context, from, to SyncEndpoint, item SyncItem, onProgress func(Progress)) error {
+	src, dst := from.location(item.Rel), to.location(item.Rel)
+	switch {
+	case from.Local():
+		mt, err := mimetype.DetectFile(src)
+		if err != nil {
+			return err
+		}
+		return NewUploader(to.Store, s.state).Upload(ctx, src, dst, mt.String(), onProgress)
+	case to.Local():
+		if err := NewDownloader(from.Store, s.state).Download(ctx, src, dst, onProgress); err != nil {
+			return err
+		}
+		return os.Chtimes(dst, item.ModTime, item.ModTime)
+	case from.Store == to.Store:
+		if err := from.Store.CopyObject(ctx, src, dst); err != nil {
+			return err
+		}
+		onProgress(Progress{Bytes: item.Size, Total: item.Size})
+		return nil
+	}
+	return NewCopier(from.Store, to.Store).Copy(ctx, src, dst, onProgress)
+}
+
+// record remembers a copied file and forgets a deleted one.
+func (s *Syncer) record(ctx context.Context, item SyncItem) error {
+	if s.state == nil {
+		return nil
+	}
+	if item.Action == SyncDeleteTarget || item.Action == SyncDeleteSource {
+		return s.state.Delete(s.recordID(item.Rel))
+	}
+	return s.remember(ctx, item.Rel)
+}
+
+func remove(ctx context.Context, e SyncEndpoint, rel string) error {
+	if e.Local() {
+		return os.Remove(e.location(rel))
+	}
+	return e.Store.DeleteObject(ctx, e.location(rel))
+}
@@ -0,0 +1,260 @@
+package transfer
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pteich/us3ui/s3/memstore"
+)
+
+func writeSyncFile(t *testing.T, dir, rel, content string, modTime time.Time) {
+	t.Helper()
+	p := filepath.Join(dir, filepath.FromSlash(rel))
+	if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chtimes(p, modTime, modTime); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func planStatuses(t *testing.T, s *Syncer) map[string]SyncItem {
+	t.Helper()
+	items, err := s.Plan(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	byRel := make(map[string]SyncItem, len(items))
+	for _, item := range items {
+		byRel[item.Rel] = item
+	}
+	return byRel
+}
+
+func TestSyncPlanComparesLocalDirectoryWithPrefix(t *testing.T) {
+	dir := t.TempDir()
+	old := time.Now().Add(-time.Hour)
+	writeSyncFile(t, dir, "same.txt", "same", old)
+	writeSyncFile(t, dir, "edited.txt", "new text", old)
+	writeSyncFile(t, dir, "sub/added.txt", "added", old)
+	writeSyncFile(t, dir, ".DS_Store", "junk", old)
+
+	store := memstore.New("bucket")
+	store.Put("backup/same.txt", []byte("same"))
+	store.Put("backup/edited.txt", []byte("old text"))
+	store.Put("backup/gone.txt", []byte("gone"))
+	store.Put("other.txt", []byte("outside the prefix"))
+
+	s := NewSyncer(SyncEndpoint{Path: dir}, SyncEndpoint{Store: store, Path: "backup/"},
+		SyncOptions{Filter: Filter{Exclude: DefaultExclude}}, NewStateStore(t.TempDir()))
+	got := planStatuses(t, s)
+
+	want := map[string]struct {
+		status SyncStatus
+		action SyncAction
+	}{
+		"same.txt":      {SyncIdentical, SyncSkip},
+		"edited.txt":    {SyncChanged, SyncCopyToTarget},
+		"sub/added.txt": {SyncNew, SyncCopyToTarget},
+		"gone.txt":      {SyncDeleted, SyncSkip},
+	}
+	if len(got) != len(want) {
+		t.Errorf("plan = %v, want %d items", got, len(want))
+	}
+	for rel, w := range want {
+		if item := got[rel]; item.Status != w.status || item.Action != w.action {
+			t.Errorf("%s = %v/%v, want %v/%v", rel, item.Status, item.Action, w.status, w.action)
+		}
+	}
+
+	s.Options.Delete = true
+	if item := planStatuses(t, s)["gone.txt"]; item.Action != SyncDeleteTarget {
+		t.Errorf("gone.txt action = %v with deletion enabled", item.Action)
+	}
+}
+
+func TestSyncApplyUploadsAndDeletes(t *testing.T) {
+	dir := t.TempDir()
+	writeSyncFile(t, dir, "a.txt", "alpha", time.Now())
+	writeSyncFile(t, dir, "sub/b.txt", "beta", time.Now())
+	store := memstore.New("bucket")
+	store.Put("mirror/stale.txt", []byte("stale"))
+
+	s := NewSyncer(SyncEndpoint{Path: dir}, SyncEndpoint{Store: store, Path: "mirror/"},
+		SyncOptions{Delete: true}, NewStateStore(t.TempDir()))
+	items, err := s.Plan(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var last SyncProgress
+	result := s.Apply(context.Background(), items, func(p SyncProgress) { last = p })
+	if result.Copied != 2 || result.Deleted != 1 || result.Bytes != 9 || len(result.Errors) != 0 {
+		t.Errorf("result = %+v", result)
+	}
+	if last.Total != 3 {
+		t.Errorf("last progress = %+v", last)
+	}
+	if data, ok := store.Get("mirror/sub/b.txt"); !ok || string(data) != "beta" {
+		t.Errorf("mirror/sub/b.txt = %q, %v", data, ok)
+	}
+	if _, ok := store.Get("mirror/stale.txt"); ok {
+		t.Errorf("mirror/stale.txt was not deleted")
+	}
+
+	for rel, item := range planStatuses(t, s) {
+		if item.Status != SyncIdentical {
+			t.Errorf("%s is %v after the sync", rel, item.Status)
+		}
+	}
+}
+
+func TestSyncToSourceDownloadsWithObjectTime(t *testing.T) {
+	dir := t.TempDir()
+	store := memstore.New("bucket")
+	store.Put("docs/report.csv", []byte("1,2,3"))
+
+	s := NewSyncer(SyncEndpoint{Path: dir}, SyncEndpoint{Store: store, Path: "docs/"},
+		SyncOptions{Direction: SyncToSource}, NewStateStore(t.TempDir()))
+	items, err := s.Plan(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 || items[0].Status != SyncNew || items[0].Action != SyncCopyToSource {
+		t.Fatalf("plan = %+v", items)
+	}
+	if result := s.Apply(context.Background(), items, nil); result.Copied != 1 {
+		t.Fatalf("result = %+v", result)
+	}
+
+	info, err := os.Stat(filepath.Join(dir, "report.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !info.ModTime().Equal(items[0].ModTime) {
+		t.Errorf("mod time = %v, want the object's %v", info.ModTime(), items[0].ModTime)
+	}
+}
+
+func TestSyncBothWaysCopiesNewerSide(t *testing.T) {
+	source := memstore.New("a")
+	source.Put("only-a.txt", []byte("a"))
+	source.Put("shared.txt", []byte("old"))
+	time.Sleep(10 * time.Millisecond)
+	target := memstore.New("b")
+	target.Put("only-b.txt", []byte("b"))
+	target.Put("shared.txt", []byte("new"))
+
+	s := NewSyncer(SyncEndpoint{Store: source}, SyncEndpoint{Store: target},
+		SyncOptions{Direction: SyncBoth, Delete: true}, NewStateStore(t.TempDir()))
+	got := planStatuses(t, s)
+	if got["only-a.txt"].Action != SyncCopyToTarget || got["only-b.txt"].Action != SyncCopyToSource {
+		t.Errorf("new files = %+v, %+v", got["only-a.txt"], got["only-b.txt"])
+	}
+	if item := got["shared.txt"]; item.Status != SyncChanged || item.Action != SyncCopyToSource {
+		t.Errorf("shared.txt = %+v, want the newer target copied back", item)
+	}
+
+	items, _ := s.Plan(context.Background())
+	s.Apply(context.Background(), items, nil)
+	if data, _ := source.Get("shared.txt"); string(data) != "new" {
+		t.Errorf("source shared.txt = %q", data)
+	}
+	if _, ok := target.Get("only-a.txt"); !ok {
+		t.Errorf("only-a.txt was not copied")
+	}
+}
+
+func TestSyncApplyStopsWhenCanceled(t *testing.T) {
+	dir := t.TempDir()
+	writeSyncFile(t, dir, "a.txt", "a", time.Now())
+	writeSyncFile(t, dir, "b.txt", "b", time.Now())
+	store := memstore.New("bucket")
+
+	s := NewSyncer(SyncEndpoint{Path: dir}, SyncEndpoint{Store: store}, SyncOptions{}, NewStateStore(t.TempDir()))
+	items, err := s.Plan(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	result := s.Apply(ctx, items, func(p SyncProgress) {
+		if p.Done == 1 {
+			cancel()
+		}
+	})
+	if result.Copied != 1 || len(result.Errors) != 0 {
+		t.Errorf("result = %+v, want one file copied before the cancel", result)
+	}
+	if _, ok := store.Get("b.txt"); ok {
+		t.Errorf("b.txt was copied after the cancel")
+	}
+}
+
+func TestSyncRejectsOverlappingPrefixes(t *testing.T) {
+	store := memstore.New("bucket")
+	s := NewSyncer(SyncEndpoint{Store: store, Path: "a/"}, SyncEndpoint{Store: store, Path: "a/b/"}, SyncOptions{}, nil)
+	if _, err := s.Plan(context.Background()); err == nil {
+		t.Errorf("a sync of a prefix into itself was planned")
+	}
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	s = NewSyncer(SyncEndpoint{Path: t.TempDir()}, SyncEndpoint{Store: store}, SyncOptions{}, nil)
+	if _, err := s.Plan(canceled); !errors.Is(err, context.Canceled) {
+		t.Errorf("Plan with a canceled context = %v", err)
+	}
+}
+
+func TestSyncBothWaysLeavesCopiedPartsAlone(t *testing.T) {
+	large := make([]byte, DefaultPartSize+1)
+	dir := t.TempDir()
+	writeSyncFile(t, dir, "video.mp4", string(large), time.Now().Add(-time.Hour))
+	source := memstore.New("a")
+	source.PutModified("video.mp4", large, time.Now().Add(-time.Hour))
+
+	for name, from := range map[string]SyncEndpoint{
+		"local":  {Path: dir},
+		"bucket": {Store: source},
+	} {
+		target := memstore.New("b")
+		s := NewSyncer(from, SyncEndpoint{Store: target}, SyncOptions{Direction: SyncBoth}, NewStateStore(t.TempDir()))
+		items, err := s.Plan(context.Background())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if result := s.Apply(context.Background(), items, nil); result.Copied != 1 || len(result.Errors) != 0 {
+			t.Fatalf("%s: result = %+v", name, result)
+		}
+		if info, _ := target.StatObject(context.Background(), "video.mp4"); isMD5(info.ETag) {
+			t.Fatalf("%s: copied in a single part, ETag %s", name, info.ETag)
+		}
+
+		for rel, item := range planStatuses(t, s) {
+			if item.Action != SyncSkip {
+				t.Errorf("%s: %s is %v/%v after the sync", name, rel, item.Status, item.Action)
+			}
+		}
+	}
+}
+
+func TestFileMD5StopsWhenCanceled(t *testing.T) {
+	dir := t.TempDir()
+	writeSyncFile(t, dir, "a.txt", "alpha", time.Now())
+	path := filepath.Join(dir, "a.txt")
+	if sum, err := fileMD5(context.Background(), path); err != nil || sum != "2c1743a391305fbf367df8e4f069f9f9" {
+		t.Errorf("fileMD5() = %s, %v", sum, err)
+	}
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := fileMD5(canceled, path); !errors.Is(err, context.Canceled) {
+		t.Errorf("fileMD5 with a canceled context = %v", err)
+	}
+}
@@ -0,0 +1,271 @@
+// Package transfer moves data between the local filesystem and an
+// s3.ObjectStore. Large uploads use explicit multipart uploads whose progress
+// is persisted, so an interrupted transfer continues where it stopped.
+package transfer
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+
+	"github.com/pteich/us3ui/s3"
+)
+
+const (
+	DefaultPartSize = 16 << 20 // 16 MiB, files up to this size use a single PUT
+	maxUploadParts  = 10000    // S3 limit for parts per multipart upload
+	partSizeAlign   = 1 << 20
+)
+
+// Progress describes how far a transfer has come. Part and Parts are zero for
+// transfers done in a single request.
+type Progress struct {
+	Bytes     int64
+	Total     int64
+	Part      int
+	Parts     int
+	PartBytes int64
+	PartSize  int64
+}
+
+// Fraction returns the overall progress between 0 and 1.
+func (p Progress) Fraction() float64 {
+	if p.Total <= 0 {
+		return 1
+	}
+	return float64(p.Bytes) / float64(p.Total)
+}
+
+// PartFraction returns the progress of the current part between 0 and 1.
+func (p Progress) PartFraction() float64 {
+	if p.PartSize <= 0 {
+		return p.Fraction()
+	}
+	return float64(p.PartBytes) / float64(p.PartSize)
+}
+
+// Uploader uploads local files, switching to resumable multipart uploads for
+// files larger than PartSize.
+type Uploader struct {
+	store    s3.ObjectStore
+	state    *StateStore
+	PartSize int64
+}
+
+// uploadState is what gets persisted between attempts of a multipart upload.
+type uploadState struct {
+	UploadID  string    `json:"uploadId"`
+	Key       string    `json:"key"`
+	LocalPath string    `json:"localPath"`
+	Size      int64     `json:"size"`
+	ModTime   time.Time `json:"modTime"`
+	PartSize  int64     `json:"partSize"`
+	Parts     []s3.Part `json:"parts"`
+}
+
+func NewUploader(store s3.ObjectStore, state *StateStore) *Uploader {
+	return &Uploader{
+		store:    store,
+		state:    state,
+		PartSize: DefaultPartSize,
+	}
+}
+
+// Upload stores the file at localPath as objectName. If a previous attempt
+// for the same file and key was interrupted, the parts already on the server
+// are kept and only the missing ones are sent.
+func (u *Uploader) Upload(ctx context.Context, localPath, objectName, mimeType string, onProgress func(Progress)) error {
+	if onProgress == nil {
+		onProgress = func(Progress) {}
+	}
+
+	f, err := os.Open(localPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	partSize := u.partSize(info.Size())
+	if info.Size() <= partSize {
+		pr := &ProgressReader{
+			Reader: f,
+			Total:  info.Size(),
+			OnProgress: func(read int64) {
+				onProgress(Progress{Bytes: read, Total: info.Size()})
+			},
+		}
+		return u.store.UploadObjectReader(ctx, localPath, objectName, pr, info.Size(), mimeType)
+	}
+
+	return u.uploadMultipart(ctx, f, info, localPath, objectName, mimeType, partSize, onProgress)
+}
+
+// Discard aborts the unfinished multipart upload of localPath to objectName,
+// if any, and forgets its saved state.
+func (u *Uploader) Discard(ctx context.Context, localPath, objectName string) error {
+	absPath, err := filepath.Abs(localPath)
+	if err != nil {
+		absPath = localPath
+	}
+	id := stateID("upload", objectName, absPath)
+
+	var st uploadState
+	if u.state.Load(id, &st) && st.UploadID != "" {
+		_ = u.store.AbortMultipartUpload(ctx, objectName, st.UploadID)
+	}
+	return u.state.Delete(id)
+}
+
+// partSize returns the configured part size, grown if needed so the file
+// fits into the maximum number of parts.
+func (u *Uploader) partSize(size int64) int64 {
+	return partSizeFor(u.PartSize, size)
+}
+
+// partSizeFor returns partSize, or the default if it is not set, grown so
+// an object of size bytes fits into the maximum number of parts.
+func partSizeFor(partSize, size int64) int64 {
+	if partSize <= 0 {
+		partSize = DefaultPartSize
+	}
+	if minimum := (size + maxUploadParts - 1) / maxUploadParts; partSize < minimum {
+		partSize = (minimum + partSizeAlign - 1) / partSizeAlign * partSizeAlign
+	}
+	return partSize
+}
+
+func (u *Uploader) uploadMultipart(ctx context.Context, f *os.File, info os.FileInfo, localPath, objectName, mimeType string, partSize int64, onProgress func(Progress)) error {
+	absPath, err := filepath.Abs(localPath)
+	if err != nil {
+		absPath = localPath
+	}
+	id := stateID("upload", objectName, absPath)
+	size := info.Size()
+	numParts := int((size + partSize - 1) / partSize)
+
+	st, err := u.resumeState(ctx, id, objectName, size, info.ModTime(), partSize)
+	if err != nil {
+		return err
+	}
+	if st == nil {
+		uploadID, err := u.store.NewMultipartUpload(ctx, objectName, mimeType)
+		if err != nil {
+			return err
+		}
+		st = &uploadState{
+			UploadID:  uploadID,
+			Key:       objectName,
+			LocalPath: absPath,
+			Size:      size,
+			ModTime:   info.ModTime(),
+			PartSize:  partSize,
+			Parts:     make([]s3.Part, 0, numParts),
+		}
+		if err := u.state.Save(id, st); err != nil {
+			return err
+		}
+	}
+
+	done := make(map[int]bool, len(st.Parts))
+	var uploaded int64
+	for _, p := range st.Parts {
+		done[p.Number] = true
+		uploaded += p.Size
+	}
+
+	for n := 1; n <= numParts; n++ {
+		if done[n] {
+			continue
+		}
+
+		offset := int64(n-1) * partSize
+		length := min(partSize, size-offset)
+		base := uploaded
+		pr := &ProgressReader{
+			Reader: io.NewSectionReader(f, offset, length),
+			Total:  length,
+			OnProgress: func(read int64) {
+				onProgress(Progress{
+					Bytes:     base + read,
+					Total:     size,
+					Part:      n,
+					Parts:     numParts,
+					PartBytes: read,
+					PartSize:  length,
+				})
+			},
+		}
+
+		part, err := u.store.UploadPart(ctx, objectName, st.UploadID, n, pr, length)
+		if err != nil {
+			return fmt.Errorf("part %d of %d: %w", n, numParts, err)
+		}
+		st.Parts = append(st.Parts, part)
+		uploaded += length
+		if err := u.state.Save(id, st); err != nil {
+			return err
+		}
+	}
+
+	if err := u.store.CompleteMultipartUpload(ctx, objectName, st.UploadID, st.Parts); err != nil {
+		return err
+	}
+	return u.state.Delete(id)
+}
+
+// resumeState returns the saved state of an earlier attempt if it still
+// matches the file and the server still knows the upload. The part list is
+// replaced with what the server reports so parts that were sent but not yet
+// recorded locally are not uploaded twice. The state is only dropped when the
+// server no longer knows the upload; other errors keep it for the next try.
+func (u *Uploader) resumeState(ctx context.Context, id, objectName string, size int64, modTime time.Time, partSize int64) (*uploadState, error) {
+	var st uploadState
+	if !u.state.Load(id, &st) {
+		return nil, nil
+	}
+
+	if st.UploadID == "" || st.Key != objectName || st.Size != size || !st.ModTime.Equal(modTime) || st.PartSize != partSize {
+		// The file changed since the last attempt; its parts are useless.
+		if st.UploadID != "" && st.Key == objectName {
+			_ = u.store.AbortMultipartUpload(ctx, objectName, st.UploadID)
+		}
+		_ = u.state.Delete(id)
+		return nil, nil
+	}
+
+	serverParts, err := u.store.ListParts(ctx, objectName, st.UploadID)
+	switch {
+	case minio.ToErrorResponse(err).Code == "NoSuchUpload":
+		// The upload was completed, aborted or expired on the server.
+		_ = u.state.Delete(id)
+		return nil, nil
+	case err != nil:
+		return nil, err
+	}
+
+	st.Parts = st.Parts[:0]
+	for _, p := range serverParts {
+		if p.Size == expectedPartSize(p.Number, size, partSize) {
+			st.Parts = append(st.Parts, p)
+		}
+	}
+	return &st, nil
+}
+
+func expectedPartSize(number int, size, partSize int64) int64 {
+	offset := int64(number-1) * partSize
+	if offset < 0 || offset >= size {
+		return -1
+	}
+	return min(partSize, size-offset)
+}
@@ -0,0 +1,251 @@
+package transfer
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/pteich/us3ui/s3"
+	"github.com/pteich/us3ui/s3/memstore"
+)
+
+// flakyStore fails UploadPart for the part numbers in failParts, fails
+// ListParts with failList and records every part that was sent.
+type flakyStore struct {
+	*memstore.Store
+	failParts map[int]bool
+	failList  error
+	sent      []int
+}
+
+func (f *flakyStore) ListParts(ctx context.Context, objectName, uploadID string) ([]s3.Part, error) {
+	if f.failList != nil {
+		return nil, f.failList
+	}
+	return f.Store.ListParts(ctx, objectName, uploadID)
+}
+
+func (f *flakyStore) UploadPart(ctx context.Context, objectName, uploadID string, partNumber int, r io.Reader, size int64) (s3.Part, error) {
+	if f.failParts[partNumber] {
+		return s3.Part{}, errors.New("connection reset")
+	}
+	f.sent = append(f.sent, partNumber)
+	return f.Store.UploadPart(ctx, objectName, uploadID, partNumber, r, size)
+}
+
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "data.bin")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestUploadSmallFileUsesSinglePut(t *testing.T) {
+	store := &flakyStore{Store: memstore.New("bucket")}
+	u := NewUploader(store, NewStateStore(t.TempDir()))
+	path := writeFile(t, "tiny")
+
+	var last Progress
+	if err := u.Upload(context.Background(), path, "tiny.bin", "text/plain", func(p Progress) { last = p }); err != nil {
+		t.Fatal(err)
+	}
+
+	if got, _ := store.Get("tiny.bin"); string(got) != "tiny" {
+		t.Errorf("stored %q, want %q", got, "tiny")
+	}
+	if len(store.sent) != 0 {
+		t.Errorf("sent parts %v for a file below the part size", store.sent)
+	}
+	if last.Fraction() != 1 || last.Parts != 0 {
+		t.Errorf("last progress = %+v", last)
+	}
+}
+
+func TestUploadMultipart(t *testing.T) {
+	store := &flakyStore{Store: memstore.New("bucket")}
+	stateDir := t.TempDir()
+	u := NewUploader(store, NewStateStore(stateDir))
+	u.PartSize = 4
+	content := "0123456789"
+	path := writeFile(t, content)
+
+	var parts []int
+	err := u.Upload(context.Background(), path, "big.bin", "application/octet-stream", func(p Progress) {
+		if len(parts) == 0 || parts[len(parts)-1] != p.Part {
+			parts = append(parts, p.Part)
+		}
+		if p.Parts != 3 {
+			t.Errorf("progress reports %d parts, want 3", p.Parts)
+		}
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got, _ := store.Get("big.bin"); string(got) != content {
+		t.Errorf("stored %q, want %q", got, content)
+	}
+	if len(parts) != 3 || parts[0] != 1 || parts[2] != 3 {
+		t.Errorf("progress parts = %v, want [1 2 3]", parts)
+	}
+	if entries, _ := os.ReadDir(stateDir); len(entries) != 0 {
+		t.Errorf("state left behind after completed upload: %v", entries)
+	}
+}
+
+func TestUploadResumesAfterFailure(t *testing.T) {
+	store := &flakyStore{Store: memstore.New("bucket"), failParts: map[int]bool{3: true}}
+	state := NewStateStore(t.TempDir())
+	content := "abcdefghijklmnopq"
+	path := writeFile(t, content)
+
+	u := NewUploader(store, state)
+	u.PartSize = 4
+	err := u.Upload(context.Background(), path, "resume.bin", "", nil)
+	if err == nil || !strings.Contains(err.Error(), "part 3 of 5") {
+		t.Fatalf("first attempt error = %v, want failure on part 3", err)
+	}
+
+	// A fresh uploader simulates an application restart.
+	store.failParts = nil
+	store.sent = nil
+	u = NewUploader(store, state)
+	u.PartSize = 4
+	if err := u.Upload(context.Background(), path, "resume.bin", "", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(store.sent) != 3 || store.sent[0] != 3 {
+		t.Errorf("resumed upload sent parts %v, want [3 4 5]", store.sent)
+	}
+	if got, _ := store.Get("resume.bin"); string(got) != content {
+		t.Errorf("stored %q, want %q", got, content)
+	}
+	if store.PendingUploads() != 0 {
+		t.Errorf("%d multipart uploads left open", store.PendingUploads())
+	}
+}
+
+func TestUploadRestartsWhenFileChanged(t *testing.T) {
+	store := &flakyStore{Store: memstore.New("bucket"), failParts: map[int]bool{2: true}}
+	state := NewStateStore(t.TempDir())
+	path := writeFile(t, "aaaabbbbcccc")
+
+	u := NewUploader(store, state)
+	u.PartSize = 4
+	if err := u.Upload(context.Background(), path, "changed.bin", "", nil); err == nil {
+		t.Fatal("expected first attempt to fail")
+	}
+
+	content := "xxxxyyyyzzzzw"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+
+	store.failParts = nil
+	store.sent = nil
+	if err := u.Upload(context.Background(), path, "changed.bin", "", nil); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(store.sent) != 4 || store.sent[0] != 1 {
+		t.Errorf("sent parts %v, want a full upload of 4 parts", store.sent)
+	}
+	if got, _ := store.Get("changed.bin"); string(got) != content {
+		t.Errorf("stored %q, want %q", got, content)
+	}
+	if store.PendingUploads() != 0 {
+		t.Errorf("%d multipart uploads left open", store.PendingUploads())
+	}
+}
+
+func TestUploadKeepsStateWhenListingPartsFails(t *testing.T) {
+	store := &flakyStore{Store: memstore.New("bucket"), failParts: map[int]bool{2: true}}
+	state := NewStateStore(t.TempDir())
+	content := "aaaabbbbcccc"
+	path := writeFile(t, content)
+
+	u := NewUploader(store, state)
+	u.PartSize = 4
+	if err := u.Upload(context.Background(), path, "kept.bin", "", nil); err == nil {
+		t.Fatal("expected first attempt to fail")
+	}
+
+	store.failParts = nil
+	store.failList = errors.New("connection reset")
+	if err := u.Upload(context.Background(), path, "kept.bin", "", nil); err == nil {
+		t.Fatal("upload started over although listing the parts failed")
+	}
+	if store.PendingUploads() != 1 {
+		t.Errorf("%d multipart uploads open, want the first one only", store.PendingUploads())
+	}
+
+	store.failList = nil
+	store.sent = nil
+	if err := u.Upload(context.Background(), path, "kept.bin", "", nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(store.sent) != 2 || store.sent[0] != 2 {
+		t.Errorf("resumed upload sent parts %v, want [2 3]", store.sent)
+	}
+	if got, _ := store.Get("kept.bin"); string(got) != content {
+		t.Errorf("stored %q, want %q", got, content)
+	}
+}
+
+func TestUploadRestartsWhenUploadIsGone(t *testing.T) {
+	store := &flakyStore{Store: memstore.New("bucket"), failParts: map[int]bool{2: true}}
+	state := NewStateStore(t.TempDir())
+	path := writeFile(t, "aaaabbbbcccc")
+
+	u := NewUploader(store, state)
+	u.PartSize = 4
+	if err := u.Upload(context.Background(), path, "gone.bin", "", nil); err == nil {
+		t.Fatal("expected first attempt to fail")
+	}
+	// The upload expired on the server while the state was kept locally.
+	var st uploadState
+	if !state.Load(stateID("upload", "gone.bin", path), &st) {
+		t.Fatal("no state saved for the failed upload")
+	}
+	if err := store.AbortMultipartUpload(context.Background(), "gone.bin", st.UploadID); err != nil {
+		t.Fatal(err)
+	}
+
+	store.failParts = nil
+	store.sent = nil
+	if err := u.Upload(context.Background(), path, "gone.bin", "", nil); err != nil {
+		t.Fatal(err)
+	}
+	if len(store.sent) != 3 || store.sent[0] != 1 {
+		t.Errorf("sent parts %v, want a full upload of 3 parts", store.sent)
+	}
+}
+
+func TestPartSizeGrowsForHugeFiles(t *testing.T) {
+	u := NewUploader(nil, nil)
+
+	if got := u.partSize(1 << 30); got != DefaultPartSize {
+		t.Errorf("partSize(1 GiB) = %d, want %d", got, DefaultPartSize)
+	}
+
+	size := int64(500) << 30
+	got := u.partSize(size)
+	if (size+got-1)/got > maxUploadParts {
+		t.Errorf("partSize(500 GiB) = %d needs more than %d parts", got, maxUploadParts)
+	}
+	if got%partSizeAlign != 0 {
+		t.Errorf("partSize(500 GiB) = %d is not MiB aligned", got)
+	}
+}
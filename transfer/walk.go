@@ -0,0 +1,158 @@
+package transfer
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// DefaultExclude lists files operating systems leave in folders that are
+// rarely meant to be uploaded.
+var DefaultExclude = []string{".DS_Store", "Thumbs.db", "desktop.ini"}
+
+// Filter selects the files of a folder upload by glob patterns in the syntax
+// of path.Match. A pattern matches either the file or folder name or the
+// path relative to the uploaded folder, using forward slashes.
+type Filter struct {
+	// Include, if not empty, limits the upload to files matching one of the
+	// patterns.
+	Include []string
+	// Exclude skips matching files and folders including their contents.
+	Exclude []string
+}
+
+// Validate reports the first malformed pattern.
+func (f Filter) Validate() error {
+	for _, pattern := range append(append([]string{}, f.Include...), f.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+// Excluded reports whether rel, a slash separated relative path, matches an
+// exclude pattern.
+func (f Filter) Excluded(rel string) bool {
+	return matchAny(f.Exclude, rel)
+}
+
+// Included reports whether the file at rel passes the filter.
+func (f Filter) Included(rel string) bool {
+	if f.Excluded(rel) {
+		return false
+	}
+	return len(f.Include) == 0 || matchAny(f.Include, rel)
+}
+
+func matchAny(patterns []string, rel string) bool {
+	name := path.Base(rel)
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, rel); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// ParsePatterns splits a comma or newline separated list of glob patterns.
+func ParsePatterns(s string) []string {
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == '\n'
+	})
+	patterns := make([]string, 0, len(fields))
+	for _, field := range fields {
+		if field = strings.TrimSpace(field); field != "" {
+			patterns = append(patterns, field)
+		}
+	}
+	return patterns
+}
+
+// UploadFile is a local file and the key it is stored under.
+type UploadFile struct {
+	LocalPath string
+	Key       string
+	Size      int64
+}
+
+// CollectUploads expands localPaths into the files to upload below prefix,
+// which is empty or ends with a slash. Folders are walked recursively and
+// keep their name and layout in the keys; filter applies to their contents.
+// Files given directly are always included. Paths that cannot be read are
+// reported in the returned error while the remaining files are still
+// returned.
+func CollectUploads(localPaths []string, prefix string, filter Filter) ([]UploadFile, error) {
+	var (
+		files []UploadFile
+		errs  []error
+	)
+
+	for _, localPath := range localPaths {
+		info, err := os.Stat(localPath)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if !info.IsDir() {
+			files = append(files, UploadFile{LocalPath: localPath, Key: prefix + filepath.Base(localPath), Size: info.Size()})
+			continue
+		}
+
+		root := filepath.Clean(localPath)
+		keyPrefix := prefix + filepath.Base(root) + "/"
+		err = filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				errs = append(errs, err)
+				if d != nil && d.IsDir() && p != root {
+					return fs.SkipDir
+				}
+				return nil
+			}
+			if p == root {
+				return nil
+			}
+
+			rel, err := filepath.Rel(root, p)
+			if err != nil {
+				return err
+			}
+			rel = filepath.ToSlash(rel)
+
+			if d.IsDir() {
+				if filter.Excluded(rel) {
+					return fs.SkipDir
+				}
+				return nil
+			}
+			if !filter.Included(rel) {
+				return nil
+			}
+
+			// Follows symlinks; anything that is not a regular file in the
+			// end, like sockets or links to folders, is left out.
+			info, err := os.Stat(p)
+			if err != nil {
+				errs = append(errs, err)
+				return nil
+			}
+			if !info.Mode().IsRegular() {
+				return nil
+			}
+			files = append(files, UploadFile{LocalPath: p, Key: keyPrefix + rel, Size: info.Size()})
+			return nil
+		})
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return files, errors.Join(errs...)
+}
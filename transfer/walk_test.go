@@ -0,0 +1,117 @@
+package transfer
+
+import (
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+)
+
+func writeTree(t *testing.T, root string, files ...string) {
+	t.Helper()
+	for _, name := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(name), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func uploadKeys(files []UploadFile) []string {
+	keys := make([]string, len(files))
+	for i, f := range files {
+		keys[i] = f.Key
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func TestCollectUploadsWalksFolders(t *testing.T) {
+	dir := t.TempDir()
+	assets := filepath.Join(dir, "assets")
+	writeTree(t, assets,
+		"logo.svg",
+		"img/hero.png",
+		"img/.DS_Store",
+		"node_modules/pkg/index.js",
+		"docs/readme.md",
+	)
+	single := filepath.Join(dir, "single.txt")
+	writeTree(t, dir, "single.txt")
+
+	filter := Filter{Exclude: []string{".DS_Store", "node_modules"}}
+	files, err := CollectUploads([]string{assets, single}, "designs/", filter)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"designs/assets/docs/readme.md",
+		"designs/assets/img/hero.png",
+		"designs/assets/logo.svg",
+		"designs/single.txt",
+	}
+	got := uploadKeys(files)
+	if len(got) != len(want) {
+		t.Fatalf("keys = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("keys = %v, want %v", got, want)
+			break
+		}
+	}
+}
+
+func TestCollectUploadsInclude(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, "a.png", "b.jpg", "sub/c.png", "sub/d.txt")
+
+	files, err := CollectUploads([]string{dir}, "", Filter{Include: []string{"*.png"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	base := filepath.Base(dir)
+	got := uploadKeys(files)
+	if len(got) != 2 || got[0] != base+"/a.png" || got[1] != base+"/sub/c.png" {
+		t.Errorf("keys = %v", got)
+	}
+}
+
+func TestCollectUploadsReportsMissingPaths(t *testing.T) {
+	dir := t.TempDir()
+	writeTree(t, dir, "ok.txt")
+
+	files, err := CollectUploads([]string{filepath.Join(dir, "ok.txt"), filepath.Join(dir, "missing.txt")}, "", Filter{})
+	if err == nil {
+		t.Errorf("missing path was not reported")
+	}
+	if len(files) != 1 || files[0].Key != "ok.txt" {
+		t.Errorf("files = %v", files)
+	}
+}
+
+func TestFilterPatterns(t *testing.T) {
+	f := Filter{Exclude: ParsePatterns(".DS_Store, build/*\n*.tmp")}
+	if len(f.Exclude) != 3 {
+		t.Fatalf("Exclude = %q", f.Exclude)
+	}
+	for rel, want := range map[string]bool{
+		"img/.DS_Store":  false,
+		"build/out.js":   false,
+		"src/build.go":   true,
+		"cache/file.tmp": false,
+		"src/main.go":    true,
+	} {
+		if got := f.Included(rel); got != want {
+			t.Errorf("Included(%q) = %v, want %v", rel, got, want)
+		}
+	}
+
+	if err := (Filter{Include: []string{"[a-"}}).Validate(); err == nil {
+		t.Errorf("Validate accepted a malformed pattern")
+	}
+}
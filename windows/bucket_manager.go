@@ -18,17 +18,20 @@ import (
 type BucketManager struct {
 	app          fyne.App
 	parentWindow fyne.Window
-	s3Service    *s3.Service
+	s3Service    s3.ObjectStore
 	window       fyne.Window
 
 	// UI elements
-	bucketList *widget.List
-	buckets    []minio.BucketInfo
-	selectedID int
-	onSelect   func(string)
+	bucketList       *widget.List
+	buckets          []minio.BucketInfo
+	selectedID       int
+	onSelect         func(string)
+	versioningLabel  *widget.Label
+	versioningAction *widget.ToolbarAction
+	versioning       string // state of the selected bucket
 }
 
-func NewBucketManager(a fyne.App, parent fyne.Window, service *s3.Service, onSelect func(string)) *BucketManager {
+func NewBucketManager(a fyne.App, parent fyne.Window, service s3.ObjectStore, onSelect func(string)) *BucketManager {
 	bm := &BucketManager{
 		app:          a,
 		parentWindow: parent,
@@ -71,27 +74,35 @@ func (bm *BucketManager) Show() {
 	})
 	selectAction.Disable()
 
+	bm.versioningAction = widget.NewToolbarAction(theme.HistoryIcon(), bm.toggleVersioning)
+	bm.versioningAction.Disable()
+	bm.versioningLabel = widget.NewLabel("")
+
 	bm.bucketList.OnSelected = func(id widget.ListItemID) {
 		bm.selectedID = id
 		deleteAction.Enable()
 		selectAction.Enable()
+		bm.loadVersioning(bm.buckets[id].Name)
 	}
 
 	bm.bucketList.OnUnselected = func(id widget.ListItemID) {
 		bm.selectedID = -1
 		deleteAction.Disable()
 		selectAction.Disable()
+		bm.versioningAction.Disable()
+		bm.versioningLabel.SetText("")
 	}
 
 	toolbar := widget.NewToolbar(
 		widget.NewToolbarAction(theme.ContentAddIcon(), bm.showAddBucketDialog),
 		deleteAction,
+		bm.versioningAction,
 		widget.NewToolbarSpacer(),
 		selectAction,
 		widget.NewToolbarAction(theme.ViewRefreshIcon(), bm.refreshBuckets),
 	)
 
-	content := container.NewBorder(toolbar, nil, nil, nil, bm.bucketList)
+	content := container.NewBorder(toolbar, container.NewPadded(bm.versioningLabel), nil, nil, bm.bucketList)
 	bm.window.SetContent(content)
 
 	bm.refreshBuckets()
@@ -177,3 +188,68 @@ func (bm *BucketManager) deleteSelectedBucket() {
 		}
 	}, bm.window)
 }
+
+// loadVersioning shows the versioning state of bucketName once it is known.
+func (bm *BucketManager) loadVersioning(bucketName string) {
+	bm.versioningAction.Disable()
+	bm.versioningLabel.SetText("Versioning: loading…")
+
+	go func() {
+		status, err := bm.s3Service.GetBucketVersioning(context.Background(), bucketName)
+		fyne.Do(func() {
+			if bm.selectedID < 0 || bm.selectedID >= len(bm.buckets) || bm.buckets[bm.selectedID].Name != bucketName {
+				return
+			}
+			if err != nil {
+				bm.versioningLabel.SetText("Versioning: unknown (" + err.Error() + ")")
+				return
+			}
+			bm.versioning = status
+			bm.versioningLabel.SetText("Versioning: " + versioningText(status))
+			bm.versioningAction.Enable()
+		})
+	}()
+}
+
+func versioningText(status string) string {
+	switch status {
+	case s3.VersioningEnabled:
+		return "enabled"
+	case s3.VersioningSuspended:
+		return "suspended"
+	default:
+		return "off"
+	}
+}
+
+// toggleVersioning enables versioning of the selected bucket or suspends it
+// if it is enabled.
+func (bm *BucketManager) toggleVersioning() {
+	if bm.selectedID < 0 || bm.selectedID >= len(bm.buckets) {
+		return
+	}
+
+	bucketName := bm.buckets[bm.selectedID].Name
+	enable := bm.versioning != s3.VersioningEnabled
+	msg := fmt.Sprintf("Enable versioning for bucket '%s'?\nOverwritten and deleted objects are kept as older versions.", bucketName)
+	if !enable {
+		msg = fmt.Sprintf("Suspend versioning for bucket '%s'?\nExisting versions are kept, new writes replace the null version.", bucketName)
+	}
+
+	dialog.ShowConfirm("Bucket Versioning", msg, func(confirm bool) {
+		if !confirm {
+			return
+		}
+		go func() {
+			if err := bm.s3Service.SetBucketVersioning(context.Background(), bucketName, enable); err != nil {
+				fyne.Do(func() {
+					dialog.ShowError(err, bm.window)
+				})
+				return
+			}
+			fyne.Do(func() {
+				bm.loadVersioning(bucketName)
+			})
+		}()
+	}, bm.window)
+}
@@ -0,0 +1,234 @@
+package windows
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"github.com/minio/minio-go/v7"
+)
+
+// searchBatchSize is how many keys a bucket search lists per request, the
+// most S3 returns at once.
+const searchBatchSize = 1000
+
+// handleSearchBucket searches all objects below the loaded prefix, or below
+// the open folder when browsing folders, instead of only the loaded ones.
+func (fm *FileManager) handleSearchBucket() {
+	if fm.context == nil {
+		return
+	}
+	if strings.TrimSpace(fm.searchTerm) == "" {
+		dialog.ShowInformation("Search Bucket", "Enter what to search for first.", fm.window)
+		return
+	}
+
+	prefix := fm.basePrefix
+	if fm.folderMode {
+		// Matches are listed flat, with their full keys.
+		prefix = fm.selectedFolder
+		fm.cancelFolders()
+		fm.folderMode = false
+		fm.folderTree.Hide()
+		fm.tree.Show()
+		fm.folderModeCheck.Checked = false
+		fm.folderModeCheck.Refresh()
+	}
+	if err := fm.SearchBucket(fm.context, prefix, fm.searchTerm); err != nil {
+		dialog.ShowError(err, fm.window)
+	}
+}
+
+// SearchBucket lists all objects below prefix in the background and keeps
+// only those matching the search term, so buckets far larger than the object
+// limit can be searched. Matches are shown as they are found.
+func (fm *FileManager) SearchBucket(ctx context.Context, prefix, term string) error {
+	query, err := parseSearchQuery(term)
+	if err != nil {
+		return fmt.Errorf("invalid search: %w", err)
+	}
+	if query == nil {
+		return errors.New("the search is empty")
+	}
+
+	fm.context = ctx
+	fm.cancelLoad()
+	fm.basePrefix = prefix
+	fm.bucketSearch = term
+
+	loadCtx, cancel := context.WithCancel(ctx)
+	handle := &loadHandle{cancel: cancel}
+	fm.loadHandle = handle
+
+	fyne.Do(func() {
+		fm.stopBtn.Show()
+		fm.loadingBar.Show()
+		fm.loadingBar.Start()
+		fm.progressBar.Hide()
+		fm.loadMoreBtn.Hide()
+		fm.itemsLabel.SetText("Searching the bucket…")
+		fm.selectedKeys = nil
+		fm.selectedPrefix = "all"
+		fm.currentObjects = nil
+		fm.allObjects = nil
+		fm.prefixes = make(map[string]bool)
+		fm.hasMoreObjects = false
+		fm.updateTree()
+		fm.resetObjectViews()
+		fm.tree.Select("all")
+		fm.updateActionButtons()
+	})
+
+	fm.loads.Add(1)
+	go func() {
+		defer fm.loads.Done()
+		fm.searchBucketAsync(loadCtx, handle, query, prefix, fm.maxObjects)
+	}()
+	return nil
+}
+
+// searchBucketAsync lists the keys below prefix page by page and adds the
+// matches of query to the listing. Other objects are dropped right away. The
+// search stops at the first match beyond maxMatches unless it is 0.
+func (fm *FileManager) searchBucketAsync(ctx context.Context, handle *loadHandle, query *searchQuery, prefix string, maxMatches int) {
+	var (
+		lastKey    string
+		scanned    int
+		matched    int
+		lastStatus time.Time
+		failed     bool
+		limited    bool
+		startTime  = time.Now()
+	)
+
+	defer fyne.Do(func() {
+		if fm.loadHandle != handle {
+			return
+		}
+		fm.loadingBar.Stop()
+		fm.loadingBar.Hide()
+		fm.stopBtn.Hide()
+		fm.updateTree()
+		fm.updateObjectListLocked(false)
+
+		switch {
+		case ctx.Err() != nil:
+			fm.itemsLabel.SetText(fmt.Sprintf("Search canceled after %d keys, %d matches", scanned, matched))
+		case failed:
+			fm.itemsLabel.SetText(fmt.Sprintf("Search failed after %d keys, %d matches", scanned, matched))
+		case limited:
+			fm.itemsLabel.SetText(fmt.Sprintf("Search stopped at %d matches after %d keys, narrow it down or raise the limit", matched, scanned))
+		default:
+			fm.itemsLabel.SetText(fmt.Sprintf("Searched %d keys in %.1fs, %d matches", scanned, time.Since(startTime).Seconds(), matched))
+		}
+		fm.loadHandle = nil
+	})
+
+	for ctx.Err() == nil && !limited {
+		batch, err := fm.s3svc.ListObjectsBatch(ctx, lastKey, prefix, searchBatchSize)
+		if err != nil {
+			if !errors.Is(err, context.Canceled) {
+				failed = true
+				fyne.Do(func() {
+					dialog.ShowError(err, fm.window)
+				})
+			}
+			return
+		}
+		if len(batch) == 0 {
+			return
+		}
+		lastKey = batch[len(batch)-1].Key
+		scanned += len(batch)
+
+		var matches []minio.ObjectInfo
+		for i := range batch {
+			if query.match(&batch[i]) {
+				matches = append(matches, batch[i])
+			}
+		}
+		// Only a match beyond the limit means the search was cut short.
+		if maxMatches > 0 && matched+len(matches) > maxMatches {
+			matches = matches[:maxMatches-matched]
+			limited = true
+		}
+		matched += len(matches)
+
+		shouldUpdate := len(matches) > 0 && (lastStatus.IsZero() || time.Since(lastStatus) >= uiUpdateInterval)
+		status := fmt.Sprintf("Searching… %d keys, %d matches", scanned, matched)
+		fyne.Do(func() {
+			if fm.loadHandle != handle {
+				return
+			}
+			fm.allObjects = append(fm.allObjects, matches...)
+			for i := range matches {
+				if idx := strings.LastIndex(matches[i].Key, "/"); idx != -1 {
+					fm.prefixes[matches[i].Key[:idx]] = true
+				}
+			}
+			if shouldUpdate {
+				fm.updateTree()
+				fm.updateObjectListLocked(false)
+			}
+			fm.itemsLabel.SetText(status)
+		})
+		if shouldUpdate {
+			lastStatus = time.Now()
+		}
+
+		if len(batch) < searchBatchSize {
+			return
+		}
+	}
+}
+
+// updateSearchMatches updates the matches of the bucket search after keys
+// changed: objects that match now are added or replaced, the others
+// removed. The bucket is only searched again when the user asks for it, so
+// without keys the status just says the matches may be outdated.
+func (fm *FileManager) updateSearchMatches(ctx context.Context, keys []string) {
+	query, err := parseSearchQuery(fm.bucketSearch)
+	if err != nil || query == nil {
+		return
+	}
+	if len(keys) == 0 {
+		fm.itemsLabel.SetText("Objects changed, refresh to search again")
+		return
+	}
+
+	search, prefix := fm.bucketSearch, fm.basePrefix
+	fm.loads.Add(1)
+	go func() {
+		defer fm.loads.Done()
+		var matches []minio.ObjectInfo
+		removed := make(map[string]bool)
+		for _, key := range keys {
+			info, err := fm.s3svc.StatObject(ctx, key)
+			switch {
+			case err == nil && strings.HasPrefix(key, prefix) && query.match(&info):
+				matches = append(matches, info)
+			case err == nil || minio.ToErrorResponse(err).Code == "NoSuchKey":
+				removed[key] = true
+			}
+		}
+
+		fyne.Do(func() {
+			if fm.bucketSearch != search || fm.basePrefix != prefix {
+				return
+			}
+			fm.removeObjects(removed)
+			for _, obj := range matches {
+				// A running search still lists keys after the last one.
+				if fm.loadHandle != nil && len(fm.allObjects) > 0 && obj.Key > fm.allObjects[len(fm.allObjects)-1].Key {
+					continue
+				}
+				fm.insertObject(obj)
+			}
+			fm.listingChanged()
+		})
+	}()
+}
@@ -0,0 +1,129 @@
+package windows
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/pteich/us3ui/s3/memstore"
+)
+
+func newSearchTestStore() *memstore.Store {
+	store := memstore.New("bucket")
+	for i := range 2*searchBatchSize + 500 {
+		store.Put(fmt.Sprintf("data/%06d.bin", i), []byte("x"))
+	}
+	store.Put("reports/2024/q1.csv", []byte("a,b"))
+	store.Put("reports/2025/q1.csv", []byte("a,b"))
+	store.Put("reports/2025/summary.pdf", []byte("%PDF"))
+	return store
+}
+
+func TestSearchBucketKeepsOnlyMatches(t *testing.T) {
+	ctx := context.Background()
+	fm := newTestFileManager(t, newSearchTestStore())
+	fm.searchTerm = "ext:csv"
+
+	if err := fm.SearchBucket(ctx, "", fm.searchTerm); err != nil {
+		t.Fatal(err)
+	}
+	fm.loads.Wait()
+
+	assertStringSet(t, keysOf(fm.allObjects), []string{"reports/2024/q1.csv", "reports/2025/q1.csv"})
+	if len(fm.currentObjects) != 2 || fm.loadHandle != nil {
+		t.Errorf("%d objects shown, search still running: %v", len(fm.currentObjects), fm.loadHandle != nil)
+	}
+	if want := "Searched 2503 keys"; !strings.HasPrefix(fm.itemsLabel.Text, want) || !strings.HasSuffix(fm.itemsLabel.Text, "2 matches") {
+		t.Errorf("status = %q, want %s… 2 matches", fm.itemsLabel.Text, want)
+	}
+
+	// Changed objects update the matches in place without searching again.
+	store := fm.s3svc.(*memstore.Store)
+	store.Put("reports/2026/q1.csv", []byte("a,b"))
+	store.Put("reports/2026/notes.txt", []byte("x"))
+	if err := store.DeleteObject(ctx, "reports/2024/q1.csv"); err != nil {
+		t.Fatal(err)
+	}
+	fm.reload(ctx, "", "reports/2026/q1.csv", "reports/2026/notes.txt", "reports/2024/q1.csv")
+	fm.loads.Wait()
+	assertStringSet(t, keysOf(fm.allObjects), []string{"reports/2025/q1.csv", "reports/2026/q1.csv"})
+	if fm.loadHandle != nil {
+		t.Errorf("reload searched the bucket again")
+	}
+
+	// Refresh searches the bucket again.
+	store.Put("reports/2027/q1.csv", []byte("a,b"))
+	fm.reload(ctx, "")
+	if len(fm.allObjects) != 2 || fm.itemsLabel.Text != "Objects changed, refresh to search again" {
+		t.Errorf("reload without keys: %v, status %q", keysOf(fm.allObjects), fm.itemsLabel.Text)
+	}
+	fm.refresh()
+	fm.loads.Wait()
+	if len(fm.allObjects) != 3 {
+		t.Errorf("after refresh: %v", keysOf(fm.allObjects))
+	}
+
+	fm.LoadObjects(ctx, "reports/")
+	fm.loads.Wait()
+	if fm.bucketSearch != "" || len(fm.allObjects) != 5 {
+		t.Errorf("loading a prefix kept the search: %q, %v", fm.bucketSearch, keysOf(fm.allObjects))
+	}
+}
+
+func TestSearchBucketStopsAtLimit(t *testing.T) {
+	ctx := context.Background()
+	fm := newTestFileManager(t, newSearchTestStore())
+	fm.maxObjects = 10
+
+	if err := fm.SearchBucket(ctx, "data/", "*.bin"); err != nil {
+		t.Fatal(err)
+	}
+	fm.loads.Wait()
+	if len(fm.allObjects) != 10 || !strings.HasPrefix(fm.itemsLabel.Text, "Search stopped at 10 matches after 1000 keys") {
+		t.Errorf("%d matches, status %q", len(fm.allObjects), fm.itemsLabel.Text)
+	}
+}
+
+func TestSearchBucketWithMatchesAtLimit(t *testing.T) {
+	fm := newTestFileManager(t, newSearchTestStore())
+	fm.maxObjects = 3
+
+	if err := fm.SearchBucket(context.Background(), "", "ext:csv,pdf"); err != nil {
+		t.Fatal(err)
+	}
+	fm.loads.Wait()
+	if len(fm.allObjects) != 3 || !strings.HasPrefix(fm.itemsLabel.Text, "Searched 2503 keys") {
+		t.Errorf("%d matches, status %q", len(fm.allObjects), fm.itemsLabel.Text)
+	}
+}
+
+func TestSearchBucketCanceled(t *testing.T) {
+	fm := newTestFileManager(t, newSearchTestStore())
+	fm.prefixes = make(map[string]bool)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	handle := &loadHandle{cancel: cancel}
+	fm.loadHandle = handle
+
+	query, err := parseSearchQuery("csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fm.searchBucketAsync(ctx, handle, query, "", 0)
+	if fm.itemsLabel.Text != "Search canceled after 0 keys, 0 matches" || fm.loadHandle != nil {
+		t.Errorf("status = %q", fm.itemsLabel.Text)
+	}
+}
+
+func TestSearchBucketRejectsInvalidSearch(t *testing.T) {
+	fm := newTestFileManager(t, newSearchTestStore())
+	for _, term := range []string{"", "size:>lots"} {
+		if err := fm.SearchBucket(context.Background(), "", term); err == nil {
+			t.Errorf("SearchBucket(%q) started", term)
+		}
+	}
+	if fm.loadHandle != nil {
+		t.Errorf("an invalid search started listing")
+	}
+}
@@ -0,0 +1,336 @@
+package windows
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/pteich/us3ui/config"
+	"github.com/pteich/us3ui/s3"
+	"github.com/pteich/us3ui/transfer"
+)
+
+// Commander shows two panes side by side, each a prefix of any saved
+// connection and bucket or a local directory, and copies or moves the
+// selection of the active pane to the other one with F5 and F6 or by
+// dragging it across.
+type Commander struct {
+	*locations
+	app       fyne.App
+	transfers *transfer.Manager
+	state     *transfer.StateStore
+	window    fyne.Window
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	loads  sync.WaitGroup // pending listings of panes and folders to transfer
+
+	panes  [2]*commanderPane
+	active int
+	status *widget.Label
+	drag   *commanderDrag
+}
+
+// commanderDrag is the entries being dragged from one pane to the other.
+type commanderDrag struct {
+	from    *commanderPane
+	entries []paneEntry
+	over    bool // the pointer is above the other pane
+}
+
+func NewCommander(a fyne.App, cfg *config.Config, conn config.S3Config, store s3.ObjectStore, openStore func(config.S3Config) (s3.ObjectStore, error), transfers *transfer.Manager) *Commander {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Commander{
+		locations: newLocations(cfg, conn, store, openStore),
+		app:       a,
+		transfers: transfers,
+		state:     transfer.NewStateStore(transfer.DefaultStateDir()),
+		ctx:       ctx,
+		cancel:    cancel,
+	}
+}
+
+// Show opens the commander with prefix of the current connection on the left
+// and the home directory on the right.
+func (c *Commander) Show(prefix string) {
+	c.window = c.app.NewWindow("Commander")
+	c.window.Resize(fyne.NewSize(1100, 650))
+	c.window.SetContent(c.createContent())
+	c.window.Canvas().SetOnTypedKey(c.typedKey)
+	c.window.SetOnClosed(c.cancel)
+	c.window.Show()
+
+	var listings []paneListing
+	for i, at := range []struct{ source, bucket, dir string }{
+		{currentConnectionLabel, c.conn.Bucket, prefix},
+		{localFilesLabel, "", ""},
+	} {
+		loc, err := c.resolve(at.source, at.bucket, at.dir)
+		if err != nil {
+			dialog.ShowError(err, c.window)
+			continue
+		}
+		listings = append(listings, c.panes[i].show(loc))
+	}
+	c.list(listings...)
+}
+
+func (c *Commander) createContent() fyne.CanvasObject {
+	c.panes[0] = newCommanderPane(c, 0)
+	c.panes[1] = newCommanderPane(c, 1)
+	c.setActive(0)
+
+	c.status = widget.NewLabel("Select entries in one pane, then press F5 to copy or F6 to move them to the other pane. Tab switches panes.")
+	c.status.Truncation = fyne.TextTruncateEllipsis
+	copyBtn := widget.NewButton("F5 Copy", func() { c.transferSelection(false) })
+	moveBtn := widget.NewButton("F6 Move", func() { c.transferSelection(true) })
+
+	split := container.NewHSplit(c.panes[0].content, c.panes[1].content)
+	bottom := container.NewBorder(nil, nil, nil, container.NewHBox(layout.NewSpacer(), copyBtn, moveBtn), c.status)
+	return container.NewBorder(nil, bottom, nil, nil, split)
+}
+
+func (c *Commander) typedKey(ev *fyne.KeyEvent) {
+	switch ev.Name {
+	case fyne.KeyF5:
+		c.transferSelection(false)
+	case fyne.KeyF6:
+		c.transferSelection(true)
+	case fyne.KeyTab:
+		c.setActive(1 - c.active)
+	}
+}
+
+// setActive marks the pane F5 and F6 act on.
+func (c *Commander) setActive(index int) {
+	c.active = index
+	for i, p := range c.panes {
+		if p == nil {
+			continue
+		}
+		if i == index {
+			p.title.Importance = widget.HighImportance
+		} else {
+			p.title.Importance = widget.MediumImportance
+		}
+		p.title.Refresh()
+	}
+}
+
+// loadBuckets offers the buckets of source in the header of p.
+func (c *Commander) loadBuckets(p *commanderPane, source string) {
+	names, configured, err := c.bucketNames(c.ctx, source)
+	if err != nil {
+		return // the bucket can still be typed in
+	}
+	fyne.Do(func() {
+		if p.source.Selected != source {
+			return
+		}
+		p.bucket.SetOptions(names)
+		if p.bucket.Text == "" {
+			p.bucket.SetText(configured)
+		}
+	})
+}
+
+// dragged follows a drag started on row index of from.
+func (c *Commander) dragged(from *commanderPane, index int, pos fyne.Position) {
+	if c.drag == nil || c.drag.from != from {
+		entries := from.dragEntries(index)
+		if len(entries) == 0 {
+			return
+		}
+		from.activate()
+		c.drag = &commanderDrag{from: from, entries: entries}
+	}
+
+	to := c.panes[1-from.index]
+	c.drag.over = c.contains(to.list, pos)
+	if c.drag.over {
+		c.status.SetText(fmt.Sprintf("Drop to copy or move %s to %s", entriesText(c.drag.entries), to.location))
+	} else {
+		c.status.SetText(fmt.Sprintf("Dragging %s", entriesText(c.drag.entries)))
+	}
+}
+
+// dragEnded asks whether to copy or move entries dropped on the other pane.
+func (c *Commander) dragEnded() {
+	drag := c.drag
+	c.drag = nil
+	c.status.SetText("")
+	if drag == nil || !drag.over {
+		return
+	}
+
+	src, dst := drag.from, c.panes[1-drag.from.index]
+	var d *dialog.CustomDialog
+	copyBtn := widget.NewButton("Copy", func() {
+		d.Hide()
+		c.transfer(src, dst, drag.entries, false)
+	})
+	copyBtn.Importance = widget.HighImportance
+	moveBtn := widget.NewButton("Move", func() {
+		d.Hide()
+		c.transfer(src, dst, drag.entries, true)
+	})
+	cancelBtn := widget.NewButton("Cancel", func() { d.Hide() })
+
+	msg := widget.NewLabel(fmt.Sprintf("Copy or move %s to %s?\nExisting files and objects with the same name are overwritten.", entriesText(drag.entries), dst.location))
+	msg.Wrapping = fyne.TextWrapWord
+	d = dialog.NewCustomWithoutButtons("Drop", msg, c.window)
+	d.SetButtons([]fyne.CanvasObject{cancelBtn, moveBtn, copyBtn})
+	d.Resize(fyne.NewSize(450, d.MinSize().Height))
+	d.Show()
+}
+
+// contains reports whether the absolute position pos is above o.
+func (c *Commander) contains(o fyne.CanvasObject, pos fyne.Position) bool {
+	topLeft := c.app.Driver().AbsolutePositionForObject(o)
+	size := o.Size()
+	return pos.X >= topLeft.X && pos.Y >= topLeft.Y && pos.X < topLeft.X+size.Width && pos.Y < topLeft.Y+size.Height
+}
+
+// transferSelection copies or moves the selection of the active pane to the
+// other pane after asking.
+func (c *Commander) transferSelection(move bool) {
+	src, dst := c.panes[c.active], c.panes[1-c.active]
+	entries := src.selectedEntries()
+	if len(entries) == 0 {
+		dialog.ShowInformation("Info", "Nothing selected in the active pane", c.window)
+		return
+	}
+
+	action := "Copy"
+	if move {
+		action = "Move"
+	}
+	msg := fmt.Sprintf("%s %s to %s?\nExisting files and objects with the same name are overwritten.", action, entriesText(entries), dst.location)
+	dialog.ShowConfirm(action, msg, func(ok bool) {
+		if ok {
+			c.transfer(src, dst, entries, move)
+		}
+	}, c.window)
+}
+
+// transfer lists what entries contain and queues a transfer per file.
+func (c *Commander) transfer(src, dst *commanderPane, entries []paneEntry, move bool) {
+	from, to := src.location, dst.location
+	if from.same(to) {
+		dialog.ShowError(errors.New("both panes show the same location"), c.window)
+		return
+	}
+	for _, e := range entries {
+		if e.Dir && within(from, e.Path, to) {
+			dialog.ShowError(fmt.Errorf("cannot copy %s into itself", e.Name), c.window)
+			return
+		}
+	}
+
+	c.status.SetText("Listing " + entriesText(entries) + "…")
+	c.loads.Add(1)
+	go func() {
+		defer c.loads.Done()
+		items, err := expandEntries(c.ctx, from, entries)
+		fyne.Do(func() {
+			c.status.SetText("")
+			if err != nil {
+				dialog.ShowError(err, c.window)
+				return
+			}
+			c.startTransfer(from, to, items, entries, move)
+		})
+	}()
+}
+
+// startTransfer queues the transfers and reloads both panes once all are
+// done.
+func (c *Commander) startTransfer(from, to paneLocation, items []paneItem, entries []paneEntry, move bool) {
+	if len(items) == 0 {
+		dialog.ShowInformation("Info", "The selected folders are empty", c.window)
+		return
+	}
+
+	title, action := "Copy Complete", "Copied"
+	if move {
+		title, action = "Move Complete", "Moved"
+	}
+	reload := func() { c.reload(from, to) }
+	batch := &transferBatch{
+		pending: len(items),
+		onDone: func(completed int, failures []string) {
+			if move && from.local() {
+				for _, e := range entries {
+					if e.Dir {
+						removeEmptyDirs(e.Path)
+					}
+				}
+			}
+			fyne.Do(func() {
+				dialog.ShowInformation(title, transferSummaryMessage(action, completed, len(items), failures)+retryHint(failures), c.window)
+				reload()
+			})
+		},
+		onRetried: func() { fyne.Do(reload) },
+	}
+	for _, item := range items {
+		c.transfers.Add(c.transferTask(from, to, item, move, batch.track(from.describe(item.Path))))
+	}
+}
+
+// list runs listings one after the other in the background.
+func (c *Commander) list(listings ...paneListing) {
+	c.loads.Add(1)
+	go func() {
+		defer c.loads.Done()
+		for _, l := range listings {
+			entries, truncated, err := listPane(c.ctx, l.loc)
+			fyne.Do(func() { l.pane.showEntries(l, entries, truncated, err) })
+		}
+	}()
+}
+
+// reload lists the panes showing one of locs again.
+func (c *Commander) reload(locs ...paneLocation) {
+	var listings []paneListing
+	for _, p := range c.panes {
+		for _, loc := range locs {
+			if p.location.same(loc) {
+				listings = append(listings, p.show(p.location))
+				break
+			}
+		}
+	}
+	if len(listings) > 0 {
+		c.list(listings...)
+	}
+}
+
+// within reports whether to lies in the folder at p of from.
+func within(from paneLocation, p string, to paneLocation) bool {
+	if from.local() != to.local() || from.connection != to.connection || from.bucket != to.bucket {
+		return false
+	}
+	if from.local() {
+		rel, err := filepath.Rel(p, to.dir)
+		return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+	}
+	return strings.HasPrefix(to.dir, p)
+}
+
+// entriesText names entries for messages.
+func entriesText(entries []paneEntry) string {
+	if len(entries) == 1 {
+		return entries[0].Name
+	}
+	return fmt.Sprintf("%d entries", len(entries))
+}
@@ -0,0 +1,430 @@
+package windows
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/pteich/us3ui/s3"
+)
+
+// paneLocation is the directory a commander pane shows: a prefix in a bucket
+// of a connection or a local directory.
+type paneLocation struct {
+	connection string // localFilesLabel, currentConnectionLabel or a saved connection
+	bucket     string
+	dir        string         // prefix ending with the delimiter, or a local directory
+	store      s3.ObjectStore // nil for local files
+}
+
+func (l paneLocation) local() bool {
+	return l.store == nil
+}
+
+func (l paneLocation) String() string {
+	return l.describe(l.dir)
+}
+
+// describe names p, a key or local path, for the transfer list.
+func (l paneLocation) describe(p string) string {
+	if l.local() {
+		return p
+	}
+	return l.connection + ": " + l.bucket + "/" + p
+}
+
+// same reports whether both locations show the same directory.
+func (l paneLocation) same(other paneLocation) bool {
+	return l.connection == other.connection && l.bucket == other.bucket && l.dir == other.dir
+}
+
+// target returns where a file at rel below another pane's directory goes.
+func (l paneLocation) target(rel string) string {
+	if l.local() {
+		return newDownloadPlanner(l.dir, "", true).target(rel)
+	}
+	return l.dir + rel
+}
+
+// parent returns the location one level up, or false at the top.
+func (l paneLocation) parent() (paneLocation, bool) {
+	if l.local() {
+		up := filepath.Dir(l.dir)
+		if up == l.dir {
+			return l, false
+		}
+		l.dir = up
+		return l, true
+	}
+	if l.dir == "" {
+		return l, false
+	}
+	l.dir = folderParent(l.dir)
+	return l, true
+}
+
+// paneEntry is a file, object or folder shown in a pane.
+type paneEntry struct {
+	Name     string
+	Path     string // object key or prefix, or local path
+	Dir      bool
+	Size     int64
+	Modified time.Time
+}
+
+// listPane returns the entries directly in loc, folders first. S3 listings
+// stop after maxObjectsDefault entries.
+func listPane(ctx context.Context, loc paneLocation) (entries []paneEntry, truncated bool, err error) {
+	if loc.local() {
+		dirEntries, err := os.ReadDir(loc.dir)
+		if err != nil {
+			return nil, false, err
+		}
+		for _, de := range dirEntries {
+			info, err := de.Info()
+			if err != nil {
+				continue // removed while listing
+			}
+			entries = append(entries, paneEntry{
+				Name:     de.Name(),
+				Path:     filepath.Join(loc.dir, de.Name()),
+				Dir:      de.IsDir(),
+				Size:     info.Size(),
+				Modified: info.ModTime(),
+			})
+		}
+	} else {
+		lastKey := ""
+		for {
+			batch, err := loc.store.ListFolderBatch(ctx, lastKey, loc.dir, batchSize)
+			if err != nil {
+				return nil, false, err
+			}
+			for _, obj := range batch {
+				if obj.Key == loc.dir {
+					continue // the folder's own marker object
+				}
+				entry := paneEntry{Name: path.Base(obj.Key), Path: obj.Key, Size: obj.Size, Modified: obj.LastModified}
+				if s3.IsFolder(obj) {
+					entry.Name = strings.TrimSuffix(folderName(obj.Key), s3.Delimiter)
+					entry.Dir = true
+				}
+				entries = append(entries, entry)
+			}
+			if len(batch) < batchSize {
+				break
+			}
+			if len(entries) >= maxObjectsDefault {
+				truncated = true
+				break
+			}
+			lastKey = batch[len(batch)-1].Key
+		}
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Dir != entries[j].Dir {
+			return entries[i].Dir
+		}
+		return strings.ToLower(entries[i].Name) < strings.ToLower(entries[j].Name)
+	})
+	return entries, truncated, nil
+}
+
+// commanderPane is one side of the commander.
+type commanderPane struct {
+	commander *Commander
+	index     int
+	location  paneLocation
+	entries   []paneEntry
+	selected  map[string]bool
+	loadSeq   int // identifies the latest listing
+
+	title   *widget.Label
+	source  *widget.Select
+	bucket  *widget.SelectEntry
+	path    *widget.Entry
+	list    *widget.List
+	status  *widget.Label
+	content fyne.CanvasObject
+}
+
+func newCommanderPane(c *Commander, index int) *commanderPane {
+	p := &commanderPane{commander: c, index: index}
+	p.createContent()
+	return p
+}
+
+func (p *commanderPane) createContent() {
+	p.title = widget.NewLabel("")
+	p.title.TextStyle = fyne.TextStyle{Bold: true}
+	p.title.Truncation = fyne.TextTruncateEllipsis
+
+	p.bucket = widget.NewSelectEntry(nil)
+	p.bucket.SetPlaceHolder("Bucket")
+	p.source = widget.NewSelect(p.commander.sources(), p.sourceChanged)
+
+	p.path = widget.NewEntry()
+	p.path.SetPlaceHolder("Prefix or directory")
+	p.path.OnSubmitted = func(string) { p.submit() }
+
+	goBtn := widget.NewButtonWithIcon("", theme.NavigateNextIcon(), p.submit)
+	upBtn := widget.NewButtonWithIcon("", theme.MoveUpIcon(), p.up)
+	refreshBtn := widget.NewButtonWithIcon("", theme.ViewRefreshIcon(), func() { p.open(p.location) })
+
+	p.list = widget.NewList(
+		func() int { return len(p.entries) },
+		func() fyne.CanvasObject { return newCommanderRow(p) },
+		func(id widget.ListItemID, o fyne.CanvasObject) { o.(*commanderRow).bind(id) },
+	)
+	p.status = widget.NewLabel("")
+	p.status.Truncation = fyne.TextTruncateEllipsis
+
+	sourceRow := container.NewGridWithColumns(2, p.source, p.bucket)
+	pathRow := container.NewBorder(nil, nil, upBtn, container.NewHBox(goBtn, refreshBtn), p.path)
+	p.content = container.NewBorder(container.NewVBox(p.title, sourceRow, pathRow), p.status, nil, nil, p.list)
+}
+
+// sourceChanged offers the buckets of the chosen connection. Local files
+// have no bucket.
+func (p *commanderPane) sourceChanged(source string) {
+	if source == localFilesLabel {
+		p.bucket.Hide()
+		return
+	}
+	p.bucket.SetText("")
+	p.bucket.Show()
+	go p.commander.loadBuckets(p, source)
+}
+
+// submit opens what was entered in the header.
+func (p *commanderPane) submit() {
+	p.activate()
+	loc, err := p.commander.resolve(p.source.Selected, strings.TrimSpace(p.bucket.Text), strings.TrimSpace(p.path.Text))
+	if err != nil {
+		dialog.ShowError(err, p.commander.window)
+		return
+	}
+	p.open(loc)
+}
+
+func (p *commanderPane) up() {
+	p.activate()
+	if loc, ok := p.location.parent(); ok {
+		p.open(loc)
+	}
+}
+
+// open shows loc and lists it in the background.
+func (p *commanderPane) open(loc paneLocation) {
+	p.commander.list(p.show(loc))
+}
+
+// paneListing is a pending listing of a pane's location.
+type paneListing struct {
+	pane *commanderPane
+	loc  paneLocation
+	seq  int
+}
+
+// show puts loc in the header and returns the listing to run for it.
+func (p *commanderPane) show(loc paneLocation) paneListing {
+	p.location = loc
+	p.loadSeq++
+
+	p.source.OnChanged = nil
+	p.source.SetSelected(loc.connection)
+	p.source.OnChanged = p.sourceChanged
+	if loc.local() {
+		p.bucket.Hide()
+	} else {
+		p.bucket.Show()
+		p.bucket.SetText(loc.bucket)
+	}
+	p.path.SetText(loc.dir)
+	p.title.SetText(loc.String())
+	p.status.SetText("Loading…")
+	return paneListing{pane: p, loc: loc, seq: p.loadSeq}
+}
+
+// showEntries shows the result of a listing unless another one was started
+// since.
+func (p *commanderPane) showEntries(l paneListing, entries []paneEntry, truncated bool, err error) {
+	if l.seq != p.loadSeq {
+		return
+	}
+	if err != nil {
+		p.entries = nil
+		p.status.SetText("Failed to list " + l.loc.String())
+		dialog.ShowError(err, p.commander.window)
+	} else {
+		p.entries = entries
+	}
+	p.selected = nil
+	if err == nil {
+		p.updateStatus(truncated)
+	}
+	p.list.Refresh()
+	p.list.ScrollToTop()
+}
+
+func (p *commanderPane) updateStatus(truncated bool) {
+	text := fmt.Sprintf("%d entries", len(p.entries))
+	if truncated {
+		text += fmt.Sprintf(" (limited to %d)", maxObjectsDefault)
+	}
+	if n := len(p.selected); n > 0 {
+		text += fmt.Sprintf(", %d selected", n)
+	}
+	p.status.SetText(text)
+}
+
+// activate makes this pane the source of F5 and F6.
+func (p *commanderPane) activate() {
+	p.commander.setActive(p.index)
+}
+
+func (p *commanderPane) setSelected(index int, selected bool) {
+	if index < 0 || index >= len(p.entries) {
+		return
+	}
+	if p.selected == nil {
+		p.selected = make(map[string]bool)
+	}
+	if selected {
+		p.selected[p.entries[index].Path] = true
+	} else {
+		delete(p.selected, p.entries[index].Path)
+	}
+	p.updateStatus(false)
+	p.list.RefreshItem(index)
+}
+
+func (p *commanderPane) toggle(index int) {
+	if index < 0 || index >= len(p.entries) {
+		return
+	}
+	p.setSelected(index, !p.selected[p.entries[index].Path])
+}
+
+// openEntry enters a folder.
+func (p *commanderPane) openEntry(index int) {
+	if index < 0 || index >= len(p.entries) || !p.entries[index].Dir {
+		return
+	}
+	loc := p.location
+	loc.dir = p.entries[index].Path
+	p.open(loc)
+}
+
+// selectedEntries returns the selected entries in display order.
+func (p *commanderPane) selectedEntries() []paneEntry {
+	entries := make([]paneEntry, 0, len(p.selected))
+	for _, e := range p.entries {
+		if p.selected[e.Path] {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// dragEntries returns what is dragged from row index: the selection if the
+// row is part of it, otherwise just the row.
+func (p *commanderPane) dragEntries(index int) []paneEntry {
+	if index < 0 || index >= len(p.entries) {
+		return nil
+	}
+	if p.selected[p.entries[index].Path] {
+		return p.selectedEntries()
+	}
+	return []paneEntry{p.entries[index]}
+}
+
+// commanderRow is a row of a pane. Tapping toggles the selection, a double
+// tap opens folders and dragging the row onto the other pane transfers it.
+type commanderRow struct {
+	widget.BaseWidget
+	pane  *commanderPane
+	index int
+
+	check *widget.Check
+	icon  *widget.Icon
+	name  *widget.Label
+	info  *widget.Label
+}
+
+var (
+	_ fyne.Tappable       = (*commanderRow)(nil)
+	_ fyne.DoubleTappable = (*commanderRow)(nil)
+	_ fyne.Draggable      = (*commanderRow)(nil)
+)
+
+func newCommanderRow(p *commanderPane) *commanderRow {
+	r := &commanderRow{
+		pane:  p,
+		index: -1,
+		check: widget.NewCheck("", nil),
+		icon:  widget.NewIcon(theme.FileIcon()),
+		name:  widget.NewLabel(""),
+		info:  widget.NewLabel(""),
+	}
+	r.name.Truncation = fyne.TextTruncateEllipsis
+	r.ExtendBaseWidget(r)
+	return r
+}
+
+func (r *commanderRow) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(container.NewBorder(nil, nil, container.NewHBox(r.check, r.icon), r.info, r.name))
+}
+
+func (r *commanderRow) bind(index int) {
+	r.index = index
+	if index >= len(r.pane.entries) {
+		return
+	}
+	e := r.pane.entries[index]
+
+	r.check.OnChanged = nil
+	r.check.SetChecked(r.pane.selected[e.Path])
+	r.check.OnChanged = func(checked bool) {
+		r.pane.activate()
+		r.pane.setSelected(r.index, checked)
+	}
+	r.name.SetText(e.Name)
+	if e.Dir {
+		r.icon.SetResource(theme.FolderIcon())
+		r.info.SetText("")
+		return
+	}
+	r.icon.SetResource(theme.FileIcon())
+	r.info.SetText(ByteCountSI(e.Size))
+}
+
+func (r *commanderRow) Tapped(*fyne.PointEvent) {
+	r.pane.activate()
+	r.pane.toggle(r.index)
+}
+
+func (r *commanderRow) DoubleTapped(*fyne.PointEvent) {
+	r.pane.activate()
+	r.pane.openEntry(r.index)
+}
+
+func (r *commanderRow) Dragged(ev *fyne.DragEvent) {
+	r.pane.commander.dragged(r.pane, r.index, ev.AbsolutePosition)
+}
+
+func (r *commanderRow) DragEnd() {
+	r.pane.commander.dragEnded()
+}
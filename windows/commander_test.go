@@ -0,0 +1,200 @@
+package windows
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	fynetest "fyne.io/fyne/v2/test"
+
+	"github.com/pteich/us3ui/config"
+	"github.com/pteich/us3ui/s3"
+	"github.com/pteich/us3ui/s3/memstore"
+	"github.com/pteich/us3ui/transfer"
+)
+
+func newTestCommander(t *testing.T, store *memstore.Store, remote *memstore.Store) *Commander {
+	t.Helper()
+	t.Setenv("HOME", t.TempDir())
+
+	a := newTestApp(t)
+	cfg := &config.Config{Settings: config.Settings{Connections: []config.S3Config{
+		{Name: "minio", Bucket: "bucket"},
+		{Name: "ceph", Bucket: "backup"},
+	}}}
+	c := NewCommander(a, cfg, config.S3Config{Name: "minio", Bucket: "bucket"}, store, func(config.S3Config) (s3.ObjectStore, error) {
+		return remote, nil
+	}, transfer.NewManager(2))
+	c.state = transfer.NewStateStore(t.TempDir())
+	c.window = fynetest.NewWindow(c.createContent())
+	t.Cleanup(c.cancel)
+	return c
+}
+
+func openPanes(t *testing.T, c *Commander, left, right paneLocation) {
+	t.Helper()
+	c.list(c.panes[0].show(left), c.panes[1].show(right))
+	c.loads.Wait()
+}
+
+// transferSelection copies or moves the selection of the left pane to the
+// right one and waits until both panes are listed again.
+func transferSelection(t *testing.T, c *Commander, move bool) {
+	t.Helper()
+	from, to := c.panes[0].location, c.panes[1].location
+	entries := c.panes[0].selectedEntries()
+	items, err := expandEntries(context.Background(), from, entries)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.startTransfer(from, to, items, entries, move)
+	c.transfers.Wait()
+	c.loads.Wait()
+}
+
+// selectAll selects the entries of p named in names.
+func selectAll(p *commanderPane, names ...string) {
+	for _, name := range names {
+		for i, e := range p.entries {
+			if e.Name == name {
+				p.setSelected(i, true)
+			}
+		}
+	}
+}
+
+func TestCommanderSourcesListSavedConnections(t *testing.T) {
+	c := newTestCommander(t, memstore.New("bucket"), memstore.New("backup"))
+	assertStringSet(t, c.sources(), []string{localFilesLabel, currentConnectionLabel, "ceph"})
+
+	loc, err := c.resolve("ceph", "", "archive")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loc.bucket != "backup" || loc.dir != "archive/" || loc.local() {
+		t.Errorf("resolve = %+v", loc)
+	}
+}
+
+func TestListPaneShowsFoldersFirst(t *testing.T) {
+	store := memstore.New("bucket")
+	store.Put("docs/b.txt", []byte("b"))
+	store.Put("docs/img/logo.png", []byte("png"))
+	store.Put("docs/a.txt", []byte("a"))
+
+	entries, truncated, err := listPane(context.Background(), paneLocation{connection: currentConnectionLabel, bucket: "bucket", dir: "docs/", store: store})
+	if err != nil || truncated {
+		t.Fatalf("listPane: %v, truncated %v", err, truncated)
+	}
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name)
+	}
+	if len(names) != 3 || names[0] != "img" || !entries[0].Dir || names[1] != "a.txt" {
+		t.Errorf("entries = %v, want img first", names)
+	}
+}
+
+func TestCommanderUploadsLocalFolder(t *testing.T) {
+	store := memstore.New("bucket")
+	c := newTestCommander(t, store, memstore.New("backup"))
+
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "photos", "2026", "a.jpg"), "a")
+	writeFile(t, filepath.Join(dir, "notes.txt"), "notes")
+
+	openPanes(t, c,
+		paneLocation{connection: localFilesLabel, dir: dir},
+		paneLocation{connection: currentConnectionLabel, bucket: "bucket", dir: "backup/", store: store})
+	selectAll(c.panes[0], "photos", "notes.txt")
+
+	transferSelection(t, c, false)
+
+	for key, want := range map[string]string{"backup/photos/2026/a.jpg": "a", "backup/notes.txt": "notes"} {
+		if data, ok := store.Get(key); !ok || string(data) != want {
+			t.Errorf("%s = %q, %v", key, data, ok)
+		}
+	}
+	if len(c.panes[1].entries) != 2 {
+		t.Errorf("the target pane was not reloaded: %v", c.panes[1].entries)
+	}
+}
+
+func TestCommanderMovesObjectsToLocalDirectory(t *testing.T) {
+	store := memstore.New("bucket")
+	store.Put("logs/app/1.log", []byte("one"))
+	store.Put("logs/system.log", []byte("two"))
+	c := newTestCommander(t, store, memstore.New("backup"))
+
+	dir := t.TempDir()
+	openPanes(t, c,
+		paneLocation{connection: currentConnectionLabel, bucket: "bucket", dir: "", store: store},
+		paneLocation{connection: localFilesLabel, dir: dir})
+	selectAll(c.panes[0], "logs")
+
+	transferSelection(t, c, true)
+
+	if data, err := os.ReadFile(filepath.Join(dir, "logs", "app", "1.log")); err != nil || string(data) != "one" {
+		t.Errorf("logs/app/1.log = %q, %v", data, err)
+	}
+	if _, ok := store.Get("logs/system.log"); ok {
+		t.Errorf("logs/system.log was not removed after the move")
+	}
+	if len(c.panes[0].entries) != 0 {
+		t.Errorf("the source pane still shows %v", c.panes[0].entries)
+	}
+}
+
+func TestCommanderCopiesBetweenConnections(t *testing.T) {
+	store := memstore.New("bucket")
+	store.Put("docs/a.txt", []byte("alpha"))
+	remote := memstore.New("backup")
+	c := newTestCommander(t, store, remote)
+
+	to, err := c.resolve("ceph", "", "2026")
+	if err != nil {
+		t.Fatal(err)
+	}
+	openPanes(t, c, paneLocation{connection: currentConnectionLabel, bucket: "bucket", dir: "docs/", store: store}, to)
+	selectAll(c.panes[0], "a.txt")
+
+	transferSelection(t, c, false)
+
+	if data, ok := remote.Get("2026/a.txt"); !ok || string(data) != "alpha" {
+		t.Errorf("2026/a.txt = %q, %v", data, ok)
+	}
+	if _, ok := store.Get("docs/a.txt"); !ok {
+		t.Errorf("the source of a copy was removed")
+	}
+}
+
+func TestWithinRejectsCopyIntoItself(t *testing.T) {
+	from := paneLocation{connection: currentConnectionLabel, bucket: "bucket", dir: "", store: memstore.New("bucket")}
+	to := from
+	to.dir = "logs/app/"
+	if !within(from, "logs/", to) {
+		t.Errorf("logs/app/ not reported inside logs/")
+	}
+	to.bucket = "other"
+	if within(from, "logs/", to) {
+		t.Errorf("a folder in another bucket was reported inside logs/")
+	}
+
+	dir := t.TempDir()
+	local := paneLocation{connection: localFilesLabel, dir: dir}
+	inside := paneLocation{connection: localFilesLabel, dir: filepath.Join(dir, "a", "b")}
+	if !within(local, filepath.Join(dir, "a"), inside) || within(local, filepath.Join(dir, "ab"), inside) {
+		t.Errorf("within gave wrong results for local directories")
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
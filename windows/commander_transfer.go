@@ -0,0 +1,202 @@
+package windows
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gabriel-vasile/mimetype"
+
+	"github.com/pteich/us3ui/s3"
+	"github.com/pteich/us3ui/transfer"
+)
+
+// paneItem is a file or object to transfer from a pane.
+type paneItem struct {
+	Path string // object key or local path
+	Rel  string // path below the pane's directory, separated by slashes
+	Size int64
+}
+
+// expandEntries returns the files and objects below entries of loc. Folders
+// are listed recursively; folder markers are left out.
+func expandEntries(ctx context.Context, loc paneLocation, entries []paneEntry) ([]paneItem, error) {
+	var items []paneItem
+	for _, e := range entries {
+		switch {
+		case !e.Dir:
+			items = append(items, paneItem{Path: e.Path, Rel: e.Name, Size: e.Size})
+		case loc.local():
+			files, err := transfer.CollectUploads([]string{e.Path}, "", transfer.Filter{})
+			if err != nil {
+				return nil, err
+			}
+			for _, f := range files {
+				items = append(items, paneItem{Path: f.LocalPath, Rel: f.Key, Size: f.Size})
+			}
+		default:
+			objects, err := listAllObjects(ctx, loc.store, e.Path)
+			if err != nil {
+				return nil, err
+			}
+			for _, obj := range objects {
+				if s3.IsFolder(obj) {
+					continue
+				}
+				items = append(items, paneItem{Path: obj.Key, Rel: strings.TrimPrefix(obj.Key, loc.dir), Size: obj.Size})
+			}
+		}
+	}
+	return items, nil
+}
+
+// transferTask copies item from one pane's location to the other's and, for
+// moves, removes the source afterwards. Depending on the two sides this is an
+// upload, a download, a copy on the server or a copy streamed through this
+// machine.
+func (c *Commander) transferTask(from, to paneLocation, item paneItem, move bool, onFinish func(error)) transfer.Task {
+	target := to.target(item.Rel)
+	task := transfer.Task{
+		Kind:     transfer.KindCopy,
+		Name:     item.Rel,
+		Source:   from.describe(item.Path),
+		Target:   to.describe(target),
+		Size:     item.Size,
+		OnFinish: onFinish,
+	}
+
+	var run func(ctx context.Context, onProgress func(transfer.Progress)) error
+	switch {
+	case from.local() && to.local():
+		run = func(ctx context.Context, onProgress func(transfer.Progress)) error {
+			return copyLocalFile(ctx, item.Path, target, onProgress)
+		}
+		task.Cleanup = func() { _ = os.Remove(transfer.PartPath(target)) }
+	case from.local():
+		uploader := transfer.NewUploader(to.store, c.state)
+		task.Kind = transfer.KindUpload
+		run = func(ctx context.Context, onProgress func(transfer.Progress)) error {
+			mt, err := mimetype.DetectFile(item.Path)
+			if err != nil {
+				return err
+			}
+			return uploader.Upload(ctx, item.Path, target, mt.String(), onProgress)
+		}
+		task.Cleanup = func() { _ = uploader.Discard(context.Background(), item.Path, target) }
+	case to.local():
+		downloader := transfer.NewDownloader(from.store, c.state)
+		task.Kind = transfer.KindDownload
+		run = func(ctx context.Context, onProgress func(transfer.Progress)) error {
+			return downloader.Download(ctx, item.Path, target, onProgress)
+		}
+		task.Cleanup = func() { _ = downloader.Discard(item.Path, target) }
+	case from.connection == to.connection:
+		run = func(ctx context.Context, onProgress func(transfer.Progress)) error {
+			if err := from.store.CopyObjectToBucket(ctx, item.Path, to.bucket, target); err != nil {
+				return err
+			}
+			onProgress(transfer.Progress{Bytes: item.Size, Total: item.Size})
+			return nil
+		}
+	default:
+		copier := transfer.NewCopier(from.store, to.store)
+		run = func(ctx context.Context, onProgress func(transfer.Progress)) error {
+			return copier.Copy(ctx, item.Path, target, onProgress)
+		}
+	}
+
+	task.Run = func(ctx context.Context, onProgress func(transfer.Progress)) error {
+		if err := run(ctx, onProgress); err != nil {
+			return err
+		}
+		if !move {
+			return nil
+		}
+		var err error
+		if from.local() {
+			err = os.Remove(item.Path)
+		} else {
+			err = from.store.DeleteObject(ctx, item.Path)
+		}
+		if err != nil {
+			return fmt.Errorf("copied but not removed: %w", err)
+		}
+		return nil
+	}
+	return task
+}
+
+// copyLocalFile copies src to dst through a partial file so dst is either
+// complete or untouched.
+func copyLocalFile(ctx context.Context, src, dst string, onProgress func(transfer.Progress)) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	partPath := transfer.PartPath(dst)
+	out, err := os.OpenFile(partPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+
+	total := info.Size()
+	reader := &transfer.ProgressReader{
+		Reader: in,
+		Total:  total,
+		OnProgress: func(n int64) {
+			if onProgress != nil {
+				onProgress(transfer.Progress{Bytes: n, Total: total})
+			}
+		},
+	}
+	_, err = io.Copy(out, contextReader{ctx: ctx, r: reader})
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(partPath)
+		return err
+	}
+	return os.Rename(partPath, dst)
+}
+
+// contextReader stops reading once ctx is done.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r contextReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
+}
+
+// removeEmptyDirs removes dir and the directories below it that are empty,
+// e.g. after moving their files away.
+func removeEmptyDirs(dir string) {
+	var dirs []string
+	_ = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err == nil && d.IsDir() {
+			dirs = append(dirs, p)
+		}
+		return nil
+	})
+	for i := len(dirs) - 1; i >= 0; i-- {
+		_ = os.Remove(dirs[i]) // fails for directories that are not empty
+	}
+}
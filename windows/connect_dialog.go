@@ -18,7 +18,7 @@ type ConnectDialog struct {
 	cfg               *config.Config
 	connectionManager *connections.Manager
 	dialog            *widget.PopUp
-	onConnected       func(*s3.Service, string)
+	onConnected       func(s3.ObjectStore, config.S3Config)
 
 	// UI elements
 	connectionsList     *widget.List
@@ -35,9 +35,11 @@ type ConnectDialog struct {
 	prefixEntry         *widget.Entry
 	regionEntry         *widget.Entry
 	sslCheck            *widget.Check
+	trashCheck          *widget.Check
+	trashPrefixEntry    *widget.Entry
 }
 
-func NewConnectDialog(a fyne.App, cfg *config.Config, parent fyne.Window, onConnected func(*s3.Service, string)) *ConnectDialog {
+func NewConnectDialog(a fyne.App, cfg *config.Config, parent fyne.Window, onConnected func(s3.ObjectStore, config.S3Config)) *ConnectDialog {
 	cd := &ConnectDialog{
 		app:          a,
 		parentWindow: parent,
@@ -115,6 +117,10 @@ func (cd *ConnectDialog) createFormEntries() {
 	cd.regionEntry.SetPlaceHolder("Optional Region")
 
 	cd.sslCheck = widget.NewCheck("Use SSL (HTTPS)", nil)
+
+	cd.trashCheck = widget.NewCheck("Move deleted objects to the trash", nil)
+	cd.trashPrefixEntry = widget.NewEntry()
+	cd.trashPrefixEntry.SetPlaceHolder(config.DefaultTrashPrefix)
 }
 
 func (cd *ConnectDialog) createConfigForm() *widget.Form {
@@ -130,6 +136,8 @@ func (cd *ConnectDialog) createConfigForm() *widget.Form {
 		{Text: "Region", Widget: cd.regionEntry},
 		{Text: "Prefix", Widget: cd.prefixEntry},
 		{Text: "", Widget: cd.sslCheck},
+		{Text: "", Widget: cd.trashCheck},
+		{Text: "Trash Prefix", Widget: cd.trashPrefixEntry, HintText: "Versioned buckets keep deleted objects as versions instead."},
 	}...)
 
 	form.OnSubmit = cd.handleFormSubmit
@@ -174,6 +182,8 @@ func (cd *ConnectDialog) handleListSelection(id widget.ListItemID) {
 	cd.prefixEntry.SetText(selectedCfg.Prefix)
 	cd.regionEntry.SetText(selectedCfg.Region)
 	cd.sslCheck.SetChecked(selectedCfg.UseSSL)
+	cd.trashCheck.SetChecked(selectedCfg.Trash)
+	cd.trashPrefixEntry.SetText(selectedCfg.TrashPrefix)
 	cd.connectionNameEntry.SetText(selectedCfg.Name)
 	cd.toolbarDeleteAction.Enable()
 	cd.toolbarCopyAction.Enable()
@@ -187,14 +197,16 @@ func (cd *ConnectDialog) handleListUnselection(id widget.ListItemID) {
 
 func (cd *ConnectDialog) handleFormSubmit() {
 	s3Cfg := config.S3Config{
-		Endpoint:  cd.endpointEntry.Text,
-		AccessKey: cd.accessKeyEntry.Text,
-		SecretKey: cd.secretKeyEntry.Text,
-		Bucket:    cd.bucketEntry.Text,
-		UseSSL:    cd.sslCheck.Checked,
-		Prefix:    cd.prefixEntry.Text,
-		Region:    cd.regionEntry.Text,
-		Name:      cd.connectionNameEntry.Text,
+		Endpoint:    cd.endpointEntry.Text,
+		AccessKey:   cd.accessKeyEntry.Text,
+		SecretKey:   cd.secretKeyEntry.Text,
+		Bucket:      cd.bucketEntry.Text,
+		UseSSL:      cd.sslCheck.Checked,
+		Prefix:      cd.prefixEntry.Text,
+		Region:      cd.regionEntry.Text,
+		Name:        cd.connectionNameEntry.Text,
+		Trash:       cd.trashCheck.Checked,
+		TrashPrefix: cd.trashPrefixEntry.Text,
 	}
 
 	// Save the connection if it has a name
@@ -218,7 +230,7 @@ func (cd *ConnectDialog) handleFormSubmit() {
 
 	// Notify that we're connected
 	if cd.onConnected != nil {
-		cd.onConnected(s3svc, s3Cfg.Prefix)
+		cd.onConnected(s3svc, s3Cfg)
 	}
 }
 
@@ -232,14 +244,16 @@ func (cd *ConnectDialog) handleNameChange(name string) {
 
 func (cd *ConnectDialog) handleSave() {
 	newcfg := config.S3Config{
-		Endpoint:  cd.endpointEntry.Text,
-		AccessKey: cd.accessKeyEntry.Text,
-		SecretKey: cd.secretKeyEntry.Text,
-		Bucket:    cd.bucketEntry.Text,
-		UseSSL:    cd.sslCheck.Checked,
-		Prefix:    cd.prefixEntry.Text,
-		Region:    cd.regionEntry.Text,
-		Name:      cd.connectionNameEntry.Text,
+		Endpoint:    cd.endpointEntry.Text,
+		AccessKey:   cd.accessKeyEntry.Text,
+		SecretKey:   cd.secretKeyEntry.Text,
+		Bucket:      cd.bucketEntry.Text,
+		UseSSL:      cd.sslCheck.Checked,
+		Prefix:      cd.prefixEntry.Text,
+		Region:      cd.regionEntry.Text,
+		Name:        cd.connectionNameEntry.Text,
+		Trash:       cd.trashCheck.Checked,
+		TrashPrefix: cd.trashPrefixEntry.Text,
 	}
 	cd.connectionManager.Add(newcfg)
 	if err := cd.connectionManager.Save(); err != nil {
@@ -293,6 +307,8 @@ func (cd *ConnectDialog) handleAdd() {
 	cd.prefixEntry.SetText(selectedCfg.Prefix)
 	cd.regionEntry.SetText(selectedCfg.Region)
 	cd.sslCheck.SetChecked(selectedCfg.UseSSL)
+	cd.trashCheck.SetChecked(selectedCfg.Trash)
+	cd.trashPrefixEntry.SetText(selectedCfg.TrashPrefix)
 	cd.connectionNameEntry.SetText(selectedCfg.Name)
 	cd.toolbarSaveAction.Disable()
 	cd.toolbarDeleteAction.Disable()
@@ -301,14 +317,16 @@ func (cd *ConnectDialog) handleAdd() {
 
 func (cd *ConnectDialog) handleManageBuckets() {
 	s3Cfg := config.S3Config{
-		Endpoint:  cd.endpointEntry.Text,
-		AccessKey: cd.accessKeyEntry.Text,
-		SecretKey: cd.secretKeyEntry.Text,
-		Bucket:    cd.bucketEntry.Text,
-		UseSSL:    cd.sslCheck.Checked,
-		Prefix:    cd.prefixEntry.Text,
-		Region:    cd.regionEntry.Text,
-		Name:      cd.connectionNameEntry.Text,
+		Endpoint:    cd.endpointEntry.Text,
+		AccessKey:   cd.accessKeyEntry.Text,
+		SecretKey:   cd.secretKeyEntry.Text,
+		Bucket:      cd.bucketEntry.Text,
+		UseSSL:      cd.sslCheck.Checked,
+		Prefix:      cd.prefixEntry.Text,
+		Region:      cd.regionEntry.Text,
+		Name:        cd.connectionNameEntry.Text,
+		Trash:       cd.trashCheck.Checked,
+		TrashPrefix: cd.trashPrefixEntry.Text,
 	}
 
 	// Create the S3 service
@@ -0,0 +1,401 @@
+package windows
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/pteich/us3ui/config"
+	"github.com/pteich/us3ui/s3"
+	"github.com/pteich/us3ui/transfer"
+)
+
+const currentConnectionLabel = "Current connection"
+
+// copyTarget is where objects are copied or moved to.
+type copyTarget struct {
+	connection string // saved connection, empty for the current one
+	bucket     string
+	prefix     string
+}
+
+// location describes key at the target, like "backup: archive/2026/a.txt".
+func (t copyTarget) location(key string) string {
+	if t.connection == "" {
+		return t.bucket + "/" + key
+	}
+	return t.connection + ": " + t.bucket + "/" + key
+}
+
+// copyFunc copies one item to its target and reports the progress.
+type copyFunc func(ctx context.Context, item moveItem, onProgress func(transfer.Progress)) error
+
+// targetConnections returns the saved connections objects can be copied to
+// besides the current one.
+func (fm *FileManager) targetConnections() []config.S3Config {
+	return otherConnections(fm.cfg, fm.conn.Name)
+}
+
+// otherConnections returns the saved connections except the one named
+// current.
+func otherConnections(cfg *config.Config, current string) []config.S3Config {
+	if cfg == nil {
+		return nil
+	}
+	conns := make([]config.S3Config, 0, len(cfg.Settings.Connections))
+	for _, conn := range cfg.Settings.Connections {
+		if conn.Name != "" && conn.Name != current {
+			conns = append(conns, conn)
+		}
+	}
+	return conns
+}
+
+func (fm *FileManager) savedConnection(name string) (config.S3Config, error) {
+	for _, conn := range fm.targetConnections() {
+		if conn.Name == name {
+			return conn, nil
+		}
+	}
+	return config.S3Config{}, fmt.Errorf("connection %q not found", name)
+}
+
+// sameBucket reports whether target is the bucket that is open.
+func (fm *FileManager) sameBucket(target copyTarget) bool {
+	return target.connection == "" && target.bucket == fm.conn.Bucket
+}
+
+// handleCopyTo asks where to copy or move the selected objects, or the folder
+// selected in the tree if no object is selected.
+func (fm *FileManager) handleCopyTo() {
+	if fm.context == nil {
+		return
+	}
+	objects := fm.selectedObjects()
+	folder := ""
+	if len(objects) == 0 {
+		var ok bool
+		if folder, ok = fm.treeFolder(); !ok || folder == "" {
+			dialog.ShowInformation("Info", "No object or folder selected", fm.window)
+			return
+		}
+	}
+
+	what := fmt.Sprintf("%d selected objects", len(objects))
+	switch {
+	case folder != "":
+		what = "folder " + folder
+	case len(objects) == 1:
+		what = objects[0].Key
+	}
+
+	mode := widget.NewRadioGroup([]string{"Copy", "Move"}, nil)
+	mode.Horizontal = true
+	mode.Required = true
+	mode.SetSelected("Copy")
+
+	bucketEntry := widget.NewSelectEntry(nil)
+	bucketEntry.SetPlaceHolder("Bucket name")
+	bucketStatus := widget.NewLabel("")
+	bucketStatus.Wrapping = fyne.TextWrapWord
+
+	prefixEntry := widget.NewEntry()
+	prefixEntry.SetPlaceHolder("e.g. archive/2026/ (empty for the bucket root)")
+	prefixEntry.SetText(fm.downloadBase())
+
+	options := []string{currentConnectionLabel}
+	for _, conn := range fm.targetConnections() {
+		options = append(options, conn.Name)
+	}
+	connSelect := widget.NewSelect(options, nil)
+	connSelect.OnChanged = func(selected string) {
+		name := ""
+		if selected != currentConnectionLabel {
+			name = selected
+		}
+		bucketEntry.SetText(fm.conn.Bucket)
+		if conn, err := fm.savedConnection(name); err == nil {
+			bucketEntry.SetText(conn.Bucket)
+		}
+		bucketEntry.SetOptions(nil)
+		bucketStatus.SetText("Loading buckets…")
+		go fm.loadTargetBuckets(fm.context, name, func(buckets []string, err error) {
+			if connSelect.Selected != selected {
+				return
+			}
+			if err != nil {
+				bucketStatus.SetText("Buckets could not be listed, enter the name.")
+				return
+			}
+			bucketEntry.SetOptions(buckets)
+			bucketStatus.SetText("")
+		})
+	}
+	connSelect.SetSelected(currentConnectionLabel)
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Objects", widget.NewLabel(what)),
+		widget.NewFormItem("", mode),
+		widget.NewFormItem("Connection", connSelect),
+		widget.NewFormItem("Bucket", container.NewVBox(bucketEntry, bucketStatus)),
+		widget.NewFormItem("To prefix", prefixEntry),
+	}
+	d := dialog.NewForm("Copy or Move To", "Start", "Cancel", items, func(ok bool) {
+		if !ok {
+			return
+		}
+		target := copyTarget{
+			bucket: strings.TrimSpace(bucketEntry.Text),
+			prefix: normalizeMovePrefix(prefixEntry.Text),
+		}
+		if connSelect.Selected != currentConnectionLabel {
+			target.connection = connSelect.Selected
+		}
+		move := mode.Selected == "Move"
+
+		if target.bucket == "" {
+			dialog.ShowError(errors.New("no bucket given"), fm.window)
+			return
+		}
+		if folder != "" && fm.sameBucket(target) && strings.HasPrefix(target.prefix, folder) {
+			dialog.ShowError(fmt.Errorf("cannot copy %s into itself", folder), fm.window)
+			return
+		}
+
+		if folder != "" {
+			go fm.prepareFolderCopy(fm.context, folder, target, move)
+			return
+		}
+		moves, err := selectionMoveTargets(objects, target.prefix)
+		if err != nil {
+			dialog.ShowError(err, fm.window)
+			return
+		}
+		fm.startCopy(moves, "", target, move)
+	}, fm.window)
+	d.Resize(fyne.NewSize(550, d.MinSize().Height))
+	d.Show()
+}
+
+// loadTargetBuckets lists the buckets of a connection and hands them to done
+// on the UI goroutine.
+func (fm *FileManager) loadTargetBuckets(ctx context.Context, connection string, done func([]string, error)) {
+	store := fm.s3svc
+	if connection != "" {
+		conn, err := fm.savedConnection(connection)
+		if err == nil {
+			store, err = fm.openStore(conn)
+		}
+		if err != nil {
+			fyne.Do(func() { done(nil, err) })
+			return
+		}
+	}
+
+	names, err := listBucketNames(ctx, store)
+	fyne.Do(func() { done(names, err) })
+}
+
+// listBucketNames returns the sorted names of the buckets in store.
+func listBucketNames(ctx context.Context, store s3.ObjectStore) ([]string, error) {
+	buckets, err := store.ListBuckets(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(buckets))
+	for _, b := range buckets {
+		names = append(names, b.Name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// prepareFolderCopy lists everything below folder and copies it with its
+// structure below the target prefix.
+func (fm *FileManager) prepareFolderCopy(ctx context.Context, folder string, target copyTarget, move bool) {
+	objects, err := fm.listObjects(ctx, folder)
+	fyne.Do(func() {
+		if err != nil {
+			dialog.ShowError(err, fm.window)
+			return
+		}
+		fm.startCopy(moveTargets(objects, folderParent(folder), target.prefix), folder, target, move)
+	})
+}
+
+// startCopy checks which destinations already hold an object in the
+// background and asks before overwriting them. Within the open bucket a move
+// is a rename and handled by startMove.
+func (fm *FileManager) startCopy(items []moveItem, folder string, target copyTarget, move bool) {
+	ctx := fm.context
+	if fm.sameBucket(target) {
+		items = dropUnmoved(items)
+		if move {
+			fm.loads.Add(1)
+			go func() {
+				defer fm.loads.Done()
+				taken, err := fm.moveConflicts(ctx, items)
+				fyne.Do(func() { fm.confirmMove(items, folder, taken, err) })
+			}()
+			return
+		}
+	}
+	if len(items) == 0 {
+		dialog.ShowInformation("Copy", "Nothing to copy, the objects are already there.", fm.window)
+		return
+	}
+
+	fm.loads.Add(1)
+	go func() {
+		defer fm.loads.Done()
+		store, err := fm.targetStore(target)
+		var taken []string
+		if err == nil {
+			taken, err = takenDestinations(ctx, store, items)
+		}
+		fyne.Do(func() { fm.confirmCopy(items, folder, target, store, move, taken, err) })
+	}()
+}
+
+// confirmCopy queues the copy of items, asking first whether objects at
+// taken destinations are overwritten or skipped.
+func (fm *FileManager) confirmCopy(items []moveItem, folder string, target copyTarget, store s3.ObjectStore, move bool, taken []string, err error) {
+	if err != nil {
+		dialog.ShowError(err, fm.window)
+		return
+	}
+	if len(taken) == 0 {
+		fm.queueCopy(items, folder, target, store, move)
+		return
+	}
+	fm.confirmOverwrite(len(items), taken, func() {
+		fm.queueCopy(items, folder, target, store, move)
+	}, func() {
+		fm.queueCopy(skipTaken(items, taken), "", target, store, move)
+	})
+}
+
+// queueCopy queues a transfer per item to dst, the store of target. Copies to
+// another bucket of the connection are done on the server, copies to another
+// connection stream the data through this machine.
+func (fm *FileManager) queueCopy(items []moveItem, folder string, target copyTarget, dst s3.ObjectStore, move bool) {
+	if len(items) == 0 {
+		dialog.ShowInformation("Copy", "Nothing to copy, the objects are already there.", fm.window)
+		return
+	}
+	sameBucket := fm.sameBucket(target)
+	copyItem := fm.copyFunc(target, dst)
+
+	title, action := "Copy Complete", "Copied"
+	if move {
+		title, action = "Move Complete", "Moved"
+	}
+	// Moves and copies within the bucket change the listing; it is updated
+	// in place as the transfers finish.
+	changesListing := move || sameBucket
+	batch := &transferBatch{
+		pending: len(items),
+		onDone: func(completed int, failures []string) {
+			fyne.Do(func() {
+				dialog.ShowInformation(title, transferSummaryMessage(action, completed, len(items), failures)+retryHint(failures), fm.window)
+				if move && folder != "" && len(failures) == 0 {
+					fm.removeFolderNode(folder)
+				}
+				if changesListing {
+					fm.listingChanged()
+				}
+			})
+		},
+		onRetried: func() {
+			if changesListing {
+				fyne.Do(fm.listingChanged)
+			}
+		},
+	}
+
+	for _, item := range items {
+		fm.transfers.Add(fm.copyTask(item, target, copyItem, move, sameBucket, batch.track(item.From.Key)))
+	}
+}
+
+// copyFunc returns how objects get to target, whose store is dst.
+func (fm *FileManager) copyFunc(target copyTarget, dst s3.ObjectStore) copyFunc {
+	src := fm.s3svc
+	if target.connection == "" {
+		return func(ctx context.Context, item moveItem, onProgress func(transfer.Progress)) error {
+			if err := src.CopyObjectToBucket(ctx, item.From.Key, target.bucket, item.To); err != nil {
+				return err
+			}
+			onProgress(transfer.Progress{Bytes: item.From.Size, Total: item.From.Size})
+			return nil
+		}
+	}
+
+	copier := transfer.NewCopier(src, dst)
+	return func(ctx context.Context, item moveItem, onProgress func(transfer.Progress)) error {
+		return copier.Copy(ctx, item.From.Key, item.To, onProgress)
+	}
+}
+
+// targetStore returns a store for the bucket of target.
+func (fm *FileManager) targetStore(target copyTarget) (s3.ObjectStore, error) {
+	if fm.sameBucket(target) {
+		return fm.s3svc, nil
+	}
+	conn := fm.conn
+	if target.connection != "" {
+		var err error
+		if conn, err = fm.savedConnection(target.connection); err != nil {
+			return nil, err
+		}
+	}
+	conn.Bucket = target.bucket
+	return fm.openStore(conn)
+}
+
+func (fm *FileManager) copyTask(item moveItem, target copyTarget, copyItem copyFunc, move, sameBucket bool, onFinish func(error)) transfer.Task {
+	src := fm.s3svc
+	copied := item.From
+	copied.Key = item.To
+	copied.LastModified = time.Now()
+	return transfer.Task{
+		Kind:   transfer.KindCopy,
+		Name:   item.From.Key,
+		Source: item.From.Key,
+		Target: target.location(item.To),
+		Size:   item.From.Size,
+		Run: func(ctx context.Context, onProgress func(transfer.Progress)) error {
+			if err := copyItem(ctx, item, onProgress); err != nil {
+				return err
+			}
+			if !move {
+				return nil
+			}
+			if err := src.DeleteObject(ctx, item.From.Key); err != nil {
+				return fmt.Errorf("copied but not removed: %w", err)
+			}
+			return nil
+		},
+		OnFinish: func(err error) {
+			if err == nil {
+				fyne.Do(func() {
+					if move {
+						fm.removeObject(item.From.Key)
+					}
+					if sameBucket {
+						fm.insertObject(copied)
+					}
+				})
+			}
+			onFinish(err)
+		},
+	}
+}
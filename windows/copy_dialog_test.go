@@ -0,0 +1,138 @@
+package windows
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pteich/us3ui/config"
+	"github.com/pteich/us3ui/s3"
+	"github.com/pteich/us3ui/s3/memstore"
+)
+
+func TestTargetConnectionsSkipCurrent(t *testing.T) {
+	fm := &FileManager{
+		cfg: &config.Config{Settings: config.Settings{Connections: []config.S3Config{
+			{Name: "minio-a"}, {Name: "minio-b"}, {Name: "ceph"},
+		}}},
+		conn: config.S3Config{Name: "minio-a"},
+	}
+
+	got := make([]string, 0)
+	for _, conn := range fm.targetConnections() {
+		got = append(got, conn.Name)
+	}
+	assertStringSet(t, got, []string{"minio-b", "ceph"})
+
+	if _, err := fm.savedConnection("minio-a"); err == nil {
+		t.Errorf("the current connection was offered as a target")
+	}
+}
+
+func TestCopyToOtherBucketOnServer(t *testing.T) {
+	store := memstore.New("bucket")
+	store.Put("docs/a.txt", []byte("alpha"))
+	if err := store.CreateBucket(context.Background(), "archive", ""); err != nil {
+		t.Fatal(err)
+	}
+
+	fm := newTestFileManager(t, store)
+	fm.conn.Bucket = "bucket"
+	fm.openStore = func(conn config.S3Config) (s3.ObjectStore, error) {
+		// Only used to look for existing objects in the archive bucket.
+		return memstore.New(conn.Bucket), nil
+	}
+	loadAll(t, fm, 0, "")
+
+	items, err := selectionMoveTargets(fm.allObjects, "2026/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fm.startCopy(items, "", copyTarget{bucket: "archive", prefix: "2026/"}, false)
+	fm.loads.Wait()
+	fm.transfers.Wait()
+
+	if data, ok := store.GetFromBucket("archive", "2026/a.txt"); !ok || string(data) != "alpha" {
+		t.Errorf("archive/2026/a.txt = %q, %v", data, ok)
+	}
+	if _, ok := store.Get("docs/a.txt"); !ok {
+		t.Errorf("the source of a copy was removed")
+	}
+	assertStringSet(t, keysOf(fm.allObjects), []string{"docs/a.txt"})
+}
+
+func TestMoveToOtherConnectionStreamsObjects(t *testing.T) {
+	store := memstore.New("bucket")
+	store.Put("logs/app/1.log", []byte("one"))
+	store.Put("logs/system.log", []byte("two"))
+	store.Put("README.md", []byte("readme"))
+	remote := memstore.New("backup")
+
+	fm := newTestFileManager(t, store)
+	fm.cfg = &config.Config{Settings: config.Settings{Connections: []config.S3Config{
+		{Name: "ceph", Bucket: "default"},
+	}}}
+	var opened config.S3Config
+	fm.openStore = func(conn config.S3Config) (s3.ObjectStore, error) {
+		opened = conn
+		return remote, nil
+	}
+	loadAll(t, fm, 0, "")
+
+	objects, err := fm.listObjects(context.Background(), "logs/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	target := copyTarget{connection: "ceph", bucket: "backup", prefix: "old/"}
+	fm.startCopy(moveTargets(objects, "", target.prefix), "logs/", target, true)
+	fm.loads.Wait()
+	fm.transfers.Wait()
+
+	if opened.Name != "ceph" || opened.Bucket != "backup" {
+		t.Errorf("opened %+v, want connection ceph with bucket backup", opened)
+	}
+	if data, ok := remote.Get("old/logs/app/1.log"); !ok || string(data) != "one" {
+		t.Errorf("old/logs/app/1.log = %q, %v", data, ok)
+	}
+	if _, ok := store.Get("logs/system.log"); ok {
+		t.Errorf("logs/system.log was not removed after the move")
+	}
+	assertStringSet(t, keysOf(fm.allObjects), []string{"README.md"})
+}
+
+func TestCopyAsksBeforeOverwriting(t *testing.T) {
+	store := memstore.New("bucket")
+	store.Put("docs/a.txt", []byte("new a"))
+	store.Put("docs/b.txt", []byte("new b"))
+	store.Put("archive/a.txt", []byte("old a"))
+	remote := memstore.New("backup")
+	remote.Put("a.txt", []byte("remote a"))
+
+	fm := newTestFileManager(t, store)
+	fm.conn.Bucket = "bucket"
+	fm.cfg = &config.Config{Settings: config.Settings{Connections: []config.S3Config{{Name: "ceph"}}}}
+	fm.openStore = func(config.S3Config) (s3.ObjectStore, error) { return remote, nil }
+	loadAll(t, fm, 0, "docs/")
+
+	// A move within the bucket is canceled.
+	fm.startCopy(moveTargets(fm.allObjects, "docs/", "archive/"), "", copyTarget{bucket: "bucket", prefix: "archive/"}, true)
+	fm.loads.Wait()
+	tapButton(t, fm.window, "Cancel")
+	if data, _ := store.Get("archive/a.txt"); string(data) != "old a" {
+		t.Errorf("archive/a.txt was overwritten with %q", data)
+	}
+	if _, ok := store.Get("docs/a.txt"); !ok {
+		t.Errorf("docs/a.txt was moved after Cancel")
+	}
+
+	// A copy to another connection skips the existing object.
+	fm.startCopy(moveTargets(fm.allObjects, "docs/", ""), "", copyTarget{connection: "ceph", bucket: "backup"}, false)
+	fm.loads.Wait()
+	tapButton(t, fm.window, "Skip Existing")
+	fm.transfers.Wait()
+	if data, _ := remote.Get("a.txt"); string(data) != "remote a" {
+		t.Errorf("a.txt was overwritten with %q", data)
+	}
+	if data, _ := remote.Get("b.txt"); string(data) != "new b" {
+		t.Errorf("b.txt = %q", data)
+	}
+}
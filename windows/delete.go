@@ -0,0 +1,281 @@
+package windows
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/pteich/us3ui/s3"
+)
+
+// deleteResult collects the outcome of a batch delete.
+type deleteResult struct {
+	deleted  int
+	failures []string
+	canceled bool
+}
+
+// handleDelete deletes the selected objects, or the folder selected in the
+// tree with everything below it if no object is selected.
+func (fm *FileManager) handleDelete() {
+	if fm.context == nil {
+		return
+	}
+	if len(fm.selectedKeys) == 0 {
+		folder, ok := fm.treeFolder()
+		if !ok || folder == "" {
+			dialog.ShowInformation("Info", "No object or folder selected", fm.window)
+			return
+		}
+		fm.handleDeleteFolder(folder)
+		return
+	}
+
+	msg := fmt.Sprintf("Do you really want to delete '%d' files?", len(fm.selectedKeys))
+	if len(fm.selectedKeys) == 1 {
+		msg = "Do you really want to delete this file?"
+	}
+	if fm.conn.Trash {
+		msg += "\n\nDeleted files are moved to the trash."
+	}
+
+	confirm := dialog.NewConfirm(
+		"Delete Objects",
+		msg,
+		func(yes bool) {
+			if !yes {
+				return
+			}
+
+			keys := make([]string, 0, len(fm.selectedKeys))
+			for key := range fm.selectedKeys {
+				keys = append(keys, key)
+			}
+			fm.startDelete(keys, "")
+		}, fm.window)
+	confirm.Show()
+}
+
+// handleDeleteFolder asks before deleting everything below folder, including
+// objects that were never loaded.
+func (fm *FileManager) handleDeleteFolder(folder string) {
+	msg := fmt.Sprintf("Do you really want to delete the folder %s and every object below it?\n\n"+
+		"This includes objects that are not loaded in the list and cannot be undone.", folder)
+	if fm.conn.Trash {
+		msg = fmt.Sprintf("Do you really want to move the folder %s and every object below it to the trash?\n\n"+
+			"This includes objects that are not loaded in the list.", folder)
+	}
+	dialog.ShowCustomConfirm("Delete Folder", "Delete Folder", "Cancel", widget.NewLabel(msg), func(yes bool) {
+		if yes {
+			fm.startDelete(nil, folder)
+		}
+	}, fm.window)
+}
+
+// startDelete deletes keys, or everything below folder if it is set, in the
+// background behind a progress dialog that can cancel it. Failures are
+// reported in one summary when the delete is done. In trash mode the deleted
+// objects can be put back with Undo for a while.
+func (fm *FileManager) startDelete(keys []string, folder string) {
+	ctx, cancel := context.WithCancel(fm.context)
+	label := widget.NewLabel(fmt.Sprintf("Deleting %d objects…", len(keys)))
+	bar := widget.NewProgressBar()
+	bar.Max = float64(len(keys))
+	var progress fyne.CanvasObject = bar
+	if folder != "" {
+		label.SetText("Deleting " + folder + "…")
+		progress = widget.NewProgressBarInfinite()
+	}
+
+	d := dialog.NewCustomWithoutButtons("Delete", container.NewVBox(label, progress), fm.window)
+	d.SetButtons([]fyne.CanvasObject{widget.NewButton("Cancel", cancel)})
+	d.Resize(fyne.NewSize(400, d.MinSize().Height))
+	d.Show()
+
+	onProgress := func(done int) {
+		fyne.Do(func() {
+			if folder != "" {
+				label.SetText(fmt.Sprintf("Deleted %d objects of %s…", done, folder))
+				return
+			}
+			label.SetText(fmt.Sprintf("Deleted %d of %d objects…", done, len(keys)))
+			bar.SetValue(float64(done))
+		})
+	}
+
+	go func() {
+		defer cancel()
+		var trash *trashBatch
+		if fm.conn.Trash {
+			trash = newTrashBatch(ctx, fm.s3svc, fm.conn, time.Now())
+		}
+		var result deleteResult
+		if folder != "" {
+			result = fm.deletePrefix(ctx, folder, trash, onProgress)
+		} else {
+			result = fm.deleteObjects(ctx, keys, trash, onProgress)
+		}
+		fyne.Do(func() {
+			d.Hide()
+			fm.showDeleteSummary(result, len(keys))
+			fm.offerUndo(trash)
+		})
+	}()
+}
+
+// showDeleteSummary reports failed and canceled deletes. A complete delete
+// needs no message, the listing shows it.
+func (fm *FileManager) showDeleteSummary(result deleteResult, total int) {
+	if len(result.failures) == 0 && !result.canceled {
+		return
+	}
+	if total == 0 {
+		total = result.deleted + len(result.failures)
+	}
+	msg := transferSummaryMessage("Deleted", result.deleted, total, result.failures)
+	if result.canceled {
+		msg = fmt.Sprintf("Canceled after deleting %d objects.", result.deleted)
+		if len(result.failures) > 0 {
+			msg += "\n\n" + transferSummaryMessage("Deleted", result.deleted, total, result.failures)
+		}
+	}
+	dialog.ShowInformation("Delete", msg, fm.window)
+}
+
+// deleteObjects removes keys from the store with multi-object deletes and
+// from the loaded listing, moving them to trash first if it is set. It blocks
+// until all deletes have been attempted and must not run on the UI goroutine.
+func (fm *FileManager) deleteObjects(ctx context.Context, keys []string, trash *trashBatch, onProgress func(done int)) deleteResult {
+	var result deleteResult
+	for start := 0; start < len(keys) && !result.canceled; start += s3.MaxDeleteBatch {
+		end := min(start+s3.MaxDeleteBatch, len(keys))
+		fm.deleteBatch(ctx, keys[start:end], trash, &result)
+		if onProgress != nil {
+			onProgress(end)
+		}
+	}
+	fyne.Do(fm.listingChanged)
+	return result
+}
+
+// deletePrefix removes every object below prefix, listing and deleting one
+// batch at a time so that objects that were never loaded are deleted too.
+// Once everything is gone the folder is removed from the tree. With trash
+// set, a trash folder below prefix is left alone.
+func (fm *FileManager) deletePrefix(ctx context.Context, prefix string, trash *trashBatch, onProgress func(done int)) deleteResult {
+	var result deleteResult
+	lastKey := ""
+	for !result.canceled {
+		batch, err := fm.s3svc.ListObjectsBatch(ctx, lastKey, prefix, s3.MaxDeleteBatch)
+		if err != nil {
+			result.canceled = ctx.Err() != nil
+			if !result.canceled {
+				result.failures = append(result.failures, fmt.Sprintf("listing %s: %v", prefix, err))
+			}
+			break
+		}
+		if len(batch) == 0 {
+			break
+		}
+
+		keys := make([]string, 0, len(batch))
+		for _, obj := range batch {
+			if trash == nil || !trash.inTrash(obj.Key) || trash.inTrash(prefix) {
+				keys = append(keys, obj.Key)
+			}
+		}
+		fm.deleteBatch(ctx, keys, trash, &result)
+		if onProgress != nil {
+			onProgress(result.deleted)
+		}
+		if len(batch) < s3.MaxDeleteBatch {
+			break
+		}
+		lastKey = batch[len(batch)-1].Key
+	}
+
+	complete := len(result.failures) == 0 && !result.canceled
+	fyne.Do(func() {
+		if complete {
+			fm.removeFolderNode(prefix)
+		}
+		fm.listingChanged()
+	})
+	return result
+}
+
+// deleteBatch deletes up to s3.MaxDeleteBatch keys with one request, removes
+// the deleted ones from the listing and adds the outcome to result. With
+// trash set, each object is copied to the trash before it is deleted unless
+// the bucket keeps versions or the object is in the trash already.
+func (fm *FileManager) deleteBatch(ctx context.Context, keys []string, trash *trashBatch, result *deleteResult) {
+	failed := make(map[string]bool)
+	fail := func(key string, err error) {
+		if ctx.Err() != nil {
+			result.canceled = true
+		} else {
+			result.failures = append(result.failures, err.Error())
+		}
+		failed[key] = true
+	}
+
+	remove := keys
+	if trash != nil && !trash.versioned {
+		remove = make([]string, 0, len(keys))
+		for _, key := range keys {
+			if !trash.inTrash(key) {
+				if err := fm.s3svc.CopyObject(ctx, key, trash.trashKey(key)); err != nil {
+					fail(key, fmt.Errorf("%s: moving to the trash: %w", key, err))
+					continue
+				}
+			}
+			remove = append(remove, key)
+		}
+	}
+	for _, e := range fm.s3svc.DeleteObjects(ctx, remove) {
+		fail(e.Key, e)
+	}
+	if ctx.Err() != nil {
+		result.canceled = true
+	}
+
+	deleted := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		if failed[key] {
+			continue
+		}
+		deleted[key] = true
+		if trash != nil && !trash.inTrash(key) {
+			trash.keys = append(trash.keys, key)
+		}
+	}
+	result.deleted += len(deleted)
+	fyne.Do(func() { fm.removeObjects(deleted) })
+}
+
+// removeObjects drops keys from the loaded listing.
+func (fm *FileManager) removeObjects(keys map[string]bool) {
+	fm.resetOrderLocked()
+	if fm.folderMode {
+		for key := range keys {
+			fm.removeFolderEntry(key)
+		}
+		if f := fm.folders[fm.selectedFolder]; f != nil {
+			fm.allObjects = f.entries
+		}
+		return
+	}
+
+	kept := fm.allObjects[:0]
+	for _, obj := range fm.allObjects {
+		if !keys[obj.Key] {
+			kept = append(kept, obj)
+		}
+	}
+	fm.allObjects = kept
+}
@@ -0,0 +1,117 @@
+package windows
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/pteich/us3ui/s3"
+	"github.com/pteich/us3ui/s3/memstore"
+)
+
+// batchDeleteStore records the size of every multi-object delete and refuses
+// to delete the keys in locked.
+type batchDeleteStore struct {
+	*memstore.Store
+	batches []int
+	locked  map[string]bool
+}
+
+func (s *batchDeleteStore) DeleteObjects(ctx context.Context, objectNames []string) []s3.DeleteError {
+	s.batches = append(s.batches, len(objectNames))
+	var allowed []string
+	var failed []s3.DeleteError
+	for _, name := range objectNames {
+		if s.locked[name] {
+			failed = append(failed, s3.DeleteError{Key: name, Err: errors.New("access denied")})
+			continue
+		}
+		allowed = append(allowed, name)
+	}
+	return append(failed, s.Store.DeleteObjects(ctx, allowed)...)
+}
+
+func TestDeleteObjectsUsesBatchesAndCollectsFailures(t *testing.T) {
+	store := memstore.New("bucket")
+	var keys []string
+	for i := 0; i < 2500; i++ {
+		key := fmt.Sprintf("data/%04d.bin", i)
+		store.Put(key, []byte("x"))
+		keys = append(keys, key)
+	}
+	store.Put("keep.txt", []byte("keep"))
+
+	fm := newTestFileManager(t, store)
+	loadAll(t, fm, 0, "")
+	wrapped := &batchDeleteStore{Store: store, locked: map[string]bool{"data/0007.bin": true, "data/2001.bin": true}}
+	fm.s3svc = wrapped
+
+	var progress []int
+	result := fm.deleteObjects(context.Background(), keys, nil, func(done int) { progress = append(progress, done) })
+
+	if fmt.Sprint(wrapped.batches) != "[1000 1000 500]" {
+		t.Errorf("delete batches = %v, want [1000 1000 500]", wrapped.batches)
+	}
+	if fmt.Sprint(progress) != "[1000 2000 2500]" {
+		t.Errorf("progress = %v", progress)
+	}
+	if result.deleted != 2498 || len(result.failures) != 2 || result.canceled {
+		t.Errorf("result = %d deleted, failures %v, canceled %v", result.deleted, result.failures, result.canceled)
+	}
+	if !strings.Contains(result.failures[0], "data/0007.bin: access denied") {
+		t.Errorf("failure = %q", result.failures[0])
+	}
+	assertStringSet(t, keysOf(fm.allObjects), []string{"data/0007.bin", "data/2001.bin", "keep.txt"})
+}
+
+func TestDeletePrefixDeletesUnloadedObjects(t *testing.T) {
+	store := memstore.New("bucket")
+	for i := 0; i < 1200; i++ {
+		store.Put(fmt.Sprintf("logs/%04d.log", i), []byte("x"))
+	}
+	store.Put("logs/app/1.log", []byte("nested"))
+	store.Put("logsbook.txt", []byte("outside the folder"))
+	store.Put("README.md", []byte("readme"))
+
+	fm := newTestFileManager(t, store)
+	// Only part of the folder is loaded into the listing.
+	loadAll(t, fm, 10, "")
+	fm.tree.Select("logs")
+
+	var last int
+	result := fm.deletePrefix(context.Background(), "logs/", nil, func(done int) { last = done })
+
+	if result.deleted != 1201 || len(result.failures) != 0 || last != 1201 {
+		t.Errorf("result = %+v, last progress %d", result, last)
+	}
+	objects, err := listAllObjects(context.Background(), store, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertStringSet(t, keysOf(objects), []string{"README.md", "logsbook.txt"})
+	for _, obj := range fm.allObjects {
+		if strings.HasPrefix(obj.Key, "logs/") {
+			t.Errorf("%s still listed", obj.Key)
+		}
+	}
+}
+
+func TestDeletePrefixStopsWhenCanceled(t *testing.T) {
+	store := memstore.New("bucket")
+	store.Put("tmp/a", []byte("a"))
+
+	fm := newTestFileManager(t, store)
+	loadAll(t, fm, 0, "")
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	result := fm.deletePrefix(ctx, "tmp/", nil, nil)
+	if !result.canceled || result.deleted != 0 || len(result.failures) != 0 {
+		t.Errorf("result = %+v, want a quiet cancel", result)
+	}
+	if _, ok := store.Get("tmp/a"); !ok {
+		t.Errorf("tmp/a was deleted after the cancel")
+	}
+}
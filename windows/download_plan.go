@@ -0,0 +1,375 @@
+package windows
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/minio/minio-go/v7"
+
+	"github.com/pteich/us3ui/s3"
+)
+
+// conflictPolicy decides what happens when a download target already exists.
+type conflictPolicy int
+
+const (
+	conflictAsk conflictPolicy = iota
+	conflictOverwrite
+	conflictSkip
+	conflictRename
+)
+
+var conflictPolicyNames = []string{"Ask", "Overwrite", "Skip", "Rename"}
+
+func (c conflictPolicy) String() string {
+	if int(c) < len(conflictPolicyNames) {
+		return conflictPolicyNames[c]
+	}
+	return "Unknown"
+}
+
+func parseConflictPolicy(name string) conflictPolicy {
+	for i, n := range conflictPolicyNames {
+		if n == name {
+			return conflictPolicy(i)
+		}
+	}
+	return conflictAsk
+}
+
+// downloadItem is an object and the local file it is written to.
+type downloadItem struct {
+	Key  string
+	Path string
+}
+
+// downloadPlanner maps object keys to local paths below dir and resolves
+// conflicts with existing files.
+type downloadPlanner struct {
+	dir           string
+	base          string // prefix stripped from keys when keeping the structure
+	keepStructure bool
+	exists        func(string) bool
+	taken         map[string]bool // paths already claimed by this download
+}
+
+func newDownloadPlanner(dir, base string, keepStructure bool) *downloadPlanner {
+	return &downloadPlanner{
+		dir:           dir,
+		base:          base,
+		keepStructure: keepStructure,
+		exists:        fileExists,
+		taken:         make(map[string]bool),
+	}
+}
+
+// target returns the local path for key. With keepStructure the key below
+// base is recreated as directories; segments that could escape dir are
+// dropped.
+func (p *downloadPlanner) target(key string) string {
+	if !p.keepStructure {
+		return filepath.Join(p.dir, path.Base(key))
+	}
+
+	rel := strings.TrimPrefix(key, p.base)
+	segments := make([]string, 0, strings.Count(rel, s3.Delimiter)+1)
+	for _, segment := range strings.Split(rel, s3.Delimiter) {
+		if segment == "" || segment == "." || segment == ".." {
+			continue
+		}
+		segments = append(segments, segment)
+	}
+	if len(segments) == 0 {
+		return filepath.Join(p.dir, path.Base(key))
+	}
+	return filepath.Join(append([]string{p.dir}, segments...)...)
+}
+
+// plan assigns local paths to keys. Targets that exist on disk are resolved
+// with policy; with conflictAsk they are returned as conflicts for the user
+// to decide. Keys that map to the same path within this download are always
+// renamed, as overwriting would throw away one of them.
+func (p *downloadPlanner) plan(keys []string, policy conflictPolicy) (items, conflicts []downloadItem, skipped int) {
+	for _, key := range keys {
+		item := downloadItem{Key: key, Path: p.target(key)}
+		switch {
+		case p.taken[item.Path]:
+			item.Path = p.freePath(item.Path)
+		case p.exists(item.Path):
+			if policy == conflictAsk {
+				conflicts = append(conflicts, item)
+				continue
+			}
+			var ok bool
+			if item, ok = p.resolve(item, policy); !ok {
+				skipped++
+				continue
+			}
+		}
+		p.taken[item.Path] = true
+		items = append(items, item)
+	}
+	return items, conflicts, skipped
+}
+
+// resolve applies policy to a conflicting item. It returns false if the item
+// is skipped.
+func (p *downloadPlanner) resolve(item downloadItem, policy conflictPolicy) (downloadItem, bool) {
+	switch policy {
+	case conflictSkip:
+		return item, false
+	case conflictRename:
+		item.Path = p.freePath(item.Path)
+	default:
+		if p.taken[item.Path] {
+			item.Path = p.freePath(item.Path)
+		}
+	}
+	p.taken[item.Path] = true
+	return item, true
+}
+
+// freePath returns "name (n).ext" for the lowest n that is neither on disk
+// nor claimed by this download.
+func (p *downloadPlanner) freePath(target string) string {
+	ext := filepath.Ext(target)
+	stem := strings.TrimSuffix(target, ext)
+	for n := 1; ; n++ {
+		candidate := fmt.Sprintf("%s (%d)%s", stem, n, ext)
+		if !p.taken[candidate] && !p.exists(candidate) {
+			return candidate
+		}
+	}
+}
+
+func fileExists(path string) bool {
+	_, err := os.Stat(path)
+	return !errors.Is(err, os.ErrNotExist)
+}
+
+// downloadBase returns the prefix download paths are made relative to: the
+// folder that is currently open.
+func (fm *FileManager) downloadBase() string {
+	if fm.folderMode {
+		return fm.selectedFolder
+	}
+	switch fm.selectedPrefix {
+	case "", "all", "root":
+		// The loaded prefix may end inside a name, like "logs/ap".
+		if idx := strings.LastIndex(fm.basePrefix, s3.Delimiter); idx != -1 {
+			return fm.basePrefix[:idx+1]
+		}
+		return ""
+	default:
+		return fm.selectedPrefix + s3.Delimiter
+	}
+}
+
+// treeFolder returns the prefix of the folder selected in the tree, or false
+// if no single folder is selected.
+func (fm *FileManager) treeFolder() (string, bool) {
+	if fm.folderMode {
+		return fm.selectedFolder, fm.folders != nil
+	}
+	switch fm.selectedPrefix {
+	case "", "all", "root":
+		return "", false
+	default:
+		return fm.selectedPrefix + s3.Delimiter, true
+	}
+}
+
+func (fm *FileManager) updateDownloadButton() {
+	if fm.downloadBtn == nil {
+		return
+	}
+	if _, ok := fm.treeFolder(); ok || len(fm.selectedKeys) > 0 {
+		fm.downloadBtn.Enable()
+		return
+	}
+	fm.downloadBtn.Disable()
+}
+
+// showDownloadOptions asks where and how to store the objects. If keys is
+// empty, the whole folder is downloaded.
+func (fm *FileManager) showDownloadOptions(keys []string, folder string) {
+	dialog.ShowFolderOpen(func(uri fyne.ListableURI, err error) {
+		if err != nil || uri == nil {
+			return
+		}
+
+		keepStructure := widget.NewCheck("Keep folder structure", nil)
+		keepStructure.SetChecked(fm.downloadKeepStructure)
+		conflicts := widget.NewSelect(conflictPolicyNames, nil)
+		conflicts.SetSelected(fm.downloadConflict.String())
+
+		what := fmt.Sprintf("%d selected objects", len(keys))
+		if len(keys) == 1 {
+			what = keys[0]
+		}
+		if len(keys) == 0 {
+			what = "folder " + folder
+		}
+
+		items := []*widget.FormItem{
+			widget.NewFormItem("Download", widget.NewLabel(what)),
+			widget.NewFormItem("Into", widget.NewLabel(uri.Path())),
+			widget.NewFormItem("", keepStructure),
+			widget.NewFormItem("Existing files", conflicts),
+		}
+		dialog.ShowForm("Download", "Download", "Cancel", items, func(ok bool) {
+			if !ok {
+				return
+			}
+			fm.downloadKeepStructure = keepStructure.Checked
+			fm.downloadConflict = parseConflictPolicy(conflicts.Selected)
+
+			base := fm.downloadBase()
+			if len(keys) == 0 {
+				// Recreate the folder itself below the chosen directory.
+				base = folderParent(folder)
+				go fm.downloadFolder(fm.context, folder, uri.Path(), base)
+				return
+			}
+			fm.planDownload(keys, uri.Path(), base)
+		}, fm.window)
+	}, fm.window)
+}
+
+// downloadFolder lists every object below prefix and downloads them.
+func (fm *FileManager) downloadFolder(ctx context.Context, prefix, dir, base string) {
+	keys, err := fm.listKeys(ctx, prefix)
+	fyne.Do(func() {
+		if err != nil {
+			dialog.ShowError(err, fm.window)
+			return
+		}
+		if len(keys) == 0 {
+			dialog.ShowInformation("Download", "The folder is empty.", fm.window)
+			return
+		}
+		fm.planDownload(keys, dir, base)
+	})
+}
+
+// listKeys returns all object keys below prefix, without folder markers.
+func (fm *FileManager) listKeys(ctx context.Context, prefix string) ([]string, error) {
+	objects, err := fm.listObjects(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	for _, obj := range objects {
+		if !strings.HasSuffix(obj.Key, s3.Delimiter) {
+			keys = append(keys, obj.Key)
+		}
+	}
+	return keys, nil
+}
+
+// listObjects returns every object below prefix, including folder markers.
+func (fm *FileManager) listObjects(ctx context.Context, prefix string) ([]minio.ObjectInfo, error) {
+	return listAllObjects(ctx, fm.s3svc, prefix)
+}
+
+// listAllObjects lists every object of store below prefix in batches.
+func listAllObjects(ctx context.Context, store s3.ObjectStore, prefix string) ([]minio.ObjectInfo, error) {
+	var objects []minio.ObjectInfo
+	lastKey := ""
+	for {
+		batch, err := store.ListObjectsBatch(ctx, lastKey, prefix, batchSize)
+		if err != nil {
+			return nil, err
+		}
+		objects = append(objects, batch...)
+		if len(batch) < batchSize {
+			return objects, nil
+		}
+		lastKey = batch[len(batch)-1].Key
+	}
+}
+
+// planDownload resolves local paths for keys, asks about conflicts if
+// needed and queues the downloads.
+func (fm *FileManager) planDownload(keys []string, dir, base string) {
+	sort.Strings(keys)
+	planner := newDownloadPlanner(dir, base, fm.downloadKeepStructure)
+	items, conflicts, skipped := planner.plan(keys, fm.downloadConflict)
+
+	fm.askConflicts(planner, conflicts, func(resolved []downloadItem, skippedConflicts int) {
+		items = append(items, resolved...)
+		skipped += skippedConflicts
+		if len(items) == 0 {
+			dialog.ShowInformation("Download", fmt.Sprintf("Nothing to download, %d existing files skipped.", skipped), fm.window)
+			return
+		}
+		fm.downloadObjects(items)
+	})
+}
+
+// askConflicts asks the user how to handle each existing file, one after
+// another, and calls done with the items to download.
+func (fm *FileManager) askConflicts(planner *downloadPlanner, conflicts []downloadItem, done func([]downloadItem, int)) {
+	var resolved []downloadItem
+	skipped := 0
+	applyAll := conflictAsk
+
+	var next func(i int)
+	next = func(i int) {
+		for ; i < len(conflicts) && applyAll != conflictAsk; i++ {
+			if item, ok := planner.resolve(conflicts[i], applyAll); ok {
+				resolved = append(resolved, item)
+			} else {
+				skipped++
+			}
+		}
+		if i >= len(conflicts) {
+			done(resolved, skipped)
+			return
+		}
+
+		item := conflicts[i]
+		remember := widget.NewCheck("Do this for all remaining conflicts", nil)
+		if len(conflicts)-i == 1 {
+			remember.Hide()
+		}
+
+		var d *dialog.CustomDialog
+		choose := func(policy conflictPolicy) func() {
+			return func() {
+				d.Hide()
+				if remember.Checked {
+					applyAll = policy
+				}
+				if item, ok := planner.resolve(item, policy); ok {
+					resolved = append(resolved, item)
+				} else {
+					skipped++
+				}
+				next(i + 1)
+			}
+		}
+
+		message := widget.NewLabel(fmt.Sprintf("%s already exists.", item.Path))
+		message.Wrapping = fyne.TextWrapBreak
+		d = dialog.NewCustomWithoutButtons("File Exists", container.NewVBox(message, remember), fm.window)
+		d.SetButtons([]fyne.CanvasObject{
+			widget.NewButton("Skip", choose(conflictSkip)),
+			widget.NewButton("Rename", choose(conflictRename)),
+			widget.NewButton("Overwrite", choose(conflictOverwrite)),
+		})
+		d.Resize(fyne.NewSize(500, d.MinSize().Height))
+		d.Show()
+	}
+	next(0)
+}
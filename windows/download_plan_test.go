@@ -0,0 +1,117 @@
+package windows
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/pteich/us3ui/s3/memstore"
+)
+
+func testPlanner(dir, base string, keepStructure bool, existing ...string) *downloadPlanner {
+	p := newDownloadPlanner(dir, base, keepStructure)
+	onDisk := make(map[string]bool)
+	for _, path := range existing {
+		onDisk[filepath.Join(dir, path)] = true
+	}
+	p.exists = func(path string) bool { return onDisk[path] }
+	return p
+}
+
+func TestDownloadPlannerTarget(t *testing.T) {
+	dir := filepath.FromSlash("/tmp/dl")
+	tests := []struct {
+		name          string
+		base          string
+		keepStructure bool
+		key           string
+		want          string
+	}{
+		{"flat", "", false, "a/b/report.csv", "report.csv"},
+		{"structure", "", true, "a/b/report.csv", "a/b/report.csv"},
+		{"relative to prefix", "a/", true, "a/b/report.csv", "b/report.csv"},
+		{"traversal dropped", "", true, "../../etc/passwd", "etc/passwd"},
+		{"empty segments dropped", "", true, "a//./b.txt", "a/b.txt"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := testPlanner(dir, tt.base, tt.keepStructure)
+			if got, want := p.target(tt.key), filepath.Join(dir, filepath.FromSlash(tt.want)); got != want {
+				t.Errorf("target(%q) = %q, want %q", tt.key, got, want)
+			}
+		})
+	}
+}
+
+func TestDownloadPlannerConflicts(t *testing.T) {
+	dir := filepath.FromSlash("/tmp/dl")
+	keys := []string{"a/report.csv", "b/report.csv", "notes.txt"}
+	join := func(name string) string { return filepath.Join(dir, name) }
+
+	// Same file name from two folders without structure: the second is
+	// renamed instead of overwriting the first.
+	items, conflicts, skipped := testPlanner(dir, "", false).plan(keys, conflictOverwrite)
+	if len(conflicts) != 0 || skipped != 0 || len(items) != 3 {
+		t.Fatalf("plan = %v, %v, %d", items, conflicts, skipped)
+	}
+	if items[0].Path != join("report.csv") || items[1].Path != join("report (1).csv") {
+		t.Errorf("paths = %q, %q", items[0].Path, items[1].Path)
+	}
+
+	items, _, skipped = testPlanner(dir, "", false, "notes.txt").plan(keys, conflictSkip)
+	if skipped != 1 || len(items) != 2 {
+		t.Errorf("skip: items = %v, skipped = %d", items, skipped)
+	}
+
+	items, _, _ = testPlanner(dir, "", false, "notes.txt", "notes (1).txt").plan(keys, conflictRename)
+	if got := items[2].Path; got != join("notes (2).txt") {
+		t.Errorf("rename: path = %q", got)
+	}
+
+	items, _, _ = testPlanner(dir, "", false, "notes.txt").plan(keys, conflictOverwrite)
+	if got := items[2].Path; got != join("notes.txt") {
+		t.Errorf("overwrite: path = %q", got)
+	}
+
+	p := testPlanner(dir, "", false, "notes.txt")
+	items, conflicts, _ = p.plan(keys, conflictAsk)
+	if len(items) != 2 || len(conflicts) != 1 || conflicts[0].Key != "notes.txt" {
+		t.Fatalf("ask: items = %v, conflicts = %v", items, conflicts)
+	}
+	if item, ok := p.resolve(conflicts[0], conflictRename); !ok || item.Path != join("notes (1).txt") {
+		t.Errorf("resolve(rename) = %v, %v", item, ok)
+	}
+}
+
+func TestDownloadFolderKeepsStructure(t *testing.T) {
+	store := memstore.New("bucket")
+	store.Put("logs/app/1.log", []byte("one"))
+	store.Put("logs/app/nested/2.log", []byte("two"))
+	store.Put("logs/app/", nil)
+	store.Put("other/3.log", []byte("three"))
+
+	fm := newTestFileManager(t, store)
+	fm.downloadConflict = conflictOverwrite
+	dir := t.TempDir()
+
+	fm.downloadFolder(context.Background(), "logs/app/", dir, "logs/")
+	fm.transfers.Wait()
+
+	for path, want := range map[string]string{
+		"app/1.log":        "one",
+		"app/nested/2.log": "two",
+	} {
+		data, err := os.ReadFile(filepath.Join(dir, filepath.FromSlash(path)))
+		if err != nil {
+			t.Errorf("%s: %v", path, err)
+			continue
+		}
+		if string(data) != want {
+			t.Errorf("%s = %q, want %q", path, data, want)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(dir, "3.log")); err == nil {
+		t.Errorf("object outside the folder was downloaded")
+	}
+}
@@ -0,0 +1,256 @@
+package windows
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/driver/desktop"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/minio/minio-go/v7"
+
+	"github.com/pteich/us3ui/s3"
+)
+
+// editorSizeLimit is the largest object the editor opens.
+const editorSizeLimit = 5 << 20
+
+// errObjectChanged reports that an object was replaced since it was opened.
+var errObjectChanged = errors.New("the object was changed since it was opened")
+
+// EditorWindow edits a text object and saves it back to the bucket with its
+// headers, user metadata and tags. A save does not overwrite changes others
+// made in the meantime unless the user confirms it.
+type EditorWindow struct {
+	app       fyne.App
+	s3Service s3.ObjectStore
+	key       string
+	onSaved   func() // called after the object was saved
+	window    fyne.Window
+
+	props  s3.ObjectProperties // the object as last opened or saved
+	saved  string              // its content
+	loaded bool
+
+	entry   *widget.Entry
+	status  *widget.Label
+	saveBtn *widget.Button
+}
+
+func NewEditorWindow(a fyne.App, service s3.ObjectStore, key string, onSaved func()) *EditorWindow {
+	return &EditorWindow{
+		app:       a,
+		s3Service: service,
+		key:       key,
+		onSaved:   onSaved,
+	}
+}
+
+func (ew *EditorWindow) Show() {
+	ew.window = ew.app.NewWindow("Edit " + ew.key)
+	ew.window.Resize(fyne.NewSize(800, 600))
+	ew.window.SetContent(ew.createContent())
+	ew.window.SetCloseIntercept(ew.handleClose)
+	save := &desktop.CustomShortcut{KeyName: fyne.KeyS, Modifier: fyne.KeyModifierShortcutDefault}
+	ew.window.Canvas().AddShortcut(save, func(fyne.Shortcut) { ew.handleSave() })
+	ew.window.Show()
+
+	go ew.open(context.Background())
+}
+
+func (ew *EditorWindow) createContent() fyne.CanvasObject {
+	ew.entry = widget.NewMultiLineEntry()
+	ew.entry.TextStyle = fyne.TextStyle{Monospace: true}
+	ew.entry.Wrapping = fyne.TextWrapOff
+	ew.entry.OnChanged = func(string) { ew.updateButtons() }
+	ew.entry.Disable()
+
+	ew.saveBtn = widget.NewButtonWithIcon("Save", theme.DocumentSaveIcon(), ew.handleSave)
+	ew.saveBtn.Importance = widget.HighImportance
+	revertBtn := widget.NewButtonWithIcon("Revert", theme.ContentUndoIcon(), func() {
+		if ew.loaded {
+			ew.entry.SetText(ew.saved)
+		}
+	})
+
+	ew.status = widget.NewLabel("Loading…")
+	ew.status.Truncation = fyne.TextTruncateEllipsis
+	toolbar := container.NewHBox(ew.saveBtn, revertBtn, layout.NewSpacer())
+	ew.updateButtons()
+	return container.NewBorder(toolbar, ew.status, nil, nil, ew.entry)
+}
+
+func (ew *EditorWindow) modified() bool {
+	return ew.loaded && ew.entry.Text != ew.saved
+}
+
+func (ew *EditorWindow) updateButtons() {
+	if ew.modified() {
+		ew.saveBtn.Enable()
+	} else {
+		ew.saveBtn.Disable()
+	}
+}
+
+// open loads the object into the editor. Objects that are too large or do
+// not look like text are refused.
+func (ew *EditorWindow) open(ctx context.Context) {
+	props, err := ew.s3Service.GetObjectProperties(ctx, ew.key)
+	var data []byte
+	if err == nil && props.Info.Size > editorSizeLimit {
+		err = fmt.Errorf("the object is larger than %s", ByteCountSI(editorSizeLimit))
+	}
+	if err == nil {
+		data, err = readRange(ctx, ew.s3Service, ew.key, props.Info.ETag, 0, props.Info.Size)
+	}
+	if err == nil && !looksLikeText(data) {
+		err = errors.New("the object is not a text file")
+	}
+
+	fyne.Do(func() {
+		if err != nil {
+			ew.status.SetText("Cannot edit " + ew.key)
+			dialog.ShowError(err, ew.window)
+			return
+		}
+		ew.props = props
+		ew.saved = string(data)
+		ew.loaded = true
+		ew.entry.SetText(ew.saved)
+		ew.entry.Enable()
+		ew.status.SetText(editorStatus(props))
+		ew.updateButtons()
+	})
+}
+
+func editorStatus(props s3.ObjectProperties) string {
+	return fmt.Sprintf("%s, %s, ETag %s", props.Headers.ContentType, ByteCountSI(props.Info.Size), props.Info.ETag)
+}
+
+func (ew *EditorWindow) handleSave() {
+	if !ew.modified() {
+		return
+	}
+	text := ew.entry.Text
+	if err := validateEdited(ew.key, ew.props.Headers.ContentType, text); err != nil {
+		dialog.ShowConfirm("Save", err.Error()+"\n\nSave anyway?", func(yes bool) {
+			if yes {
+				ew.status.SetText("Saving…")
+				go ew.save(context.Background(), text, false)
+			}
+		}, ew.window)
+		return
+	}
+	ew.status.SetText("Saving…")
+	go ew.save(context.Background(), text, false)
+}
+
+// validateEdited checks JSON, YAML and XML objects for syntax errors before
+// they are saved.
+func validateEdited(key, contentType, text string) error {
+	kind := previewKindOf(key, contentType)
+	var err error
+	switch kind {
+	case previewJSON:
+		var v any
+		err = json.Unmarshal([]byte(text), &v)
+	case previewYAML:
+		_, err = indentYAML([]byte(text))
+	case previewXML:
+		_, err = indentXML([]byte(text))
+	}
+	if err != nil {
+		return fmt.Errorf("this is not valid %s: %w", previewKindNames[kind], err)
+	}
+	return nil
+}
+
+// save uploads text with the headers, metadata and tags of the object. Unless
+// force is set, it refuses when the object no longer has the ETag it had when
+// it was opened and asks whether to overwrite it.
+func (ew *EditorWindow) save(ctx context.Context, text string, force bool) {
+	props := ew.props
+	err := replaceUnchanged(ctx, ew.s3Service, ew.key, strings.NewReader(text), int64(len(text)), props, force)
+	if err == nil {
+		props.Info, err = ew.s3Service.StatObject(ctx, ew.key)
+	}
+
+	fyne.Do(func() {
+		switch {
+		case errors.Is(err, errObjectChanged):
+			ew.status.SetText("Not saved: " + err.Error())
+			ew.showConflict(text)
+			return
+		case err != nil:
+			ew.status.SetText("Not saved")
+			dialog.ShowError(err, ew.window)
+			return
+		}
+		ew.props = props
+		ew.saved = text
+		ew.updateButtons()
+		ew.status.SetText(fmt.Sprintf("Saved at %s. %s", time.Now().Format("15:04:05"), editorStatus(props)))
+		if ew.onSaved != nil {
+			ew.onSaved()
+		}
+	})
+}
+
+// replaceUnchanged writes r to key unless the object changed since props
+// were read, in which case it returns errObjectChanged. The ETag is compared
+// before the upload and, on servers that support it, by the upload itself.
+// force skips the check.
+func replaceUnchanged(ctx context.Context, store s3.ObjectStore, key string, r io.Reader, length int64, props s3.ObjectProperties, force bool) error {
+	matchETag := ""
+	if !force {
+		matchETag = props.Info.ETag
+		info, err := store.StatObject(ctx, key)
+		switch {
+		case minio.ToErrorResponse(err).Code == "NoSuchKey":
+			return fmt.Errorf("%w: it was deleted", errObjectChanged)
+		case err != nil:
+			return err
+		case info.ETag != matchETag:
+			return errObjectChanged
+		}
+	}
+	err := store.ReplaceObject(ctx, key, r, length, props, matchETag)
+	if minio.ToErrorResponse(err).Code == "PreconditionFailed" {
+		return errObjectChanged
+	}
+	return err
+}
+
+// showConflict asks whether to overwrite the changed object with text.
+func (ew *EditorWindow) showConflict(text string) {
+	msg := fmt.Sprintf("%s was changed by someone else since you opened it.\n\n"+
+		"Overwrite it with your version? Their changes will be lost.", ew.key)
+	dialog.ShowCustomConfirm("Object Changed", "Overwrite", "Cancel", widget.NewLabel(msg), func(yes bool) {
+		if yes {
+			ew.status.SetText("Saving…")
+			go ew.save(context.Background(), text, true)
+		}
+	}, ew.window)
+}
+
+// handleClose asks before unsaved changes are discarded.
+func (ew *EditorWindow) handleClose() {
+	if !ew.modified() {
+		ew.window.Close()
+		return
+	}
+	dialog.ShowConfirm("Unsaved Changes", "Discard your changes to "+ew.key+"?", func(yes bool) {
+		if yes {
+			ew.window.Close()
+		}
+	}, ew.window)
+}
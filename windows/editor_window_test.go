@@ -0,0 +1,126 @@
+package windows
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	fynetest "fyne.io/fyne/v2/test"
+
+	"github.com/pteich/us3ui/s3"
+	"github.com/pteich/us3ui/s3/memstore"
+)
+
+func newTestEditorWindow(t *testing.T, store *memstore.Store, key string) (*EditorWindow, *int) {
+	t.Helper()
+
+	onSave, saves := countCalls()
+	ew := NewEditorWindow(newTestApp(t), store, key, onSave)
+	ew.window = fynetest.NewWindow(nil)
+	ew.createContent()
+	ew.open(context.Background())
+	return ew, saves
+}
+
+func TestEditorWindowSavesWithProperties(t *testing.T) {
+	ctx := context.Background()
+	store := memstore.New("bucket")
+	store.Put("config/app.json", []byte(`{"debug": false}`))
+	headers := s3.ObjectHeaders{ContentType: "application/json", CacheControl: "no-cache"}
+	if err := store.UpdateObjectProperties(ctx, "config/app.json", headers, map[string]string{"owner": "ops"}, map[string]string{"env": "prod"}); err != nil {
+		t.Fatal(err)
+	}
+
+	ew, saves := newTestEditorWindow(t, store, "config/app.json")
+	if !ew.loaded || ew.entry.Text != `{"debug": false}` || !ew.saveBtn.Disabled() {
+		t.Fatalf("opened editor: loaded %v, text %q", ew.loaded, ew.entry.Text)
+	}
+
+	ew.entry.SetText(`{"debug": true}`)
+	if ew.saveBtn.Disabled() {
+		t.Errorf("Save is disabled after an edit")
+	}
+	ew.save(ctx, ew.entry.Text, false)
+
+	if data, _ := store.Get("config/app.json"); string(data) != `{"debug": true}` {
+		t.Errorf("saved content = %q", data)
+	}
+	props, err := store.GetObjectProperties(ctx, "config/app.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if props.Headers != headers || props.Metadata["owner"] != "ops" || props.Tags["env"] != "prod" {
+		t.Errorf("properties after save = %+v", props)
+	}
+	if *saves != 1 || ew.modified() || ew.props.Info.ETag != props.Info.ETag {
+		t.Errorf("saves = %d, modified %v, editor ETag %s, object ETag %s", *saves, ew.modified(), ew.props.Info.ETag, props.Info.ETag)
+	}
+
+	// A second save builds on the first one.
+	ew.entry.SetText(`{"debug": false}`)
+	ew.save(ctx, ew.entry.Text, false)
+	if data, _ := store.Get("config/app.json"); string(data) != `{"debug": false}` || *saves != 2 {
+		t.Errorf("second save: content %q, saves %d", data, *saves)
+	}
+}
+
+func TestEditorWindowRefusesChangedObject(t *testing.T) {
+	ctx := context.Background()
+	store := memstore.New("bucket")
+	store.Put("notes.txt", []byte("mine"))
+
+	ew, saves := newTestEditorWindow(t, store, "notes.txt")
+	store.Put("notes.txt", []byte("theirs"))
+	ew.entry.SetText("mine, edited")
+
+	ew.save(ctx, ew.entry.Text, false)
+	if data, _ := store.Get("notes.txt"); string(data) != "theirs" {
+		t.Errorf("a changed object was overwritten with %q", data)
+	}
+	if *saves != 0 || !ew.modified() || !strings.HasPrefix(ew.status.Text, "Not saved") {
+		t.Errorf("saves = %d, modified %v, status %q", *saves, ew.modified(), ew.status.Text)
+	}
+
+	ew.save(ctx, ew.entry.Text, true)
+	if data, _ := store.Get("notes.txt"); string(data) != "mine, edited" || *saves != 1 {
+		t.Errorf("overwrite: content %q, saves %d", data, *saves)
+	}
+
+	if err := store.DeleteObject(ctx, "notes.txt"); err != nil {
+		t.Fatal(err)
+	}
+	ew.entry.SetText("again")
+	if err := replaceUnchanged(ctx, store, "notes.txt", strings.NewReader("again"), 5, ew.props, false); err == nil || !strings.Contains(err.Error(), "deleted") {
+		t.Errorf("saving a deleted object = %v", err)
+	}
+}
+
+func TestEditorWindowRefusesBinaryObject(t *testing.T) {
+	store := memstore.New("bucket")
+	store.Put("archive.gz", []byte("\x1f\x8b\x08\x00\x00binary"))
+
+	ew, _ := newTestEditorWindow(t, store, "archive.gz")
+	if ew.loaded || !ew.entry.Disabled() {
+		t.Errorf("binary object opened for editing")
+	}
+}
+
+func TestValidateEdited(t *testing.T) {
+	tests := []struct {
+		key, contentType, text string
+		valid                  bool
+	}{
+		{"app.json", "", `{"a": 1}`, true},
+		{"app.json", "", `{"a": 1,}`, false},
+		{"data", "application/json", `[1, 2`, false},
+		{"app.yaml", "", "a: [1, 2", false},
+		{"app.yml", "", "a:\n  - 1\n", true},
+		{"feed.xml", "", "<a><b></a>", false},
+		{"notes.txt", "", `{"not": json`, true},
+	}
+	for _, tt := range tests {
+		if err := validateEdited(tt.key, tt.contentType, tt.text); (err == nil) != tt.valid {
+			t.Errorf("validateEdited(%q, %q) = %v, want valid %v", tt.key, tt.text, err, tt.valid)
+		}
+	}
+}
@@ -7,9 +7,10 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"fyne.io/fyne/v2"
@@ -22,7 +23,9 @@ import (
 	"github.com/gabriel-vasile/mimetype"
 	"github.com/minio/minio-go/v7"
 
+	"github.com/pteich/us3ui/config"
 	"github.com/pteich/us3ui/s3"
+	"github.com/pteich/us3ui/transfer"
 )
 
 const (
@@ -47,7 +50,25 @@ type loadHandle struct {
 type FileManager struct {
 	app                  fyne.App
 	window               fyne.Window
-	s3svc                *s3.Service
+	cfg                  *config.Config
+	conn                 config.S3Config // the connection; its name is empty if unsaved
+	s3svc                s3.ObjectStore
+	openStore            func(config.S3Config) (s3.ObjectStore, error)
+	uploader             *transfer.Uploader
+	downloader           *transfer.Downloader
+	transfers            *transfer.Manager
+	transferPanel        *TransferPanel
+	unsubscribeTransfers func()
+	jobs                 *JobScheduler
+	jobsWindow           *JobsWindow
+	opened               *OpenedFiles
+	unsubscribeJobs      func()
+	lastTrash            *trashBatch // the delete Undo puts back
+	preview              *PreviewPane
+	gridView             bool // objects are shown as thumbnails
+	thumbnailer          *Thumbnailer
+	thumbnails           map[string]fyne.Resource // by thumbnailKey, nil if there is none
+	thumbnailsPending    map[string]*thumbnailRequest
 	Container            fyne.CanvasObject
 	currentObjects       []minio.ObjectInfo
 	allObjects           []minio.ObjectInfo
@@ -57,38 +78,79 @@ type FileManager struct {
 	basePrefix           string
 	selectedPrefix       string
 	searchTerm           string
+	compiledSearch       string       // the searchTerm searchQuery was compiled from
+	searchQuery          *searchQuery // nil matches everything
+	searchErr            error        // why compiledSearch is invalid
+	bucketSearch         string       // the search the listing holds the matches of, if any
+	sort                 objectSort
+	objectOrder          objectOrder
 	treeData             binding.StringTree
 	searchDebounceTimer  *time.Timer
 	context              context.Context
 	loadHandle           *loadHandle
-	maxObjects           int  // Maximum objects to load (0 = unlimited)
-	hasMoreObjects       bool // True if load stopped due to limit
+	loads                sync.WaitGroup // running listing and thumbnail goroutines
+	maxObjects           int            // Maximum objects to load (0 = unlimited)
+	hasMoreObjects       bool           // True if load stopped due to limit
 	changeConnectionFunc func()
 
-	itemsLabel   *widget.Label
-	objectList   *widget.Table
-	searchInput  *widget.Entry
-	prefixInput  *widget.Entry
-	progressBar  *widget.ProgressBar
-	loadingBar   *widget.ProgressBarInfinite
-	stopBtn      *widget.Button
-	deleteBtn    *widget.Button
-	downloadBtn  *widget.Button
-	linkBtn      *widget.Button
-	tree         *widget.Tree
-	loadMoreBtn  *widget.Button
-	maxObjsInput *widget.Entry
+	// Folder browsing lists one level at a time with a delimiter instead of
+	// the whole bucket.
+	folderMode     bool
+	folders        map[string]*folder
+	selectedFolder string
+	foldersCtx     context.Context
+	foldersCancel  context.CancelFunc
+
+	downloadKeepStructure bool
+	downloadConflict      conflictPolicy
+
+	itemsLabel      *widget.Label
+	objectList      *widget.Table
+	objectGrid      *widget.GridWrap
+	searchInput     *widget.Entry
+	prefixInput     *widget.Entry
+	progressBar     *widget.ProgressBar
+	loadingBar      *widget.ProgressBarInfinite
+	stopBtn         *widget.Button
+	deleteBtn       *widget.Button
+	downloadBtn     *widget.Button
+	renameBtn       *widget.Button
+	moveBtn         *widget.Button
+	copyBtn         *widget.Button
+	tree            *widget.Tree
+	folderTree      *widget.Tree
+	folderModeCheck *widget.Check
+	loadMoreBtn     *widget.Button
+	maxObjsInput    *widget.Entry
+	transfersBtn    *widget.Button
+	jobsBtn         *widget.Button
+	undoBtn         *widget.Button
 }
 
-func NewFileManager(a fyne.App, s3svc *s3.Service, window fyne.Window, changeConn func()) *FileManager {
+func NewFileManager(a fyne.App, cfg *config.Config, conn config.S3Config, s3svc s3.ObjectStore, transfers *transfer.Manager, jobs *JobScheduler, opened *OpenedFiles, window fyne.Window, changeConn func()) *FileManager {
 	fm := &FileManager{
-		app:                  a,
-		window:               window,
-		s3svc:                s3svc,
-		maxObjects:           maxObjectsDefault,
-		changeConnectionFunc: changeConn,
+		app:    a,
+		window: window,
+		cfg:    cfg,
+		conn:   conn,
+		s3svc:  s3svc,
+		openStore: func(c config.S3Config) (s3.ObjectStore, error) {
+			return s3.New(c)
+		},
+		transfers:             transfers,
+		jobs:                  jobs,
+		opened:                opened,
+		uploader:              transfer.NewUploader(s3svc, transfer.NewStateStore(transfer.DefaultStateDir())),
+		downloader:            transfer.NewDownloader(s3svc, transfer.NewStateStore(transfer.DefaultStateDir())),
+		maxObjects:            maxObjectsDefault,
+		changeConnectionFunc:  changeConn,
+		downloadKeepStructure: true,
+		downloadConflict:      conflictAsk,
 	}
+	fm.thumbnailer = newThumbnailer(s3svc, conn)
 
+	fm.loadSort()
+	fm.loadGridView()
 	fm.setupUI()
 
 	return fm
@@ -102,6 +164,7 @@ func (fm *FileManager) setupUI() {
 
 	fm.itemsLabel = fm.createItemsLabel()
 	fm.objectList = fm.createObjectList()
+	fm.objectGrid = fm.createObjectGrid()
 	fm.searchInput = fm.createSearchInput()
 	fm.prefixInput = fm.createPrefixInput()
 	fm.progressBar = fm.createProgressBar()
@@ -110,10 +173,19 @@ func (fm *FileManager) setupUI() {
 	fm.stopBtn = fm.createStopButton()
 
 	tree := fm.createDirTree(treeData)
-	tree.Select("all")
 	fm.tree = tree
+	tree.Select("all")
+
+	fm.folderTree = fm.createFolderTree()
+	fm.folderTree.Hide()
+
+	fm.preview = NewPreviewPane(fm.s3svc)
+	fm.preview.Container.Hide()
+	fm.showObjectView()
+	objectsContent := container.NewHSplit(container.NewStack(fm.objectList, fm.objectGrid), fm.preview.Container)
+	objectsContent.SetOffset(0.6)
 
-	listContent := container.NewHSplit(tree, fm.objectList)
+	listContent := container.NewHSplit(container.NewStack(tree, fm.folderTree), objectsContent)
 	listContent.SetOffset(0.2)
 
 	bottomContainer := fm.createBottomContainer()
@@ -126,6 +198,115 @@ func (fm *FileManager) setupUI() {
 	fm.window.SetOnDropped(func(p fyne.Position, files []fyne.URI) {
 		fm.uploadDroppedFiles(files)
 	})
+
+	fm.unsubscribeTransfers = fm.transfers.Subscribe(func() {
+		fyne.Do(fm.updateTransferStatus)
+	})
+	if fm.jobs != nil {
+		fm.unsubscribeJobs = fm.jobs.Subscribe(fm.updateJobStatus)
+	}
+}
+
+// reload refreshes the listing after objects changed. Folder browsing reloads
+// just the open folder so the user stays where they are. The matches of a
+// bucket search are updated in place from keys, the changed objects, as
+// searching again may take long.
+func (fm *FileManager) reload(ctx context.Context, basePrefix string, keys ...string) {
+	if fm.bucketSearch != "" {
+		fm.updateSearchMatches(ctx, keys)
+		return
+	}
+	if fm.folderMode && fm.folders != nil {
+		fm.loadFolder(fm.selectedFolder, true)
+		return
+	}
+	fm.LoadObjects(ctx, basePrefix)
+}
+
+// refresh lists the objects again, or searches the bucket again if the
+// listing holds the matches of a search.
+func (fm *FileManager) refresh() {
+	if fm.context == nil {
+		return
+	}
+	if fm.bucketSearch != "" {
+		if err := fm.SearchBucket(fm.context, fm.basePrefix, fm.bucketSearch); err != nil {
+			dialog.ShowError(err, fm.window)
+		}
+		return
+	}
+	fm.reload(fm.context, strings.TrimSpace(fm.prefixInput.Text))
+}
+
+// Close releases resources held by the file manager before it is replaced and
+// waits for a running listing to stop.
+func (fm *FileManager) Close() {
+	if fm.unsubscribeTransfers != nil {
+		fm.unsubscribeTransfers()
+	}
+	if fm.unsubscribeJobs != nil {
+		fm.unsubscribeJobs()
+	}
+	fm.preview.SetObject(nil, "")
+	fm.cancelLoad()
+	fm.cancelFolders()
+	fm.loads.Wait()
+}
+
+// updateTransferStatus shows the combined progress of all active transfers in
+// the status bar.
+func (fm *FileManager) updateTransferStatus() {
+	var done, total int64
+	active := 0
+	for _, info := range fm.transfers.Jobs() {
+		if info.Status != transfer.StatusQueued && info.Status != transfer.StatusRunning {
+			continue
+		}
+		active++
+		done += info.Progress.Bytes
+		total += info.Progress.Total
+	}
+
+	if active == 0 {
+		fm.transfersBtn.SetText("Transfers")
+		fm.progressBar.Hide()
+		return
+	}
+
+	fm.transfersBtn.SetText(fmt.Sprintf("Transfers (%d active)", active))
+	fm.progressBar.Show()
+	if total > 0 {
+		fm.progressBar.SetValue(float64(done) / float64(total))
+	}
+}
+
+func (fm *FileManager) showTransfers() {
+	if fm.transferPanel == nil {
+		fm.transferPanel = NewTransferPanel(fm.app, fm.cfg, fm.transfers)
+	}
+	fm.transferPanel.Show()
+}
+
+// updateJobStatus shows the number of running sync jobs on the jobs button.
+func (fm *FileManager) updateJobStatus() {
+	running := 0
+	for _, job := range fm.cfg.Settings.Jobs {
+		if fm.jobs.Running(job.Name) {
+			running++
+		}
+	}
+	if running == 0 {
+		fm.jobsBtn.SetText("Jobs")
+		return
+	}
+	fm.jobsBtn.SetText(fmt.Sprintf("Jobs (%d running)", running))
+}
+
+func (fm *FileManager) showJobs() {
+	if fm.jobsWindow == nil {
+		fm.jobsWindow = NewJobsWindow(fm.app, fm.cfg, fm.jobs)
+	}
+	fm.jobsWindow.Show()
 }
 
 func (fm *FileManager) createDirTree(data binding.DataTree) *widget.Tree {
@@ -139,6 +320,7 @@ func (fm *FileManager) createDirTree(data binding.DataTree) *widget.Tree {
 
 	tree.OnSelected = func(id string) {
 		fm.selectedPrefix = id
+		fm.updateActionButtons()
 		fm.updateObjectList()
 	}
 
@@ -152,6 +334,10 @@ func (fm *FileManager) createItemsLabel() *widget.Label {
 }
 
 func (fm *FileManager) updateItemsLabel() {
+	if fm.searchErr != nil {
+		fm.itemsLabel.SetText("Invalid search: " + fm.searchErr.Error())
+		return
+	}
 	filtered := len(fm.currentObjects)
 	all := len(fm.allObjects)
 	suffix := ""
@@ -190,9 +376,15 @@ func (fm *FileManager) createObjectList() *widget.Table {
 			}
 
 			obj := fm.currentObjects[id.Row]
+			isFolder := fm.folderMode && s3.IsFolder(obj)
 
 			switch id.Col {
 			case 0:
+				if isFolder {
+					check.Hide()
+					label.Hide()
+					return
+				}
 				check.Show()
 				label.Hide()
 				currentSelected := fm.selectedKeys[obj.Key]
@@ -208,16 +400,24 @@ func (fm *FileManager) createObjectList() *widget.Table {
 				check.Hide()
 				label.Show()
 				label.Truncation = fyne.TextTruncateEllipsis
-				label.SetText(obj.Key)
+				label.SetText(fm.displayName(obj))
 			case 2:
 				check.Hide()
 				label.Show()
 				label.Truncation = fyne.TextTruncateOff
+				if isFolder {
+					label.SetText("Folder")
+					return
+				}
 				label.SetText(ByteCountSI(obj.Size))
 			case 3:
 				check.Hide()
 				label.Show()
 				label.Truncation = fyne.TextTruncateClip
+				if isFolder {
+					label.SetText("")
+					return
+				}
 				label.SetText(obj.LastModified.Format("2006-01-02 15:04:05"))
 			}
 		},
@@ -229,7 +429,7 @@ func (fm *FileManager) createObjectList() *widget.Table {
 	objectList.SetColumnWidth(3, dateColumnWidth)
 	objectList.ShowHeaderColumn = false
 	objectList.CreateHeader = func() fyne.CanvasObject {
-		b := widget.NewButton("", func() {})
+		b := widget.NewButton("", nil)
 		b.Alignment = widget.ButtonAlignLeading
 		return b
 	}
@@ -242,21 +442,33 @@ func (fm *FileManager) createObjectList() *widget.Table {
 			return
 		}
 
-		switch id.Col {
-		case 0:
-		case 1:
-			b.SetText("Name")
-			b.Icon = theme.MoveUpIcon()
-		case 2:
-			b.SetText("Size")
-		case 3:
-			b.SetText("Last Modified")
+		column := sortColumn(id.Col)
+		switch column {
+		case sortByName:
+			b.Text = "Name"
+		case sortBySize:
+			b.Text = "Size"
+		case sortByModified:
+			b.Text = "Last Modified"
+		default:
+			b.OnTapped = nil
+			return
+		}
+		b.Icon = fm.sort.icon(column)
+		b.OnTapped = func() {
+			fm.sortBy(column)
 		}
+		b.Refresh()
 	}
 
 	objectList.OnSelected = func(id widget.TableCellID) {
 		if id.Row < len(fm.currentObjects) {
 			obj := fm.currentObjects[id.Row]
+			if fm.folderMode && s3.IsFolder(obj) {
+				fm.objectList.UnselectAll()
+				fm.navigateToFolder(obj.Key)
+				return
+			}
 			currentSelected := fm.selectedKeys[obj.Key]
 			fm.updateSelect(id.Row, !currentSelected)
 		} else {
@@ -267,9 +479,18 @@ func (fm *FileManager) createObjectList() *widget.Table {
 	return objectList
 }
 
+// displayName returns the name shown for obj. Folder browsing shows names
+// relative to the open folder, the flat listing shows full keys.
+func (fm *FileManager) displayName(obj minio.ObjectInfo) string {
+	if fm.folderMode {
+		return strings.TrimPrefix(obj.Key, fm.selectedFolder)
+	}
+	return obj.Key
+}
+
 func (fm *FileManager) createSearchInput() *widget.Entry {
 	searchInput := widget.NewEntry()
-	searchInput.SetPlaceHolder("Search...")
+	searchInput.SetPlaceHolder("Search... (e.g. *.jpg >1MB modified:>2025-01-01 OR ext:png)")
 	searchInput.OnChanged = func(s string) {
 		fm.searchTerm = s
 		if fm.searchDebounceTimer != nil {
@@ -315,13 +536,27 @@ func (fm *FileManager) createStopButton() *widget.Button {
 func (fm *FileManager) createBottomContainer() *fyne.Container {
 	dropHint := container.NewHBox(
 		widget.NewIcon(theme.UploadIcon()),
-		widget.NewLabel("Drop files anywhere to upload"),
+		widget.NewLabel("Drop files or folders anywhere to upload"),
 	)
 
+	fm.transfersBtn = widget.NewButtonWithIcon("Transfers", theme.ListIcon(), fm.showTransfers)
+	fm.transfersBtn.Importance = widget.LowImportance
+	fm.undoBtn = widget.NewButtonWithIcon("Undo Delete", theme.ContentUndoIcon(), fm.handleUndoDelete)
+	fm.undoBtn.Importance = widget.WarningImportance
+	fm.undoBtn.Hide()
+	fm.jobsBtn = widget.NewButtonWithIcon("Jobs", theme.HistoryIcon(), fm.showJobs)
+	fm.jobsBtn.Importance = widget.LowImportance
+	if fm.jobs == nil {
+		fm.jobsBtn.Hide()
+	}
+
 	return container.NewHBox(
 		container.NewGridWrap(fyne.NewSize(statusLabelWidth, fm.itemsLabel.MinSize().Height), fm.itemsLabel),
 		dropHint,
 		layout.NewSpacer(),
+		fm.undoBtn,
+		fm.jobsBtn,
+		fm.transfersBtn,
 		fm.stopBtn,
 		container.NewGridWrap(fyne.NewSize(loadingBarWidth, fm.progressBar.MinSize().Height), fm.loadingBar),
 		container.NewGridWrap(fyne.NewSize(progressBarWidth, fm.progressBar.MinSize().Height), fm.progressBar),
@@ -329,12 +564,7 @@ func (fm *FileManager) createBottomContainer() *fyne.Container {
 }
 
 func (fm *FileManager) createButtonBar() *fyne.Container {
-	refreshBtn := widget.NewButton("Refresh", func() {
-		if fm.context == nil {
-			return
-		}
-		fm.LoadObjects(fm.context, strings.TrimSpace(fm.prefixInput.Text))
-	})
+	refreshBtn := widget.NewButton("Refresh", fm.refresh)
 	refreshBtn.Icon = theme.ViewRefreshIcon()
 
 	deleteBtn := widget.NewButton("Delete", func() {
@@ -349,6 +579,11 @@ func (fm *FileManager) createButtonBar() *fyne.Container {
 	})
 	uploadBtn.Icon = theme.UploadIcon()
 
+	uploadFolderBtn := widget.NewButton("Upload Folder", func() {
+		fm.handleUploadFolder()
+	})
+	uploadFolderBtn.Icon = theme.FolderOpenIcon()
+
 	downloadBtn := widget.NewButton("Download", func() {
 		fm.handleDownload()
 	})
@@ -356,12 +591,47 @@ func (fm *FileManager) createButtonBar() *fyne.Container {
 	downloadBtn.Disable()
 	fm.downloadBtn = downloadBtn
 
-	linkBtn := widget.NewButton("Link", func() {
-		fm.handleLink()
+	renameBtn := widget.NewButton("Rename", func() {
+		fm.handleRename()
+	})
+	renameBtn.Icon = theme.DocumentCreateIcon()
+	renameBtn.Disable()
+	fm.renameBtn = renameBtn
+
+	moveBtn := widget.NewButton("Move", func() {
+		fm.handleMove()
+	})
+	moveBtn.Icon = theme.ContentCutIcon()
+	moveBtn.Disable()
+	fm.moveBtn = moveBtn
+
+	copyBtn := widget.NewButton("Copy To", func() {
+		fm.handleCopyTo()
+	})
+	copyBtn.Icon = theme.ContentCopyIcon()
+	copyBtn.Disable()
+	fm.copyBtn = copyBtn
+
+	commanderBtn := widget.NewButton("Commander", func() {
+		fm.handleCommander()
 	})
-	linkBtn.Icon = theme.MailSendIcon()
-	linkBtn.Disable()
-	fm.linkBtn = linkBtn
+	commanderBtn.Icon = theme.ViewRestoreIcon()
+
+	syncBtn := widget.NewButton("Sync", func() {
+		fm.handleSync()
+	})
+	syncBtn.Icon = theme.MediaReplayIcon()
+
+	// Actions on the selected object open from a menu to keep the bar short.
+	actionsBtn := widget.NewButtonWithIcon("Actions", theme.MoreHorizontalIcon(), nil)
+	actionsBtn.OnTapped = func() {
+		widget.ShowPopUpMenuAtRelativePosition(fm.actionsMenu(), fm.window.Canvas(), fyne.NewPos(0, actionsBtn.Size().Height), actionsBtn)
+	}
+
+	trashBtn := widget.NewButtonWithIcon("Trash", theme.ContentClearIcon(), fm.handleTrash)
+	if !fm.conn.Trash {
+		trashBtn.Hide()
+	}
 
 	exitBtn := widget.NewButton("Exit", func() {
 		fm.window.Close()
@@ -376,7 +646,7 @@ func (fm *FileManager) createButtonBar() *fyne.Container {
 		}
 	})
 
-	return container.NewHBox(refreshBtn, downloadBtn, deleteBtn, linkBtn, uploadBtn, layout.NewSpacer(), exitBtn, changeConnBtn)
+	return container.NewHBox(refreshBtn, downloadBtn, deleteBtn, renameBtn, moveBtn, copyBtn, actionsBtn, trashBtn, uploadBtn, uploadFolderBtn, syncBtn, commanderBtn, layout.NewSpacer(), exitBtn, changeConnBtn)
 }
 
 func (fm *FileManager) createTopContainer(btnBar *fyne.Container) *fyne.Container {
@@ -406,10 +676,19 @@ func (fm *FileManager) createTopContainer(btnBar *fyne.Container) *fyne.Containe
 		if fm.context == nil || !fm.hasMoreObjects {
 			return
 		}
+		if fm.folderMode {
+			fm.continueFolder(fm.selectedFolder)
+			return
+		}
 		fm.continueLoading(fm.context)
 	})
 	fm.loadMoreBtn.Hide()
 
+	folderModeCheck := widget.NewCheck("Browse folders", fm.setFolderMode)
+	fm.folderModeCheck = folderModeCheck
+	gridCheck := widget.NewCheck("Thumbnails", fm.setGridView)
+	gridCheck.Checked = fm.gridView
+
 	prefixRow := container.NewHBox(
 		widget.NewLabel("Prefix:"),
 		container.NewGridWrap(fyne.NewSize(prefixInputWidth, fm.prefixInput.MinSize().Height), fm.prefixInput),
@@ -418,9 +697,18 @@ func (fm *FileManager) createTopContainer(btnBar *fyne.Container) *fyne.Containe
 		container.NewGridWrap(fyne.NewSize(maxObjectsWidth, fm.maxObjsInput.MinSize().Height), fm.maxObjsInput),
 		fm.loadMoreBtn,
 		layout.NewSpacer(),
+		gridCheck,
+		folderModeCheck,
 	)
 
-	searchBar := container.New(layout.NewStackLayout(), fm.searchInput)
+	searchHelpBtn := widget.NewButtonWithIcon("", theme.HelpIcon(), func() {
+		help := widget.NewLabel(searchHelp)
+		help.TextStyle = fyne.TextStyle{Monospace: true}
+		dialog.ShowCustom("Search", "Close", help, fm.window)
+	})
+	searchHelpBtn.Importance = widget.LowImportance
+	searchBucketBtn := widget.NewButtonWithIcon("Search Bucket", theme.SearchIcon(), fm.handleSearchBucket)
+	searchBar := container.NewBorder(nil, nil, nil, container.NewHBox(searchBucketBtn, searchHelpBtn), fm.searchInput)
 	return container.NewVBox(btnBar, container.NewPadded(prefixRow), container.NewPadded(searchBar))
 }
 
@@ -487,28 +775,49 @@ func (fm *FileManager) cancelLoad() {
 	}
 }
 
+// filterObjectsLocked returns the loaded objects below the selected prefix
+// that match the search, in the table order. An invalid search matches
+// nothing.
 func (fm *FileManager) filterObjectsLocked() []minio.ObjectInfo {
-	if fm.searchTerm == "" && (fm.selectedPrefix == "" || fm.selectedPrefix == "all") {
+	selectedPrefix := fm.selectedPrefix
+	if fm.folderMode {
+		// The listing already only holds the open folder.
+		selectedPrefix = "all"
+	}
+	allPrefixes := selectedPrefix == "" || selectedPrefix == "all"
+
+	query, err := fm.compileSearchLocked()
+	if err != nil {
+		return nil
+	}
+	index := fm.sortedIndexLocked()
+	if query == nil && allPrefixes && index == nil {
 		return fm.allObjects
 	}
 
-	searchTermLower := strings.ToLower(fm.searchTerm)
 	filteredObjects := make([]minio.ObjectInfo, 0, len(fm.allObjects)/2)
-
-	for _, obj := range fm.allObjects {
-		switch {
-		case (fm.selectedPrefix == "" || fm.selectedPrefix == "all") && strings.Contains(strings.ToLower(obj.Key), searchTermLower):
-			fallthrough
-		case searchTermLower == "" && strings.HasPrefix(obj.Key, fm.selectedPrefix):
-			fallthrough
-		case strings.HasPrefix(obj.Key, fm.selectedPrefix) && strings.Contains(strings.ToLower(obj.Key), searchTermLower):
-			filteredObjects = append(filteredObjects, obj)
+	for i := range fm.allObjects {
+		obj := &fm.allObjects[i]
+		if index != nil {
+			obj = &fm.allObjects[index[i]]
+		}
+		if (allPrefixes || strings.HasPrefix(obj.Key, selectedPrefix)) && (query == nil || query.match(obj)) {
+			filteredObjects = append(filteredObjects, *obj)
 		}
 	}
-
 	return filteredObjects
 }
 
+// compileSearchLocked returns the compiled search term. It is compiled again
+// only when the term changed.
+func (fm *FileManager) compileSearchLocked() (*searchQuery, error) {
+	if fm.searchTerm != fm.compiledSearch {
+		fm.searchQuery, fm.searchErr = parseSearchQuery(fm.searchTerm)
+		fm.compiledSearch = fm.searchTerm
+	}
+	return fm.searchQuery, fm.searchErr
+}
+
 func (fm *FileManager) updateSelect(idx int, selected bool) {
 	if idx < 0 || idx >= len(fm.currentObjects) {
 		return
@@ -522,24 +831,28 @@ func (fm *FileManager) updateSelect(idx int, selected bool) {
 		}
 
 		fm.selectedKeys[obj.Key] = true
-
-		fm.deleteBtn.Enable()
-		fm.downloadBtn.Enable()
-		fm.linkBtn.Enable()
 	} else {
 		delete(fm.selectedKeys, obj.Key)
 		if len(fm.selectedKeys) == 0 {
 			fm.selectedKeys = nil
-			fm.deleteBtn.Disable()
-			fm.downloadBtn.Disable()
-			fm.linkBtn.Disable()
 		}
 	}
+	fm.updateActionButtons()
 	fm.objectList.RefreshItem(widget.TableCellID{Row: idx, Col: 0})
 	fm.objectList.Refresh()
+	fm.objectGrid.RefreshItem(idx)
 }
 
 func (fm *FileManager) removeObject(key string) {
+	fm.resetOrderLocked()
+	if fm.folderMode {
+		fm.removeFolderEntry(key)
+		if f := fm.folders[fm.selectedFolder]; f != nil {
+			fm.allObjects = f.entries
+		}
+		return
+	}
+
 	for idx, obj := range fm.allObjects {
 		if obj.Key == key {
 			if idx == len(fm.allObjects)-1 {
@@ -552,6 +865,139 @@ func (fm *FileManager) removeObject(key string) {
 	}
 }
 
+// updateActionButtons enables the buttons that act on the selected objects.
+func (fm *FileManager) updateActionButtons() {
+	setEnabled := func(enabled bool, buttons ...*widget.Button) {
+		for _, btn := range buttons {
+			switch {
+			case btn == nil:
+			case enabled:
+				btn.Enable()
+			default:
+				btn.Disable()
+			}
+		}
+	}
+	// Rename acts on a single object.
+	setEnabled(len(fm.selectedKeys) == 1, fm.renameBtn)
+	_, folderSelected := fm.treeFolder()
+	setEnabled(len(fm.selectedKeys) > 0 || folderSelected, fm.deleteBtn, fm.moveBtn, fm.copyBtn)
+	fm.updateDownloadButton()
+	fm.updatePreview()
+}
+
+// actionsMenu returns the menu of actions on the selected object and the
+// open folder. It is built when shown so it follows the selection.
+func (fm *FileManager) actionsMenu() *fyne.Menu {
+	single := len(fm.selectedKeys) == 1
+	item := func(label string, icon fyne.Resource, action func(), enabled bool) *fyne.MenuItem {
+		mi := fyne.NewMenuItemWithIcon(label, icon, action)
+		mi.Disabled = !enabled
+		return mi
+	}
+	preview := item("Preview", theme.VisibilityIcon(), fm.handlePreview, true)
+	preview.Checked = fm.preview != nil && fm.preview.Container.Visible()
+
+	items := []*fyne.MenuItem{item("Edit", theme.FileTextIcon(), fm.handleEdit, single)}
+	if fm.opened != nil {
+		items = append(items, item("Open", theme.FileApplicationIcon(), fm.handleOpen, single))
+	}
+	items = append(items,
+		item("Properties", theme.InfoIcon(), fm.handleProperties, single),
+		item("Versions", theme.HistoryIcon(), fm.handleVersions, single),
+		preview,
+		fyne.NewMenuItemSeparator(),
+		item("Link", theme.MailSendIcon(), fm.handleLink, true),
+		item("Upload Form", theme.DocumentIcon(), fm.handleUploadForm, true),
+	)
+	return fyne.NewMenu("Actions", items...)
+}
+
+// handlePreview shows or hides the preview pane.
+func (fm *FileManager) handlePreview() {
+	if fm.preview.Container.Visible() {
+		fm.preview.Container.Hide()
+	} else {
+		fm.preview.Container.Show()
+	}
+	fm.updatePreview()
+}
+
+// updatePreview previews the selected object while the preview pane is
+// shown.
+func (fm *FileManager) updatePreview() {
+	if fm.preview == nil {
+		return
+	}
+	key := ""
+	if len(fm.selectedKeys) == 1 && fm.preview.Container.Visible() {
+		for k := range fm.selectedKeys {
+			key = k
+		}
+	}
+	fm.preview.SetObject(fm.context, key)
+}
+
+// handleCommander opens the dual-pane commander at the open folder.
+func (fm *FileManager) handleCommander() {
+	NewCommander(fm.app, fm.cfg, fm.conn, fm.s3svc, fm.openStore, fm.transfers).Show(fm.downloadBase())
+}
+
+// handleSync opens the sync window for the open folder.
+func (fm *FileManager) handleSync() {
+	if fm.context == nil {
+		return
+	}
+	ctx, basePrefix := fm.context, fm.basePrefix
+	NewSyncWindow(fm.app, fm.cfg, fm.conn, fm.s3svc, fm.openStore, fm.downloadBase(), func() {
+		fm.reload(ctx, basePrefix)
+	}).Show()
+}
+
+// handleEdit opens the selected object in the text editor.
+func (fm *FileManager) handleEdit() {
+	if len(fm.selectedKeys) != 1 || fm.context == nil {
+		return
+	}
+	for key := range fm.selectedKeys {
+		ctx, basePrefix := fm.context, fm.basePrefix
+		NewEditorWindow(fm.app, fm.s3svc, key, func() {
+			// The preview shows the saved text once the object is selected again.
+			fm.preview.SetObject(nil, "")
+			fm.reload(ctx, basePrefix, key)
+		}).Show()
+	}
+}
+
+// handleOpen opens the selected object with the default application of the
+// system and offers to upload it again when it is changed there.
+func (fm *FileManager) handleOpen() {
+	if len(fm.selectedKeys) != 1 || fm.context == nil || fm.opened == nil {
+		return
+	}
+	for key := range fm.selectedKeys {
+		ctx, basePrefix := fm.context, fm.basePrefix
+		fm.opened.Open(ctx, fm.s3svc, key, func() {
+			fm.preview.SetObject(nil, "")
+			fm.reload(ctx, basePrefix, key)
+		})
+	}
+}
+
+func (fm *FileManager) handleVersions() {
+	if len(fm.selectedKeys) != 1 {
+		return
+	}
+	for key := range fm.selectedKeys {
+		ctx, basePrefix := fm.context, fm.basePrefix
+		NewVersionsPanel(fm.app, fm.s3svc, fm.transfers, fm.downloader, key, func() {
+			if ctx != nil {
+				fm.reload(ctx, basePrefix, key)
+			}
+		}).Show()
+	}
+}
+
 func (fm *FileManager) updateObjectList() {
 	fyne.Do(func() {
 		fm.updateObjectListLocked(true)
@@ -561,12 +1007,12 @@ func (fm *FileManager) updateObjectList() {
 func (fm *FileManager) updateObjectListLocked(scrollToTop bool) {
 	filtered := fm.filterObjectsLocked()
 
-	fm.objectList.UnselectAll()
 	fm.currentObjects = filtered
-	fm.objectList.Refresh()
+	fm.resetObjectViews()
 
 	if scrollToTop && len(filtered) > 0 {
 		fm.objectList.ScrollTo(widget.TableCellID{Row: 0, Col: 0})
+		fm.objectGrid.ScrollToTop()
 	}
 
 	fm.updateItemsLabel()
@@ -576,9 +1022,22 @@ func (fm *FileManager) updateObjectListLocked(scrollToTop bool) {
 func (fm *FileManager) LoadObjects(ctx context.Context, prefix string) {
 	fm.context = ctx
 	fm.cancelLoad()
+	fm.bucketSearch = ""
 
 	cleanPrefix := strings.TrimSpace(prefix)
 	cleanPrefix = strings.TrimLeft(cleanPrefix, "/")
+
+	if fm.folderMode {
+		fyne.Do(func() {
+			if fm.prefixInput != nil && fm.prefixInput.Text != cleanPrefix {
+				fm.prefixInput.SetText(cleanPrefix)
+			}
+		})
+		fm.loadFolderRoot(ctx, cleanPrefix)
+		return
+	}
+
+	fm.cancelFolders()
 	fm.basePrefix = cleanPrefix
 
 	loadCtx, cancel := context.WithCancel(ctx)
@@ -607,15 +1066,18 @@ func (fm *FileManager) LoadObjects(ctx context.Context, prefix string) {
 		fm.prefixes = make(map[string]bool)
 		fm.hasMoreObjects = false
 		fm.updateTree()
-		fm.objectList.UnselectAll()
-		fm.objectList.Refresh()
+		fm.resetObjectViews()
 		fm.tree.Refresh()
 		if fm.tree != nil {
 			fm.tree.Select("all")
 		}
 	})
 
-	go fm.loadObjectsAsync(loadCtx, handle, "", 0, fm.maxObjects, fm.basePrefix)
+	fm.loads.Add(1)
+	go func() {
+		defer fm.loads.Done()
+		fm.loadObjectsAsync(loadCtx, handle, "", 0, fm.maxObjects, fm.basePrefix)
+	}()
 }
 
 func (fm *FileManager) continueLoading(ctx context.Context) {
@@ -640,7 +1102,11 @@ func (fm *FileManager) continueLoading(ctx context.Context) {
 		fm.itemsLabel.SetText(fmt.Sprintf("Loading more objects (currently %d)…", len(fm.allObjects)))
 	})
 
-	go fm.loadObjectsAsync(loadCtx, handle, lastKey, len(fm.allObjects), fm.maxObjects, fm.basePrefix)
+	fm.loads.Add(1)
+	go func() {
+		defer fm.loads.Done()
+		fm.loadObjectsAsync(loadCtx, handle, lastKey, len(fm.allObjects), fm.maxObjects, fm.basePrefix)
+	}()
 }
 
 func (fm *FileManager) loadObjectsAsync(loadCtx context.Context, handle *loadHandle, startAfter string, startCount, maxObjects int, basePrefix string) {
@@ -799,103 +1265,29 @@ func (fm *FileManager) loadObjectsAsync(loadCtx context.Context, handle *loadHan
 	}
 }
 
-func (fm *FileManager) handleDelete() {
-	if fm.selectedKeys == nil {
-		dialog.ShowInformation("Info", "No object selected", fm.window)
-		return
-	}
-
-	msg := fmt.Sprintf("Do you really want to delete '%d' files?", len(fm.selectedKeys))
-	if len(fm.selectedKeys) == 1 {
-		msg = "Do you really want to delete this file?"
-	}
-
-	confirm := dialog.NewConfirm(
-		"Delete Objects",
-		msg,
-		func(yes bool) {
-			if !yes {
-				return
-			}
-
-			keys := make([]string, 0, len(fm.selectedKeys))
-			for key := range fm.selectedKeys {
-				keys = append(keys, key)
-			}
-			go func() {
-				for _, key := range keys {
-					if err := fm.s3svc.DeleteObject(fm.context, key); err != nil {
-						fyne.Do(func() { dialog.ShowError(err, fm.window) })
-						continue
-					}
-					fyne.Do(func() { fm.removeObject(key) })
-				}
-				fyne.Do(func() {
-					fm.selectedKeys = nil
-					fm.deleteBtn.Disable()
-					fm.downloadBtn.Disable()
-					fm.linkBtn.Disable()
-					fm.updateObjectListLocked(false)
-				})
-			}()
-		}, fm.window)
-	confirm.Show()
-}
-
 func (fm *FileManager) handleUpload() {
 	fd := dialog.NewFileOpen(func(reader fyne.URIReadCloser, err error) {
 		if err != nil || reader == nil {
 			return
 		}
+		// The transfer opens the file itself, possibly several times on resume.
+		reader.Close()
 
-		fm.uploadFile(reader, reader.URI())
+		fm.uploadPaths([]string{reader.URI().Path()}, transfer.Filter{})
 	}, fm.window)
 
 	fd.Show()
 }
 
-func (fm *FileManager) uploadFile(reader io.ReadCloser, path fyne.URI) {
-	ctx := fm.context
-	if ctx == nil {
-		dialog.ShowError(fmt.Errorf("no active connection"), fm.window)
-		return
-	}
-
-	fullname := uploadObjectName(fm.selectedPrefix, path)
-	basePrefix := fm.basePrefix
-
-	// Show progress bar before starting upload
-	fm.progressBar.Show()
-	fm.progressBar.SetValue(0)
-	fm.itemsLabel.SetText(fmt.Sprintf("Uploading %s", fullname))
-
-	// Perform upload in a separate goroutine
-	go func() {
-		err := fm.uploadReader(ctx, reader, path, fullname, func(progress float64) {
-			fyne.Do(func() {
-				fm.progressBar.Show()
-				fm.progressBar.SetValue(progress)
-				fm.itemsLabel.SetText(fmt.Sprintf("Uploading %s: %.1f%%", fullname, progress*100))
-			})
-		})
-		if err != nil {
-			fmt.Println("Error uploading file: ", err)
-			fyne.Do(func() {
-				fm.progressBar.Hide()
-				fm.itemsLabel.SetText("")
-				dialog.ShowError(err, fm.window)
-			})
+func (fm *FileManager) handleUploadFolder() {
+	fd := dialog.NewFolderOpen(func(uri fyne.ListableURI, err error) {
+		if err != nil || uri == nil {
 			return
 		}
+		fm.showUploadFilters([]string{uri.Path()})
+	}, fm.window)
 
-		// Update UI when upload is complete
-		fyne.Do(func() {
-			fm.progressBar.Hide()
-			fm.itemsLabel.SetText("")
-			dialog.ShowInformation("OK", fmt.Sprintf("Uploaded file %s!", fullname), fm.window)
-			fm.LoadObjects(ctx, basePrefix)
-		})
-	}()
+	fd.Show()
 }
 
 func (fm *FileManager) uploadDroppedFiles(files []fyne.URI) {
@@ -904,71 +1296,85 @@ func (fm *FileManager) uploadDroppedFiles(files []fyne.URI) {
 		return
 	}
 
+	paths := make([]string, len(files))
+	hasFolder := false
+	for i, file := range files {
+		paths[i] = file.Path()
+		if info, err := os.Stat(paths[i]); err == nil && info.IsDir() {
+			hasFolder = true
+		}
+	}
+
+	if hasFolder {
+		fm.showUploadFilters(paths)
+		return
+	}
+	fm.uploadPaths(paths, transfer.Filter{})
+}
+
+// uploadPaths uploads local files and folders below the selected prefix.
+func (fm *FileManager) uploadPaths(paths []string, filter transfer.Filter) {
 	ctx := fm.context
 	if ctx == nil {
 		dialog.ShowError(fmt.Errorf("no active connection"), fm.window)
 		return
 	}
 
-	selectedPrefix := fm.selectedPrefix
-	basePrefix := fm.basePrefix
-
-	go func() {
-		uploaded := 0
-		failures := make([]string, 0)
-
-		for i, file := range files {
-			fullname := uploadObjectName(selectedPrefix, file)
-			f, err := os.Open(file.Path())
-			if err != nil {
-				failures = appendUploadFailure(failures, file, err)
-				continue
-			}
+	go fm.collectUploads(ctx, paths, uploadKeyPrefix(fm.uploadPrefix()), fm.basePrefix, filter)
+}
 
-			fileIndex := i + 1
-			totalFiles := len(files)
+// uploadFiles queues uploads of local files and reloads basePrefix once all
+// of them have finished.
+func (fm *FileManager) uploadFiles(ctx context.Context, files []transfer.UploadFile, basePrefix string) {
+	keys := make([]string, len(files))
+	for i, file := range files {
+		keys[i] = file.Key
+	}
+	batch := &transferBatch{
+		pending: len(files),
+		onDone: func(completed int, failures []string) {
 			fyne.Do(func() {
-				fm.progressBar.Show()
-				fm.progressBar.SetValue(0)
-				fm.itemsLabel.SetText(fmt.Sprintf("Uploading %d of %d: %s", fileIndex, totalFiles, fullname))
-			})
-
-			err = fm.uploadReader(ctx, f, file, fullname, func(progress float64) {
-				fyne.Do(func() {
-					fm.progressBar.Show()
-					fm.progressBar.SetValue(progress)
-					fm.itemsLabel.SetText(fmt.Sprintf("Uploading %d of %d: %s (%.1f%%)", fileIndex, totalFiles, fullname, progress*100))
-				})
+				dialog.ShowInformation("Upload Complete", uploadSummaryMessage(completed, len(files), failures)+retryHint(failures), fm.window)
+				if completed > 0 {
+					fm.reload(ctx, basePrefix, keys...)
+				}
 			})
-			if err != nil {
-				fmt.Println("Error uploading file: ", err)
-				failures = appendUploadFailure(failures, file, err)
-				continue
-			}
+		},
+		onRetried: func() {
+			fyne.Do(func() { fm.reload(ctx, basePrefix, keys...) })
+		},
+	}
 
-			uploaded++
-		}
+	for _, file := range files {
+		fm.transfers.Add(fm.uploadTask(file, batch.track(file.Key)))
+	}
+}
 
-		fyne.Do(func() {
-			fm.progressBar.Hide()
-			fm.itemsLabel.SetText("")
-			dialog.ShowInformation("Upload Complete", uploadSummaryMessage(uploaded, len(files), failures), fm.window)
-			if uploaded > 0 {
-				fm.LoadObjects(ctx, basePrefix)
+func (fm *FileManager) uploadTask(file transfer.UploadFile, onFinish func(error)) transfer.Task {
+	uploader := fm.uploader
+	return transfer.Task{
+		Kind:   transfer.KindUpload,
+		Name:   file.Key,
+		Source: file.LocalPath,
+		Target: file.Key,
+		Size:   file.Size,
+		Run: func(ctx context.Context, onProgress func(transfer.Progress)) error {
+			f, err := os.Open(file.LocalPath)
+			if err != nil {
+				return err
 			}
-		})
-	}()
+			return fm.uploadReader(ctx, f, file.LocalPath, file.Key, onProgress)
+		},
+		Cleanup: func() {
+			_ = uploader.Discard(context.Background(), file.LocalPath, file.Key)
+		},
+		OnFinish: onFinish,
+	}
 }
 
-func (fm *FileManager) uploadReader(ctx context.Context, reader io.ReadCloser, path fyne.URI, objectName string, onProgress func(float64)) error {
+func (fm *FileManager) uploadReader(ctx context.Context, reader io.ReadCloser, filePath string, objectName string, onProgress func(transfer.Progress)) error {
 	defer reader.Close()
 
-	fileInfo, err := os.Stat(path.Path())
-	if err != nil {
-		return err
-	}
-	totalSize := fileInfo.Size()
-
 	bufreader := bufio.NewReader(reader)
 	detectBytes, err := bufreader.Peek(1024)
 	if err != nil && err != io.EOF {
@@ -977,19 +1383,22 @@ func (fm *FileManager) uploadReader(ctx context.Context, reader io.ReadCloser, p
 
 	mt := mimetype.Detect(detectBytes)
 
-	pr := &ProgressReader{
-		Reader: bufreader,
-		Total:  totalSize,
-		OnProgress: func(bytesRead int64) {
-			progress := 1.0
-			if totalSize > 0 {
-				progress = float64(bytesRead) / float64(totalSize)
-			}
-			onProgress(progress)
-		},
+	return fm.uploader.Upload(ctx, filePath, objectName, mt.String(), onProgress)
+}
+
+func progressText(label string, p transfer.Progress) string {
+	if p.Parts > 0 {
+		return fmt.Sprintf("%s: part %d of %d at %.0f%% (%.1f%%)", label, p.Part, p.Parts, p.PartFraction()*100, p.Fraction()*100)
 	}
+	return fmt.Sprintf("%s: %.1f%%", label, p.Fraction()*100)
+}
 
-	return fm.s3svc.UploadObjectReader(ctx, path.Path(), objectName, pr, totalSize, mt.String())
+// uploadPrefix returns the folder new uploads are placed in.
+func (fm *FileManager) uploadPrefix() string {
+	if fm.folderMode {
+		return strings.TrimSuffix(fm.selectedFolder, s3.Delimiter)
+	}
+	return fm.selectedPrefix
 }
 
 func fileURIs(files []fyne.URI) []fyne.URI {
@@ -1002,16 +1411,12 @@ func fileURIs(files []fyne.URI) []fyne.URI {
 	return filtered
 }
 
-func uploadObjectName(prefix string, path fyne.URI) string {
-	fullname := path.Name()
-	if prefix != "" && prefix != "root" && prefix != "all" {
-		fullname = prefix + "/" + fullname
+// uploadKeyPrefix returns the key prefix for uploads into the tree prefix.
+func uploadKeyPrefix(prefix string) string {
+	if prefix == "" || prefix == "root" || prefix == "all" {
+		return ""
 	}
-	return fullname
-}
-
-func appendUploadFailure(failures []string, path fyne.URI, err error) []string {
-	return append(failures, fmt.Sprintf("%s: %v", path.Name(), err))
+	return prefix + s3.Delimiter
 }
 
 func uploadSummaryMessage(uploaded, total int, failures []string) string {
@@ -1049,102 +1454,82 @@ func transferSummaryMessage(action string, completed, total int, failures []stri
 	return msg.String()
 }
 
-func appendDownloadFailure(failures []string, key string, err error) []string {
-	return append(failures, fmt.Sprintf("%s: %v", key, err))
-}
-
+// handleLink opens the share dialog for the selected objects. Without a
+// selection it offers an upload link into the open folder.
 func (fm *FileManager) handleLink() {
-	if fm.selectedKeys == nil {
-		dialog.ShowInformation("Info", "No object selected", fm.window)
+	if fm.context == nil {
 		return
 	}
-
+	keys := make([]string, 0, len(fm.selectedKeys))
 	for key := range fm.selectedKeys {
-		linkurl, err := fm.s3svc.GetPresignedURL(fm.context, key, 1*time.Hour)
-		if err != nil {
-			dialog.ShowError(err, fm.window)
-			return
-		}
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
 
-		t := widget.NewEntry()
-		t.SetText(linkurl.String())
+	uploadKey := fm.downloadBase()
+	if len(keys) == 1 {
+		uploadKey = keys[0]
+	}
+	NewShareDialog(fm.window, fm.s3svc, fm.context, keys, uploadKey).Show()
+}
 
-		d := dialog.NewCustomWithoutButtons("Link to "+key, t, fm.window)
-		d.SetButtons([]fyne.CanvasObject{widget.NewButton("Copy and Close", func() {
-			fm.window.Clipboard().SetContent(linkurl.String())
-			d.Hide()
-		})})
-		d.Show()
+// handleUploadForm opens the POST form generator for uploads into the open
+// folder.
+func (fm *FileManager) handleUploadForm() {
+	if fm.context == nil {
+		return
 	}
+	NewPostPolicyDialog(fm.window, fm.s3svc, fm.context, fm.downloadBase()).Show()
 }
 
 func (fm *FileManager) handleDownload() {
 	if fm.selectedKeys == nil {
+		if folder, ok := fm.treeFolder(); ok {
+			fm.showDownloadOptions(nil, folder)
+			return
+		}
 		dialog.ShowInformation("Info", "No object selected!", fm.window)
 		return
 	}
 
-	folderSaveDialog := dialog.NewFolderOpen(func(uri fyne.ListableURI, err error) {
-		if err != nil || uri == nil {
-			return
-		}
+	keys := make([]string, 0, len(fm.selectedKeys))
+	for key := range fm.selectedKeys {
+		keys = append(keys, key)
+	}
+	fm.showDownloadOptions(keys, "")
+}
 
-		keys := make([]string, 0, len(fm.selectedKeys))
-		for key := range fm.selectedKeys {
-			keys = append(keys, key)
-		}
-		go func() {
-			num := len(keys)
-			downloaded := 0
-			failures := make([]string, 0)
-
-			for i, key := range keys {
-				progress := float64(i+1) / float64(num)
-				label := fmt.Sprintf("Downloading %s", key)
-				fyne.Do(func() {
-					fm.progressBar.Show()
-					fm.progressBar.SetValue(progress)
-					fm.itemsLabel.SetText(label)
-				})
-
-				filePath := filepath.Join(uri.Path(), filepath.Base(key))
-				s3obj, err := fm.s3svc.DownloadObject(fm.context, key)
-				if err != nil {
-					failures = appendDownloadFailure(failures, key, err)
-					continue
-				}
-				f, err := os.Create(filePath)
-				if err != nil {
-					failures = appendDownloadFailure(failures, key, err)
-					s3obj.Close()
-					continue
-				}
-				if _, copyErr := io.Copy(f, s3obj); copyErr != nil {
-					failures = appendDownloadFailure(failures, key, copyErr)
-					f.Close()
-					s3obj.Close()
-					continue
-				}
-				if err := f.Close(); err != nil {
-					failures = appendDownloadFailure(failures, key, err)
-					s3obj.Close()
-					continue
-				}
-				if err := s3obj.Close(); err != nil {
-					failures = appendDownloadFailure(failures, key, err)
-					continue
-				}
-				downloaded++
-			}
+// downloadObjects queues downloads of items. Each object is written to a
+// ".part" file first, so a paused or failed download continues from the
+// chunks already on disk.
+func (fm *FileManager) downloadObjects(items []downloadItem) {
+	batch := &transferBatch{
+		pending: len(items),
+		onDone: func(completed int, failures []string) {
 			fyne.Do(func() {
-				fm.progressBar.Hide()
-				fm.itemsLabel.SetText("")
-				dialog.ShowInformation("Download Complete", downloadSummaryMessage(downloaded, num, failures), fm.window)
-				fm.updateObjectListLocked(false)
+				dialog.ShowInformation("Download Complete", downloadSummaryMessage(completed, len(items), failures)+retryHint(failures), fm.window)
 			})
-		}()
+		},
+	}
 
-	}, fm.window)
+	for _, item := range items {
+		fm.transfers.Add(fm.downloadTask(item.Key, item.Path, batch.track(item.Key)))
+	}
+}
 
-	folderSaveDialog.Show()
+func (fm *FileManager) downloadTask(key, filePath string, onFinish func(error)) transfer.Task {
+	downloader := fm.downloader
+	return transfer.Task{
+		Kind:   transfer.KindDownload,
+		Name:   key,
+		Source: key,
+		Target: filePath,
+		Run: func(ctx context.Context, onProgress func(transfer.Progress)) error {
+			return downloader.Download(ctx, key, filePath, onProgress)
+		},
+		Cleanup: func() {
+			_ = downloader.Discard(key, filePath)
+		},
+		OnFinish: onFinish,
+	}
 }
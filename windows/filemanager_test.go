@@ -1,6 +1,9 @@
 package windows
 
 import (
+	"context"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"fyne.io/fyne/v2"
@@ -8,6 +11,10 @@ import (
 	"fyne.io/fyne/v2/storage"
 	fynetest "fyne.io/fyne/v2/test"
 	minio "github.com/minio/minio-go/v7"
+
+	"github.com/pteich/us3ui/config"
+	"github.com/pteich/us3ui/s3/memstore"
+	"github.com/pteich/us3ui/transfer"
 )
 
 func makeObjects(keys ...string) []minio.ObjectInfo {
@@ -116,24 +123,22 @@ func TestFileURIsFiltersNonFileSchemes(t *testing.T) {
 	}
 }
 
-func TestUploadObjectName(t *testing.T) {
-	uri := storage.NewFileURI("/tmp/report.csv")
-
+func TestUploadKeyPrefix(t *testing.T) {
 	tests := []struct {
 		name   string
 		prefix string
 		want   string
 	}{
-		{name: "all", prefix: "all", want: "report.csv"},
-		{name: "root", prefix: "root", want: "report.csv"},
-		{name: "empty", prefix: "", want: "report.csv"},
-		{name: "folder", prefix: "reports/2026", want: "reports/2026/report.csv"},
+		{name: "all", prefix: "all", want: ""},
+		{name: "root", prefix: "root", want: ""},
+		{name: "empty", prefix: "", want: ""},
+		{name: "folder", prefix: "reports/2026", want: "reports/2026/"},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := uploadObjectName(tt.prefix, uri); got != tt.want {
-				t.Fatalf("uploadObjectName(%q) = %q, want %q", tt.prefix, got, tt.want)
+			if got := uploadKeyPrefix(tt.prefix); got != tt.want {
+				t.Fatalf("uploadKeyPrefix(%q) = %q, want %q", tt.prefix, got, tt.want)
 			}
 		})
 	}
@@ -183,6 +188,213 @@ func TestDownloadSummaryMessage(t *testing.T) {
 	}
 }
 
+func TestProgressText(t *testing.T) {
+	single := transfer.Progress{Bytes: 50, Total: 200}
+	if got, want := progressText("Uploading a.txt", single), "Uploading a.txt: 25.0%"; got != want {
+		t.Errorf("progressText() = %q, want %q", got, want)
+	}
+
+	multi := transfer.Progress{Bytes: 150, Total: 200, Part: 3, Parts: 4, PartBytes: 25, PartSize: 50}
+	if got, want := progressText("Uploading b.bin", multi), "Uploading b.bin: part 3 of 4 at 50% (75.0%)"; got != want {
+		t.Errorf("progressText() = %q, want %q", got, want)
+	}
+}
+
+func newTestFileManager(t *testing.T, store *memstore.Store) *FileManager {
+	t.Helper()
+
+	a := newTestApp(t)
+	transfers := transfer.NewManager(2)
+	fm := NewFileManager(a, nil, config.S3Config{}, store, transfers, nil, nil, fynetest.NewWindow(nil), nil)
+	// The test driver runs fyne.Do callbacks on the calling goroutine, so
+	// status bar updates from parallel workers would race with each other.
+	fm.unsubscribeTransfers()
+	t.Cleanup(fm.Close)
+	state := transfer.NewStateStore(t.TempDir())
+	fm.uploader = transfer.NewUploader(store, state)
+	fm.downloader = transfer.NewDownloader(store, state)
+	fm.thumbnailer = NewThumbnailer(store, NewThumbnailCache(t.TempDir(), thumbnailCacheLimit), "", "bucket")
+	return fm
+}
+
+func loadAll(t *testing.T, fm *FileManager, maxObjects int, prefix string) {
+	t.Helper()
+
+	fm.context = context.Background()
+	fm.prefixes = make(map[string]bool)
+	handle := &loadHandle{cancel: func() {}}
+	fm.loadHandle = handle
+	fm.loadObjectsAsync(context.Background(), handle, "", 0, maxObjects, prefix)
+}
+
+func TestLoadObjectsAsyncLoadsListing(t *testing.T) {
+	store := memstore.New("bucket")
+	store.Put("logs/app/1.log", []byte("one"))
+	store.Put("logs/system.log", []byte("two"))
+	store.Put("README.md", []byte("three"))
+	store.Put("other/file.txt", []byte("four"))
+
+	fm := newTestFileManager(t, store)
+	loadAll(t, fm, 0, "logs/")
+
+	if len(fm.allObjects) != 2 {
+		t.Fatalf("allObjects = %v, want 2 objects under logs/", keysOf(fm.allObjects))
+	}
+	if fm.hasMoreObjects {
+		t.Errorf("hasMoreObjects = true after complete listing")
+	}
+	if !fm.prefixes["logs/app"] || !fm.prefixes["logs"] {
+		t.Errorf("prefixes = %v, want logs and logs/app", fm.prefixes)
+	}
+	if fm.loadHandle != nil {
+		t.Errorf("loadHandle not cleared after load finished")
+	}
+}
+
+func TestLoadObjectsAsyncStopsAtLimit(t *testing.T) {
+	store := memstore.New("bucket")
+	for _, key := range []string{"a", "b", "c", "d"} {
+		store.Put(key, []byte(key))
+	}
+
+	fm := newTestFileManager(t, store)
+	loadAll(t, fm, 3, "")
+
+	if len(fm.allObjects) != 3 {
+		t.Fatalf("allObjects = %v, want 3 objects", keysOf(fm.allObjects))
+	}
+	if !fm.hasMoreObjects {
+		t.Errorf("hasMoreObjects = false, want true when the limit was hit")
+	}
+}
+
+func TestActionsMenuFollowsSelection(t *testing.T) {
+	fm := newTestFileManager(t, memstore.New("bucket"))
+	enabled := func() []string {
+		var labels []string
+		for _, item := range fm.actionsMenu().Items {
+			if !item.IsSeparator && !item.Disabled {
+				labels = append(labels, item.Label)
+			}
+		}
+		return labels
+	}
+
+	// Without an opened files tracker there is no Open action.
+	assertStringSet(t, enabled(), []string{"Preview", "Link", "Upload Form"})
+	fm.selectedKeys = map[string]bool{"a.txt": true}
+	assertStringSet(t, enabled(), []string{"Edit", "Properties", "Versions", "Preview", "Link", "Upload Form"})
+	fm.selectedKeys["b.txt"] = true
+	assertStringSet(t, enabled(), []string{"Preview", "Link", "Upload Form"})
+}
+
+func TestDeleteObjectsRemovesFromStoreAndListing(t *testing.T) {
+	store := memstore.New("bucket")
+	store.Put("keep.txt", []byte("keep"))
+	store.Put("drop.txt", []byte("drop"))
+
+	fm := newTestFileManager(t, store)
+	loadAll(t, fm, 0, "")
+	fm.selectedKeys = map[string]bool{"drop.txt": true}
+
+	fm.deleteObjects(context.Background(), []string{"drop.txt"}, nil, nil)
+
+	if _, ok := store.Get("drop.txt"); ok {
+		t.Errorf("drop.txt still present in store")
+	}
+	if got := keysOf(fm.allObjects); len(got) != 1 || got[0] != "keep.txt" {
+		t.Errorf("allObjects = %v, want [keep.txt]", got)
+	}
+	if fm.selectedKeys != nil {
+		t.Errorf("selectedKeys = %v, want nil after delete", fm.selectedKeys)
+	}
+}
+
+func TestUploadFilesUploadsBelowPrefix(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "report.csv")
+	if err := os.WriteFile(path, []byte("a,b\n1,2\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	store := memstore.New("bucket")
+	fm := newTestFileManager(t, store)
+	fm.collectUploads(context.Background(), []string{path, filepath.Join(dir, "missing.csv")}, "reports/", "", transfer.Filter{})
+	fm.transfers.Wait()
+	fm.loads.Wait() // reload after the uploads
+
+	data, ok := store.Get("reports/report.csv")
+	if !ok {
+		t.Fatalf("reports/report.csv was not uploaded")
+	}
+	if string(data) != "a,b\n1,2\n" {
+		t.Errorf("uploaded content = %q", data)
+	}
+	if _, ok := store.Get("reports/missing.csv"); ok {
+		t.Errorf("missing file should not have been uploaded")
+	}
+}
+
+func TestCollectUploadsUploadsFolderTree(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "site")
+	for name, content := range map[string]string{
+		"index.html":                "<html>",
+		"css/main.css":              "body{}",
+		"css/.DS_Store":             "junk",
+		"node_modules/lib/index.js": "module",
+	} {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	store := memstore.New("bucket")
+	fm := newTestFileManager(t, store)
+	filter := transfer.Filter{Exclude: []string{".DS_Store", "node_modules"}}
+	fm.collectUploads(context.Background(), []string{dir}, "www/", "", filter)
+	fm.transfers.Wait()
+	fm.loads.Wait()
+
+	objs, err := store.ListObjectsBatch(context.Background(), "", "", 100)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertStringSet(t, keysOf(objs), []string{"www/site/index.html", "www/site/css/main.css"})
+}
+
+func TestDownloadObjectsWritesFiles(t *testing.T) {
+	store := memstore.New("bucket")
+	store.Put("reports/2026/summary.csv", []byte("total,42\n"))
+	store.Put("notes.txt", []byte("hello"))
+
+	fm := newTestFileManager(t, store)
+	dir := t.TempDir()
+	items, _, _ := newDownloadPlanner(dir, "", false).plan([]string{"reports/2026/summary.csv", "notes.txt", "missing.txt"}, conflictOverwrite)
+	fm.downloadObjects(items)
+	fm.transfers.Wait()
+
+	data, err := os.ReadFile(filepath.Join(dir, "summary.csv"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "total,42\n" {
+		t.Errorf("summary.csv = %q", data)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "notes.txt")); err != nil {
+		t.Errorf("notes.txt not downloaded: %v", err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "missing.txt")); err == nil {
+		t.Errorf("missing.txt should not exist")
+	}
+	if matches, _ := filepath.Glob(filepath.Join(dir, "*.part")); len(matches) != 0 {
+		t.Errorf("partial files left behind: %v", matches)
+	}
+}
+
 func keysOf(objs []minio.ObjectInfo) []string {
 	keys := make([]string, len(objs))
 	for i, o := range objs {
@@ -0,0 +1,370 @@
+package windows
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/minio/minio-go/v7"
+
+	"github.com/pteich/us3ui/s3"
+)
+
+// folderRootNode is the tree node of the loaded base prefix. Every other node
+// is a folder prefix ending with the delimiter, so it cannot collide.
+const folderRootNode = "root"
+
+// folder holds what a delimiter listing returned for one prefix.
+type folder struct {
+	children  []string // subfolder prefixes
+	entries   []minio.ObjectInfo
+	loaded    bool
+	truncated bool // listing stopped at the object limit
+}
+
+func (fm *FileManager) createFolderTree() *widget.Tree {
+	tree := widget.NewTree(
+		func(uid widget.TreeNodeID) []widget.TreeNodeID {
+			if uid == "" {
+				return []widget.TreeNodeID{folderRootNode}
+			}
+			if f := fm.folders[fm.folderPrefix(uid)]; f != nil {
+				return f.children
+			}
+			return nil
+		},
+		func(uid widget.TreeNodeID) bool {
+			if uid == "" || uid == folderRootNode {
+				return true
+			}
+			// Unloaded folders stay expandable; their children are listed
+			// when the branch is opened.
+			f := fm.folders[uid]
+			return f == nil || !f.loaded || len(f.children) > 0
+		},
+		func(branch bool) fyne.CanvasObject {
+			label := widget.NewLabel("Folder")
+			label.Truncation = fyne.TextTruncateEllipsis
+			return container.NewBorder(nil, nil, widget.NewIcon(theme.FolderIcon()), nil, label)
+		},
+		func(uid widget.TreeNodeID, branch bool, o fyne.CanvasObject) {
+			label := o.(*fyne.Container).Objects[0].(*widget.Label)
+			label.SetText(fm.folderLabel(uid))
+		},
+	)
+
+	tree.OnBranchOpened = func(uid widget.TreeNodeID) {
+		// Expanding only needs the subfolders; it must not interrupt the
+		// listing of the selected folder.
+		prefix := fm.folderPrefix(uid)
+		if fm.folders[prefix] == nil {
+			fm.loadFolder(prefix, false)
+		}
+	}
+
+	tree.OnSelected = func(uid widget.TreeNodeID) {
+		fm.openFolder(fm.folderPrefix(uid))
+	}
+
+	return tree
+}
+
+// folderPrefix maps a tree node to the prefix it lists.
+func (fm *FileManager) folderPrefix(uid string) string {
+	if uid == folderRootNode {
+		return fm.basePrefix
+	}
+	return uid
+}
+
+// folderNode maps a prefix to its tree node.
+func (fm *FileManager) folderNode(prefix string) string {
+	if prefix == fm.basePrefix {
+		return folderRootNode
+	}
+	return prefix
+}
+
+func (fm *FileManager) folderLabel(uid string) string {
+	if uid == folderRootNode {
+		if fm.basePrefix == "" {
+			return "Bucket root"
+		}
+		return fm.basePrefix
+	}
+	return folderName(uid)
+}
+
+// folderName returns the last path segment of a folder prefix including the
+// trailing delimiter.
+func folderName(prefix string) string {
+	trimmed := strings.TrimSuffix(prefix, s3.Delimiter)
+	if idx := strings.LastIndex(trimmed, s3.Delimiter); idx != -1 {
+		return trimmed[idx+1:] + s3.Delimiter
+	}
+	return prefix
+}
+
+// folderParent returns the prefix of the folder containing key.
+func folderParent(key string) string {
+	trimmed := strings.TrimSuffix(key, s3.Delimiter)
+	if idx := strings.LastIndex(trimmed, s3.Delimiter); idx != -1 {
+		return trimmed[:idx+1]
+	}
+	return ""
+}
+
+// setFolderMode switches between the flat recursive listing and folder
+// browsing and reloads the current prefix.
+func (fm *FileManager) setFolderMode(on bool) {
+	if fm.folderMode == on {
+		return
+	}
+	fm.folderMode = on
+	if on {
+		fm.tree.Hide()
+		fm.folderTree.Show()
+	} else {
+		fm.folderTree.Hide()
+		fm.tree.Show()
+	}
+
+	if fm.context != nil {
+		fm.LoadObjects(fm.context, strings.TrimSpace(fm.prefixInput.Text))
+	}
+}
+
+// loadFolderRoot starts folder browsing at prefix.
+func (fm *FileManager) loadFolderRoot(ctx context.Context, prefix string) {
+	if prefix != "" && !strings.HasSuffix(prefix, s3.Delimiter) {
+		prefix += s3.Delimiter
+	}
+	fm.cancelFolders()
+	fm.foldersCtx, fm.foldersCancel = context.WithCancel(ctx)
+	fm.basePrefix = prefix
+	fm.folders = make(map[string]*folder)
+	fm.selectedFolder = prefix
+
+	fyne.Do(func() {
+		fm.searchInput.SetText("")
+		fm.selectedKeys = nil
+		fm.currentObjects = nil
+		fm.allObjects = nil
+		fm.hasMoreObjects = false
+		fm.resetObjectViews()
+		fm.folderTree.Refresh()
+		// Unselect first so OnSelected fires when the root is reloaded.
+		fm.folderTree.UnselectAll()
+		fm.folderTree.Select(folderRootNode)
+		fm.folderTree.OpenBranch(folderRootNode)
+	})
+}
+
+// cancelFolders stops all folder listings of the current browsing session.
+func (fm *FileManager) cancelFolders() {
+	if fm.foldersCancel != nil {
+		fm.foldersCancel()
+		fm.foldersCancel = nil
+	}
+}
+
+// openFolder shows the entries of prefix in the object list, listing it
+// first if needed.
+func (fm *FileManager) openFolder(prefix string) {
+	fm.selectedFolder = prefix
+	fm.selectedKeys = nil
+	fm.updateActionButtons()
+
+	f := fm.folders[prefix]
+	if f == nil || !f.loaded {
+		fm.allObjects = nil
+		fm.hasMoreObjects = false
+		fm.loadMoreBtn.Hide()
+		fm.updateObjectListLocked(true)
+		if f == nil {
+			fm.loadFolder(prefix, true)
+		}
+		return
+	}
+
+	fm.allObjects = f.entries
+	fm.hasMoreObjects = f.truncated
+	if f.truncated {
+		fm.loadMoreBtn.Show()
+	} else {
+		fm.loadMoreBtn.Hide()
+	}
+	fm.updateObjectListLocked(true)
+}
+
+// navigateToFolder selects prefix in the folder tree, opening its parents.
+func (fm *FileManager) navigateToFolder(prefix string) {
+	for parent := folderParent(prefix); len(parent) > len(fm.basePrefix); parent = folderParent(parent) {
+		fm.folderTree.OpenBranch(fm.folderNode(parent))
+	}
+	fm.folderTree.OpenBranch(folderRootNode)
+	node := fm.folderNode(prefix)
+	fm.folderTree.Select(node)
+	fm.folderTree.ScrollTo(node)
+}
+
+// loadFolder lists prefix from the start, replacing what was loaded before.
+// A tracked listing is the one of the selected folder; it drives the loading
+// indicator and can be stopped by the user.
+func (fm *FileManager) loadFolder(prefix string, tracked bool) {
+	if fm.foldersCtx == nil {
+		return
+	}
+	f := &folder{}
+	fm.folders[prefix] = f
+	fm.startFolderLoad(f, prefix, "", 0, tracked)
+}
+
+// continueFolder loads the next batches of a folder that hit the object limit.
+func (fm *FileManager) continueFolder(prefix string) {
+	f := fm.folders[prefix]
+	if f == nil || len(f.entries) == 0 || fm.loadHandle != nil || fm.foldersCtx == nil {
+		return
+	}
+	f.loaded = false
+	fm.startFolderLoad(f, prefix, f.entries[len(f.entries)-1].Key, len(f.entries), true)
+}
+
+func (fm *FileManager) startFolderLoad(f *folder, prefix, startAfter string, startCount int, tracked bool) {
+	loadCtx, cancel := context.WithCancel(fm.foldersCtx)
+	var handle *loadHandle
+	if tracked {
+		fm.cancelLoad()
+		handle = &loadHandle{cancel: cancel}
+		fm.loadHandle = handle
+
+		fm.stopBtn.Show()
+		fm.loadingBar.Show()
+		fm.loadingBar.Start()
+		fm.loadMoreBtn.Hide()
+		fm.itemsLabel.SetText(fmt.Sprintf("Loading folder %q…", fm.folderLabel(fm.folderNode(prefix))))
+	}
+
+	fm.loads.Add(1)
+	go func() {
+		defer fm.loads.Done()
+		defer cancel()
+		fm.loadFolderAsync(loadCtx, handle, f, prefix, startAfter, startCount, fm.maxObjects)
+	}()
+}
+
+// loadFolderAsync lists the objects and subfolders directly below prefix into
+// f in batches until the listing ends or maxObjects entries were loaded.
+func (fm *FileManager) loadFolderAsync(loadCtx context.Context, handle *loadHandle, f *folder, prefix, startAfter string, startCount, maxObjects int) {
+	var (
+		entries   []minio.ObjectInfo
+		children  []string
+		truncated bool
+		loadErr   error
+	)
+	lastKey := startAfter
+	loaded := startCount
+
+	for loadCtx.Err() == nil {
+		if maxObjects > 0 && loaded >= maxObjects {
+			truncated = true
+			break
+		}
+
+		currentBatchSize := batchSize
+		if maxObjects > 0 {
+			currentBatchSize = min(batchSize, maxObjects-loaded)
+		}
+
+		batch, err := fm.s3svc.ListFolderBatch(loadCtx, lastKey, prefix, currentBatchSize)
+		if err != nil {
+			loadErr = err
+			break
+		}
+		for _, obj := range batch {
+			if obj.Key == prefix {
+				continue // the folder's own marker object
+			}
+			if s3.IsFolder(obj) {
+				children = append(children, obj.Key)
+			}
+			entries = append(entries, obj)
+		}
+		if len(batch) > 0 {
+			lastKey = batch[len(batch)-1].Key
+		}
+		loaded += len(batch)
+
+		if len(batch) < currentBatchSize {
+			break
+		}
+	}
+
+	canceled := loadCtx.Err() != nil
+
+	fyne.Do(func() {
+		// The folder may have been reloaded or browsing restarted meanwhile.
+		current := fm.folders[prefix] == f
+		if current {
+			// A stopped listing keeps what it got so far and can be
+			// continued with Load More.
+			complete := loadErr == nil && !canceled
+			f.entries = append(f.entries, entries...)
+			f.children = append(f.children, children...)
+			f.truncated = truncated || !complete
+			f.loaded = complete || len(f.entries) > 0
+			if !f.loaded {
+				delete(fm.folders, prefix)
+				current = false
+			}
+		}
+
+		if handle != nil && fm.loadHandle == handle {
+			fm.loadingBar.Stop()
+			fm.loadingBar.Hide()
+			fm.stopBtn.Hide()
+			fm.loadHandle = nil
+		}
+
+		fm.folderTree.Refresh()
+		if current && prefix == fm.selectedFolder {
+			fm.allObjects = f.entries
+			fm.hasMoreObjects = f.truncated
+			if f.truncated {
+				fm.loadMoreBtn.Show()
+			}
+			fm.updateObjectListLocked(startCount == 0)
+		}
+
+		if handle == nil {
+			return
+		}
+		switch {
+		case loadErr != nil && !errors.Is(loadErr, context.Canceled):
+			fm.itemsLabel.SetText("Failed to load folder")
+			dialog.ShowError(loadErr, fm.window)
+		case canceled:
+			fm.itemsLabel.SetText(fmt.Sprintf("Load canceled (%d entries loaded)", len(fm.allObjects)))
+		}
+	})
+}
+
+// removeFolderEntry drops key from the cached listing of its folder.
+func (fm *FileManager) removeFolderEntry(key string) {
+	f := fm.folders[folderParent(key)]
+	if f == nil {
+		return
+	}
+	for idx, obj := range f.entries {
+		if obj.Key == key {
+			f.entries = append(f.entries[:idx:idx], f.entries[idx+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package windows
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pteich/us3ui/s3/memstore"
+)
+
+func TestFolderName(t *testing.T) {
+	tests := map[string]string{
+		"logs/":        "logs/",
+		"logs/app/":    "app/",
+		"a/b/c/":       "c/",
+		"no-delimiter": "no-delimiter",
+	}
+	for prefix, want := range tests {
+		if got := folderName(prefix); got != want {
+			t.Errorf("folderName(%q) = %q, want %q", prefix, got, want)
+		}
+	}
+}
+
+func TestFolderParent(t *testing.T) {
+	tests := map[string]string{
+		"logs/":          "",
+		"logs/app/":      "logs/",
+		"logs/app/x.log": "logs/app/",
+		"README.md":      "",
+	}
+	for key, want := range tests {
+		if got := folderParent(key); got != want {
+			t.Errorf("folderParent(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
+
+// browseFolder lists prefix in folder mode on the calling goroutine, starting
+// a new browsing session at base when needed.
+func browseFolder(t *testing.T, fm *FileManager, base, prefix string) {
+	t.Helper()
+
+	if !fm.folderMode {
+		fm.folderMode = true
+		fm.context = context.Background()
+		fm.foldersCtx = context.Background()
+		fm.basePrefix = base
+		fm.folders = make(map[string]*folder)
+	}
+	fm.selectedFolder = prefix
+	f := &folder{}
+	fm.folders[prefix] = f
+	fm.loadFolderAsync(context.Background(), nil, f, prefix, "", 0, fm.maxObjects)
+}
+
+func TestFolderModeListsOneLevel(t *testing.T) {
+	store := memstore.New("bucket")
+	store.Put("README.md", []byte("readme"))
+	store.Put("logs/app/1.log", []byte("one"))
+	store.Put("logs/app/2.log", []byte("two"))
+	store.Put("logs/system.log", []byte("three"))
+	store.Put("data/report.csv", []byte("four"))
+
+	fm := newTestFileManager(t, store)
+	browseFolder(t, fm, "", "")
+
+	assertStringSet(t, keysOf(fm.allObjects), []string{"README.md", "data/", "logs/"})
+	assertStringSet(t, fm.folders[""].children, []string{"data/", "logs/"})
+
+	browseFolder(t, fm, "", "logs/app/")
+
+	assertStringSet(t, keysOf(fm.allObjects), []string{"logs/app/1.log", "logs/app/2.log"})
+	if got := fm.displayName(fm.allObjects[0]); got != "1.log" {
+		t.Errorf("displayName() = %q, want 1.log", got)
+	}
+	if got := fm.uploadPrefix(); got != "logs/app" {
+		t.Errorf("uploadPrefix() = %q, want logs/app", got)
+	}
+
+	fm.deleteObjects(context.Background(), []string{"logs/app/1.log"}, nil, nil)
+	assertStringSet(t, keysOf(fm.allObjects), []string{"logs/app/2.log"})
+}
+
+func TestFolderModeStopsAtLimit(t *testing.T) {
+	store := memstore.New("bucket")
+	for _, key := range []string{"a/1", "b/2", "c", "d", "e"} {
+		store.Put(key, []byte(key))
+	}
+
+	fm := newTestFileManager(t, store)
+	fm.maxObjects = 3
+	browseFolder(t, fm, "", "")
+
+	if !fm.hasMoreObjects || len(fm.allObjects) != 3 {
+		t.Fatalf("loaded %v, hasMoreObjects = %v; want 3 entries and more available", keysOf(fm.allObjects), fm.hasMoreObjects)
+	}
+
+	fm.maxObjects = 0
+	fm.continueFolder(fm.selectedFolder)
+	fm.loads.Wait()
+
+	assertStringSet(t, keysOf(fm.allObjects), []string{"a/", "b/", "c", "d", "e"})
+	if fm.hasMoreObjects {
+		t.Errorf("hasMoreObjects = true after the listing completed")
+	}
+}
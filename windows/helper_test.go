@@ -1,6 +1,25 @@
 package windows
 
-import "testing"
+import (
+	"testing"
+
+	"fyne.io/fyne/v2"
+	fynetest "fyne.io/fyne/v2/test"
+)
+
+// newTestApp starts a test app that quits when the test ends.
+func newTestApp(t *testing.T) fyne.App {
+	t.Helper()
+	a := fynetest.NewApp()
+	t.Cleanup(a.Quit)
+	return a
+}
+
+// countCalls returns a callback that counts how often it was called.
+func countCalls() (func(), *int) {
+	calls := 0
+	return func() { calls++ }, &calls
+}
 
 func TestByteCountSI(t *testing.T) {
 	tests := []struct {
@@ -0,0 +1,281 @@
+package windows
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+
+	"github.com/pteich/us3ui/config"
+	"github.com/pteich/us3ui/s3"
+	"github.com/pteich/us3ui/transfer"
+)
+
+const (
+	jobCheckInterval = 30 * time.Second
+	jobHistoryLimit  = 50 // runs kept per job
+)
+
+// JobScheduler runs the sync jobs saved in the config while the app is open.
+// Jobs are started and recorded on the UI goroutine; the syncs themselves
+// run in the background.
+type JobScheduler struct {
+	cfg       *config.Config
+	openStore func(config.S3Config) (s3.ObjectStore, error)
+	save      func() error
+	state     *transfer.StateStore
+	now       func() time.Time
+	ctx       context.Context // jobs stop when it is done
+	stop      context.CancelFunc
+
+	running   map[string]context.CancelFunc // by job name
+	listeners map[int]func()
+	nextID    int
+	wg        sync.WaitGroup
+}
+
+func NewJobScheduler(cfg *config.Config, openStore func(config.S3Config) (s3.ObjectStore, error)) *JobScheduler {
+	ctx, stop := context.WithCancel(context.Background())
+	return &JobScheduler{
+		cfg:       cfg,
+		openStore: openStore,
+		save:      cfg.Save,
+		state:     transfer.NewStateStore(transfer.DefaultStateDir()),
+		now:       time.Now,
+		ctx:       ctx,
+		stop:      stop,
+		running:   make(map[string]context.CancelFunc),
+		listeners: make(map[int]func()),
+	}
+}
+
+// Start checks for due jobs until ctx is done or Stop is called. Running
+// jobs are canceled with ctx.
+func (s *JobScheduler) Start(ctx context.Context) {
+	ctx, s.stop = context.WithCancel(ctx)
+	s.ctx = ctx
+	go func() {
+		ticker := time.NewTicker(jobCheckInterval)
+		defer ticker.Stop()
+		fyne.Do(s.runDue)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				fyne.Do(s.runDue)
+			}
+		}
+	}()
+}
+
+// Stop cancels the running jobs and waits until their runs are recorded.
+func (s *JobScheduler) Stop() {
+	s.stop()
+	s.wg.Wait()
+}
+
+// Subscribe registers fn to be called on the UI goroutine whenever a job
+// starts or finishes. The returned function removes the subscription.
+func (s *JobScheduler) Subscribe(fn func()) func() {
+	id := s.nextID
+	s.nextID++
+	s.listeners[id] = fn
+	return func() { delete(s.listeners, id) }
+}
+
+func (s *JobScheduler) notify() {
+	for _, fn := range s.listeners {
+		fn()
+	}
+}
+
+// Running reports whether the job named name is running.
+func (s *JobScheduler) Running(name string) bool {
+	_, ok := s.running[name]
+	return ok
+}
+
+// Cancel stops the job named name if it is running.
+func (s *JobScheduler) Cancel(name string) {
+	if cancel, ok := s.running[name]; ok {
+		cancel()
+	}
+}
+
+// runDue starts the enabled jobs whose interval has passed since their last
+// run.
+func (s *JobScheduler) runDue() {
+	now := s.now()
+	for _, job := range s.cfg.Settings.Jobs {
+		if jobDue(job, now) && !s.Running(job.Name) {
+			_ = s.Run(job.Name)
+		}
+	}
+}
+
+// jobDue reports whether job should run at now.
+func jobDue(job config.SyncJob, now time.Time) bool {
+	if !job.Enabled || job.Interval <= 0 {
+		return false
+	}
+	last, ok := job.LastRun()
+	return !ok || !now.Before(last.Started.Add(time.Duration(job.Interval)*time.Minute))
+}
+
+// Run starts the job named name now.
+func (s *JobScheduler) Run(name string) error {
+	if s.Running(name) {
+		return fmt.Errorf("job %q is already running", name)
+	}
+	job, ok := s.job(name)
+	if !ok {
+		return fmt.Errorf("job %q not found", name)
+	}
+	conn, err := s.connection(job.Connection)
+	if err != nil {
+		return err
+	}
+	conn.Bucket = job.Bucket
+
+	ctx, cancel := context.WithCancel(s.ctx)
+	s.running[name] = cancel
+	s.notify()
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer cancel()
+		run := s.execute(ctx, job, conn)
+		fyne.Do(func() {
+			delete(s.running, name)
+			s.record(name, run)
+			s.notify()
+		})
+	}()
+	return nil
+}
+
+func (s *JobScheduler) job(name string) (config.SyncJob, bool) {
+	for _, job := range s.cfg.Settings.Jobs {
+		if job.Name == name {
+			return job, true
+		}
+	}
+	return config.SyncJob{}, false
+}
+
+func (s *JobScheduler) connection(name string) (config.S3Config, error) {
+	for _, conn := range s.cfg.Settings.Connections {
+		if conn.Name == name {
+			return conn, nil
+		}
+	}
+	return config.S3Config{}, fmt.Errorf("connection %q not found", name)
+}
+
+// execute runs one sync of job against the bucket of conn.
+func (s *JobScheduler) execute(ctx context.Context, job config.SyncJob, conn config.S3Config) config.JobRun {
+	run := config.JobRun{Started: s.now()}
+
+	fail := func(err error) config.JobRun {
+		run.Canceled = errors.Is(err, context.Canceled)
+		if !run.Canceled {
+			run.Errors = append(run.Errors, err.Error())
+		}
+		run.Finished = s.now()
+		return run
+	}
+
+	dir, err := expandHome(job.LocalDir)
+	if err != nil {
+		return fail(err)
+	}
+	if info, err := os.Stat(dir); err != nil {
+		return fail(err)
+	} else if !info.IsDir() {
+		return fail(fmt.Errorf("%s is not a directory", dir))
+	}
+	store, err := s.openStore(conn)
+	if err != nil {
+		return fail(err)
+	}
+
+	opts, err := jobSyncOptions(job.Mode)
+	if err != nil {
+		return fail(err)
+	}
+	syncer := transfer.NewSyncer(
+		transfer.SyncEndpoint{Path: dir},
+		transfer.SyncEndpoint{Store: store, Path: normalizeMovePrefix(job.Prefix)},
+		opts, s.state)
+	items, err := syncer.Plan(ctx)
+	if err != nil {
+		return fail(err)
+	}
+
+	result := syncer.Apply(ctx, items, nil)
+	run.Files = result.Copied
+	run.Deleted = result.Deleted
+	run.Bytes = result.Bytes
+	run.Errors = result.Errors
+	run.Canceled = ctx.Err() != nil
+	run.Finished = s.now()
+	return run
+}
+
+// jobSyncOptions maps a job mode to a sync from the local directory, the
+// source, to the prefix, the target.
+func jobSyncOptions(mode string) (transfer.SyncOptions, error) {
+	opts := transfer.SyncOptions{Filter: transfer.Filter{Exclude: transfer.DefaultExclude}}
+	switch mode {
+	case config.JobUpload:
+		opts.Direction = transfer.SyncToTarget
+	case config.JobDownload:
+		opts.Direction = transfer.SyncToSource
+	case config.JobMirror:
+		opts.Direction = transfer.SyncToTarget
+		opts.Delete = true
+	default:
+		return opts, fmt.Errorf("unknown job mode %q", mode)
+	}
+	return opts, nil
+}
+
+// record adds run to the history of the job named name and saves the
+// config.
+func (s *JobScheduler) record(name string, run config.JobRun) {
+	jobs := s.cfg.Settings.Jobs
+	for i := range jobs {
+		if jobs[i].Name != name {
+			continue
+		}
+		history := append([]config.JobRun{run}, jobs[i].History...)
+		if len(history) > jobHistoryLimit {
+			history = history[:jobHistoryLimit]
+		}
+		jobs[i].History = history
+		if err := s.save(); err != nil {
+			fmt.Println("Error saving job history: ", err)
+		}
+		return
+	}
+}
+
+// expandHome replaces a leading ~ with the home directory.
+func expandHome(dir string) (string, error) {
+	if dir != "~" && !strings.HasPrefix(dir, "~/") && !strings.HasPrefix(dir, `~\`) {
+		return dir, nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, dir[1:]), nil
+}
@@ -0,0 +1,239 @@
+package windows
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pteich/us3ui/config"
+	"github.com/pteich/us3ui/s3"
+	"github.com/pteich/us3ui/s3/memstore"
+	"github.com/pteich/us3ui/transfer"
+)
+
+func newTestJobScheduler(t *testing.T, store *memstore.Store, jobs ...config.SyncJob) (*JobScheduler, *int) {
+	t.Helper()
+
+	newTestApp(t)
+	cfg := &config.Config{Settings: config.Settings{
+		Connections: []config.S3Config{{Name: "prod", Bucket: "default"}},
+		Jobs:        jobs,
+	}}
+	s := NewJobScheduler(cfg, func(config.S3Config) (s3.ObjectStore, error) {
+		return store, nil
+	})
+	saved, saves := countCalls()
+	s.save = func() error {
+		saved()
+		return nil
+	}
+	s.state = transfer.NewStateStore(t.TempDir())
+	return s, saves
+}
+
+func TestJobDue(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	ran := func(ago time.Duration) []config.JobRun {
+		return []config.JobRun{{Started: now.Add(-ago)}}
+	}
+	tests := []struct {
+		name string
+		job  config.SyncJob
+		want bool
+	}{
+		{"never ran", config.SyncJob{Enabled: true, Interval: 30}, true},
+		{"interval passed", config.SyncJob{Enabled: true, Interval: 30, History: ran(31 * time.Minute)}, true},
+		{"interval exactly passed", config.SyncJob{Enabled: true, Interval: 30, History: ran(30 * time.Minute)}, true},
+		{"ran recently", config.SyncJob{Enabled: true, Interval: 30, History: ran(10 * time.Minute)}, false},
+		{"disabled", config.SyncJob{Interval: 30}, false},
+		{"no interval", config.SyncJob{Enabled: true}, false},
+	}
+	for _, tt := range tests {
+		if got := jobDue(tt.job, now); got != tt.want {
+			t.Errorf("%s: jobDue = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestJobSchedulerRunsDueMirrorJob(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a.csv"), "alpha")
+	writeFile(t, filepath.Join(dir, "sub", "b.csv"), "beta")
+	store := memstore.New("exports-bucket")
+	store.Put("exports/stale.csv", []byte("stale"))
+
+	s, saves := newTestJobScheduler(t, store,
+		config.SyncJob{Name: "exports", Connection: "prod", Bucket: "exports-bucket", Prefix: "exports",
+			LocalDir: dir, Mode: config.JobMirror, Interval: 30, Enabled: true},
+		config.SyncJob{Name: "paused", Connection: "prod", Bucket: "exports-bucket",
+			LocalDir: dir, Mode: config.JobUpload, Interval: 30},
+	)
+	var opened config.S3Config
+	openStore := s.openStore
+	s.openStore = func(c config.S3Config) (s3.ObjectStore, error) {
+		opened = c
+		return openStore(c)
+	}
+	notified := 0
+	unsubscribe := s.Subscribe(func() { notified++ })
+	defer unsubscribe()
+
+	s.runDue()
+	s.wg.Wait()
+
+	if data, ok := store.Get("exports/sub/b.csv"); !ok || string(data) != "beta" {
+		t.Errorf("exports/sub/b.csv = %q, %v", data, ok)
+	}
+	if _, ok := store.Get("exports/stale.csv"); ok {
+		t.Errorf("mirror job kept exports/stale.csv")
+	}
+	if _, ok := store.Get("a.csv"); ok {
+		t.Errorf("job ignored its prefix")
+	}
+
+	jobs := s.cfg.Settings.Jobs
+	run, ok := jobs[0].LastRun()
+	if !ok || run.Files != 2 || run.Deleted != 1 || run.Bytes != 9 || len(run.Errors) != 0 {
+		t.Errorf("last run = %+v, %v", run, ok)
+	}
+	if len(jobs[1].History) != 0 {
+		t.Errorf("disabled job ran: %+v", jobs[1].History)
+	}
+	if s.Running("exports") {
+		t.Errorf("job still marked as running")
+	}
+	if *saves != 1 || notified != 2 {
+		t.Errorf("saves = %d, notifications = %d", *saves, notified)
+	}
+	if opened.Bucket != "exports-bucket" {
+		t.Errorf("job opened bucket %q instead of its own", opened.Bucket)
+	}
+
+	// The job is not due again until its interval has passed.
+	s.runDue()
+	s.wg.Wait()
+	if len(s.cfg.Settings.Jobs[0].History) != 1 {
+		t.Errorf("job ran again before its interval")
+	}
+}
+
+func TestJobSchedulerRecordsFailures(t *testing.T) {
+	store := memstore.New("bucket")
+	s, _ := newTestJobScheduler(t, store,
+		config.SyncJob{Name: "missing", Connection: "prod", Bucket: "bucket",
+			LocalDir: filepath.Join(t.TempDir(), "missing"), Mode: config.JobUpload},
+	)
+
+	if err := s.Run("missing"); err != nil {
+		t.Fatal(err)
+	}
+	s.wg.Wait()
+	run, ok := s.cfg.Settings.Jobs[0].LastRun()
+	if !ok || len(run.Errors) != 1 {
+		t.Errorf("last run = %+v, want the missing directory reported", run)
+	}
+
+	if err := s.Run("unknown"); err == nil {
+		t.Errorf("Run started a job that does not exist")
+	}
+	s.cfg.Settings.Jobs[0].Connection = "gone"
+	if err := s.Run("missing"); err == nil {
+		t.Errorf("Run started a job without its connection")
+	}
+}
+
+func TestJobHistoryIsCapped(t *testing.T) {
+	s, _ := newTestJobScheduler(t, memstore.New("bucket"), config.SyncJob{Name: "job"})
+	for i := 0; i < jobHistoryLimit+5; i++ {
+		s.record("job", config.JobRun{Files: i})
+	}
+	history := s.cfg.Settings.Jobs[0].History
+	if len(history) != jobHistoryLimit || history[0].Files != jobHistoryLimit+4 {
+		t.Errorf("history has %d runs, newest %+v", len(history), history[0])
+	}
+}
+
+func TestJobSchedulerStopWaitsForRunningJobs(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a.csv"), "alpha")
+	s, saves := newTestJobScheduler(t, memstore.New("bucket"),
+		config.SyncJob{Name: "exports", Connection: "prod", Bucket: "bucket", LocalDir: dir, Mode: config.JobUpload},
+	)
+
+	if err := s.Run("exports"); err != nil {
+		t.Fatal(err)
+	}
+	s.Stop()
+	if s.Running("exports") || len(s.cfg.Settings.Jobs[0].History) != 1 || *saves != 1 {
+		t.Errorf("after Stop: running %v, history %+v", s.Running("exports"), s.cfg.Settings.Jobs[0].History)
+	}
+
+	// Jobs started after Stop are canceled right away.
+	if err := s.Run("exports"); err != nil {
+		t.Fatal(err)
+	}
+	s.wg.Wait()
+	if run, _ := s.cfg.Settings.Jobs[0].LastRun(); !run.Canceled {
+		t.Errorf("a job started after Stop ran: %+v", run)
+	}
+}
+
+func TestApplyJobKeepsHistory(t *testing.T) {
+	job := config.SyncJob{Name: "nightly", Connection: "prod", Bucket: "bucket", LocalDir: "/data", Mode: config.JobUpload, Interval: 30}
+	cfg := &config.Config{Settings: config.Settings{
+		Jobs: []config.SyncJob{{Name: "other", Connection: "prod", Bucket: "bucket", LocalDir: "/other", Interval: 5}, job},
+	}}
+	jw := &JobsWindow{cfg: cfg, scheduler: NewJobScheduler(cfg, nil)}
+
+	// While the form was open, the job ran and the job before it was deleted.
+	jobs := jw.cfg.Settings.Jobs
+	jobs[1].History = []config.JobRun{{Files: 3}}
+	jw.cfg.Settings.Jobs = jobs[1:]
+
+	form := job
+	form.Name = "hourly"
+	form.Interval = 60
+
+	// A job that started while the form was open is not changed.
+	jw.scheduler.running["nightly"] = func() {}
+	if err := jw.applyJob("nightly", form); err == nil || jw.cfg.Settings.Jobs[0].Name != "nightly" {
+		t.Errorf("saved a running job: %v", err)
+	}
+	delete(jw.scheduler.running, "nightly")
+
+	if err := jw.applyJob("nightly", form); err != nil {
+		t.Fatal(err)
+	}
+	got := jw.cfg.Settings.Jobs
+	if len(got) != 1 || got[0].Name != "hourly" || got[0].Interval != 60 || len(got[0].History) != 1 {
+		t.Errorf("jobs after saving = %+v", got)
+	}
+
+	if err := jw.applyJob("nightly", form); err == nil {
+		t.Errorf("saved a job that no longer exists")
+	}
+	form.Name = "daily"
+	if err := jw.applyJob("", form); err != nil || len(jw.cfg.Settings.Jobs) != 2 || jw.cfg.Settings.Jobs[1].History != nil {
+		t.Errorf("adding a job: %v, %+v", err, jw.cfg.Settings.Jobs)
+	}
+	if err := jw.applyJob("", form); err == nil {
+		t.Errorf("added a second job named %q", form.Name)
+	}
+}
+
+func TestExpandHome(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	for in, want := range map[string]string{
+		"~":                      home,
+		"~/exports":              filepath.Join(home, "exports"),
+		"/data/exports":          "/data/exports",
+		"~other/exports":         "~other/exports",
+		string(os.PathSeparator): string(os.PathSeparator),
+	} {
+		if got, err := expandHome(in); err != nil || got != want {
+			t.Errorf("expandHome(%q) = %q, %v, want %q", in, got, err, want)
+		}
+	}
+}
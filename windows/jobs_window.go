@@ -0,0 +1,456 @@
+package windows
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/pteich/us3ui/config"
+)
+
+var jobModeNames = map[string]string{
+	config.JobUpload:   "Upload new and changed files",
+	config.JobDownload: "Download new and changed objects",
+	config.JobMirror:   "Mirror the directory, deleting extraneous objects",
+}
+
+// JobsWindow lists the saved sync jobs with their history and lets the user
+// add, edit, delete, start and cancel them.
+type JobsWindow struct {
+	app         fyne.App
+	cfg         *config.Config
+	scheduler   *JobScheduler
+	window      fyne.Window
+	unsubscribe func()
+
+	selected     int // index into cfg.Settings.Jobs, -1 if none
+	jobsTable    *widget.Table
+	historyTable *widget.Table
+	editBtn      *widget.Button
+	deleteBtn    *widget.Button
+	runBtn       *widget.Button
+	cancelBtn    *widget.Button
+}
+
+func NewJobsWindow(a fyne.App, cfg *config.Config, scheduler *JobScheduler) *JobsWindow {
+	return &JobsWindow{
+		app:       a,
+		cfg:       cfg,
+		scheduler: scheduler,
+		selected:  -1,
+	}
+}
+
+// Show opens the jobs window, or brings it to the front if it is open
+// already.
+func (jw *JobsWindow) Show() {
+	if jw.window != nil {
+		jw.window.RequestFocus()
+		return
+	}
+
+	jw.window = jw.app.NewWindow("Sync Jobs")
+	jw.window.Resize(fyne.NewSize(950, 550))
+	jw.window.SetContent(jw.createContent())
+	jw.unsubscribe = jw.scheduler.Subscribe(jw.refresh)
+	jw.window.SetOnClosed(func() {
+		jw.unsubscribe()
+		jw.window = nil
+	})
+	jw.window.Show()
+}
+
+func (jw *JobsWindow) createContent() fyne.CanvasObject {
+	jw.jobsTable = widget.NewTableWithHeaders(
+		func() (int, int) { return len(jw.cfg.Settings.Jobs), 6 },
+		newTruncatedLabel,
+		func(id widget.TableCellID, o fyne.CanvasObject) {
+			label := o.(*widget.Label)
+			if id.Row >= len(jw.cfg.Settings.Jobs) {
+				label.SetText("")
+				return
+			}
+			job := jw.cfg.Settings.Jobs[id.Row]
+			label.SetText(jobCellText(job, jw.scheduler.Running(job.Name), id.Col))
+		},
+	)
+	jw.jobsTable.ShowHeaderColumn = false
+	jw.jobsTable.UpdateHeader = func(id widget.TableCellID, o fyne.CanvasObject) {
+		o.(*widget.Label).SetText([]string{"Name", "Mode", "Local Directory", "Bucket", "Every", "Last Run"}[id.Col])
+	}
+	for col, width := range []float32{140, 90, 200, 200, 80, 220} {
+		jw.jobsTable.SetColumnWidth(col, width)
+	}
+	jw.jobsTable.OnSelected = func(id widget.TableCellID) {
+		jw.selected = id.Row
+		jw.refresh()
+	}
+
+	jw.historyTable = widget.NewTableWithHeaders(
+		func() (int, int) { return len(jw.history()), 5 },
+		newTruncatedLabel,
+		func(id widget.TableCellID, o fyne.CanvasObject) {
+			label := o.(*widget.Label)
+			history := jw.history()
+			if id.Row >= len(history) {
+				label.SetText("")
+				return
+			}
+			label.SetText(jobRunCellText(history[id.Row], id.Col))
+		},
+	)
+	jw.historyTable.ShowHeaderColumn = false
+	jw.historyTable.UpdateHeader = func(id widget.TableCellID, o fyne.CanvasObject) {
+		o.(*widget.Label).SetText([]string{"Started", "Duration", "Files", "Bytes", "Errors"}[id.Col])
+	}
+	for col, width := range []float32{dateColumnWidth, 90, 120, sizeColumnWidth + 30, 400} {
+		jw.historyTable.SetColumnWidth(col, width)
+	}
+
+	addBtn := widget.NewButtonWithIcon("Add", theme.ContentAddIcon(), func() { jw.showEditor(-1) })
+	jw.editBtn = widget.NewButtonWithIcon("Edit", theme.DocumentCreateIcon(), func() { jw.showEditor(jw.selected) })
+	jw.deleteBtn = widget.NewButtonWithIcon("Delete", theme.DeleteIcon(), jw.handleDelete)
+	jw.runBtn = widget.NewButtonWithIcon("Run Now", theme.MediaPlayIcon(), jw.handleRun)
+	jw.cancelBtn = widget.NewButtonWithIcon("Cancel", theme.CancelIcon(), func() {
+		if job, ok := jw.selectedJob(); ok {
+			jw.scheduler.Cancel(job.Name)
+		}
+	})
+	toolbar := container.NewHBox(addBtn, jw.editBtn, jw.deleteBtn, layout.NewSpacer(), jw.runBtn, jw.cancelBtn)
+
+	note := widget.NewLabel("Jobs run while the app is open. A job that is due when the app starts runs right away.")
+	historyBox := container.NewBorder(widget.NewLabelWithStyle("History", fyne.TextAlignLeading, fyne.TextStyle{Bold: true}), nil, nil, nil, jw.historyTable)
+	split := container.NewVSplit(jw.jobsTable, historyBox)
+	split.Offset = 0.45
+
+	jw.updateButtons()
+	return container.NewBorder(toolbar, note, nil, nil, split)
+}
+
+func newTruncatedLabel() fyne.CanvasObject {
+	label := widget.NewLabel("")
+	label.Truncation = fyne.TextTruncateEllipsis
+	return label
+}
+
+// jobCellText returns the text of column col for a job.
+func jobCellText(job config.SyncJob, running bool, col int) string {
+	switch col {
+	case 0:
+		return job.Name
+	case 1:
+		return job.Mode
+	case 2:
+		return job.LocalDir
+	case 3:
+		return job.Connection + ": " + job.Bucket + "/" + job.Prefix
+	case 4:
+		if !job.Enabled {
+			return "Disabled"
+		}
+		return fmt.Sprintf("%d min", job.Interval)
+	case 5:
+		if running {
+			return "Running…"
+		}
+		run, ok := job.LastRun()
+		if !ok {
+			return "Never"
+		}
+		return run.Started.Format("2006-01-02 15:04") + ", " + jobRunSummary(run)
+	}
+	return ""
+}
+
+// jobRunCellText returns the text of column col for a run of a job.
+func jobRunCellText(run config.JobRun, col int) string {
+	switch col {
+	case 0:
+		return run.Started.Format("2006-01-02 15:04:05")
+	case 1:
+		return run.Finished.Sub(run.Started).Round(1e9).String()
+	case 2:
+		text := fmt.Sprintf("%d copied", run.Files)
+		if run.Deleted > 0 {
+			text += fmt.Sprintf(", %d deleted", run.Deleted)
+		}
+		return text
+	case 3:
+		return ByteCountSI(run.Bytes)
+	case 4:
+		text := strings.Join(run.Errors, "; ")
+		if run.Canceled {
+			text = strings.TrimSuffix("Canceled; "+text, "; ")
+		}
+		return text
+	}
+	return ""
+}
+
+// jobRunSummary describes the outcome of a run in a few words.
+func jobRunSummary(run config.JobRun) string {
+	summary := fmt.Sprintf("%d files, %s", run.Files+run.Deleted, ByteCountSI(run.Bytes))
+	switch {
+	case run.Canceled:
+		summary += ", canceled"
+	case len(run.Errors) == 1:
+		summary += ", 1 error"
+	case len(run.Errors) > 1:
+		summary += fmt.Sprintf(", %d errors", len(run.Errors))
+	}
+	return summary
+}
+
+func (jw *JobsWindow) selectedJob() (config.SyncJob, bool) {
+	if jw.selected < 0 || jw.selected >= len(jw.cfg.Settings.Jobs) {
+		return config.SyncJob{}, false
+	}
+	return jw.cfg.Settings.Jobs[jw.selected], true
+}
+
+func (jw *JobsWindow) history() []config.JobRun {
+	job, _ := jw.selectedJob()
+	return job.History
+}
+
+func (jw *JobsWindow) refresh() {
+	if jw.window == nil {
+		return
+	}
+	jw.jobsTable.Refresh()
+	jw.historyTable.Refresh()
+	jw.updateButtons()
+}
+
+func (jw *JobsWindow) updateButtons() {
+	job, ok := jw.selectedJob()
+	running := ok && jw.scheduler.Running(job.Name)
+	setEnabled := func(enabled bool, buttons ...*widget.Button) {
+		for _, btn := range buttons {
+			if enabled {
+				btn.Enable()
+			} else {
+				btn.Disable()
+			}
+		}
+	}
+	setEnabled(ok && !running, jw.editBtn, jw.deleteBtn, jw.runBtn)
+	setEnabled(running, jw.cancelBtn)
+}
+
+func (jw *JobsWindow) handleRun() {
+	job, ok := jw.selectedJob()
+	if !ok {
+		return
+	}
+	if err := jw.scheduler.Run(job.Name); err != nil {
+		dialog.ShowError(err, jw.window)
+	}
+}
+
+func (jw *JobsWindow) handleDelete() {
+	job, ok := jw.selectedJob()
+	if !ok {
+		return
+	}
+	dialog.ShowConfirm("Delete Job", fmt.Sprintf("Do you really want to delete the job %q and its history?", job.Name), func(yes bool) {
+		if !yes || jw.scheduler.Running(job.Name) {
+			return
+		}
+		jobs := jw.cfg.Settings.Jobs
+		for i := range jobs {
+			if jobs[i].Name == job.Name {
+				jw.cfg.Settings.Jobs = append(jobs[:i:i], jobs[i+1:]...)
+				break
+			}
+		}
+		jw.selected = -1
+		jw.jobsTable.UnselectAll()
+		jw.saveJobs()
+	}, jw.window)
+}
+
+// showEditor opens the form for the job at index, or for a new job if index
+// is negative.
+func (jw *JobsWindow) showEditor(index int) {
+	job := config.SyncJob{Mode: config.JobUpload, Interval: 30, Enabled: true}
+	if index >= 0 && index < len(jw.cfg.Settings.Jobs) {
+		job = jw.cfg.Settings.Jobs[index]
+	} else {
+		index = -1
+	}
+
+	name := widget.NewEntry()
+	name.SetText(job.Name)
+	var connNames []string
+	for _, conn := range jw.cfg.Settings.Connections {
+		if conn.Name != "" && conn.Name != config.Transient {
+			connNames = append(connNames, conn.Name)
+		}
+	}
+	connSelect := widget.NewSelect(connNames, nil)
+	connSelect.SetSelected(job.Connection)
+	bucket := widget.NewEntry()
+	bucket.SetText(job.Bucket)
+	prefix := widget.NewEntry()
+	prefix.SetPlaceHolder("e.g. exports/ (empty for the bucket root)")
+	prefix.SetText(job.Prefix)
+	connSelect.OnChanged = func(selected string) {
+		for _, conn := range jw.cfg.Settings.Connections {
+			if conn.Name == selected && bucket.Text == "" {
+				bucket.SetText(conn.Bucket)
+			}
+		}
+	}
+
+	localDir := widget.NewEntry()
+	localDir.SetPlaceHolder("e.g. ~/exports")
+	localDir.SetText(job.LocalDir)
+	browseBtn := widget.NewButtonWithIcon("", theme.FolderOpenIcon(), func() {
+		dialog.NewFolderOpen(func(dir fyne.ListableURI, err error) {
+			if err == nil && dir != nil {
+				localDir.SetText(dir.Path())
+			}
+		}, jw.window).Show()
+	})
+
+	modeOptions := make([]string, len(config.JobModes))
+	for i, mode := range config.JobModes {
+		modeOptions[i] = jobModeNames[mode]
+	}
+	modeSelect := widget.NewSelect(modeOptions, nil)
+	modeSelect.SetSelected(jobModeNames[job.Mode])
+	interval := widget.NewEntry()
+	interval.SetText(strconv.Itoa(job.Interval))
+	interval.Validator = func(s string) error {
+		if n, err := strconv.Atoi(strings.TrimSpace(s)); err != nil || n < 1 {
+			return errors.New("enter the number of minutes")
+		}
+		return nil
+	}
+	enabled := widget.NewCheck("Run on schedule", nil)
+	enabled.SetChecked(job.Enabled)
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Name", name),
+		widget.NewFormItem("Connection", connSelect),
+		widget.NewFormItem("Bucket", bucket),
+		widget.NewFormItem("Prefix", prefix),
+		widget.NewFormItem("Local directory", container.NewBorder(nil, nil, nil, browseBtn, localDir)),
+		widget.NewFormItem("Mode", modeSelect),
+		widget.NewFormItem("Every (minutes)", interval),
+		widget.NewFormItem("", enabled),
+	}
+	title := "Add Job"
+	if index >= 0 {
+		title = "Edit Job"
+	}
+	d := dialog.NewForm(title, "Save", "Cancel", items, func(ok bool) {
+		if !ok {
+			return
+		}
+		form := config.SyncJob{
+			Name:       strings.TrimSpace(name.Text),
+			Connection: connSelect.Selected,
+			Bucket:     strings.TrimSpace(bucket.Text),
+			Prefix:     normalizeMovePrefix(prefix.Text),
+			LocalDir:   strings.TrimSpace(localDir.Text),
+			Enabled:    enabled.Checked,
+		}
+		for _, mode := range config.JobModes {
+			if jobModeNames[mode] == modeSelect.Selected {
+				form.Mode = mode
+			}
+		}
+		form.Interval, _ = strconv.Atoi(strings.TrimSpace(interval.Text))
+
+		original := ""
+		if index >= 0 {
+			original = job.Name
+		}
+		if err := jw.applyJob(original, form); err != nil {
+			dialog.ShowError(err, jw.window)
+			return
+		}
+		jw.saveJobs()
+	}, jw.window)
+	d.Resize(fyne.NewSize(550, d.MinSize().Height))
+	d.Show()
+}
+
+// applyJob stores the settings entered in the form as the job named
+// original, or as a new job if original is empty. The job is looked up by
+// name as jobs may have run or been deleted while the form was open, and
+// keeps its history. A running job cannot be changed, as its run is
+// recorded and tracked by name.
+func (jw *JobsWindow) applyJob(original string, form config.SyncJob) error {
+	jobs := jw.cfg.Settings.Jobs
+	if original != "" && jw.scheduler.Running(original) {
+		return fmt.Errorf("the job %q started in the meantime, edit it again once it has finished", original)
+	}
+	if original == "" {
+		if err := jw.validateJob(form, -1); err != nil {
+			return err
+		}
+		jw.cfg.Settings.Jobs = append(jobs, form)
+		return nil
+	}
+
+	index := slices.IndexFunc(jobs, func(job config.SyncJob) bool { return job.Name == original })
+	if index < 0 {
+		return fmt.Errorf("the job %q was deleted", original)
+	}
+	job := jobs[index]
+	job.Name = form.Name
+	job.Connection = form.Connection
+	job.Bucket = form.Bucket
+	job.Prefix = form.Prefix
+	job.LocalDir = form.LocalDir
+	job.Mode = form.Mode
+	job.Interval = form.Interval
+	job.Enabled = form.Enabled
+	if err := jw.validateJob(job, index); err != nil {
+		return err
+	}
+	jobs[index] = job
+	return nil
+}
+
+// validateJob checks a job entered in the form; index is the job it
+// replaces or -1.
+func (jw *JobsWindow) validateJob(job config.SyncJob, index int) error {
+	switch {
+	case job.Name == "":
+		return errors.New("the job needs a name")
+	case job.Connection == "":
+		return errors.New("no connection selected")
+	case job.Bucket == "":
+		return errors.New("no bucket given")
+	case job.LocalDir == "":
+		return errors.New("no local directory given")
+	case job.Interval < 1:
+		return errors.New("the interval must be at least one minute")
+	}
+	for i, other := range jw.cfg.Settings.Jobs {
+		if i != index && other.Name == job.Name {
+			return fmt.Errorf("a job named %q exists already", job.Name)
+		}
+	}
+	return nil
+}
+
+func (jw *JobsWindow) saveJobs() {
+	if err := jw.cfg.Save(); err != nil {
+		dialog.ShowError(err, jw.window)
+	}
+	jw.refresh()
+}
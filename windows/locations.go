@@ -0,0 +1,137 @@
+package windows
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/pteich/us3ui/config"
+	"github.com/pteich/us3ui/s3"
+)
+
+const localFilesLabel = "Local files"
+
+// locations resolves places the user picks, a local directory or a prefix in
+// a bucket of the open or a saved connection, and keeps the stores opened for
+// them.
+type locations struct {
+	cfg       *config.Config
+	conn      config.S3Config // the connection the file manager has open
+	store     s3.ObjectStore
+	openStore func(config.S3Config) (s3.ObjectStore, error)
+
+	mu     sync.Mutex
+	stores map[string]s3.ObjectStore // opened stores by connection and bucket
+}
+
+func newLocations(cfg *config.Config, conn config.S3Config, store s3.ObjectStore, openStore func(config.S3Config) (s3.ObjectStore, error)) *locations {
+	return &locations{
+		cfg:       cfg,
+		conn:      conn,
+		store:     store,
+		openStore: openStore,
+		stores:    make(map[string]s3.ObjectStore),
+	}
+}
+
+// sources returns the choices for where a location is.
+func (l *locations) sources() []string {
+	options := []string{localFilesLabel, currentConnectionLabel}
+	for _, conn := range otherConnections(l.cfg, l.conn.Name) {
+		options = append(options, conn.Name)
+	}
+	return options
+}
+
+// resolve turns a source, bucket and directory picked by the user into a
+// location. An empty bucket is the one configured for the connection, an
+// empty directory the home directory.
+func (l *locations) resolve(source, bucket, dir string) (paneLocation, error) {
+	if source == localFilesLabel {
+		if dir == "" {
+			home, err := os.UserHomeDir()
+			if err != nil {
+				return paneLocation{}, err
+			}
+			dir = home
+		}
+		abs, err := filepath.Abs(dir)
+		if err != nil {
+			return paneLocation{}, err
+		}
+		return paneLocation{connection: localFilesLabel, dir: abs}, nil
+	}
+
+	conn, err := l.connection(source)
+	if err != nil {
+		return paneLocation{}, err
+	}
+	if bucket == "" {
+		bucket = conn.Bucket
+	}
+	if bucket == "" {
+		return paneLocation{}, errors.New("no bucket given")
+	}
+	conn.Bucket = bucket
+	store, err := l.bucketStore(source, conn)
+	if err != nil {
+		return paneLocation{}, err
+	}
+	return paneLocation{connection: source, bucket: bucket, dir: normalizeMovePrefix(dir), store: store}, nil
+}
+
+// connection returns the settings of a source other than local files.
+func (l *locations) connection(source string) (config.S3Config, error) {
+	if source == currentConnectionLabel {
+		return l.conn, nil
+	}
+	for _, conn := range otherConnections(l.cfg, l.conn.Name) {
+		if conn.Name == source {
+			return conn, nil
+		}
+	}
+	return config.S3Config{}, fmt.Errorf("connection %q not found", source)
+}
+
+// bucketStore returns a store for conn.Bucket, reusing the open connection
+// and stores opened before.
+func (l *locations) bucketStore(source string, conn config.S3Config) (s3.ObjectStore, error) {
+	if source == currentConnectionLabel && conn.Bucket == l.conn.Bucket {
+		return l.store, nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	id := source + "\x00" + conn.Bucket
+	if store, ok := l.stores[id]; ok {
+		return store, nil
+	}
+	store, err := l.openStore(conn)
+	if err != nil {
+		return nil, err
+	}
+	l.stores[id] = store
+	return store, nil
+}
+
+// bucketNames lists the buckets of source and returns them with the bucket
+// configured for it.
+func (l *locations) bucketNames(ctx context.Context, source string) ([]string, string, error) {
+	conn, err := l.connection(source)
+	if err != nil {
+		return nil, "", err
+	}
+	configured := conn.Bucket
+	if conn.Bucket == "" {
+		conn.Bucket = l.conn.Bucket
+	}
+	store, err := l.bucketStore(source, conn)
+	if err != nil {
+		return nil, "", err
+	}
+	names, err := listBucketNames(ctx, store)
+	return names, configured, err
+}
@@ -18,15 +18,19 @@ import (
 
 	"github.com/pteich/us3ui/config"
 	"github.com/pteich/us3ui/s3"
+	"github.com/pteich/us3ui/transfer"
 )
 
 type MainWindow struct {
 	app       fyne.App
 	window    fyne.Window
 	cfg       *config.Config
-	s3Service *s3.Service
+	s3Service s3.ObjectStore
+	transfers *transfer.Manager
+	jobs      *JobScheduler
+	opened    *OpenedFiles
 	ctx       context.Context
-	prefix    string
+	conn      config.S3Config
 
 	// Current content management
 	currentContent fyne.CanvasObject
@@ -40,9 +44,14 @@ func NewMainWindow(a fyne.App, cfg *config.Config) *MainWindow {
 	window.SetMaster()
 
 	mw := &MainWindow{
-		app:    a,
-		window: window,
-		cfg:    cfg,
+		app:       a,
+		window:    window,
+		cfg:       cfg,
+		transfers: transfer.NewManager(cfg.Settings.TransferWorkers),
+		jobs: NewJobScheduler(cfg, func(c config.S3Config) (s3.ObjectStore, error) {
+			return s3.New(c)
+		}),
+		opened: NewOpenedFiles(a, window),
 	}
 
 	// Set up macOS menu if needed
@@ -64,6 +73,7 @@ func NewMainWindow(a fyne.App, cfg *config.Config) *MainWindow {
 
 func (mw *MainWindow) Show(ctx context.Context) {
 	mw.ctx = ctx
+	mw.jobs.Start(ctx)
 
 	// Create an empty placeholder content
 	placeholder := widget.NewLabel("Select a connection to begin")
@@ -79,6 +89,14 @@ func (mw *MainWindow) Show(ctx context.Context) {
 	mw.checkVersion()
 
 	mw.window.ShowAndRun()
+
+	// Cancel running jobs and wait until their runs are recorded.
+	mw.jobs.Stop()
+
+	// Remove the files of opened objects once the app has quit.
+	if err := mw.opened.Close(); err != nil {
+		fmt.Println("Error removing opened files: ", err)
+	}
 }
 
 func (mw *MainWindow) showAboutDialog() {
@@ -88,10 +106,10 @@ func (mw *MainWindow) showAboutDialog() {
 func (mw *MainWindow) showConnectionDialog() {
 	// Create connection dialog if it doesn't exist
 	if mw.connectDialog == nil {
-		mw.connectDialog = NewConnectDialog(mw.app, mw.cfg, mw.window, func(service *s3.Service, prefix string) {
+		mw.connectDialog = NewConnectDialog(mw.app, mw.cfg, mw.window, func(service s3.ObjectStore, conn config.S3Config) {
 			// This will be called when a connection is established
 			mw.s3Service = service
-			mw.prefix = prefix
+			mw.conn = conn
 			mw.loadFileManager()
 		})
 	}
@@ -100,8 +118,13 @@ func (mw *MainWindow) showConnectionDialog() {
 }
 
 func (mw *MainWindow) loadFileManager() {
-	// Create file manager
-	mw.fileManager = NewFileManager(mw.app, mw.s3Service, mw.window, func() {
+	if mw.fileManager != nil {
+		mw.fileManager.Close()
+	}
+
+	// Create file manager. The transfer queue outlives connection changes, so
+	// running transfers keep going against their original store.
+	mw.fileManager = NewFileManager(mw.app, mw.cfg, mw.conn, mw.s3Service, mw.transfers, mw.jobs, mw.opened, mw.window, func() {
 		mw.showConnectionDialog()
 	})
 
@@ -109,7 +132,7 @@ func (mw *MainWindow) loadFileManager() {
 	mw.window.SetContent(mw.fileManager.Container)
 
 	// Start loading objects
-	mw.fileManager.LoadObjects(mw.ctx, mw.prefix)
+	mw.fileManager.LoadObjects(mw.ctx, mw.conn.Prefix)
 }
 
 func (mw *MainWindow) checkVersion() {
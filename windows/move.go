@@ -0,0 +1,544 @@
+package windows
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+	"github.com/minio/minio-go/v7"
+
+	"github.com/pteich/us3ui/s3"
+)
+
+// moveItem is an object and the key it is moved to.
+type moveItem struct {
+	From minio.ObjectInfo
+	To   string
+}
+
+// moveResult is the outcome of moving a list of items.
+type moveResult struct {
+	moved    []moveItem
+	failures []string
+	canceled bool // the items after the moved and failed ones were not tried
+}
+
+// undo returns the items that move the moved objects back where they were.
+func (r moveResult) undo() []moveItem {
+	items := make([]moveItem, 0, len(r.moved))
+	for _, item := range r.moved {
+		from := item.From
+		from.Key = item.To
+		items = append(items, moveItem{From: from, To: item.From.Key})
+	}
+	return items
+}
+
+// normalizeMovePrefix turns the entered destination into a folder prefix.
+func normalizeMovePrefix(prefix string) string {
+	prefix = strings.TrimLeft(strings.TrimSpace(prefix), s3.Delimiter)
+	if prefix != "" && !strings.HasSuffix(prefix, s3.Delimiter) {
+		prefix += s3.Delimiter
+	}
+	return prefix
+}
+
+// moveTargets maps objects to keys below target, keeping their path below
+// base.
+func moveTargets(objects []minio.ObjectInfo, base, target string) []moveItem {
+	items := make([]moveItem, 0, len(objects))
+	for _, obj := range objects {
+		items = append(items, moveItem{From: obj, To: target + strings.TrimPrefix(obj.Key, base)})
+	}
+	return items
+}
+
+// selectionMoveTargets maps each object to its name below target.
+func selectionMoveTargets(objects []minio.ObjectInfo, target string) ([]moveItem, error) {
+	items := make([]moveItem, 0, len(objects))
+	sources := make(map[string]string, len(objects))
+	for _, obj := range objects {
+		to := target + path.Base(obj.Key)
+		if other, ok := sources[to]; ok {
+			return nil, fmt.Errorf("%s and %s would both be moved to %s", other, obj.Key, to)
+		}
+		sources[to] = obj.Key
+		items = append(items, moveItem{From: obj, To: to})
+	}
+	return items, nil
+}
+
+// dropUnmoved leaves out items that would stay where they are.
+func dropUnmoved(items []moveItem) []moveItem {
+	moved := items[:0:0]
+	for _, item := range items {
+		if item.To != item.From.Key {
+			moved = append(moved, item)
+		}
+	}
+	return moved
+}
+
+// validateObjectName checks a new name for an object in its folder.
+func validateObjectName(name string) error {
+	switch {
+	case name == "":
+		return errors.New("the name must not be empty")
+	case strings.Contains(name, s3.Delimiter):
+		return errors.New("the name must not contain \"/\", use Move to change the folder")
+	}
+	return nil
+}
+
+// selectedObjects returns the loaded objects that are selected, in key order.
+func (fm *FileManager) selectedObjects() []minio.ObjectInfo {
+	objects := make([]minio.ObjectInfo, 0, len(fm.selectedKeys))
+	for _, obj := range fm.allObjects {
+		if fm.selectedKeys[obj.Key] {
+			objects = append(objects, obj)
+		}
+	}
+	return objects
+}
+
+func (fm *FileManager) handleRename() {
+	objects := fm.selectedObjects()
+	if len(objects) != 1 || fm.context == nil {
+		return
+	}
+	obj := objects[0]
+
+	nameEntry := widget.NewEntry()
+	nameEntry.SetText(path.Base(obj.Key))
+	nameEntry.Validator = validateObjectName
+
+	items := []*widget.FormItem{widget.NewFormItem("New name", nameEntry)}
+	d := dialog.NewForm("Rename "+obj.Key, "Rename", "Cancel", items, func(ok bool) {
+		if !ok {
+			return
+		}
+		to := folderParent(obj.Key) + strings.TrimSpace(nameEntry.Text)
+		if to == obj.Key {
+			return
+		}
+		go fm.renameObject(fm.context, obj, to)
+	}, fm.window)
+	d.Resize(fyne.NewSize(450, d.MinSize().Height))
+	d.Show()
+}
+
+// renameObject moves obj to the key to unless an object already has that key.
+func (fm *FileManager) renameObject(ctx context.Context, obj minio.ObjectInfo, to string) {
+	exists, err := fm.objectExists(ctx, to)
+	if err == nil && exists {
+		err = fmt.Errorf("%s already exists", to)
+	}
+	if err != nil {
+		fyne.Do(func() { dialog.ShowError(err, fm.window) })
+		return
+	}
+
+	result := fm.moveObjects(ctx, []moveItem{{From: obj, To: to}}, "", nil)
+	if len(result.failures) > 0 {
+		fyne.Do(func() {
+			dialog.ShowError(errors.New(result.failures[0]), fm.window)
+		})
+	}
+}
+
+func (fm *FileManager) objectExists(ctx context.Context, key string) (bool, error) {
+	return objectExists(ctx, fm.s3svc, key)
+}
+
+func objectExists(ctx context.Context, store s3.ObjectStore, key string) (bool, error) {
+	_, err := store.StatObject(ctx, key)
+	switch {
+	case err == nil:
+		return true, nil
+	case minio.ToErrorResponse(err).Code == "NoSuchKey":
+		return false, nil
+	default:
+		return false, err
+	}
+}
+
+// handleMove asks for the destination of the selected objects, or of the
+// folder selected in the tree if no object is selected.
+func (fm *FileManager) handleMove() {
+	if fm.context == nil {
+		return
+	}
+	objects := fm.selectedObjects()
+	folder := ""
+	if len(objects) == 0 {
+		var ok bool
+		if folder, ok = fm.treeFolder(); !ok || folder == "" {
+			dialog.ShowInformation("Info", "No object or folder selected", fm.window)
+			return
+		}
+	}
+
+	what := fmt.Sprintf("%d selected objects", len(objects))
+	switch {
+	case folder != "":
+		what = "folder " + folder
+	case len(objects) == 1:
+		what = objects[0].Key
+	}
+
+	target := widget.NewEntry()
+	target.SetPlaceHolder("e.g. archive/2026/ (empty for the bucket root)")
+	target.SetText(fm.downloadBase())
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Move", widget.NewLabel(what)),
+		widget.NewFormItem("To prefix", target),
+	}
+	d := dialog.NewForm("Move", "Move", "Cancel", items, func(ok bool) {
+		if !ok {
+			return
+		}
+		prefix := normalizeMovePrefix(target.Text)
+		if folder != "" {
+			if strings.HasPrefix(prefix, folder) {
+				dialog.ShowError(fmt.Errorf("cannot move %s into itself", folder), fm.window)
+				return
+			}
+			go fm.prepareFolderMove(fm.context, folder, prefix)
+			return
+		}
+
+		moves, err := selectionMoveTargets(objects, prefix)
+		if err != nil {
+			dialog.ShowError(err, fm.window)
+			return
+		}
+		items := dropUnmoved(moves)
+		go func() {
+			taken, err := fm.moveConflicts(fm.context, items)
+			fyne.Do(func() { fm.confirmMove(items, "", taken, err) })
+		}()
+	}, fm.window)
+	d.Resize(fyne.NewSize(500, d.MinSize().Height))
+	d.Show()
+}
+
+// prepareFolderMove lists everything below folder and moves it with its
+// structure below prefix.
+func (fm *FileManager) prepareFolderMove(ctx context.Context, folder, prefix string) {
+	objects, err := fm.listObjects(ctx, folder)
+	var items []moveItem
+	var taken []string
+	if err == nil {
+		items = dropUnmoved(moveTargets(objects, folderParent(folder), prefix))
+		taken, err = fm.moveConflicts(ctx, items)
+	}
+	fyne.Do(func() { fm.confirmMove(items, folder, taken, err) })
+}
+
+// moveConflicts returns the destination keys of items that already hold an
+// object, which a move would overwrite.
+func (fm *FileManager) moveConflicts(ctx context.Context, items []moveItem) ([]string, error) {
+	return takenDestinations(ctx, fm.s3svc, items)
+}
+
+// takenDestinations returns the destination keys of items that already hold
+// an object in store.
+func takenDestinations(ctx context.Context, store s3.ObjectStore, items []moveItem) ([]string, error) {
+	var taken []string
+	for _, item := range items {
+		exists, err := objectExists(ctx, store, item.To)
+		if err != nil {
+			return nil, err
+		}
+		if exists {
+			taken = append(taken, item.To)
+		}
+	}
+	return taken, nil
+}
+
+// skipTaken leaves out the items whose destination is taken.
+func skipTaken(items []moveItem, taken []string) []moveItem {
+	skip := make(map[string]bool, len(taken))
+	for _, key := range taken {
+		skip[key] = true
+	}
+	kept := items[:0:0]
+	for _, item := range items {
+		if !skip[item.To] {
+			kept = append(kept, item)
+		}
+	}
+	return kept
+}
+
+// confirmMove starts the move of items, asking first whether objects at
+// taken destinations are overwritten or skipped. A folder that keeps skipped
+// objects stays in the tree.
+func (fm *FileManager) confirmMove(items []moveItem, folder string, taken []string, err error) {
+	if err != nil {
+		dialog.ShowError(err, fm.window)
+		return
+	}
+	if len(taken) == 0 {
+		fm.startMove(items, folder, true)
+		return
+	}
+	fm.confirmOverwrite(len(items), taken, func() {
+		fm.startMove(items, folder, true)
+	}, func() {
+		fm.startMove(skipTaken(items, taken), "", true)
+	})
+}
+
+// confirmOverwrite asks whether the objects at taken destinations, of count
+// in all, are overwritten or skipped.
+func (fm *FileManager) confirmOverwrite(count int, taken []string, overwrite, skip func()) {
+	var msg strings.Builder
+	fmt.Fprintf(&msg, "%d of %d destinations already hold an object that would be overwritten:", len(taken), count)
+	limit := min(len(taken), 10)
+	for _, key := range taken[:limit] {
+		msg.WriteString("\n- " + key)
+	}
+	if len(taken) > limit {
+		fmt.Fprintf(&msg, "\n- ... and %d more", len(taken)-limit)
+	}
+
+	var d *dialog.CustomDialog
+	d = dialog.NewCustomWithoutButtons("Objects Exist", widget.NewLabel(msg.String()), fm.window)
+	d.SetButtons([]fyne.CanvasObject{
+		widget.NewButton("Cancel", func() { d.Hide() }),
+		widget.NewButton("Skip Existing", func() {
+			d.Hide()
+			skip()
+		}),
+		&widget.Button{Text: "Overwrite", Importance: widget.DangerImportance, OnTapped: func() {
+			d.Hide()
+			overwrite()
+		}},
+	})
+	d.Show()
+}
+
+// startMove runs the move in the background behind a progress dialog that
+// can cancel it. If undoable, a move that did not complete offers to move
+// back what was moved.
+func (fm *FileManager) startMove(items []moveItem, folder string, undoable bool) {
+	if len(items) == 0 {
+		dialog.ShowInformation("Move", "Nothing to move, the objects are already there.", fm.window)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(fm.context)
+	label := widget.NewLabel(fmt.Sprintf("Moving %d objects…", len(items)))
+	bar := widget.NewProgressBar()
+	bar.Max = float64(len(items))
+
+	d := dialog.NewCustomWithoutButtons("Move", container.NewVBox(label, bar), fm.window)
+	d.SetButtons([]fyne.CanvasObject{widget.NewButton("Cancel", cancel)})
+	d.Resize(fyne.NewSize(400, d.MinSize().Height))
+	d.Show()
+
+	go func() {
+		defer cancel()
+		result := fm.moveObjects(ctx, items, folder, func(done int) {
+			fyne.Do(func() {
+				label.SetText(fmt.Sprintf("Moved %d of %d objects…", done, len(items)))
+				bar.SetValue(float64(done))
+			})
+		})
+		fyne.Do(func() {
+			d.Hide()
+			fm.showMoveSummary(result, len(items), folder, undoable)
+		})
+	}()
+}
+
+func (fm *FileManager) showMoveSummary(result moveResult, total int, folder string, undoable bool) {
+	msg := transferSummaryMessage("Moved", len(result.moved), total, result.failures)
+	if result.canceled {
+		skipped := total - len(result.moved) - len(result.failures)
+		msg = fmt.Sprintf("Canceled after moving %d objects, %d were not moved.", len(result.moved), skipped)
+		if len(result.failures) > 0 {
+			msg += "\n\n" + transferSummaryMessage("Moved", len(result.moved), total, result.failures)
+		}
+	}
+	if len(result.failures) == 0 && !result.canceled || len(result.moved) == 0 || !undoable {
+		dialog.ShowInformation("Move", msg, fm.window)
+		return
+	}
+
+	msg += "\n\nMove the moved objects back to where they were?"
+	dialog.ShowCustomConfirm("Move Incomplete", "Move Back", "Keep", widget.NewLabel(msg), func(back bool) {
+		if back {
+			fm.startMove(result.undo(), "", false)
+		}
+	}, fm.window)
+}
+
+// moveObjects copies each item to its new key on the server and then deletes
+// the original. A failed copy leaves the original in place, a failed delete
+// leaves both. The loaded listing is updated as objects are moved instead of
+// being reloaded. If folder is set and everything was moved, the folder is
+// removed from the tree. It stops when ctx is canceled, blocks and must not
+// run on the UI goroutine.
+func (fm *FileManager) moveObjects(ctx context.Context, items []moveItem, folder string, onProgress func(done int)) moveResult {
+	var result moveResult
+	for i, item := range items {
+		if ctx.Err() != nil {
+			result.canceled = true
+			break
+		}
+		moved := item.From
+		moved.Key = item.To
+		moved.LastModified = time.Now()
+
+		if err := fm.s3svc.CopyObject(ctx, item.From.Key, item.To); err != nil && ctx.Err() != nil {
+			result.canceled = true
+			break
+		} else if err != nil {
+			result.failures = append(result.failures, fmt.Sprintf("%s: %v", item.From.Key, err))
+		} else if err := fm.s3svc.DeleteObject(ctx, item.From.Key); err != nil {
+			result.failures = append(result.failures, fmt.Sprintf("%s: copied to %s but not removed: %v", item.From.Key, item.To, err))
+			fyne.Do(func() { fm.insertObject(moved) })
+		} else {
+			result.moved = append(result.moved, item)
+			fyne.Do(func() {
+				fm.removeObject(item.From.Key)
+				fm.insertObject(moved)
+			})
+		}
+		if onProgress != nil {
+			onProgress(i + 1)
+		}
+	}
+
+	complete := folder != "" && len(result.failures) == 0 && !result.canceled
+	fyne.Do(func() {
+		if complete {
+			fm.removeFolderNode(folder)
+		}
+		fm.listingChanged()
+	})
+	return result
+}
+
+// insertObject adds obj to the loaded listing at its place in key order.
+// Objects outside the loaded part of the listing are left for Load More.
+func (fm *FileManager) insertObject(obj minio.ObjectInfo) {
+	fm.resetOrderLocked()
+	if !strings.HasPrefix(obj.Key, fm.basePrefix) {
+		return
+	}
+	if fm.folderMode {
+		fm.insertFolderEntry(folderParent(obj.Key), obj)
+		// Create the entries of new folders up to the loaded base.
+		for prefix := folderParent(obj.Key); len(prefix) > len(fm.basePrefix); prefix = folderParent(prefix) {
+			fm.insertFolderEntry(folderParent(prefix), minio.ObjectInfo{Key: prefix})
+		}
+		if f := fm.folders[fm.selectedFolder]; f != nil {
+			fm.allObjects = f.entries
+		}
+		return
+	}
+
+	fm.allObjects = insertEntry(fm.allObjects, obj, fm.hasMoreObjects)
+}
+
+// insertFolderEntry adds obj to the cached listing of the folder prefix.
+func (fm *FileManager) insertFolderEntry(prefix string, obj minio.ObjectInfo) {
+	f := fm.folders[prefix]
+	if f == nil || !f.loaded {
+		return
+	}
+	if s3.IsFolder(obj) {
+		if idx := sort.SearchStrings(f.children, obj.Key); idx == len(f.children) || f.children[idx] != obj.Key {
+			if idx < len(f.children) || !f.truncated {
+				f.children = append(f.children[:idx:idx], append([]string{obj.Key}, f.children[idx:]...)...)
+			}
+		}
+	}
+	f.entries = insertEntry(f.entries, obj, f.truncated)
+}
+
+// insertEntry inserts obj into entries sorted by key, replacing an entry
+// with the same key. If the listing is truncated, keys after the last entry
+// are not added, as continuing the listing would add them again.
+func insertEntry(entries []minio.ObjectInfo, obj minio.ObjectInfo, truncated bool) []minio.ObjectInfo {
+	idx := sort.Search(len(entries), func(i int) bool {
+		return entries[i].Key >= obj.Key
+	})
+	switch {
+	case idx < len(entries) && entries[idx].Key == obj.Key:
+		if !s3.IsFolder(obj) {
+			entries[idx] = obj
+		}
+		return entries
+	case idx == len(entries) && truncated:
+		return entries
+	}
+	return append(entries[:idx:idx], append([]minio.ObjectInfo{obj}, entries[idx:]...)...)
+}
+
+// removeFolderNode drops a folder that was moved away from the folder tree
+// and the cached listings.
+func (fm *FileManager) removeFolderNode(prefix string) {
+	if !fm.folderMode || prefix == fm.basePrefix {
+		return
+	}
+	for p := range fm.folders {
+		if strings.HasPrefix(p, prefix) {
+			delete(fm.folders, p)
+		}
+	}
+	if parent := fm.folders[folderParent(prefix)]; parent != nil {
+		fm.removeFolderEntry(prefix)
+		if idx := sort.SearchStrings(parent.children, prefix); idx < len(parent.children) && parent.children[idx] == prefix {
+			parent.children = append(parent.children[:idx:idx], parent.children[idx+1:]...)
+		}
+	}
+	if strings.HasPrefix(fm.selectedFolder, prefix) {
+		fm.navigateToFolder(folderParent(prefix))
+	}
+}
+
+// listingChanged refreshes the tree and the object list after objects were
+// added or removed in place.
+func (fm *FileManager) listingChanged() {
+	if fm.folderMode {
+		if f := fm.folders[fm.selectedFolder]; f != nil {
+			fm.allObjects = f.entries
+		}
+		fm.folderTree.Refresh()
+	} else {
+		fm.prefixes = objectPrefixes(fm.allObjects)
+		fm.updateTree()
+		if _, ok := fm.treeFolder(); ok && !fm.prefixes[fm.selectedPrefix] {
+			fm.tree.Select("all")
+		}
+	}
+	fm.selectedKeys = nil
+	fm.updateActionButtons()
+	fm.updateObjectListLocked(false)
+}
+
+// objectPrefixes returns the folders of the flat tree, each key's parent
+// path without the trailing delimiter.
+func objectPrefixes(objects []minio.ObjectInfo) map[string]bool {
+	prefixes := make(map[string]bool)
+	for _, obj := range objects {
+		if idx := strings.LastIndex(obj.Key, s3.Delimiter); idx != -1 {
+			prefixes[obj.Key[:idx]] = true
+		}
+	}
+	return prefixes
+}
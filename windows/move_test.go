@@ -0,0 +1,222 @@
+package windows
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pteich/us3ui/s3/memstore"
+)
+
+func TestNormalizeMovePrefix(t *testing.T) {
+	tests := map[string]string{
+		"":               "",
+		"  ":             "",
+		"archive":        "archive/",
+		"/archive/2026/": "archive/2026/",
+	}
+	for in, want := range tests {
+		if got := normalizeMovePrefix(in); got != want {
+			t.Errorf("normalizeMovePrefix(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestMoveTargetsKeepFolderStructure(t *testing.T) {
+	objects := makeObjects("logs/", "logs/app/1.log", "logs/system.log")
+	items := moveTargets(objects, folderParent("logs/"), "archive/")
+
+	got := make([]string, len(items))
+	for i, item := range items {
+		got[i] = item.To
+	}
+	assertStringSet(t, got, []string{"archive/logs/", "archive/logs/app/1.log", "archive/logs/system.log"})
+
+	if items := dropUnmoved(moveTargets(objects, "", "")); len(items) != 0 {
+		t.Errorf("moving to the same place gave %v", items)
+	}
+}
+
+func TestSelectionMoveTargetsRejectsCollisions(t *testing.T) {
+	if _, err := selectionMoveTargets(makeObjects("a/report.csv", "b/report.csv"), "archive/"); err == nil {
+		t.Errorf("two objects with the same name were moved to one key")
+	}
+
+	items, err := selectionMoveTargets(makeObjects("a/report.csv", "archive/notes.txt"), "archive/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if items = dropUnmoved(items); len(items) != 1 || items[0].To != "archive/report.csv" {
+		t.Errorf("items = %v, want only a/report.csv moved", items)
+	}
+}
+
+func TestMoveObjectsUpdatesListingInPlace(t *testing.T) {
+	store := memstore.New("bucket")
+	store.Put("logs/a.log", []byte("a"))
+	store.Put("logs/b.log", []byte("b"))
+	store.Put("z.txt", []byte("z"))
+
+	fm := newTestFileManager(t, store)
+	loadAll(t, fm, 0, "")
+
+	items, err := selectionMoveTargets(makeObjects("logs/a.log", "logs/b.log"), "archive/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := fm.moveObjects(context.Background(), items, "", nil)
+	if len(result.failures) != 0 || len(result.moved) != 2 {
+		t.Fatalf("result = %+v", result)
+	}
+
+	if _, ok := store.Get("logs/a.log"); ok {
+		t.Errorf("logs/a.log still exists")
+	}
+	if data, ok := store.Get("archive/b.log"); !ok || string(data) != "b" {
+		t.Errorf("archive/b.log = %q, %v", data, ok)
+	}
+	want := []string{"archive/a.log", "archive/b.log", "z.txt"}
+	if got := keysOf(fm.allObjects); len(got) != len(want) || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] {
+		t.Errorf("allObjects = %v, want %v", got, want)
+	}
+	if !fm.prefixes["archive"] || fm.prefixes["logs"] {
+		t.Errorf("prefixes = %v, want archive only", fm.prefixes)
+	}
+}
+
+func TestMoveObjectsReportsFailuresAndUndo(t *testing.T) {
+	store := memstore.New("bucket")
+	store.Put("a.txt", []byte("a"))
+
+	fm := newTestFileManager(t, store)
+	loadAll(t, fm, 0, "")
+
+	items, err := selectionMoveTargets(makeObjects("a.txt", "gone.txt"), "moved/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := fm.moveObjects(context.Background(), items, "", nil)
+	if len(result.moved) != 1 || len(result.failures) != 1 {
+		t.Fatalf("result = %+v, want one moved and one failure", result)
+	}
+
+	back := fm.moveObjects(context.Background(), result.undo(), "", nil)
+	if len(back.failures) != 0 {
+		t.Fatalf("undo failures = %v", back.failures)
+	}
+	if _, ok := store.Get("a.txt"); !ok {
+		t.Errorf("a.txt was not moved back")
+	}
+	if got := keysOf(fm.allObjects); len(got) != 1 || got[0] != "a.txt" {
+		t.Errorf("allObjects = %v, want [a.txt]", got)
+	}
+}
+
+func TestMoveObjectsStopsWhenCanceled(t *testing.T) {
+	store := memstore.New("bucket")
+	for _, key := range []string{"a.txt", "b.txt", "c.txt"} {
+		store.Put(key, []byte(key))
+	}
+
+	fm := newTestFileManager(t, store)
+	loadAll(t, fm, 0, "")
+	items, err := selectionMoveTargets(fm.allObjects, "moved/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	result := fm.moveObjects(ctx, items, "", func(done int) {
+		if done == 1 {
+			cancel()
+		}
+	})
+	if len(result.moved) != 1 || len(result.failures) != 0 || !result.canceled {
+		t.Fatalf("result = %+v, want one moved before the cancel", result)
+	}
+	if _, ok := store.Get("b.txt"); !ok {
+		t.Errorf("b.txt was moved after the cancel")
+	}
+
+	// Moving the moved object back is offered.
+	fm.showMoveSummary(result, len(items), "", true)
+	tapButton(t, fm.window, "Keep")
+}
+
+func TestMoveFolderInFolderMode(t *testing.T) {
+	store := memstore.New("bucket")
+	store.Put("logs/app/1.log", []byte("one"))
+	store.Put("logs/system.log", []byte("two"))
+	store.Put("README.md", []byte("readme"))
+
+	fm := newTestFileManager(t, store)
+	browseFolder(t, fm, "", "")
+
+	objects, err := fm.listObjects(context.Background(), "logs/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	result := fm.moveObjects(context.Background(), moveTargets(objects, "", "archive/"), "logs/", nil)
+	if len(result.failures) != 0 {
+		t.Fatalf("failures = %v", result.failures)
+	}
+
+	if _, ok := store.Get("archive/logs/app/1.log"); !ok {
+		t.Errorf("archive/logs/app/1.log missing")
+	}
+	root := fm.folders[""]
+	assertStringSet(t, root.children, []string{"archive/"})
+	assertStringSet(t, keysOf(fm.allObjects), []string{"archive/", "README.md"})
+	if fm.folders["logs/"] != nil {
+		t.Errorf("the moved folder is still cached")
+	}
+}
+
+func TestRenameObjectRefusesExistingTarget(t *testing.T) {
+	store := memstore.New("bucket")
+	store.Put("docs/a.txt", []byte("a"))
+	store.Put("docs/b.txt", []byte("b"))
+
+	fm := newTestFileManager(t, store)
+	loadAll(t, fm, 0, "")
+
+	fm.renameObject(context.Background(), fm.allObjects[0], "docs/b.txt")
+	if data, _ := store.Get("docs/b.txt"); string(data) != "b" {
+		t.Errorf("docs/b.txt was overwritten with %q", data)
+	}
+
+	fm.renameObject(context.Background(), fm.allObjects[0], "docs/c.txt")
+	if _, ok := store.Get("docs/c.txt"); !ok {
+		t.Errorf("docs/a.txt was not renamed")
+	}
+	assertStringSet(t, keysOf(fm.allObjects), []string{"docs/b.txt", "docs/c.txt"})
+}
+
+func TestMoveConflictsFindsTakenDestinations(t *testing.T) {
+	store := memstore.New("bucket")
+	store.Put("in/a.txt", []byte("new a"))
+	store.Put("in/b.txt", []byte("new b"))
+	store.Put("archive/a.txt", []byte("old a"))
+
+	fm := newTestFileManager(t, store)
+	loadAll(t, fm, 0, "in/")
+	items := moveTargets(fm.allObjects, "in/", "archive/")
+
+	taken, err := fm.moveConflicts(context.Background(), items)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(taken) != 1 || taken[0] != "archive/a.txt" {
+		t.Fatalf("taken = %v, want [archive/a.txt]", taken)
+	}
+
+	kept := skipTaken(items, taken)
+	if len(kept) != 1 || kept[0].To != "archive/b.txt" {
+		t.Fatalf("skipTaken() = %v", kept)
+	}
+	fm.moveObjects(context.Background(), kept, "", nil)
+	if data, _ := store.Get("archive/a.txt"); string(data) != "old a" {
+		t.Errorf("archive/a.txt was overwritten with %q", data)
+	}
+	if _, ok := store.Get("archive/b.txt"); !ok {
+		t.Errorf("in/b.txt was not moved")
+	}
+}
@@ -0,0 +1,301 @@
+package windows
+
+import (
+	"context"
+	"mime"
+	"path"
+	"slices"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/minio/minio-go/v7"
+
+	"github.com/pteich/us3ui/config"
+	"github.com/pteich/us3ui/s3"
+)
+
+const (
+	gridThumbnailSize = 128
+	// thumbnailMemoryLimit is how many thumbnails the grid keeps in memory
+	// before it starts over from the disk cache.
+	thumbnailMemoryLimit = 500
+)
+
+// objectGridItem is one object in the thumbnail grid: its thumbnail or an
+// icon for its type, a check box to select it and its name.
+type objectGridItem struct {
+	widget.BaseWidget
+	icon      *widget.Icon
+	image     *canvas.Image
+	check     *widget.Check
+	label     *widget.Label
+	thumbnail string // thumbnailKey of the object shown, if it has one
+}
+
+func newObjectGridItem() *objectGridItem {
+	item := &objectGridItem{
+		icon:  widget.NewIcon(theme.FileIcon()),
+		image: canvas.NewImageFromResource(nil),
+		check: widget.NewCheck("", nil),
+		label: widget.NewLabel(""),
+	}
+	item.image.FillMode = canvas.ImageFillContain
+	item.image.Hide()
+	item.label.Truncation = fyne.TextTruncateEllipsis
+	item.ExtendBaseWidget(item)
+	return item
+}
+
+func (i *objectGridItem) CreateRenderer() fyne.WidgetRenderer {
+	space := canvas.NewRectangle(nil)
+	space.SetMinSize(fyne.NewSquareSize(gridThumbnailSize))
+	thumb := container.NewStack(space, i.icon, i.image)
+	return widget.NewSimpleRenderer(container.NewBorder(nil, container.NewBorder(nil, nil, i.check, nil, i.label), nil, nil, thumb))
+}
+
+// showThumbnail shows res, or icon if there is no thumbnail.
+func (i *objectGridItem) showThumbnail(res, icon fyne.Resource) {
+	if res == nil {
+		i.image.Hide()
+		i.icon.SetResource(icon)
+		i.icon.Show()
+		return
+	}
+	i.icon.Hide()
+	if i.image.Resource != res {
+		i.image.Resource = res
+		i.image.Refresh()
+	}
+	i.image.Show()
+}
+
+// createObjectGrid creates the thumbnail view of the listing. It shows the
+// same objects as the table and selects them the same way.
+func (fm *FileManager) createObjectGrid() *widget.GridWrap {
+	grid := widget.NewGridWrap(
+		func() int {
+			return len(fm.currentObjects)
+		},
+		func() fyne.CanvasObject {
+			return newObjectGridItem()
+		},
+		func(id widget.GridWrapItemID, o fyne.CanvasObject) {
+			fm.updateGridItem(id, o.(*objectGridItem))
+		},
+	)
+
+	grid.OnSelected = func(id widget.GridWrapItemID) {
+		// Selection is shown by the check boxes, so an item can be tapped
+		// again to toggle it.
+		grid.Unselect(id)
+		if id >= len(fm.currentObjects) {
+			return
+		}
+		obj := fm.currentObjects[id]
+		if fm.folderMode && s3.IsFolder(obj) {
+			fm.navigateToFolder(obj.Key)
+			return
+		}
+		fm.updateSelect(id, !fm.selectedKeys[obj.Key])
+	}
+	return grid
+}
+
+func (fm *FileManager) updateGridItem(id widget.GridWrapItemID, item *objectGridItem) {
+	if id >= len(fm.currentObjects) {
+		return
+	}
+	obj := fm.currentObjects[id]
+	item.label.SetText(fm.displayName(obj))
+
+	// A recycled item no longer needs the thumbnail of the object it showed.
+	key := ""
+	if hasThumbnail(obj) {
+		key = thumbnailKey(obj)
+	}
+	if item.thumbnail != key {
+		fm.releaseThumbnail(item)
+		item.thumbnail = key
+	}
+
+	if fm.folderMode && s3.IsFolder(obj) {
+		item.check.Hide()
+		item.showThumbnail(nil, theme.FolderIcon())
+		return
+	}
+
+	item.check.Show()
+	if selected := fm.selectedKeys[obj.Key]; item.check.Checked != selected {
+		item.check.Checked = selected
+		item.check.Refresh()
+	}
+	item.check.OnChanged = func(checked bool) {
+		fm.updateSelect(id, checked)
+	}
+
+	res, ok := fm.thumbnails[key]
+	if !ok && key != "" {
+		fm.requestThumbnail(obj, item)
+	}
+	item.showThumbnail(res, mimeIcon(obj.Key))
+}
+
+func thumbnailKey(obj minio.ObjectInfo) string {
+	return obj.Key + "\x00" + obj.ETag
+}
+
+// thumbnailRequest is a thumbnail being made for the grid item showing it.
+type thumbnailRequest struct {
+	cancel context.CancelFunc
+	item   *objectGridItem
+}
+
+// requestThumbnail makes the thumbnail of obj in the background and redraws
+// its grid item once it is there. A thumbnail that cannot be made is
+// remembered as missing so it is not tried again. The request is canceled
+// when item shows another object before it is done.
+func (fm *FileManager) requestThumbnail(obj minio.ObjectInfo, item *objectGridItem) {
+	key := thumbnailKey(obj)
+	if fm.thumbnailer == nil || fm.context == nil {
+		return
+	}
+	if req := fm.thumbnailsPending[key]; req != nil {
+		req.item = item
+		return
+	}
+	if fm.thumbnails == nil || len(fm.thumbnails) >= thumbnailMemoryLimit {
+		fm.thumbnails = make(map[string]fyne.Resource)
+	}
+	if fm.thumbnailsPending == nil {
+		fm.thumbnailsPending = make(map[string]*thumbnailRequest)
+	}
+	ctx, cancel := context.WithCancel(fm.context)
+	req := &thumbnailRequest{cancel: cancel, item: item}
+	fm.thumbnailsPending[key] = req
+
+	fm.loads.Add(1)
+	go func() {
+		defer fm.loads.Done()
+		defer cancel()
+		data, err := fm.thumbnailer.Thumbnail(ctx, obj)
+		fyne.Do(func() {
+			if fm.thumbnailsPending[key] != req {
+				return
+			}
+			delete(fm.thumbnailsPending, key)
+			switch {
+			case err == nil:
+				fm.thumbnails[key] = fyne.NewStaticResource(path.Base(obj.Key)+".png", data)
+			case ctx.Err() == nil:
+				fm.thumbnails[key] = nil
+			default:
+				return
+			}
+			idx := slices.IndexFunc(fm.currentObjects, func(o minio.ObjectInfo) bool { return o.Key == obj.Key })
+			if idx >= 0 {
+				fm.objectGrid.RefreshItem(idx)
+			}
+		})
+	}()
+}
+
+// releaseThumbnail cancels the thumbnail item is waiting for, unless another
+// item shows the same object by now.
+func (fm *FileManager) releaseThumbnail(item *objectGridItem) {
+	if req := fm.thumbnailsPending[item.thumbnail]; req != nil && req.item == item {
+		req.cancel()
+		delete(fm.thumbnailsPending, item.thumbnail)
+	}
+}
+
+// cancelThumbnails cancels all thumbnails being made, as none is shown.
+func (fm *FileManager) cancelThumbnails() {
+	for key, req := range fm.thumbnailsPending {
+		req.cancel()
+		delete(fm.thumbnailsPending, key)
+	}
+}
+
+// mimeIcon returns the icon for the type of key.
+func mimeIcon(key string) fyne.Resource {
+	mimeType := mime.TypeByExtension(strings.ToLower(path.Ext(key)))
+	switch {
+	case strings.HasPrefix(mimeType, "image/"):
+		return theme.FileImageIcon()
+	case strings.HasPrefix(mimeType, "video/"):
+		return theme.FileVideoIcon()
+	case strings.HasPrefix(mimeType, "audio/"):
+		return theme.FileAudioIcon()
+	case strings.HasPrefix(mimeType, "text/"):
+		return theme.FileTextIcon()
+	case strings.HasPrefix(mimeType, "application/"):
+		return theme.FileApplicationIcon()
+	}
+	return theme.FileIcon()
+}
+
+// setGridView switches between the object table and the thumbnail grid and
+// remembers the choice for the connection.
+func (fm *FileManager) setGridView(grid bool) {
+	if fm.gridView == grid {
+		return
+	}
+	fm.gridView = grid
+	fm.showObjectView()
+
+	name, ok := fm.sortConnection()
+	if !ok {
+		return
+	}
+	if grid {
+		if fm.cfg.Settings.GridView == nil {
+			fm.cfg.Settings.GridView = make(map[string]bool)
+		}
+		fm.cfg.Settings.GridView[name] = true
+	} else {
+		delete(fm.cfg.Settings.GridView, name)
+	}
+	if err := fm.cfg.Save(); err != nil {
+		dialog.ShowError(err, fm.window)
+	}
+}
+
+// showObjectView shows the table or the grid.
+func (fm *FileManager) showObjectView() {
+	if fm.gridView {
+		fm.objectList.Hide()
+		fm.objectGrid.Show()
+		fm.objectGrid.Refresh()
+		return
+	}
+	fm.cancelThumbnails()
+	fm.objectGrid.Hide()
+	fm.objectList.Show()
+	fm.objectList.Refresh()
+}
+
+func (fm *FileManager) loadGridView() {
+	if name, ok := fm.sortConnection(); ok {
+		fm.gridView = fm.cfg.Settings.GridView[name]
+	}
+}
+
+// newThumbnailer returns the thumbnail maker for conn, caching in the
+// default directory.
+func newThumbnailer(store s3.ObjectStore, conn config.S3Config) *Thumbnailer {
+	return NewThumbnailer(store, NewThumbnailCache(DefaultThumbnailDir(), thumbnailCacheLimit), conn.Endpoint, conn.Bucket)
+}
+
+// resetObjectViews clears the highlighted rows and items of the table and
+// the grid and redraws them.
+func (fm *FileManager) resetObjectViews() {
+	fm.objectList.UnselectAll()
+	fm.objectList.Refresh()
+	fm.objectGrid.UnselectAll()
+	fm.objectGrid.Refresh()
+}
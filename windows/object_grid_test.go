@@ -0,0 +1,128 @@
+package windows
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"fyne.io/fyne/v2/theme"
+
+	"github.com/pteich/us3ui/s3/memstore"
+)
+
+// stalledStore never finishes a download until it is canceled. It reports
+// the keys of the canceled downloads and returns once release is closed.
+type stalledStore struct {
+	*memstore.Store
+	started  chan string
+	canceled chan string
+	release  chan struct{}
+}
+
+func (s *stalledStore) DownloadObjectRange(ctx context.Context, objectName, etag string, offset, length int64) (io.ReadCloser, error) {
+	s.started <- objectName
+	<-ctx.Done()
+	s.canceled <- objectName
+	<-s.release
+	return nil, ctx.Err()
+}
+
+func TestMimeIcon(t *testing.T) {
+	for key, want := range map[string]string{
+		"photos/cat.PNG":  theme.FileImageIcon().Name(),
+		"docs/report.pdf": theme.FileApplicationIcon().Name(),
+		"data.json":       theme.FileApplicationIcon().Name(),
+		"noextension":     theme.FileIcon().Name(),
+	} {
+		if got := mimeIcon(key).Name(); got != want {
+			t.Errorf("mimeIcon(%q) = %s, want %s", key, got, want)
+		}
+	}
+}
+
+func TestObjectGridSelectsLikeTable(t *testing.T) {
+	store := memstore.New("bucket")
+	store.Put("a.json", []byte("a"))
+	store.Put("b.json", []byte("b"))
+
+	fm := newTestFileManager(t, store)
+	loadAll(t, fm, 0, "")
+	fm.setGridView(true)
+	if fm.objectList.Visible() || !fm.objectGrid.Visible() {
+		t.Fatalf("grid view shows the table")
+	}
+
+	idx := -1
+	for i, obj := range fm.currentObjects {
+		if obj.Key == "b.json" {
+			idx = i
+		}
+	}
+	fm.objectGrid.OnSelected(idx)
+	if len(fm.selectedKeys) != 1 || !fm.selectedKeys["b.json"] || !fm.deleteBtn.Visible() || fm.deleteBtn.Disabled() {
+		t.Errorf("selected = %v after tapping b.json", fm.selectedKeys)
+	}
+	item := newObjectGridItem()
+	fm.updateGridItem(idx, item)
+	if !item.check.Checked || item.label.Text != "b.json" || item.icon.Resource.Name() != theme.FileApplicationIcon().Name() {
+		t.Errorf("grid item: checked %v, label %q, icon %s", item.check.Checked, item.label.Text, item.icon.Resource.Name())
+	}
+
+	fm.objectGrid.OnSelected(idx)
+	if len(fm.selectedKeys) != 0 {
+		t.Errorf("tapping b.json again left %v selected", fm.selectedKeys)
+	}
+
+	fm.setGridView(false)
+	if !fm.objectList.Visible() || fm.objectGrid.Visible() {
+		t.Errorf("list view shows the grid")
+	}
+}
+
+func TestObjectGridCancelsThumbnailsOfRecycledItems(t *testing.T) {
+	store := memstore.New("bucket")
+	store.Put("a.png", encodePNG(t, 10, 10))
+	store.Put("b.png", encodePNG(t, 10, 10))
+
+	fm := newTestFileManager(t, store)
+	// The items of the grid itself make no thumbnails.
+	fm.thumbnailer = nil
+	loadAll(t, fm, 0, "")
+	fm.setGridView(true)
+	stalled := &stalledStore{Store: store, started: make(chan string, 2), canceled: make(chan string, 2), release: make(chan struct{})}
+	fm.thumbnailer = NewThumbnailer(stalled, NewThumbnailCache(t.TempDir(), thumbnailCacheLimit), "", "bucket")
+
+	first, second := newObjectGridItem(), newObjectGridItem()
+	fm.updateGridItem(0, first)
+	if key := <-stalled.started; key != "a.png" {
+		t.Fatalf("started %s, want a.png", key)
+	}
+	// Another item shows a.png now, so its thumbnail is still needed.
+	fm.updateGridItem(0, second)
+	fm.updateGridItem(1, first)
+	if key := <-stalled.started; key != "b.png" {
+		t.Fatalf("started %s, want b.png", key)
+	}
+	if len(fm.thumbnailsPending) != 2 {
+		t.Fatalf("%d thumbnails pending, want 2", len(fm.thumbnailsPending))
+	}
+
+	// Scrolling on recycles the item showing a.png.
+	fm.updateGridItem(1, second)
+	if key := <-stalled.canceled; key != "a.png" {
+		t.Errorf("canceled %s, want a.png", key)
+	}
+	if req := fm.thumbnailsPending[thumbnailKey(fm.currentObjects[1])]; req == nil || len(fm.thumbnailsPending) != 1 {
+		t.Errorf("pending thumbnails = %v, want b.png only", fm.thumbnailsPending)
+	}
+
+	fm.setGridView(false)
+	if key := <-stalled.canceled; key != "b.png" || len(fm.thumbnailsPending) != 0 {
+		t.Errorf("canceled %s, %d pending after leaving the grid", key, len(fm.thumbnailsPending))
+	}
+	close(stalled.release)
+	fm.loads.Wait()
+	if _, ok := fm.thumbnails[thumbnailKey(fm.currentObjects[0])]; ok {
+		t.Errorf("a canceled thumbnail was remembered as missing")
+	}
+}
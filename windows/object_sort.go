@@ -0,0 +1,209 @@
+package windows
+
+import (
+	"cmp"
+	"sort"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"github.com/minio/minio-go/v7"
+
+	"github.com/pteich/us3ui/config"
+	"github.com/pteich/us3ui/s3"
+)
+
+// sortColumn is a sortable column of the object table. The values are the
+// table column indexes.
+type sortColumn int
+
+const (
+	sortByName     sortColumn = 1
+	sortBySize     sortColumn = 2
+	sortByModified sortColumn = 3
+)
+
+var sortColumnNames = map[sortColumn]string{
+	sortByName:     "name",
+	sortBySize:     "size",
+	sortByModified: "modified",
+}
+
+// objectSort is the order of the object table.
+type objectSort struct {
+	column     sortColumn
+	descending bool
+}
+
+func parseObjectSort(s config.Sorting) objectSort {
+	for column, name := range sortColumnNames {
+		if name == s.Column {
+			return objectSort{column: column, descending: s.Descending}
+		}
+	}
+	return objectSort{column: sortByName}
+}
+
+func (o objectSort) config() config.Sorting {
+	return config.Sorting{Column: sortColumnNames[o.column], Descending: o.descending}
+}
+
+// icon returns the direction indicator for the header of column.
+func (o objectSort) icon(column sortColumn) fyne.Resource {
+	switch {
+	case o.column != column:
+		return nil
+	case o.descending:
+		return theme.MoveDownIcon()
+	default:
+		return theme.MoveUpIcon()
+	}
+}
+
+// toggle returns the order after the header of column was clicked: the
+// active column flips its direction, any other column sorts ascending.
+func (o objectSort) toggle(column sortColumn) objectSort {
+	if o.column == column {
+		o.descending = !o.descending
+		return o
+	}
+	return objectSort{column: column}
+}
+
+// keyOrder reports whether o is the order S3 lists keys in, which the loaded
+// listing is kept in.
+func (o objectSort) keyOrder() bool {
+	return !o.descending && o.column != sortBySize && o.column != sortByModified
+}
+
+// less orders a before b. Ties fall back to the key, so the order does not
+// depend on the order objects were listed in.
+func (o objectSort) less(a, b *minio.ObjectInfo) bool {
+	var c int
+	switch o.column {
+	case sortBySize:
+		c = cmp.Compare(a.Size, b.Size)
+	case sortByModified:
+		c = a.LastModified.Compare(b.LastModified)
+	}
+	if c == 0 {
+		c = strings.Compare(a.Key, b.Key)
+	}
+	if o.descending {
+		return c > 0
+	}
+	return c < 0
+}
+
+// sortIndex returns the positions of objects, which are in key order, in the
+// given order, or nil if they already are in it. Only the positions are
+// sorted, so the listing that further batches are appended to is not
+// modified. With foldersFirst subfolders are kept above the objects.
+func sortIndex(objects []minio.ObjectInfo, order objectSort, foldersFirst bool) []int {
+	if order.keyOrder() && !foldersFirst {
+		return nil
+	}
+
+	var folders []bool
+	if foldersFirst {
+		folders = make([]bool, len(objects))
+		for i := range objects {
+			folders[i] = s3.IsFolder(objects[i])
+		}
+	}
+
+	index := make([]int, 0, len(objects))
+	if order.keyOrder() {
+		// Both groups are already in key order.
+		for _, folder := range []bool{true, false} {
+			for i := range objects {
+				if folders[i] == folder {
+					index = append(index, i)
+				}
+			}
+		}
+		return index
+	}
+
+	for i := range objects {
+		index = append(index, i)
+	}
+	sort.Slice(index, func(i, j int) bool {
+		a, b := index[i], index[j]
+		if foldersFirst && folders[a] != folders[b] {
+			return folders[a]
+		}
+		return order.less(&objects[a], &objects[b])
+	})
+	return index
+}
+
+// objectOrder is the sorted index of a listing. It is kept until the listing
+// or the order changes.
+type objectOrder struct {
+	objects      []minio.ObjectInfo
+	order        objectSort
+	foldersFirst bool
+	index        []int
+}
+
+// sortedIndexLocked returns the positions of the loaded objects in the table
+// order, or nil if the listing is in that order. The listing is only sorted
+// again when it was replaced, grew or shrank, or the order changed; changes in
+// place must call resetOrderLocked.
+func (fm *FileManager) sortedIndexLocked() []int {
+	c := &fm.objectOrder
+	sameListing := len(c.objects) == len(fm.allObjects) && len(c.objects) > 0 && &c.objects[0] == &fm.allObjects[0]
+	if !sameListing || c.order != fm.sort || c.foldersFirst != fm.folderMode {
+		*c = objectOrder{
+			objects:      fm.allObjects,
+			order:        fm.sort,
+			foldersFirst: fm.folderMode,
+			index:        sortIndex(fm.allObjects, fm.sort, fm.folderMode),
+		}
+	}
+	return c.index
+}
+
+// resetOrderLocked drops the sorted index after the listing was changed in
+// place.
+func (fm *FileManager) resetOrderLocked() {
+	fm.objectOrder = objectOrder{}
+}
+
+// sortConnection returns the name the sort order is saved under, or false if
+// the connection is not saved.
+func (fm *FileManager) sortConnection() (string, bool) {
+	if fm.cfg == nil || fm.conn.Name == "" || fm.conn.Name == config.Transient {
+		return "", false
+	}
+	return fm.conn.Name, true
+}
+
+func (fm *FileManager) loadSort() {
+	fm.sort = objectSort{column: sortByName}
+	if name, ok := fm.sortConnection(); ok {
+		if s, ok := fm.cfg.Settings.Sorting[name]; ok {
+			fm.sort = parseObjectSort(s)
+		}
+	}
+}
+
+// sortBy handles a click on the header of column.
+func (fm *FileManager) sortBy(column sortColumn) {
+	fm.sort = fm.sort.toggle(column)
+	fm.updateObjectListLocked(true)
+
+	name, ok := fm.sortConnection()
+	if !ok {
+		return
+	}
+	if fm.cfg.Settings.Sorting == nil {
+		fm.cfg.Settings.Sorting = make(map[string]config.Sorting)
+	}
+	fm.cfg.Settings.Sorting[name] = fm.sort.config()
+	if err := fm.cfg.Save(); err != nil {
+		dialog.ShowError(err, fm.window)
+	}
+}
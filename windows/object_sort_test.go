@@ -0,0 +1,114 @@
+package windows
+
+import (
+	"testing"
+	"time"
+
+	minio "github.com/minio/minio-go/v7"
+
+	"github.com/pteich/us3ui/config"
+	"github.com/pteich/us3ui/s3/memstore"
+)
+
+func TestSortObjects(t *testing.T) {
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	objs := []minio.ObjectInfo{
+		{Key: "a.txt", Size: 30, LastModified: day, ETag: "2"},
+		{Key: "b.txt", Size: 10, LastModified: day.Add(2 * time.Hour), ETag: "1"},
+		{Key: "c.txt", Size: 10, LastModified: day.Add(time.Hour), ETag: "3"},
+		{Key: "docs/"},
+	}
+
+	inOrder := func(index []int) []string {
+		if index == nil {
+			return keysOf(objs)
+		}
+		keys := make([]string, len(index))
+		for i, idx := range index {
+			keys[i] = objs[idx].Key
+		}
+		return keys
+	}
+
+	tests := []struct {
+		name         string
+		order        objectSort
+		foldersFirst bool
+		want         []string
+	}{
+		{name: "name", order: objectSort{column: sortByName}, want: []string{"a.txt", "b.txt", "c.txt", "docs/"}},
+		{name: "name descending", order: objectSort{column: sortByName, descending: true}, want: []string{"docs/", "c.txt", "b.txt", "a.txt"}},
+		{name: "size ties by key", order: objectSort{column: sortBySize}, want: []string{"docs/", "b.txt", "c.txt", "a.txt"}},
+		{name: "modified descending", order: objectSort{column: sortByModified, descending: true}, want: []string{"b.txt", "c.txt", "a.txt", "docs/"}},
+		{name: "folders first", order: objectSort{column: sortBySize, descending: true}, foldersFirst: true, want: []string{"docs/", "a.txt", "c.txt", "b.txt"}},
+		{name: "name with folders first", order: objectSort{column: sortByName}, foldersFirst: true, want: []string{"docs/", "a.txt", "b.txt", "c.txt"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := inOrder(sortIndex(objs, tt.order, tt.foldersFirst))
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Fatalf("order = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+
+	if index := sortIndex(objs, objectSort{column: sortByName}, false); index != nil {
+		t.Errorf("the listing was sorted by name again: %v", index)
+	}
+}
+
+func TestObjectSortToggle(t *testing.T) {
+	order := objectSort{column: sortByName}
+	order = order.toggle(sortByName)
+	if order.column != sortByName || !order.descending {
+		t.Errorf("clicking the active column = %+v, want name descending", order)
+	}
+	order = order.toggle(sortBySize)
+	if order.column != sortBySize || order.descending {
+		t.Errorf("clicking another column = %+v, want size ascending", order)
+	}
+
+	if got := parseObjectSort(order.config()); got != order {
+		t.Errorf("parseObjectSort(config()) = %+v, want %+v", got, order)
+	}
+	if got := parseObjectSort(config.Sorting{Column: "unknown"}); got.column != sortByName {
+		t.Errorf("unknown column parsed as %+v, want name", got)
+	}
+}
+
+func TestSortKeptWhileBatchesLoad(t *testing.T) {
+	store := memstore.New("bucket")
+	store.Put("a.bin", make([]byte, 3))
+	store.Put("b.bin", make([]byte, 1))
+	store.Put("c.bin", make([]byte, 2))
+
+	fm := newTestFileManager(t, store)
+	fm.sortBy(sortBySize)
+	loadAll(t, fm, 0, "")
+
+	if got := keysOf(fm.currentObjects); len(got) != 3 || got[0] != "b.bin" || got[1] != "c.bin" || got[2] != "a.bin" {
+		t.Errorf("currentObjects = %v, want sorted by size", got)
+	}
+	if last := fm.allObjects[len(fm.allObjects)-1].Key; last != "c.bin" {
+		t.Errorf("listing order changed, last loaded key = %q", last)
+	}
+
+	// The index is kept while only the search changes.
+	index := fm.objectOrder.index
+	fm.searchTerm = "a"
+	fm.updateObjectListLocked(false)
+	if &fm.objectOrder.index[0] != &index[0] || len(fm.currentObjects) != 1 {
+		t.Errorf("searching sorted the listing again, %d objects shown", len(fm.currentObjects))
+	}
+
+	// A change in place sorts again.
+	fm.searchTerm = ""
+	fm.insertObject(minio.ObjectInfo{Key: "b.bin", Size: 5, ETag: "x"})
+	fm.updateObjectListLocked(false)
+	if got := keysOf(fm.currentObjects); len(got) != 3 || got[0] != "c.bin" || got[2] != "b.bin" {
+		t.Errorf("after replacing b.bin: %v, want sorted by size", got)
+	}
+}
@@ -0,0 +1,329 @@
+package windows
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/storage"
+	"fyne.io/fyne/v2/widget"
+	"github.com/fsnotify/fsnotify"
+
+	"github.com/pteich/us3ui/config"
+	"github.com/pteich/us3ui/s3"
+)
+
+// openedFileSettle is how long a changed file has to stay untouched before an
+// upload is offered. Applications often save a file in several writes.
+const openedFileSettle = time.Second
+
+// OpenedFiles downloads objects to a temporary directory, opens them with the
+// default application of the system and offers to upload a file back to its
+// object when it is changed there. The directory is removed by Close when the
+// app exits. Except for the downloads and uploads, it is used on the UI
+// goroutine.
+type OpenedFiles struct {
+	window fyne.Window
+	launch func(path string) error
+	settle time.Duration
+
+	dir     string
+	watcher *fsnotify.Watcher
+	files   map[string]*openedFile // by local path
+}
+
+// openedFile is an object that was opened and the local file it was
+// downloaded to.
+type openedFile struct {
+	store      s3.ObjectStore
+	key        string
+	path       string
+	props      s3.ObjectProperties // of the object as downloaded or last uploaded
+	stamp      fileStamp           // of the file then
+	onUploaded func()
+	timer      *time.Timer
+	asking     bool // an upload is offered or running
+}
+
+// fileStamp tells whether a local file was changed.
+type fileStamp struct {
+	size    int64
+	modTime time.Time
+}
+
+func statStamp(path string) (fileStamp, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}, err
+	}
+	return fileStamp{size: info.Size(), modTime: info.ModTime()}, nil
+}
+
+func NewOpenedFiles(a fyne.App, window fyne.Window) *OpenedFiles {
+	return &OpenedFiles{
+		window: window,
+		launch: func(path string) error {
+			u, err := url.Parse(storage.NewFileURI(path).String())
+			if err != nil {
+				return err
+			}
+			return a.OpenURL(u)
+		},
+		settle: openedFileSettle,
+		files:  make(map[string]*openedFile),
+	}
+}
+
+// Open downloads key from store and opens it with the default application.
+// onUploaded is called after a changed file was uploaded to key. An object
+// that is already open is opened again from its file.
+func (o *OpenedFiles) Open(ctx context.Context, store s3.ObjectStore, key string, onUploaded func()) {
+	for _, of := range o.files {
+		if of.store == store && of.key == key {
+			if err := o.launch(of.path); err != nil {
+				dialog.ShowError(err, o.window)
+			}
+			return
+		}
+	}
+	if err := o.init(); err != nil {
+		dialog.ShowError(err, o.window)
+		return
+	}
+
+	dir := o.dir
+	go func() {
+		of, err := downloadOpened(ctx, store, key, dir)
+		fyne.Do(func() {
+			if err == nil {
+				of.onUploaded = onUploaded
+				err = o.track(of)
+			}
+			if err == nil {
+				err = o.launch(of.path)
+			}
+			if err != nil {
+				dialog.ShowError(fmt.Errorf("cannot open %s: %w", key, err), o.window)
+			}
+		})
+	}()
+}
+
+// init creates the temporary directory and starts watching for changes.
+func (o *OpenedFiles) init() error {
+	if o.watcher != nil {
+		return nil
+	}
+	dir, err := os.MkdirTemp("", config.Name+"-open-*")
+	if err != nil {
+		return err
+	}
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		_ = os.RemoveAll(dir)
+		return err
+	}
+	o.dir, o.watcher = dir, watcher
+
+	go func() {
+		for {
+			select {
+			case ev, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				// Many applications save by writing a new file and renaming it
+				// over the old one, so creates count as writes.
+				if ev.Has(fsnotify.Write) || ev.Has(fsnotify.Create) {
+					name := filepath.Clean(ev.Name)
+					fyne.Do(func() { o.fileWritten(name) })
+				}
+			case _, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// downloadOpened downloads key into a new directory below dir. The file keeps
+// the name of the object so applications recognize its type.
+func downloadOpened(ctx context.Context, store s3.ObjectStore, key, dir string) (*openedFile, error) {
+	props, err := store.GetObjectProperties(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	fileDir, err := os.MkdirTemp(dir, "")
+	if err != nil {
+		return nil, err
+	}
+	of := &openedFile{
+		store: store,
+		key:   key,
+		path:  filepath.Join(fileDir, path.Base(key)),
+		props: props,
+	}
+
+	err = func() error {
+		rc, err := store.DownloadObjectRange(ctx, key, props.Info.ETag, 0, 0)
+		if err != nil {
+			return err
+		}
+		defer rc.Close()
+		f, err := os.Create(of.path)
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(f, rc)
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+		return err
+	}()
+	if err == nil {
+		of.stamp, err = statStamp(of.path)
+	}
+	if err != nil {
+		_ = os.RemoveAll(fileDir)
+		return nil, err
+	}
+	return of, nil
+}
+
+// track watches the directory of of for changes.
+func (o *OpenedFiles) track(of *openedFile) error {
+	if o.watcher == nil {
+		return errors.New("the app is closing")
+	}
+	if err := o.watcher.Add(filepath.Dir(of.path)); err != nil {
+		return err
+	}
+	o.files[of.path] = of
+	return nil
+}
+
+// fileWritten waits for writes to the file at path to settle before it is
+// checked.
+func (o *OpenedFiles) fileWritten(path string) {
+	of, ok := o.files[path]
+	if !ok {
+		return
+	}
+	if of.timer != nil {
+		of.timer.Stop()
+	}
+	of.timer = time.AfterFunc(o.settle, func() {
+		fyne.Do(func() { o.check(of) })
+	})
+}
+
+// changed returns the stamp of the file of of and whether it differs from the
+// one it had when it was downloaded or last uploaded.
+func (of *openedFile) changed() (fileStamp, bool) {
+	stamp, err := statStamp(of.path)
+	return stamp, err == nil && stamp != of.stamp
+}
+
+// check offers to upload the file of of if it was changed.
+func (o *OpenedFiles) check(of *openedFile) {
+	if of.asking || o.files[of.path] != of {
+		return
+	}
+	stamp, ok := of.changed()
+	if !ok {
+		return
+	}
+	of.asking = true
+	msg := fmt.Sprintf("%s was changed in another application.\n\nUpload it to %s?", path.Base(of.key), of.key)
+	dialog.ShowCustomConfirm("File Changed", "Upload", "Ignore", widget.NewLabel(msg), func(upload bool) {
+		if !upload {
+			of.stamp = stamp
+			of.asking = false
+			return
+		}
+		go o.upload(of, stamp, false)
+	}, o.window)
+}
+
+// upload writes the file of of to its object, keeping the headers, metadata
+// and tags of the object. Unless force is set, it asks before it overwrites
+// an object that was changed in the bucket since it was opened.
+func (o *OpenedFiles) upload(of *openedFile, stamp fileStamp, force bool) {
+	ctx := context.Background()
+	props, err := uploadOpened(ctx, of, force)
+
+	fyne.Do(func() {
+		switch {
+		case errors.Is(err, errObjectChanged):
+			msg := fmt.Sprintf("%s was changed in the bucket since you opened it.\n\n"+
+				"Overwrite it with your file? The changes in the bucket will be lost.", of.key)
+			dialog.ShowCustomConfirm("Object Changed", "Overwrite", "Cancel", widget.NewLabel(msg), func(yes bool) {
+				if yes {
+					go o.upload(of, stamp, true)
+					return
+				}
+				of.stamp = stamp
+				of.asking = false
+			}, o.window)
+			return
+		case err != nil:
+			of.asking = false
+			dialog.ShowError(fmt.Errorf("cannot upload %s: %w", of.key, err), o.window)
+			return
+		}
+		of.props = props
+		of.stamp = stamp
+		of.asking = false
+		if of.onUploaded != nil {
+			of.onUploaded()
+		}
+		// The file may have been saved again during the upload.
+		o.check(of)
+	})
+}
+
+// uploadOpened uploads the file of of and returns the properties of the new
+// object.
+func uploadOpened(ctx context.Context, of *openedFile, force bool) (s3.ObjectProperties, error) {
+	props := of.props
+	f, err := os.Open(of.path)
+	if err != nil {
+		return props, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return props, err
+	}
+	if err := replaceUnchanged(ctx, of.store, of.key, f, info.Size(), props, force); err != nil {
+		return props, err
+	}
+	props.Info, err = of.store.StatObject(ctx, of.key)
+	return props, err
+}
+
+// Close stops watching the opened files and removes them.
+func (o *OpenedFiles) Close() error {
+	for _, of := range o.files {
+		if of.timer != nil {
+			of.timer.Stop()
+		}
+	}
+	clear(o.files)
+	if o.watcher == nil {
+		return nil
+	}
+	_ = o.watcher.Close()
+	o.watcher = nil
+	return os.RemoveAll(o.dir)
+}
@@ -0,0 +1,205 @@
+package windows
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"fyne.io/fyne/v2"
+	fynetest "fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/pteich/us3ui/s3"
+	"github.com/pteich/us3ui/s3/memstore"
+)
+
+func newTestOpenedFiles(t *testing.T) (*OpenedFiles, fyne.Window) {
+	t.Helper()
+
+	w := fynetest.NewWindow(nil)
+	o := NewOpenedFiles(newTestApp(t), w)
+	t.Cleanup(func() { _ = o.Close() })
+	if err := o.init(); err != nil {
+		t.Fatal(err)
+	}
+	return o, w
+}
+
+// changeFile writes data to path and moves its modification time forward so
+// the change is seen on file systems with coarse timestamps.
+func changeFile(t *testing.T, path, data string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// tapButton taps the button labeled text in the dialog shown on w.
+func tapButton(t *testing.T, w fyne.Window, text string) {
+	t.Helper()
+	var walk func(fyne.CanvasObject) bool
+	walk = func(o fyne.CanvasObject) bool {
+		if b, ok := o.(*widget.Button); ok && b.Text == text {
+			fynetest.Tap(b)
+			return true
+		}
+		if c, ok := o.(*fyne.Container); ok {
+			for _, child := range c.Objects {
+				if walk(child) {
+					return true
+				}
+			}
+		}
+		if wid, ok := o.(fyne.Widget); ok {
+			for _, child := range fynetest.WidgetRenderer(wid).Objects() {
+				if walk(child) {
+					return true
+				}
+			}
+		}
+		return false
+	}
+	top := w.Canvas().Overlays().Top()
+	if top == nil || !walk(top) {
+		t.Fatalf("no %q button is shown", text)
+	}
+}
+
+func TestOpenedFilesUploadChangedFile(t *testing.T) {
+	ctx := context.Background()
+	store := memstore.New("bucket")
+	store.Put("docs/report.csv", []byte("a,b\n"))
+	headers := s3.ObjectHeaders{ContentType: "text/csv", ContentDisposition: "attachment"}
+	if err := store.UpdateObjectProperties(ctx, "docs/report.csv", headers, map[string]string{"owner": "ops"}, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	o, _ := newTestOpenedFiles(t)
+	of, err := downloadOpened(ctx, store, "docs/report.csv", o.dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if filepath.Base(of.path) != "report.csv" {
+		t.Errorf("downloaded to %s", of.path)
+	}
+	if data, _ := os.ReadFile(of.path); string(data) != "a,b\n" {
+		t.Errorf("downloaded %q", data)
+	}
+	if _, changed := of.changed(); changed {
+		t.Errorf("a downloaded file counts as changed")
+	}
+
+	changeFile(t, of.path, "a,b\n1,2\n")
+	if _, changed := of.changed(); !changed {
+		t.Fatalf("a written file does not count as changed")
+	}
+	props, err := uploadOpened(ctx, of, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := store.Get("docs/report.csv"); string(data) != "a,b\n1,2\n" {
+		t.Errorf("uploaded %q", data)
+	}
+	got, err := store.GetObjectProperties(ctx, "docs/report.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Headers != headers || got.Metadata["owner"] != "ops" || props.Info.ETag != got.Info.ETag {
+		t.Errorf("properties after upload = %+v, returned ETag %s", got, props.Info.ETag)
+	}
+
+	// The object changed in the bucket since it was opened.
+	of.props = props
+	store.Put("docs/report.csv", []byte("theirs"))
+	if _, err := uploadOpened(ctx, of, false); !errors.Is(err, errObjectChanged) {
+		t.Errorf("uploading over a changed object = %v", err)
+	}
+	if data, _ := store.Get("docs/report.csv"); string(data) != "theirs" {
+		t.Errorf("a changed object was overwritten with %q", data)
+	}
+	if _, err := uploadOpened(ctx, of, true); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := store.Get("docs/report.csv"); string(data) != "a,b\n1,2\n" {
+		t.Errorf("overwrite uploaded %q", data)
+	}
+}
+
+func TestOpenedFilesOpenAndClose(t *testing.T) {
+	store := memstore.New("bucket")
+	store.Put("img/logo.svg", []byte("<svg/>"))
+
+	o, _ := newTestOpenedFiles(t)
+	launched := make(chan string, 2)
+	o.launch = func(path string) error {
+		launched <- path
+		return nil
+	}
+
+	o.Open(context.Background(), store, "img/logo.svg", nil)
+	path := <-launched
+	if data, _ := os.ReadFile(path); string(data) != "<svg/>" {
+		t.Errorf("opened %q", data)
+	}
+	if of := o.files[path]; of == nil || of.key != "img/logo.svg" {
+		t.Fatalf("opened files = %v", o.files)
+	}
+
+	// Opening the object again opens the same file.
+	o.Open(context.Background(), store, "img/logo.svg", nil)
+	if again := <-launched; again != path {
+		t.Errorf("opened again as %s, want %s", again, path)
+	}
+
+	dir := o.dir
+	if err := o.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(dir); !os.IsNotExist(err) {
+		t.Errorf("temporary directory remains after Close: %v", err)
+	}
+}
+
+func TestOpenedFilesCheckOffersUpload(t *testing.T) {
+	ctx := context.Background()
+	store := memstore.New("bucket")
+	store.Put("notes.md", []byte("# Notes"))
+
+	o, w := newTestOpenedFiles(t)
+	of, err := downloadOpened(ctx, store, "notes.md", o.dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	o.files[of.path] = of
+
+	o.check(of)
+	if of.asking || w.Canvas().Overlays().Top() != nil {
+		t.Fatalf("upload offered for an unchanged file")
+	}
+
+	changeFile(t, of.path, "# Notes\n\n- one")
+	o.check(of)
+	if !of.asking {
+		t.Fatalf("no upload offered for a changed file")
+	}
+	tapButton(t, w, "Ignore")
+	if of.asking {
+		t.Errorf("still asking after Ignore")
+	}
+
+	// An ignored change is not offered again.
+	o.check(of)
+	if of.asking {
+		t.Errorf("an ignored change was offered again")
+	}
+	if data, _ := store.Get("notes.md"); string(data) != "# Notes" {
+		t.Errorf("an ignored change was uploaded: %q", data)
+	}
+}
@@ -0,0 +1,49 @@
+package windows
+
+import (
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+)
+
+// showOutputDialog shows content, a form that generates text, with buttons
+// to close it, copy the text and save it to a file. text returns the text,
+// or false while none was generated; fileName returns the name it is saved
+// under by default. Copying is confirmed on status.
+func showOutputDialog(window fyne.Window, title string, content fyne.CanvasObject, size fyne.Size, status *widget.Label, text func() (string, bool), fileName func() string) {
+	d := dialog.NewCustomWithoutButtons(title, content, window)
+	copyBtn := widget.NewButtonWithIcon("Copy", theme.ContentCopyIcon(), func() {
+		if s, ok := text(); ok {
+			fyne.CurrentApp().Clipboard().SetContent(s)
+			status.SetText("Copied to the clipboard.")
+		}
+	})
+	saveBtn := widget.NewButtonWithIcon("Save…", theme.DocumentSaveIcon(), func() {
+		if s, ok := text(); ok {
+			saveText(window, s, fileName())
+		}
+	})
+	d.SetButtons([]fyne.CanvasObject{
+		widget.NewButton("Close", d.Hide),
+		copyBtn,
+		saveBtn,
+	})
+	d.Resize(size)
+	d.Show()
+}
+
+// saveText asks where to save text, suggesting name, and writes it there.
+func saveText(window fyne.Window, text, name string) {
+	fd := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil || writer == nil {
+			return
+		}
+		defer writer.Close()
+		if _, err := writer.Write([]byte(text)); err != nil {
+			dialog.ShowError(err, window)
+		}
+	}, window)
+	fd.SetFileName(name)
+	fd.Show()
+}
@@ -0,0 +1,297 @@
+package windows
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/pteich/us3ui/s3"
+)
+
+const (
+	postFormatFields = "Form fields"
+	postFormatHTML   = "HTML form"
+	postFormatCurl   = "curl"
+)
+
+var postFormats = []string{postFormatFields, postFormatHTML, postFormatCurl}
+
+// postFormatExt is the file extension of each output format.
+var postFormatExt = map[string]string{
+	postFormatFields: ".txt",
+	postFormatHTML:   ".html",
+	postFormatCurl:   ".sh",
+}
+
+var sizeUnitNames = []string{"bytes", "KB", "MB", "GB"}
+
+var sizeUnits = map[string]int64{
+	"bytes": 1,
+	"KB":    1000,
+	"MB":    1000 * 1000,
+	"GB":    1000 * 1000 * 1000,
+}
+
+var successStatuses = []string{"default", "200", "201", "204"}
+
+// postForm is a generated presigned POST form.
+type postForm struct {
+	URL     string
+	Fields  map[string]string
+	Expires time.Time
+	// TypePrefix marks the Content-Type field as a prefix the uploader
+	// completes.
+	TypePrefix bool
+}
+
+// PostPolicyDialog creates presigned POST forms that let browsers upload
+// below a key prefix with size and content-type conditions.
+type PostPolicyDialog struct {
+	window fyne.Window
+	store  s3.ObjectStore
+	ctx    context.Context
+	prefix string // suggested key prefix
+	now    func() time.Time
+
+	prefixEntry      *widget.Entry
+	expiryEntry      *widget.Entry
+	unitSelect       *widget.Select
+	minSizeEntry     *widget.Entry
+	maxSizeEntry     *widget.Entry
+	sizeUnitSelect   *widget.Select
+	typeEntry        *widget.Entry
+	typePrefixCheck  *widget.Check
+	dispositionEntry *widget.Entry
+	statusSelect     *widget.Select
+	redirectEntry    *widget.Entry
+	metadata         *keyValueEditor
+	formatSelect     *widget.Select
+	output           *widget.Entry
+	status           *widget.Label
+	form             *postForm
+}
+
+func NewPostPolicyDialog(window fyne.Window, store s3.ObjectStore, ctx context.Context, prefix string) *PostPolicyDialog {
+	return &PostPolicyDialog{
+		window: window,
+		store:  store,
+		ctx:    ctx,
+		prefix: prefix,
+		now:    time.Now,
+	}
+}
+
+func (pd *PostPolicyDialog) Show() {
+	showOutputDialog(pd.window, "Upload Form", pd.createContent(), fyne.NewSize(750, 700), pd.status, pd.outputText, func() string {
+		return "upload-form" + postFormatExt[pd.formatSelect.Selected]
+	})
+}
+
+func (pd *PostPolicyDialog) createContent() fyne.CanvasObject {
+	pd.prefixEntry = widget.NewEntry()
+	pd.prefixEntry.SetText(pd.prefix)
+	pd.prefixEntry.SetPlaceHolder("empty allows any key")
+
+	pd.expiryEntry = widget.NewEntry()
+	pd.expiryEntry.SetText("1")
+	pd.unitSelect = widget.NewSelect(expiryUnitNames, nil)
+	pd.unitSelect.SetSelected("hours")
+
+	pd.minSizeEntry = widget.NewEntry()
+	pd.minSizeEntry.SetPlaceHolder("min")
+	pd.maxSizeEntry = widget.NewEntry()
+	pd.maxSizeEntry.SetPlaceHolder("max")
+	pd.sizeUnitSelect = widget.NewSelect(sizeUnitNames, nil)
+	pd.sizeUnitSelect.SetSelected("MB")
+
+	pd.typeEntry = widget.NewEntry()
+	pd.typeEntry.SetPlaceHolder("e.g. image/png, empty allows any type")
+	pd.typePrefixCheck = widget.NewCheck("Prefix", nil)
+	pd.dispositionEntry = widget.NewEntry()
+	pd.dispositionEntry.SetPlaceHolder("e.g. attachment")
+	pd.statusSelect = widget.NewSelect(successStatuses, nil)
+	pd.statusSelect.SetSelected("default")
+	pd.redirectEntry = widget.NewEntry()
+	pd.redirectEntry.SetPlaceHolder("URL the browser is sent to after the upload")
+	pd.metadata = newKeyValueEditor(nil, "Name", "Value")
+
+	form := widget.NewForm(
+		widget.NewFormItem("Key prefix", pd.prefixEntry),
+		widget.NewFormItem("Expires after", container.NewBorder(nil, nil, nil, pd.unitSelect, pd.expiryEntry)),
+		widget.NewFormItem("Size", container.NewBorder(nil, nil, nil, pd.sizeUnitSelect,
+			container.NewGridWithColumns(2, pd.minSizeEntry, pd.maxSizeEntry))),
+		widget.NewFormItem("Content-Type", container.NewBorder(nil, nil, nil, pd.typePrefixCheck, pd.typeEntry)),
+		widget.NewFormItem("Content-Disposition", pd.dispositionEntry),
+		widget.NewFormItem("Success status", pd.statusSelect),
+		widget.NewFormItem("Success redirect", pd.redirectEntry),
+		widget.NewFormItem("Metadata", pd.metadata.content("Add Metadata")),
+	)
+
+	pd.formatSelect = widget.NewSelect(postFormats, func(string) { pd.showForm() })
+	pd.formatSelect.SetSelected(postFormatFields)
+	generateBtn := widget.NewButtonWithIcon("Generate", theme.MailSendIcon(), pd.handleGenerate)
+	generateBtn.Importance = widget.HighImportance
+
+	pd.output = widget.NewMultiLineEntry()
+	pd.output.Wrapping = fyne.TextWrapOff
+	pd.status = widget.NewLabel("")
+	pd.status.Wrapping = fyne.TextWrapWord
+
+	controls := container.NewHBox(generateBtn, widget.NewLabel("Format"), pd.formatSelect)
+	return container.NewBorder(container.NewVBox(form, controls), pd.status, nil, nil, pd.output)
+}
+
+// options reads the form settings.
+func (pd *PostPolicyDialog) options() (s3.PostPolicyOptions, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(pd.expiryEntry.Text))
+	if err != nil || n < 1 {
+		return s3.PostPolicyOptions{}, errors.New("enter how long the form stays valid")
+	}
+	unit := sizeUnits[pd.sizeUnitSelect.Selected]
+	minSize, err := parseSize(pd.minSizeEntry.Text, unit)
+	if err != nil {
+		return s3.PostPolicyOptions{}, fmt.Errorf("minimum size: %w", err)
+	}
+	maxSize, err := parseSize(pd.maxSizeEntry.Text, unit)
+	if err != nil {
+		return s3.PostPolicyOptions{}, fmt.Errorf("maximum size: %w", err)
+	}
+	metadata, err := pd.metadata.values()
+	if err != nil {
+		return s3.PostPolicyOptions{}, fmt.Errorf("metadata: %w", err)
+	}
+
+	opts := s3.PostPolicyOptions{
+		KeyPrefix:          strings.TrimLeft(strings.TrimSpace(pd.prefixEntry.Text), s3.Delimiter),
+		Expires:            time.Duration(n) * expiryUnits[pd.unitSelect.Selected],
+		MinSize:            minSize,
+		MaxSize:            maxSize,
+		ContentType:        strings.TrimSpace(pd.typeEntry.Text),
+		ContentTypePrefix:  pd.typePrefixCheck.Checked,
+		ContentDisposition: strings.TrimSpace(pd.dispositionEntry.Text),
+		SuccessRedirect:    strings.TrimSpace(pd.redirectEntry.Text),
+		Metadata:           metadata,
+	}
+	if pd.statusSelect.Selected != "default" {
+		opts.SuccessStatus = pd.statusSelect.Selected
+	}
+	return opts, opts.Validate()
+}
+
+// parseSize reads a size given in unit. An empty text is no limit.
+func parseSize(text string, unit int64) (int64, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return 0, nil
+	}
+	n, err := strconv.ParseFloat(text, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%q is not a size", text)
+	}
+	return int64(n * float64(unit)), nil
+}
+
+func (pd *PostPolicyDialog) handleGenerate() {
+	opts, err := pd.options()
+	if err != nil {
+		dialog.ShowError(err, pd.window)
+		return
+	}
+
+	pd.status.SetText("Generating form…")
+	go func() {
+		form, err := pd.generate(pd.ctx, opts)
+		fyne.Do(func() {
+			if err != nil {
+				pd.status.SetText("")
+				dialog.ShowError(err, pd.window)
+				return
+			}
+			pd.setForm(form)
+		})
+	}()
+}
+
+// generate signs a POST form for opts.
+func (pd *PostPolicyDialog) generate(ctx context.Context, opts s3.PostPolicyOptions) (*postForm, error) {
+	expires := pd.now().Add(opts.Expires)
+	u, fields, err := pd.store.PresignPost(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	return &postForm{
+		URL:        u.String(),
+		Fields:     fields,
+		Expires:    expires,
+		TypePrefix: opts.ContentTypePrefix && opts.ContentType != "",
+	}, nil
+}
+
+func (pd *PostPolicyDialog) setForm(form *postForm) {
+	pd.form = form
+	pd.showForm()
+	pd.status.SetText(fmt.Sprintf("Valid until %s. Uploads are named after the uploaded file.", form.Expires.Format("2006-01-02 15:04")))
+}
+
+func (pd *PostPolicyDialog) showForm() {
+	if pd.output == nil || pd.form == nil {
+		return
+	}
+	pd.output.SetText(formatPostForm(pd.form, pd.formatSelect.Selected))
+}
+
+// outputText returns the generated text, or false if there is none.
+func (pd *PostPolicyDialog) outputText() (string, bool) {
+	return pd.output.Text, pd.form != nil
+}
+
+// formatPostForm renders form in one of the output formats. Fields are
+// sorted by name; the file always comes last as S3 ignores fields after it.
+func formatPostForm(form *postForm, format string) string {
+	names := slices.Sorted(maps.Keys(form.Fields))
+	var b strings.Builder
+	switch format {
+	case postFormatHTML:
+		fmt.Fprintf(&b, "<form action=\"%s\" method=\"post\" enctype=\"multipart/form-data\">\n", html.EscapeString(form.URL))
+		for _, name := range names {
+			kind := "hidden"
+			if name == "Content-Type" && form.TypePrefix {
+				kind = "text"
+			}
+			fmt.Fprintf(&b, "  <input type=\"%s\" name=\"%s\" value=\"%s\">\n", kind, html.EscapeString(name), html.EscapeString(form.Fields[name]))
+		}
+		b.WriteString("  <input type=\"file\" name=\"file\">\n")
+		b.WriteString("  <input type=\"submit\" value=\"Upload\">\n")
+		b.WriteString("</form>\n")
+	case postFormatCurl:
+		b.WriteString("curl")
+		for _, name := range names {
+			fmt.Fprintf(&b, " \\\n  --form-string %s", shellQuote(name+"="+form.Fields[name]))
+		}
+		fmt.Fprintf(&b, " \\\n  -F %s \\\n  %s\n", shellQuote("file=@upload.bin"), shellQuote(form.URL))
+	default:
+		fmt.Fprintf(&b, "POST %s\n\n", form.URL)
+		for _, name := range names {
+			fmt.Fprintf(&b, "%s: %s\n", name, form.Fields[name])
+		}
+		b.WriteString("file: <the file to upload>\n")
+	}
+	return b.String()
+}
+
+// shellQuote quotes s for a POSIX shell.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
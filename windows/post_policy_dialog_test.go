@@ -0,0 +1,115 @@
+package windows
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	fynetest "fyne.io/fyne/v2/test"
+
+	"github.com/pteich/us3ui/s3/memstore"
+)
+
+func newTestPostPolicyDialog(t *testing.T, prefix string) *PostPolicyDialog {
+	t.Helper()
+
+	newTestApp(t)
+	pd := NewPostPolicyDialog(fynetest.NewWindow(nil), memstore.New("bucket"), context.Background(), prefix)
+	pd.now = func() time.Time { return time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC) }
+	pd.createContent()
+	return pd
+}
+
+func TestPostPolicyDialogOptions(t *testing.T) {
+	pd := newTestPostPolicyDialog(t, "incoming/")
+
+	pd.minSizeEntry.SetText("1")
+	pd.maxSizeEntry.SetText("2.5")
+	pd.typeEntry.SetText("image/")
+	pd.typePrefixCheck.SetChecked(true)
+	pd.statusSelect.SetSelected("201")
+	pd.metadata.addRow("customer", "42")
+	opts, err := pd.options()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if opts.KeyPrefix != "incoming/" || opts.Expires != time.Hour || opts.MinSize != 1000000 || opts.MaxSize != 2500000 ||
+		!opts.ContentTypePrefix || opts.SuccessStatus != "201" || opts.Metadata["customer"] != "42" {
+		t.Errorf("options = %+v", opts)
+	}
+
+	pd.maxSizeEntry.SetText("0.5")
+	if _, err := pd.options(); err == nil {
+		t.Errorf("a maximum size below the minimum was accepted")
+	}
+	pd.maxSizeEntry.SetText("lots")
+	if _, err := pd.options(); err == nil {
+		t.Errorf("a non-numeric size was accepted")
+	}
+	pd.maxSizeEntry.SetText("")
+	pd.statusSelect.SetSelected("default")
+	if opts, err := pd.options(); err != nil || opts.MaxSize != 0 || opts.SuccessStatus != "" {
+		t.Errorf("options without limit = %+v, %v", opts, err)
+	}
+}
+
+func TestPostPolicyDialogGeneratesForm(t *testing.T) {
+	pd := newTestPostPolicyDialog(t, "incoming/")
+	pd.typeEntry.SetText("image/")
+	pd.typePrefixCheck.SetChecked(true)
+	opts, err := pd.options()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	form, err := pd.generate(context.Background(), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := time.Date(2026, 10, 17, 13, 0, 0, 0, time.UTC); !form.Expires.Equal(want) || !form.TypePrefix {
+		t.Errorf("form = %+v", form)
+	}
+
+	pd.setForm(form)
+	pd.formatSelect.SetSelected(postFormatHTML)
+	for _, want := range []string{
+		`<form action="memory://bucket/" method="post" enctype="multipart/form-data">`,
+		`<input type="hidden" name="key" value="incoming/${filename}">`,
+		`<input type="text" name="Content-Type" value="image/">`,
+	} {
+		if !strings.Contains(pd.output.Text, want) {
+			t.Errorf("HTML form lacks %s:\n%s", want, pd.output.Text)
+		}
+	}
+}
+
+func TestFormatPostForm(t *testing.T) {
+	form := &postForm{
+		URL:    "https://s3.example.com/bucket/",
+		Fields: map[string]string{"key": "in/${filename}", "policy": "eyJ9", "x-amz-meta-note": `it's "new"`},
+	}
+
+	want := "curl \\\n" +
+		"  --form-string 'key=in/${filename}' \\\n" +
+		"  --form-string 'policy=eyJ9' \\\n" +
+		"  --form-string 'x-amz-meta-note=it'\\''s \"new\"' \\\n" +
+		"  -F 'file=@upload.bin' \\\n" +
+		"  'https://s3.example.com/bucket/'\n"
+	if got := formatPostForm(form, postFormatCurl); got != want {
+		t.Errorf("curl = %q, want %q", got, want)
+	}
+
+	html := formatPostForm(form, postFormatHTML)
+	if !strings.Contains(html, `value="it&#39;s &#34;new&#34;"`) {
+		t.Errorf("HTML does not escape values:\n%s", html)
+	}
+	if strings.Index(html, `type="file"`) < strings.Index(html, `name="x-amz-meta-note"`) {
+		t.Errorf("file input is not the last field:\n%s", html)
+	}
+
+	fields := formatPostForm(form, postFormatFields)
+	if !strings.HasPrefix(fields, "POST https://s3.example.com/bucket/\n\nkey: in/${filename}\n") {
+		t.Errorf("fields = %q", fields)
+	}
+}
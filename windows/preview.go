@@ -0,0 +1,476 @@
+package windows
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+	"unicode/utf8"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/minio/minio-go/v7"
+	"go.yaml.in/yaml/v3"
+
+	"github.com/pteich/us3ui/s3"
+)
+
+const (
+	// previewTextChunk is how much text one fetch or Load More adds.
+	previewTextChunk = 64 << 10
+	// previewTextLimit is the most text the preview loads of an object.
+	previewTextLimit = 4 << 20
+	// previewImageLimit is the largest image that is previewed.
+	previewImageLimit = 10 << 20
+)
+
+type previewKind int
+
+const (
+	previewNone previewKind = iota
+	previewImage
+	previewText
+	previewJSON
+	previewYAML
+	previewXML
+	previewMarkdown
+)
+
+var previewKindNames = map[previewKind]string{
+	previewJSON: "JSON",
+	previewYAML: "YAML",
+	previewXML:  "XML",
+}
+
+var previewExtensions = map[string]previewKind{
+	".png":      previewImage,
+	".jpg":      previewImage,
+	".jpeg":     previewImage,
+	".gif":      previewImage,
+	".bmp":      previewImage,
+	".svg":      previewImage,
+	".txt":      previewText,
+	".log":      previewText,
+	".csv":      previewText,
+	".tsv":      previewText,
+	".ini":      previewText,
+	".conf":     previewText,
+	".toml":     previewText,
+	".json":     previewJSON,
+	".geojson":  previewJSON,
+	".yaml":     previewYAML,
+	".yml":      previewYAML,
+	".xml":      previewXML,
+	".md":       previewMarkdown,
+	".markdown": previewMarkdown,
+}
+
+// previewKindOf picks how an object is previewed from its extension, or its
+// content type if the extension is unknown.
+func previewKindOf(key, contentType string) previewKind {
+	if kind, ok := previewExtensions[strings.ToLower(path.Ext(key))]; ok {
+		return kind
+	}
+	contentType, _, _ = strings.Cut(strings.ToLower(contentType), ";")
+	switch {
+	case contentType == "image/svg+xml":
+		return previewImage
+	case strings.HasSuffix(contentType, "json"):
+		return previewJSON
+	case strings.HasSuffix(contentType, "yaml"):
+		return previewYAML
+	case strings.HasSuffix(contentType, "/xml"):
+		return previewXML
+	case contentType == "text/markdown":
+		return previewMarkdown
+	case strings.HasPrefix(contentType, "image/"):
+		return previewImage
+	case strings.HasPrefix(contentType, "text/"):
+		return previewText
+	}
+	return previewNone
+}
+
+// previewContent is the loaded start of an object.
+type previewContent struct {
+	info minio.ObjectInfo
+	kind previewKind
+	data []byte
+}
+
+// complete reports whether the whole object is loaded.
+func (c previewContent) complete() bool {
+	return int64(len(c.data)) >= c.info.Size
+}
+
+// canLoadMore reports whether Load More may fetch another chunk of text.
+func (c previewContent) canLoadMore() bool {
+	return c.kind != previewImage && !c.complete() && len(c.data) < previewTextLimit
+}
+
+// fetchPreview loads what the preview shows of key: a whole image, or the
+// first chunk of a text. Objects of an unknown type are previewed as text if
+// their first chunk looks like text.
+func fetchPreview(ctx context.Context, store s3.ObjectStore, key string) (previewContent, error) {
+	info, err := store.StatObject(ctx, key)
+	if err != nil {
+		return previewContent{}, err
+	}
+	c := previewContent{info: info, kind: previewKindOf(key, info.ContentType)}
+	if c.kind == previewImage {
+		if info.Size > previewImageLimit {
+			return c, fmt.Errorf("the image is larger than %s", ByteCountSI(previewImageLimit))
+		}
+		c.data, err = readRange(ctx, store, key, info.ETag, 0, info.Size)
+		return c, err
+	}
+
+	c.data, err = readRange(ctx, store, key, info.ETag, 0, min(previewTextChunk, info.Size))
+	if err != nil {
+		return c, err
+	}
+	if !looksLikeText(c.data) {
+		c.kind = previewNone
+	} else if c.kind == previewNone {
+		c.kind = previewText
+	}
+	return c, nil
+}
+
+// fetchMore loads the next chunk of a text. It fails if the object changed
+// since the first chunk was loaded.
+func fetchMore(ctx context.Context, store s3.ObjectStore, c previewContent) (previewContent, error) {
+	offset := int64(len(c.data))
+	data, err := readRange(ctx, store, c.info.Key, c.info.ETag, offset, min(previewTextChunk, c.info.Size-offset))
+	if err != nil {
+		if minio.ToErrorResponse(err).Code == "PreconditionFailed" {
+			return c, errors.New("the object changed, reopen the preview")
+		}
+		return c, err
+	}
+	c.data = append(bytes.Clone(c.data), data...)
+	return c, nil
+}
+
+// readRange reads length bytes of key starting at offset. Nothing is
+// requested for a length of zero, since S3 rejects empty ranges.
+func readRange(ctx context.Context, store s3.ObjectStore, key, etag string, offset, length int64) ([]byte, error) {
+	if length <= 0 {
+		return nil, nil
+	}
+	rc, err := store.DownloadObjectRange(ctx, key, etag, offset, length)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(io.LimitReader(rc, length))
+}
+
+// looksLikeText reports whether data is UTF-8 without NUL bytes. A rune cut
+// off at the end of data does not count.
+func looksLikeText(data []byte) bool {
+	return bytes.IndexByte(data, 0) < 0 && utf8.Valid(completeRunes(data))
+}
+
+// completeRunes drops a rune that is cut off at the end of data.
+func completeRunes(data []byte) []byte {
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				return data[:i]
+			}
+			break
+		}
+	}
+	return data
+}
+
+// formatPreviewText returns the text shown for c. JSON, YAML and XML are
+// pretty-printed once they are loaded completely; the note says why they are
+// shown as they are otherwise.
+func formatPreviewText(c previewContent) (text, note string) {
+	text = string(completeRunes(c.data))
+	name, formatted := previewKindNames[c.kind]
+	if !formatted {
+		return text, ""
+	}
+	if !c.complete() {
+		return text, fmt.Sprintf("Load the whole object to format the %s.", name)
+	}
+
+	var pretty string
+	var err error
+	switch c.kind {
+	case previewJSON:
+		var b bytes.Buffer
+		err = json.Indent(&b, c.data, "", "  ")
+		pretty = b.String()
+	case previewYAML:
+		pretty, err = indentYAML(c.data)
+	case previewXML:
+		pretty, err = indentXML(c.data)
+	}
+	if err != nil {
+		return text, fmt.Sprintf("Not valid %s: %v", name, err)
+	}
+	return pretty, ""
+}
+
+// indentYAML reformats every document in data with an indent of two.
+func indentYAML(data []byte) (string, error) {
+	var b strings.Builder
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	for {
+		var doc yaml.Node
+		err := dec.Decode(&doc)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		if err := enc.Encode(&doc); err != nil {
+			return "", err
+		}
+	}
+	if err := enc.Close(); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// indentXML puts every element of data on its own line, indented by its
+// depth. Elements that hold only text stay on one line. Namespace prefixes
+// are kept as written.
+func indentXML(data []byte) (string, error) {
+	dec := xml.NewDecoder(bytes.NewReader(data))
+	var b strings.Builder
+	depth := 0
+	inline := false // the open element has no content on its own line yet
+	newline := func() {
+		if b.Len() > 0 {
+			b.WriteByte('\n')
+		}
+		b.WriteString(strings.Repeat("  ", depth))
+	}
+	for {
+		tok, err := dec.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			newline()
+			b.WriteString("<" + xmlName(t.Name))
+			for _, attr := range t.Attr {
+				b.WriteString(" " + xmlName(attr.Name) + `="`)
+				_ = xml.EscapeText(&b, []byte(attr.Value))
+				b.WriteString(`"`)
+			}
+			b.WriteString(">")
+			depth++
+			inline = true
+		case xml.EndElement:
+			depth--
+			if !inline {
+				newline()
+			}
+			b.WriteString("</" + xmlName(t.Name) + ">")
+			inline = false
+		case xml.CharData:
+			s := strings.TrimSpace(string(t))
+			if s == "" {
+				continue
+			}
+			if !inline {
+				newline()
+			}
+			_ = xml.EscapeText(&b, []byte(s))
+		case xml.Comment:
+			newline()
+			b.WriteString("<!--" + string(t) + "-->")
+			inline = false
+		case xml.ProcInst:
+			newline()
+			b.WriteString("<?" + t.Target + " " + string(t.Inst) + "?>")
+			inline = false
+		case xml.Directive:
+			newline()
+			b.WriteString("<!" + string(t) + ">")
+			inline = false
+		}
+	}
+	if depth != 0 {
+		return "", errors.New("unclosed element")
+	}
+	return b.String() + "\n", nil
+}
+
+func xmlName(n xml.Name) string {
+	if n.Space == "" {
+		return n.Local
+	}
+	return n.Space + ":" + n.Local
+}
+
+// PreviewPane shows the selected object next to the listing: images, text and
+// logs, formatted JSON, YAML and XML, and rendered Markdown. Text is loaded in
+// chunks with ranged requests, so large files only load as far as needed.
+type PreviewPane struct {
+	store s3.ObjectStore
+
+	key     string
+	ctx     context.Context // canceled when another object is selected
+	cancel  context.CancelFunc
+	content previewContent
+
+	title       *widget.Label
+	status      *widget.Label
+	body        *fyne.Container
+	loadMoreBtn *widget.Button
+	Container   fyne.CanvasObject
+}
+
+func NewPreviewPane(store s3.ObjectStore) *PreviewPane {
+	p := &PreviewPane{store: store}
+	p.title = widget.NewLabel("")
+	p.title.TextStyle = fyne.TextStyle{Bold: true}
+	p.title.Truncation = fyne.TextTruncateEllipsis
+	p.status = widget.NewLabel("")
+	p.status.Wrapping = fyne.TextWrapWord
+	p.body = container.NewStack()
+	p.loadMoreBtn = widget.NewButtonWithIcon("Load More", theme.MoreVerticalIcon(), p.handleLoadMore)
+	p.loadMoreBtn.Hide()
+
+	p.Container = container.NewBorder(p.title, container.NewVBox(p.status, p.loadMoreBtn), nil, nil, p.body)
+	p.SetObject(nil, "")
+	return p
+}
+
+// SetObject previews key, or clears the pane if key is empty. Selecting the
+// object that is shown already keeps its loaded text.
+func (p *PreviewPane) SetObject(ctx context.Context, key string) {
+	if key != "" && key == p.key {
+		return
+	}
+	if p.cancel != nil {
+		p.cancel()
+		p.ctx, p.cancel = nil, nil
+	}
+	p.key = key
+	p.content = previewContent{}
+	p.loadMoreBtn.Hide()
+	p.body.RemoveAll()
+	if key == "" || ctx == nil {
+		p.key = ""
+		p.title.SetText("Preview")
+		p.status.SetText("Select an object to preview it.")
+		return
+	}
+
+	p.ctx, p.cancel = context.WithCancel(ctx)
+	p.title.SetText(path.Base(key))
+	p.status.SetText("Loading…")
+	go p.load(p.ctx, key)
+}
+
+// load fetches the start of key and shows it unless another object was
+// selected in the meantime.
+func (p *PreviewPane) load(ctx context.Context, key string) {
+	c, err := fetchPreview(ctx, p.store, key)
+	fyne.Do(func() {
+		if ctx.Err() != nil || p.key != key {
+			return
+		}
+		if err != nil {
+			p.status.SetText("No preview: " + err.Error())
+			return
+		}
+		p.show(c)
+	})
+}
+
+func (p *PreviewPane) handleLoadMore() {
+	if p.ctx == nil || !p.content.canLoadMore() {
+		return
+	}
+	ctx, c := p.ctx, p.content
+	p.loadMoreBtn.Disable()
+	go func() {
+		more, err := fetchMore(ctx, p.store, c)
+		fyne.Do(func() {
+			p.loadMoreBtn.Enable()
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				p.status.SetText("Loading more failed: " + err.Error())
+				return
+			}
+			p.show(more)
+		})
+	}()
+}
+
+// show renders c in the pane.
+func (p *PreviewPane) show(c previewContent) {
+	p.content = c
+	p.body.RemoveAll()
+	status := previewStatus(c)
+
+	switch c.kind {
+	case previewNone:
+		p.status.SetText(status + "\nThis object has no preview.")
+		p.loadMoreBtn.Hide()
+		return
+	case previewImage:
+		img := canvas.NewImageFromReader(bytes.NewReader(c.data), path.Base(c.info.Key))
+		img.FillMode = canvas.ImageFillContain
+		p.body.Add(img)
+	case previewMarkdown:
+		rt := widget.NewRichTextFromMarkdown(string(completeRunes(c.data)))
+		rt.Wrapping = fyne.TextWrapWord
+		p.body.Add(container.NewVScroll(rt))
+	default:
+		text, note := formatPreviewText(c)
+		p.body.Add(widget.NewTextGridFromString(text))
+		if note != "" {
+			status += "\n" + note
+		}
+	}
+
+	p.status.SetText(status)
+	if c.canLoadMore() {
+		p.loadMoreBtn.Show()
+	} else {
+		p.loadMoreBtn.Hide()
+	}
+}
+
+// previewStatus describes the object and how much of it is shown.
+func previewStatus(c previewContent) string {
+	status := ByteCountSI(c.info.Size)
+	if c.info.ContentType != "" {
+		status = c.info.ContentType + ", " + status
+	}
+	if c.kind != previewNone && !c.complete() {
+		status += fmt.Sprintf(", showing the first %s", ByteCountSI(int64(len(c.data))))
+		if !c.canLoadMore() {
+			status += ". Download the object to see the rest."
+		}
+	}
+	return status
+}
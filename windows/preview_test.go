@@ -0,0 +1,180 @@
+package windows
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/png"
+	"strings"
+	"testing"
+
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/pteich/us3ui/s3/memstore"
+)
+
+func TestPreviewKindOf(t *testing.T) {
+	tests := []struct {
+		key, contentType string
+		want             previewKind
+	}{
+		{"photos/cat.JPG", "application/octet-stream", previewImage},
+		{"logs/app.log", "", previewText},
+		{"config/app.yml", "", previewYAML},
+		{"docs/README.md", "", previewMarkdown},
+		{"api/response", "application/json; charset=utf-8", previewJSON},
+		{"feed", "application/rss+xml", previewNone},
+		{"sitemap", "text/xml", previewXML},
+		{"notes", "text/plain", previewText},
+		{"backup.tar.gz", "application/gzip", previewNone},
+	}
+	for _, tt := range tests {
+		if got := previewKindOf(tt.key, tt.contentType); got != tt.want {
+			t.Errorf("previewKindOf(%q, %q) = %v, want %v", tt.key, tt.contentType, got, tt.want)
+		}
+	}
+}
+
+func TestLooksLikeText(t *testing.T) {
+	cut := []byte("grüße")[:3] // ends inside ü
+	if !looksLikeText(cut) || string(completeRunes(cut)) != "gr" {
+		t.Errorf("a rune cut off at the end is not text: %q", completeRunes(cut))
+	}
+	if looksLikeText([]byte("PK\x03\x04\x00\x00")) {
+		t.Errorf("binary data looks like text")
+	}
+	if looksLikeText([]byte{0xff, 0xfe, 'a'}) {
+		t.Errorf("invalid UTF-8 looks like text")
+	}
+}
+
+func TestFormatPreviewText(t *testing.T) {
+	complete := func(kind previewKind, data string) previewContent {
+		c := previewContent{kind: kind, data: []byte(data)}
+		c.info.Size = int64(len(data))
+		return c
+	}
+
+	tests := []struct {
+		name      string
+		c         previewContent
+		want      string
+		wantNote  bool
+		wantEqual bool // shown as loaded
+	}{
+		{"json", complete(previewJSON, `{"a":[1,2]}`), "{\n  \"a\": [\n    1,\n    2\n  ]\n}", false, false},
+		{"yaml", complete(previewYAML, "a:   1\nb:\n    - x\n---\nc: 2\n"), "a: 1\nb:\n  - x\n---\nc: 2\n", false, false},
+		{"xml", complete(previewXML, `<?xml version="1.0"?><s:a x="1 &amp; 2"><b>text</b><c/><!-- note --></s:a>`),
+			"<?xml version=\"1.0\"?>\n<s:a x=\"1 &amp; 2\">\n  <b>text</b>\n  <c></c>\n  <!-- note -->\n</s:a>\n", false, false},
+		{"invalid json", complete(previewJSON, `{"a":`), "", true, true},
+		{"unclosed xml", complete(previewXML, `<a><b>`), "", true, true},
+		{"plain text", complete(previewText, "  keep  as is "), "", false, true},
+	}
+	for _, tt := range tests {
+		text, note := formatPreviewText(tt.c)
+		if tt.wantEqual {
+			tt.want = string(tt.c.data)
+		}
+		if text != tt.want || (note != "") != tt.wantNote {
+			t.Errorf("%s: text = %q, note = %q, want %q", tt.name, text, note, tt.want)
+		}
+	}
+
+	partial := complete(previewJSON, `{"a":1}`)
+	partial.info.Size = 1000
+	if text, note := formatPreviewText(partial); text != `{"a":1}` || !strings.Contains(note, "whole object") {
+		t.Errorf("partial JSON = %q, note %q", text, note)
+	}
+}
+
+func TestFetchPreviewLoadsTextInChunks(t *testing.T) {
+	store := memstore.New("bucket")
+	log := strings.Repeat("line of the log\n", previewTextChunk/8)
+	store.Put("logs/app", []byte(log))
+
+	ctx := context.Background()
+	c, err := fetchPreview(ctx, store, "logs/app")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.kind != previewText || len(c.data) != previewTextChunk || c.complete() || !c.canLoadMore() {
+		t.Fatalf("first chunk: kind %v, %d bytes, complete %v", c.kind, len(c.data), c.complete())
+	}
+	if status := previewStatus(c); !strings.Contains(status, "showing the first 65.5 kB") {
+		t.Errorf("status = %q", status)
+	}
+
+	c, err = fetchMore(ctx, store, c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(c.data) != log || !c.complete() || c.canLoadMore() {
+		t.Errorf("after Load More: %d of %d bytes", len(c.data), len(log))
+	}
+
+	store.Put("logs/app", []byte("rotated"))
+	c.data = c.data[:10]
+	if _, err := fetchMore(ctx, store, c); err == nil || !strings.Contains(err.Error(), "changed") {
+		t.Errorf("Load More of a changed object = %v", err)
+	}
+
+	store.Put("archive", []byte("\x1f\x8b\x08\x00binary"))
+	if c, err := fetchPreview(ctx, store, "archive"); err != nil || c.kind != previewNone {
+		t.Errorf("binary object: kind %v, %v", c.kind, err)
+	}
+
+	store.Put("notes.txt", []byte("short"))
+	if c, err := fetchPreview(ctx, store, "notes.txt"); err != nil || string(c.data) != "short" || !c.complete() {
+		t.Errorf("short object: %q, %v", c.data, err)
+	}
+	store.Put("empty.txt", nil)
+	if c, err := fetchPreview(ctx, store, "empty.txt"); err != nil || len(c.data) != 0 || !c.complete() {
+		t.Errorf("empty object: %q, %v", c.data, err)
+	}
+}
+
+func TestPreviewPaneShowsSelectedObject(t *testing.T) {
+	newTestApp(t)
+
+	var img bytes.Buffer
+	if err := png.Encode(&img, image.NewRGBA(image.Rect(0, 0, 4, 4))); err != nil {
+		t.Fatal(err)
+	}
+	store := memstore.New("bucket")
+	store.Put("photos/cat.png", img.Bytes())
+	store.Put("docs/readme.md", []byte("# Title\n\nSome *text*."))
+
+	p := NewPreviewPane(store)
+	p.key = "photos/cat.png"
+	p.load(context.Background(), "photos/cat.png")
+	if len(p.body.Objects) != 1 {
+		t.Fatalf("body = %v", p.body.Objects)
+	}
+	if _, ok := p.body.Objects[0].(*canvas.Image); !ok {
+		t.Errorf("image preview shows %T", p.body.Objects[0])
+	}
+
+	p.key = "docs/readme.md"
+	p.load(context.Background(), "docs/readme.md")
+	if scroll, ok := p.body.Objects[0].(*container.Scroll); !ok {
+		t.Errorf("Markdown preview shows %T", p.body.Objects[0])
+	} else if _, ok := scroll.Content.(*widget.RichText); !ok {
+		t.Errorf("Markdown preview scrolls %T", scroll.Content)
+	}
+	if p.loadMoreBtn.Visible() {
+		t.Errorf("Load More offered for a complete object")
+	}
+
+	// A late result for an object that is no longer selected is dropped.
+	p.load(context.Background(), "photos/cat.png")
+	if _, ok := p.body.Objects[0].(*canvas.Image); ok {
+		t.Errorf("preview of a deselected object was shown")
+	}
+
+	p.SetObject(nil, "")
+	if len(p.body.Objects) != 0 || p.key != "" || p.status.Text != "Select an object to preview it." {
+		t.Errorf("cleared pane shows %v, %q", p.body.Objects, p.status.Text)
+	}
+}
@@ -0,0 +1,235 @@
+package windows
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/pteich/us3ui/s3"
+)
+
+var (
+	contentTypeOptions = []string{
+		"application/json",
+		"application/octet-stream",
+		"application/pdf",
+		"application/javascript",
+		"image/jpeg",
+		"image/png",
+		"image/svg+xml",
+		"image/webp",
+		"text/css; charset=utf-8",
+		"text/html; charset=utf-8",
+		"text/plain; charset=utf-8",
+	}
+	cacheControlOptions = []string{
+		"no-cache",
+		"no-store",
+		"max-age=300",
+		"max-age=3600",
+		"public, max-age=86400",
+		"public, max-age=31536000, immutable",
+	}
+)
+
+// keyValueEditor edits a map of strings as rows of key and value entries.
+type keyValueEditor struct {
+	rows      *fyne.Container
+	keyHint   string
+	valueHint string
+}
+
+func newKeyValueEditor(values map[string]string, keyHint, valueHint string) *keyValueEditor {
+	e := &keyValueEditor{
+		rows:      container.NewVBox(),
+		keyHint:   keyHint,
+		valueHint: valueHint,
+	}
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		e.addRow(k, values[k])
+	}
+	return e
+}
+
+func (e *keyValueEditor) addRow(key, value string) {
+	keyEntry := widget.NewEntry()
+	keyEntry.SetPlaceHolder(e.keyHint)
+	keyEntry.SetText(key)
+	valueEntry := widget.NewEntry()
+	valueEntry.SetPlaceHolder(e.valueHint)
+	valueEntry.SetText(value)
+
+	var row *fyne.Container
+	removeBtn := widget.NewButtonWithIcon("", theme.ContentRemoveIcon(), func() {
+		e.rows.Remove(row)
+	})
+	row = container.NewBorder(nil, nil, nil, removeBtn, container.NewGridWithColumns(2, keyEntry, valueEntry))
+	e.rows.Add(row)
+}
+
+// content returns the rows followed by a button to add another one.
+func (e *keyValueEditor) content(addLabel string) fyne.CanvasObject {
+	addBtn := widget.NewButtonWithIcon(addLabel, theme.ContentAddIcon(), func() {
+		e.addRow("", "")
+	})
+	return container.NewVBox(e.rows, container.NewHBox(addBtn))
+}
+
+// values returns the entered pairs. Rows without key and value are ignored;
+// a value without key or a key given twice is an error.
+func (e *keyValueEditor) values() (map[string]string, error) {
+	values := make(map[string]string)
+	for _, o := range e.rows.Objects {
+		grid := o.(*fyne.Container).Objects[0].(*fyne.Container)
+		key := strings.TrimSpace(grid.Objects[0].(*widget.Entry).Text)
+		value := grid.Objects[1].(*widget.Entry).Text
+		if key == "" {
+			if value == "" {
+				continue
+			}
+			return nil, fmt.Errorf("value %q has no key", value)
+		}
+		if _, ok := values[key]; ok {
+			return nil, fmt.Errorf("key %q is given twice", key)
+		}
+		values[key] = value
+	}
+	return values, nil
+}
+
+func (fm *FileManager) handleProperties() {
+	if len(fm.selectedKeys) != 1 || fm.context == nil {
+		return
+	}
+	for key := range fm.selectedKeys {
+		go fm.loadProperties(fm.context, key)
+	}
+}
+
+func (fm *FileManager) loadProperties(ctx context.Context, key string) {
+	props, err := fm.s3svc.GetObjectProperties(ctx, key)
+	fyne.Do(func() {
+		if err != nil {
+			dialog.ShowError(err, fm.window)
+			return
+		}
+		fm.showProperties(ctx, key, props)
+	})
+}
+
+// showProperties shows the details of an object and lets the user change its
+// content headers, user metadata and tags.
+func (fm *FileManager) showProperties(ctx context.Context, key string, props s3.ObjectProperties) {
+	info := props.Info
+	storageClass := info.StorageClass
+	if storageClass == "" {
+		storageClass = "STANDARD"
+	}
+	details := widget.NewForm(
+		widget.NewFormItem("Key", readOnlyText(key)),
+		widget.NewFormItem("Size", widget.NewLabel(fmt.Sprintf("%s (%d bytes)", ByteCountSI(info.Size), info.Size))),
+		widget.NewFormItem("Last Modified", widget.NewLabel(info.LastModified.Format("2006-01-02 15:04:05"))),
+		widget.NewFormItem("ETag", readOnlyText(info.ETag)),
+		widget.NewFormItem("Storage Class", widget.NewLabel(storageClass)),
+	)
+	if info.VersionID != "" {
+		details.Append("Version ID", readOnlyText(info.VersionID))
+	}
+
+	contentType := widget.NewSelectEntry(contentTypeOptions)
+	contentType.SetText(props.Headers.ContentType)
+	cacheControl := widget.NewSelectEntry(cacheControlOptions)
+	cacheControl.SetText(props.Headers.CacheControl)
+	contentDisposition := widget.NewEntry()
+	contentDisposition.SetPlaceHolder(`e.g. attachment; filename="report.pdf"`)
+	contentDisposition.SetText(props.Headers.ContentDisposition)
+	contentEncoding := widget.NewEntry()
+	contentEncoding.SetPlaceHolder("e.g. gzip")
+	contentEncoding.SetText(props.Headers.ContentEncoding)
+	contentLanguage := widget.NewEntry()
+	contentLanguage.SetPlaceHolder("e.g. en-US")
+	contentLanguage.SetText(props.Headers.ContentLanguage)
+
+	headers := widget.NewForm(
+		widget.NewFormItem("Content-Type", contentType),
+		widget.NewFormItem("Cache-Control", cacheControl),
+		widget.NewFormItem("Content-Disposition", contentDisposition),
+		widget.NewFormItem("Content-Encoding", contentEncoding),
+		widget.NewFormItem("Content-Language", contentLanguage),
+	)
+
+	metadata := newKeyValueEditor(props.Metadata, "Name", "Value")
+	objectTags := newKeyValueEditor(props.Tags, "Tag", "Value")
+
+	tabs := container.NewAppTabs(
+		container.NewTabItem("Details", container.NewVScroll(details)),
+		container.NewTabItem("Headers", container.NewVScroll(headers)),
+		container.NewTabItem("Metadata", container.NewVScroll(metadata.content("Add Metadata"))),
+		container.NewTabItem("Tags", container.NewVScroll(objectTags.content("Add Tag"))),
+	)
+
+	var d *dialog.CustomDialog
+	saveBtn := widget.NewButtonWithIcon("Save", theme.DocumentSaveIcon(), func() {
+		meta, err := metadata.values()
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("metadata: %w", err), fm.window)
+			return
+		}
+		tagValues, err := objectTags.values()
+		if err != nil {
+			dialog.ShowError(fmt.Errorf("tags: %w", err), fm.window)
+			return
+		}
+		h := s3.ObjectHeaders{
+			ContentType:        strings.TrimSpace(contentType.Text),
+			CacheControl:       strings.TrimSpace(cacheControl.Text),
+			ContentDisposition: strings.TrimSpace(contentDisposition.Text),
+			ContentEncoding:    strings.TrimSpace(contentEncoding.Text),
+			ContentLanguage:    strings.TrimSpace(contentLanguage.Text),
+		}
+		d.Hide()
+		go fm.saveProperties(ctx, key, h, meta, tagValues)
+	})
+	saveBtn.Importance = widget.HighImportance
+
+	d = dialog.NewCustomWithoutButtons("Properties of "+key, tabs, fm.window)
+	d.SetButtons([]fyne.CanvasObject{
+		widget.NewButton("Close", func() { d.Hide() }),
+		saveBtn,
+	})
+	d.Resize(fyne.NewSize(600, 450))
+	d.Show()
+}
+
+// saveProperties writes the changed properties with a copy in place and
+// reloads the listing, as the copy changes the modification date.
+func (fm *FileManager) saveProperties(ctx context.Context, key string, headers s3.ObjectHeaders, metadata, tags map[string]string) {
+	err := fm.s3svc.UpdateObjectProperties(ctx, key, headers, metadata, tags)
+	fyne.Do(func() {
+		if err != nil {
+			dialog.ShowError(err, fm.window)
+			return
+		}
+		fm.reload(ctx, fm.basePrefix, key)
+	})
+}
+
+// readOnlyText shows text that can be selected and copied but not changed.
+func readOnlyText(text string) fyne.CanvasObject {
+	label := widget.NewLabel(text)
+	label.Selectable = true
+	label.Wrapping = fyne.TextWrapBreak
+	return label
+}
@@ -0,0 +1,71 @@
+package windows
+
+import (
+	"context"
+	"testing"
+
+	"fyne.io/fyne/v2"
+	fynetest "fyne.io/fyne/v2/test"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/pteich/us3ui/s3"
+	"github.com/pteich/us3ui/s3/memstore"
+)
+
+func TestKeyValueEditorValues(t *testing.T) {
+	e := newKeyValueEditor(map[string]string{"owner": "cdn"}, "Name", "Value")
+	e.addRow("", "")
+	e.addRow(" team ", "web")
+
+	values, err := e.values()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != 2 || values["owner"] != "cdn" || values["team"] != "web" {
+		t.Errorf("values = %v", values)
+	}
+
+	e.addRow("owner", "someone else")
+	if _, err := e.values(); err == nil {
+		t.Errorf("duplicate key was accepted")
+	}
+
+	e = newKeyValueEditor(nil, "Name", "Value")
+	e.addRow("", "orphan")
+	if _, err := e.values(); err == nil {
+		t.Errorf("value without key was accepted")
+	}
+}
+
+func TestKeyValueEditorRemoveRow(t *testing.T) {
+	e := newKeyValueEditor(map[string]string{"a": "1", "b": "2"}, "Name", "Value")
+	row := e.rows.Objects[0].(*fyne.Container)
+	fynetest.Tap(row.Objects[1].(*widget.Button))
+
+	values, err := e.values()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(values) != 1 || values["b"] != "2" {
+		t.Errorf("values after removing the first row = %v", values)
+	}
+}
+
+func TestSavePropertiesCopiesInPlace(t *testing.T) {
+	store := memstore.New("bucket")
+	store.Put("assets/app.js", []byte("console.log(1)"))
+
+	fm := newTestFileManager(t, store)
+	ctx := context.Background()
+	headers := s3.ObjectHeaders{ContentType: "application/javascript", CacheControl: "public, max-age=31536000, immutable"}
+	fm.saveProperties(ctx, "assets/app.js", headers, map[string]string{"build": "42"}, map[string]string{"env": "prod"})
+	fm.loads.Wait() // reload after saving
+
+	props, err := store.GetObjectProperties(ctx, "assets/app.js")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if props.Headers != headers || props.Metadata["build"] != "42" || props.Tags["env"] != "prod" {
+		t.Errorf("properties = %+v", props)
+	}
+}
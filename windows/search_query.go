@@ -0,0 +1,550 @@
+package windows
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// searchHelp explains the search syntax to users.
+const searchHelp = `Words are searched for in object keys, ignoring case. Several words must all match.
+
+*.jpg, report-202?-*     glob pattern, matched against the name, or the whole key if it contains a /
+logs/**/*.gz             ** also matches across folders
+/^logs\/\d+$/            regular expression, add i after it to ignore case
+"two words"              exact text, including spaces
+>100MB, size:<=1KiB      size, also size:1MB..10MB or size:0
+modified:<2025-01-01     last modified, also modified:2025-03 or modified:2025-01-01..2025-01-31
+class:glacier            storage class
+ext:jpg,png              file extension
+a OR b, a | b            either one matches
+-tmp, NOT tmp            does not match
+(a OR b) c               parentheses group terms`
+
+// searchNode is a compiled part of a search query.
+type searchNode interface {
+	match(obj *minio.ObjectInfo, lowerKey string) bool
+}
+
+// searchQuery is a compiled search. It is parsed once when the search changes
+// and then matched against every loaded object.
+type searchQuery struct {
+	root  searchNode
+	lower bool // whether nodes need the lowercase key
+}
+
+func (q *searchQuery) match(obj *minio.ObjectInfo) bool {
+	lowerKey := ""
+	if q.lower {
+		lowerKey = strings.ToLower(obj.Key)
+	}
+	return q.root.match(obj, lowerKey)
+}
+
+type andNode []searchNode
+
+func (n andNode) match(obj *minio.ObjectInfo, lowerKey string) bool {
+	for _, c := range n {
+		if !c.match(obj, lowerKey) {
+			return false
+		}
+	}
+	return true
+}
+
+type orNode []searchNode
+
+func (n orNode) match(obj *minio.ObjectInfo, lowerKey string) bool {
+	for _, c := range n {
+		if c.match(obj, lowerKey) {
+			return true
+		}
+	}
+	return false
+}
+
+type notNode struct{ searchNode }
+
+func (n notNode) match(obj *minio.ObjectInfo, lowerKey string) bool {
+	return !n.searchNode.match(obj, lowerKey)
+}
+
+// containsNode matches keys that contain a lowercase text.
+type containsNode string
+
+func (n containsNode) match(_ *minio.ObjectInfo, lowerKey string) bool {
+	return strings.Contains(lowerKey, string(n))
+}
+
+// regexpNode matches the key, or only its last segment when name is set.
+type regexpNode struct {
+	re   *regexp.Regexp
+	name bool
+}
+
+func (n regexpNode) match(obj *minio.ObjectInfo, _ string) bool {
+	if n.name {
+		return n.re.MatchString(path.Base(obj.Key))
+	}
+	return n.re.MatchString(obj.Key)
+}
+
+// interval is the half-open range [lo, hi) of a size or a time in Unix
+// nanoseconds that a value of a search stands for. A day covers all its
+// nanoseconds, so modified:>2025-01-01 starts at the next day.
+type interval struct {
+	lo, hi int64
+}
+
+// rangeNode matches objects whose size or modification time is in
+// [lo, hi).
+type rangeNode struct {
+	lo, hi   int64
+	modified bool
+}
+
+func (n rangeNode) match(obj *minio.ObjectInfo, _ string) bool {
+	v := obj.Size
+	if n.modified {
+		if obj.LastModified.IsZero() {
+			return false
+		}
+		v = obj.LastModified.UnixNano()
+	}
+	return v >= n.lo && v < n.hi
+}
+
+// storageClassNode matches any of the uppercase storage classes.
+type storageClassNode []string
+
+func (n storageClassNode) match(obj *minio.ObjectInfo, _ string) bool {
+	class := strings.ToUpper(obj.StorageClass)
+	if class == "" {
+		class = "STANDARD"
+	}
+	for _, c := range n {
+		if c == class {
+			return true
+		}
+	}
+	return false
+}
+
+// extNode matches any of the lowercase extensions, without dot.
+type extNode []string
+
+func (n extNode) match(_ *minio.ObjectInfo, lowerKey string) bool {
+	ext := strings.TrimPrefix(path.Ext(lowerKey), ".")
+	for _, e := range n {
+		if e == ext {
+			return true
+		}
+	}
+	return false
+}
+
+type searchTokenKind int
+
+const (
+	tokenWord searchTokenKind = iota
+	tokenQuoted
+	tokenRegexp
+	tokenOpen
+	tokenClose
+	tokenNot
+	tokenAnd
+	tokenOr
+)
+
+type searchToken struct {
+	kind searchTokenKind
+	text string
+}
+
+// tokenizeSearch splits a search into words, quoted texts, regular
+// expressions, operators and parentheses. A parenthesis only counts at the
+// start of a word or, inside a group, at its end, so keys like "file(1).txt"
+// can be searched for.
+func tokenizeSearch(s string) ([]searchToken, error) {
+	var tokens []searchToken
+	depth := 0
+	for i := 0; i < len(s); {
+		switch c := s[i]; {
+		case c == ' ' || c == '\t':
+			i++
+		case c == '(':
+			tokens = append(tokens, searchToken{kind: tokenOpen})
+			depth++
+			i++
+		case c == ')' && depth > 0:
+			tokens = append(tokens, searchToken{kind: tokenClose})
+			depth--
+			i++
+		case c == '-' && i+1 < len(s) && s[i+1] != ' ':
+			tokens = append(tokens, searchToken{kind: tokenNot})
+			i++
+		case c == '"':
+			end := strings.IndexByte(s[i+1:], '"')
+			if end < 0 {
+				return nil, errors.New("missing closing quote")
+			}
+			tokens = append(tokens, searchToken{kind: tokenQuoted, text: s[i+1 : i+1+end]})
+			i += end + 2
+		case c == '/':
+			end := closingSlash(s, i+1)
+			if end < 0 {
+				return nil, errors.New("missing / at the end of the regular expression")
+			}
+			pattern := strings.ReplaceAll(s[i+1:end], `\/`, "/")
+			i = end + 1
+			if i < len(s) && s[i] == 'i' {
+				pattern = "(?i)" + pattern
+				i++
+			}
+			tokens = append(tokens, searchToken{kind: tokenRegexp, text: pattern})
+		default:
+			end := i
+			for end < len(s) && s[end] != ' ' && s[end] != '\t' {
+				end++
+			}
+			word := s[i:end]
+			closing := 0
+			for closing < depth && strings.HasSuffix(word, ")") {
+				word = word[:len(word)-1]
+				closing++
+			}
+			switch word {
+			case "AND", "&&":
+				tokens = append(tokens, searchToken{kind: tokenAnd})
+			case "OR", "|", "||":
+				tokens = append(tokens, searchToken{kind: tokenOr})
+			case "NOT":
+				tokens = append(tokens, searchToken{kind: tokenNot})
+			case "":
+			default:
+				tokens = append(tokens, searchToken{kind: tokenWord, text: word})
+			}
+			for range closing {
+				tokens = append(tokens, searchToken{kind: tokenClose})
+			}
+			depth -= closing
+			i = end
+		}
+	}
+	return tokens, nil
+}
+
+// closingSlash returns the index of the first / at or after i that is not
+// escaped, or -1.
+func closingSlash(s string, i int) int {
+	for ; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '/':
+			return i
+		}
+	}
+	return -1
+}
+
+type searchParser struct {
+	tokens []searchToken
+	pos    int
+	lower  bool
+}
+
+// parseSearchQuery compiles a search. An empty search returns nil, which
+// matches everything.
+func parseSearchQuery(text string) (*searchQuery, error) {
+	tokens, err := tokenizeSearch(text)
+	if err != nil || len(tokens) == 0 {
+		return nil, err
+	}
+	p := &searchParser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, errors.New("unexpected )")
+	}
+	return &searchQuery{root: root, lower: p.lower}, nil
+}
+
+func (p *searchParser) peek() (searchToken, bool) {
+	if p.pos >= len(p.tokens) {
+		return searchToken{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+func (p *searchParser) parseOr() (searchNode, error) {
+	var nodes orNode
+	for {
+		node, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+		if t, ok := p.peek(); !ok || t.kind != tokenOr {
+			break
+		}
+		p.pos++
+	}
+	if len(nodes) == 1 {
+		return nodes[0], nil
+	}
+	return nodes, nil
+}
+
+func (p *searchParser) parseAnd() (searchNode, error) {
+	var nodes andNode
+	for {
+		t, ok := p.peek()
+		if !ok || t.kind == tokenOr || t.kind == tokenClose {
+			break
+		}
+		if t.kind == tokenAnd {
+			p.pos++
+			continue
+		}
+		node, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+	switch len(nodes) {
+	case 0:
+		return nil, errors.New("missing search term")
+	case 1:
+		return nodes[0], nil
+	}
+	return nodes, nil
+}
+
+func (p *searchParser) parseUnary() (searchNode, error) {
+	t, ok := p.peek()
+	if !ok {
+		return nil, errors.New("missing search term")
+	}
+	p.pos++
+	switch t.kind {
+	case tokenNot:
+		node, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return notNode{node}, nil
+	case tokenOpen:
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if t, ok := p.peek(); !ok || t.kind != tokenClose {
+			return nil, errors.New("missing )")
+		}
+		p.pos++
+		return node, nil
+	case tokenQuoted:
+		p.lower = true
+		return containsNode(strings.ToLower(t.text)), nil
+	case tokenRegexp:
+		re, err := regexp.Compile(t.text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression: %w", err)
+		}
+		return regexpNode{re: re}, nil
+	case tokenWord:
+		return p.parseWord(t.text)
+	}
+	return nil, errors.New("missing search term")
+}
+
+// parseWord compiles a filter like size:>1MB, a glob pattern or a text.
+func (p *searchParser) parseWord(word string) (searchNode, error) {
+	if strings.ContainsAny(word[:1], "<>=") {
+		return parseRange(word, false)
+	}
+	if field, value, ok := strings.Cut(word, ":"); ok {
+		switch strings.ToLower(field) {
+		case "size":
+			return parseRange(value, false)
+		case "modified", "mtime", "date":
+			return parseRange(value, true)
+		case "class", "storage":
+			var classes storageClassNode
+			for c := range strings.SplitSeq(value, ",") {
+				classes = append(classes, strings.ToUpper(c))
+			}
+			return classes, nil
+		case "ext":
+			p.lower = true
+			var exts extNode
+			for e := range strings.SplitSeq(value, ",") {
+				exts = append(exts, strings.TrimPrefix(strings.ToLower(e), "."))
+			}
+			return exts, nil
+		}
+	}
+	if strings.ContainsAny(word, "*?[") {
+		return compileGlob(word)
+	}
+	p.lower = true
+	return containsNode(strings.ToLower(word)), nil
+}
+
+// compileGlob compiles a glob pattern. Without a /, it is matched against the
+// last segment of the key. * and ? do not match /, ** matches anything.
+func compileGlob(pattern string) (searchNode, error) {
+	var b strings.Builder
+	b.WriteString("(?i)^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			switch {
+			case strings.HasPrefix(pattern[i:], "**/"):
+				b.WriteString("(?:.*/)?")
+				i += 2
+			case strings.HasPrefix(pattern[i:], "**"):
+				b.WriteString(".*")
+				i++
+			default:
+				b.WriteString("[^/]*")
+			}
+		case '?':
+			b.WriteString("[^/]")
+		case '[':
+			end := strings.IndexByte(pattern[i+1:], ']')
+			if end < 0 {
+				return nil, fmt.Errorf("missing ] in %q", pattern)
+			}
+			class := pattern[i+1 : i+1+end]
+			if strings.HasPrefix(class, "!") {
+				class = "^" + class[1:]
+			}
+			b.WriteString("[" + class + "]")
+			i += end + 1
+		default:
+			b.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+	b.WriteString("/?$")
+	re, err := regexp.Compile(b.String())
+	if err != nil {
+		return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+	return regexpNode{re: re, name: !strings.Contains(pattern, "/")}, nil
+}
+
+// parseRange compiles a comparison like >100MB, <=2025-01-01, a range like
+// 1MB..10MB or a single value.
+func parseRange(text string, modified bool) (searchNode, error) {
+	parse := parseSizeInterval
+	if modified {
+		parse = parseTimeInterval
+	}
+
+	if from, to, ok := strings.Cut(text, ".."); ok {
+		lo, err := parse(from)
+		if err != nil {
+			return nil, err
+		}
+		hi, err := parse(to)
+		if err != nil {
+			return nil, err
+		}
+		return rangeNode{lo: lo.lo, hi: hi.hi, modified: modified}, nil
+	}
+
+	op := ""
+	for _, o := range []string{">=", "<=", ">", "<", "="} {
+		if strings.HasPrefix(text, o) {
+			op = o
+			break
+		}
+	}
+	v, err := parse(text[len(op):])
+	if err != nil {
+		return nil, err
+	}
+	n := rangeNode{lo: math.MinInt64, hi: math.MaxInt64, modified: modified}
+	switch op {
+	case ">":
+		n.lo = v.hi
+	case ">=":
+		n.lo = v.lo
+	case "<":
+		n.hi = v.lo
+	case "<=":
+		n.hi = v.hi
+	default:
+		n.lo, n.hi = v.lo, v.hi
+	}
+	return n, nil
+}
+
+// searchSizeUnits are the units sizes can be given in.
+var searchSizeUnits = map[string]float64{
+	"":    1,
+	"b":   1,
+	"k":   1e3,
+	"kb":  1e3,
+	"m":   1e6,
+	"mb":  1e6,
+	"g":   1e9,
+	"gb":  1e9,
+	"t":   1e12,
+	"tb":  1e12,
+	"kib": 1 << 10,
+	"mib": 1 << 20,
+	"gib": 1 << 30,
+	"tib": 1 << 40,
+}
+
+// parseSizeInterval parses a size like 100MB or 1.5GiB.
+func parseSizeInterval(text string) (interval, error) {
+	num := strings.TrimRightFunc(text, func(r rune) bool {
+		return r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z'
+	})
+	unit, ok := searchSizeUnits[strings.ToLower(text[len(num):])]
+	n, err := strconv.ParseFloat(num, 64)
+	if !ok || err != nil || n < 0 {
+		return interval{}, fmt.Errorf("%q is not a size", text)
+	}
+	size := int64(n * unit)
+	return interval{lo: size, hi: size + 1}, nil
+}
+
+// searchTimeLayouts are the layouts dates can be given in and how long the
+// time they stand for lasts.
+var searchTimeLayouts = []struct {
+	layout string
+	next   func(time.Time) time.Time
+}{
+	{"2006-01-02T15:04:05Z07:00", func(t time.Time) time.Time { return t.Add(time.Second) }},
+	{"2006-01-02T15:04:05", func(t time.Time) time.Time { return t.Add(time.Second) }},
+	{"2006-01-02T15:04", func(t time.Time) time.Time { return t.Add(time.Minute) }},
+	{"2006-01-02", func(t time.Time) time.Time { return t.AddDate(0, 0, 1) }},
+	{"2006-01", func(t time.Time) time.Time { return t.AddDate(0, 1, 0) }},
+	{"2006", func(t time.Time) time.Time { return t.AddDate(1, 0, 0) }},
+}
+
+// parseTimeInterval parses a date in local time, from a year down to a
+// second.
+func parseTimeInterval(text string) (interval, error) {
+	for _, l := range searchTimeLayouts {
+		if t, err := time.ParseInLocation(l.layout, text, time.Local); err == nil {
+			return interval{lo: t.UnixNano(), hi: l.next(t).UnixNano()}, nil
+		}
+	}
+	return interval{}, fmt.Errorf("%q is not a date like 2025-01-31", text)
+}
@@ -0,0 +1,122 @@
+package windows
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+)
+
+func searchTestObjects() []minio.ObjectInfo {
+	day := func(y int, m time.Month, d int) time.Time {
+		return time.Date(y, m, d, 12, 0, 0, 0, time.Local)
+	}
+	return []minio.ObjectInfo{
+		{Key: "photos/2024/beach.JPG", Size: 3_500_000, LastModified: day(2024, 7, 14), StorageClass: "STANDARD"},
+		{Key: "photos/2025/city.png", Size: 150_000_000, LastModified: day(2025, 1, 1), StorageClass: "GLACIER"},
+		{Key: "logs/app-2025-01-02.log.gz", Size: 2048, LastModified: day(2025, 1, 2)},
+		{Key: "logs/tmp/debug.log", Size: 0, LastModified: day(2025, 3, 9)},
+		{Key: "docs/file(1).txt", Size: 1024, LastModified: day(2023, 12, 31), StorageClass: "STANDARD_IA"},
+		{Key: "docs/annual report.pdf", Size: 1_000_000, LastModified: day(2025, 3, 10)},
+	}
+}
+
+func TestParseSearchQuery(t *testing.T) {
+	objs := searchTestObjects()
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"LOG", []string{"logs/app-2025-01-02.log.gz", "logs/tmp/debug.log"}},
+		{"logs debug", []string{"logs/tmp/debug.log"}},
+		{"*.jpg", []string{"photos/2024/beach.JPG"}},
+		{"app-????-*", []string{"logs/app-2025-01-02.log.gz"}},
+		{"photos/*", nil},
+		{"photos/**", []string{"photos/2024/beach.JPG", "photos/2025/city.png"}},
+		{"**/*.log", []string{"logs/tmp/debug.log"}},
+		{"[!a-c]*.pdf", nil},
+		{`/^logs\/[a-z]+-\d{4}/`, []string{"logs/app-2025-01-02.log.gz"}},
+		{`/beach\.jpg$/i`, []string{"photos/2024/beach.JPG"}},
+		{`"annual report"`, []string{"docs/annual report.pdf"}},
+		{">100MB", []string{"photos/2025/city.png"}},
+		{"size:<=1KiB", []string{"logs/tmp/debug.log", "docs/file(1).txt"}},
+		{"size:2KB..3.5MB", []string{"photos/2024/beach.JPG", "logs/app-2025-01-02.log.gz", "docs/annual report.pdf"}},
+		{"size:0", []string{"logs/tmp/debug.log"}},
+		{"modified:<2025-01-01", []string{"photos/2024/beach.JPG", "docs/file(1).txt"}},
+		{"modified:>2025-01-01", []string{"logs/app-2025-01-02.log.gz", "logs/tmp/debug.log", "docs/annual report.pdf"}},
+		{"modified:2025-01-01", []string{"photos/2025/city.png"}},
+		{"modified:2025-01..2025-02", []string{"photos/2025/city.png", "logs/app-2025-01-02.log.gz"}},
+		{"modified:<=2023", []string{"docs/file(1).txt"}},
+		{"class:glacier", []string{"photos/2025/city.png"}},
+		{"class:standard", []string{"photos/2024/beach.JPG", "logs/app-2025-01-02.log.gz", "logs/tmp/debug.log", "docs/annual report.pdf"}},
+		{"ext:jpg,.PDF", []string{"photos/2024/beach.JPG", "docs/annual report.pdf"}},
+		{"ext:gz OR ext:txt", []string{"logs/app-2025-01-02.log.gz", "docs/file(1).txt"}},
+		{"photos | docs -pdf", []string{"photos/2024/beach.JPG", "photos/2025/city.png", "docs/file(1).txt"}},
+		{"logs NOT tmp", []string{"logs/app-2025-01-02.log.gz"}},
+		{"(photos OR docs) AND modified:>=2025-01-01", []string{"photos/2025/city.png", "docs/annual report.pdf"}},
+		{"file(1).txt", []string{"docs/file(1).txt"}},
+		{"(file(1).txt)", []string{"docs/file(1).txt"}},
+		{"error:none", nil},
+	}
+	for _, tt := range tests {
+		q, err := parseSearchQuery(tt.query)
+		if err != nil {
+			t.Errorf("parseSearchQuery(%q) = %v", tt.query, err)
+			continue
+		}
+		var got []string
+		for i := range objs {
+			if q.match(&objs[i]) {
+				got = append(got, objs[i].Key)
+			}
+		}
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("%q matches %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestParseSearchQueryErrors(t *testing.T) {
+	if q, err := parseSearchQuery("  "); q != nil || err != nil {
+		t.Errorf("empty search = %v, %v", q, err)
+	}
+	for _, query := range []string{
+		`"unclosed`,
+		"/[a-/",
+		"/unclosed",
+		">big",
+		"size:5XB",
+		"modified:<yesterday",
+		"(a OR b",
+		"a OR",
+		"NOT",
+		"[abc",
+	} {
+		if _, err := parseSearchQuery(query); err == nil {
+			t.Errorf("parseSearchQuery(%q) is valid", query)
+		}
+	}
+}
+
+func TestFilterObjectsAdvancedSearch(t *testing.T) {
+	fm := &FileManager{
+		allObjects:     searchTestObjects(),
+		searchTerm:     "ext:log,gz",
+		selectedPrefix: "logs/tmp/",
+	}
+	if got := keysOf(fm.filterObjectsLocked()); len(got) != 1 || got[0] != "logs/tmp/debug.log" {
+		t.Errorf("filterObjectsLocked() = %v", got)
+	}
+
+	fm.searchTerm = "size:<"
+	if got := fm.filterObjectsLocked(); len(got) != 0 || fm.searchErr == nil {
+		t.Errorf("invalid search: %d objects, error %v", len(got), fm.searchErr)
+	}
+
+	fm.searchTerm = ""
+	fm.selectedPrefix = "all"
+	if got := fm.filterObjectsLocked(); len(got) != len(fm.allObjects) || fm.searchErr != nil {
+		t.Errorf("cleared search: %d objects, error %v", len(got), fm.searchErr)
+	}
+}
@@ -0,0 +1,342 @@
+package windows
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/pteich/us3ui/s3"
+)
+
+const (
+	linkMethodGet = "Download (GET)"
+	linkMethodPut = "Upload (PUT)"
+
+	linkFormatText     = "Plain text"
+	linkFormatCSV      = "CSV"
+	linkFormatMarkdown = "Markdown"
+)
+
+var linkFormats = []string{linkFormatText, linkFormatCSV, linkFormatMarkdown}
+
+// linkFormatExt is the file extension of each export format.
+var linkFormatExt = map[string]string{
+	linkFormatText:     ".txt",
+	linkFormatCSV:      ".csv",
+	linkFormatMarkdown: ".md",
+}
+
+var expiryUnitNames = []string{"minutes", "hours", "days"}
+
+var expiryUnits = map[string]time.Duration{
+	"minutes": time.Minute,
+	"hours":   time.Hour,
+	"days":    24 * time.Hour,
+}
+
+// shareLink is a generated presigned link.
+type shareLink struct {
+	Key     string
+	Method  string
+	Expires time.Time
+	URL     string
+}
+
+// ShareDialog creates presigned download links for a selection of objects,
+// or an upload link for one key, and exports them as text, CSV or Markdown.
+type ShareDialog struct {
+	window    fyne.Window
+	store     s3.ObjectStore
+	ctx       context.Context
+	keys      []string // objects download links are made for
+	uploadKey string   // suggested key of an upload link
+	now       func() time.Time
+
+	methodSelect     *widget.RadioGroup
+	expiryEntry      *widget.Entry
+	unitSelect       *widget.Select
+	attachmentCheck  *widget.Check
+	dispositionEntry *widget.Entry
+	typeEntry        *widget.Entry
+	keyEntry         *widget.Entry
+	objectsLabel     *widget.Label
+	formatSelect     *widget.Select
+	output           *widget.Entry
+	status           *widget.Label
+	links            []shareLink
+}
+
+func NewShareDialog(window fyne.Window, store s3.ObjectStore, ctx context.Context, keys []string, uploadKey string) *ShareDialog {
+	return &ShareDialog{
+		window:    window,
+		store:     store,
+		ctx:       ctx,
+		keys:      keys,
+		uploadKey: uploadKey,
+		now:       time.Now,
+	}
+}
+
+func (sd *ShareDialog) Show() {
+	showOutputDialog(sd.window, "Share Links", sd.createContent(), fyne.NewSize(750, 600), sd.status, sd.outputText, func() string {
+		return "links" + linkFormatExt[sd.formatSelect.Selected]
+	})
+}
+
+func (sd *ShareDialog) createContent() fyne.CanvasObject {
+	sd.objectsLabel = widget.NewLabel(fmt.Sprintf("%d selected objects", len(sd.keys)))
+	if len(sd.keys) == 1 {
+		sd.objectsLabel.SetText(sd.keys[0])
+	}
+	sd.objectsLabel.Truncation = fyne.TextTruncateEllipsis
+	sd.keyEntry = widget.NewEntry()
+	sd.keyEntry.SetText(sd.uploadKey)
+
+	sd.expiryEntry = widget.NewEntry()
+	sd.expiryEntry.SetText("1")
+	sd.unitSelect = widget.NewSelect(expiryUnitNames, nil)
+	sd.unitSelect.SetSelected("hours")
+
+	sd.attachmentCheck = widget.NewCheck("Download as a file named like the object", nil)
+	sd.dispositionEntry = widget.NewEntry()
+	sd.dispositionEntry.SetPlaceHolder(`e.g. attachment; filename="report.pdf"`)
+	sd.typeEntry = widget.NewEntry()
+	sd.typeEntry.SetPlaceHolder("e.g. application/octet-stream")
+
+	sd.methodSelect = widget.NewRadioGroup([]string{linkMethodGet, linkMethodPut}, func(string) { sd.updateFields() })
+	sd.methodSelect.Horizontal = true
+	sd.methodSelect.Required = true
+	if len(sd.keys) == 0 {
+		sd.methodSelect.SetSelected(linkMethodPut)
+	} else {
+		sd.methodSelect.SetSelected(linkMethodGet)
+	}
+
+	form := widget.NewForm(
+		widget.NewFormItem("Link", sd.methodSelect),
+		widget.NewFormItem("Objects", sd.objectsLabel),
+		widget.NewFormItem("Upload key", sd.keyEntry),
+		widget.NewFormItem("Expires after", container.NewBorder(nil, nil, nil, sd.unitSelect, sd.expiryEntry)),
+		widget.NewFormItem("", sd.attachmentCheck),
+		widget.NewFormItem("Content-Disposition", sd.dispositionEntry),
+		widget.NewFormItem("Content-Type", sd.typeEntry),
+	)
+
+	sd.formatSelect = widget.NewSelect(linkFormats, func(string) { sd.showLinks() })
+	sd.formatSelect.SetSelected(linkFormatText)
+	generateBtn := widget.NewButtonWithIcon("Generate", theme.MailSendIcon(), sd.handleGenerate)
+	generateBtn.Importance = widget.HighImportance
+
+	sd.output = widget.NewMultiLineEntry()
+	sd.output.Wrapping = fyne.TextWrapOff
+	sd.status = widget.NewLabel("")
+	sd.status.Wrapping = fyne.TextWrapWord
+
+	sd.updateFields()
+	controls := container.NewHBox(generateBtn, widget.NewLabel("Format"), sd.formatSelect)
+	return container.NewBorder(container.NewVBox(form, controls), sd.status, nil, nil, sd.output)
+}
+
+// updateFields shows the fields that apply to the chosen link method.
+func (sd *ShareDialog) updateFields() {
+	if sd.keyEntry == nil {
+		return
+	}
+	upload := sd.methodSelect.Selected == linkMethodPut
+	for _, w := range []fyne.Disableable{sd.attachmentCheck, sd.dispositionEntry, sd.typeEntry} {
+		if upload {
+			w.Disable()
+		} else {
+			w.Enable()
+		}
+	}
+	if upload {
+		sd.keyEntry.Enable()
+		return
+	}
+	sd.keyEntry.Disable()
+}
+
+// options reads the link settings from the form.
+func (sd *ShareDialog) options() (s3.PresignOptions, error) {
+	n, err := strconv.Atoi(strings.TrimSpace(sd.expiryEntry.Text))
+	if err != nil || n < 1 {
+		return s3.PresignOptions{}, errors.New("enter how long the links stay valid")
+	}
+	opts := s3.PresignOptions{
+		Method:  http.MethodGet,
+		Expires: time.Duration(n) * expiryUnits[sd.unitSelect.Selected],
+	}
+	if sd.methodSelect.Selected == linkMethodPut {
+		opts.Method = http.MethodPut
+	} else {
+		opts.ContentDisposition = strings.TrimSpace(sd.dispositionEntry.Text)
+		opts.ContentType = strings.TrimSpace(sd.typeEntry.Text)
+	}
+	return opts, opts.Validate()
+}
+
+// targets returns the keys to create links for.
+func (sd *ShareDialog) targets() ([]string, error) {
+	if sd.methodSelect.Selected != linkMethodPut {
+		if len(sd.keys) == 0 {
+			return nil, errors.New("no object selected")
+		}
+		return sd.keys, nil
+	}
+	key := strings.TrimSpace(sd.keyEntry.Text)
+	if key == "" || strings.HasSuffix(key, s3.Delimiter) {
+		return nil, errors.New("enter the key the upload link writes to, including the file name")
+	}
+	return []string{key}, nil
+}
+
+func (sd *ShareDialog) handleGenerate() {
+	opts, err := sd.options()
+	if err != nil {
+		dialog.ShowError(err, sd.window)
+		return
+	}
+	keys, err := sd.targets()
+	if err != nil {
+		dialog.ShowError(err, sd.window)
+		return
+	}
+
+	sd.status.SetText(fmt.Sprintf("Generating %d links…", len(keys)))
+	attachment := sd.attachmentCheck.Checked && opts.Method == http.MethodGet
+	go func() {
+		links, failures := sd.generate(sd.ctx, keys, opts, attachment)
+		fyne.Do(func() { sd.setLinks(links, failures) })
+	}()
+}
+
+// generate presigns a link for each key. With attachment set, download links
+// save the object under its own name unless the Content-Disposition is
+// overridden.
+func (sd *ShareDialog) generate(ctx context.Context, keys []string, opts s3.PresignOptions, attachment bool) ([]shareLink, []string) {
+	expires := sd.now().Add(opts.Expires)
+	links := make([]shareLink, 0, len(keys))
+	var failures []string
+	for _, key := range keys {
+		keyOpts := opts
+		if attachment && keyOpts.ContentDisposition == "" {
+			keyOpts.ContentDisposition = attachmentDisposition(path.Base(key))
+		}
+		u, err := sd.store.PresignURL(ctx, key, keyOpts)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", key, err))
+			continue
+		}
+		links = append(links, shareLink{Key: key, Method: opts.Method, Expires: expires, URL: u.String()})
+	}
+	return links, failures
+}
+
+func (sd *ShareDialog) setLinks(links []shareLink, failures []string) {
+	sd.links = links
+	sd.showLinks()
+	status := fmt.Sprintf("%d links, valid until %s.", len(links), linksExpiry(links))
+	if len(failures) > 0 {
+		status = transferSummaryMessage("Generated links for", len(links), len(links)+len(failures), failures)
+	}
+	sd.status.SetText(status)
+}
+
+func (sd *ShareDialog) showLinks() {
+	if sd.output == nil {
+		return
+	}
+	sd.output.SetText(formatLinks(sd.links, sd.formatSelect.Selected))
+}
+
+// outputText returns the generated text, or false if there is none.
+func (sd *ShareDialog) outputText() (string, bool) {
+	return sd.output.Text, len(sd.links) > 0
+}
+
+func linksExpiry(links []shareLink) string {
+	if len(links) == 0 {
+		return "-"
+	}
+	return links[0].Expires.Format("2006-01-02 15:04")
+}
+
+// attachmentDisposition returns a Content-Disposition that saves a download
+// as name, following RFC 6266: browsers use the UTF-8 filename* and older
+// clients the ASCII filename with other characters replaced.
+func attachmentDisposition(name string) string {
+	var fallback, encoded strings.Builder
+	for _, r := range name {
+		switch {
+		case r == '"' || r == '\\':
+			fallback.WriteByte('\\')
+			fallback.WriteRune(r)
+		case r < 0x20 || r >= 0x7f:
+			fallback.WriteByte('_')
+		default:
+			fallback.WriteRune(r)
+		}
+	}
+	for _, c := range []byte(name) {
+		if isAttrChar(c) {
+			encoded.WriteByte(c)
+		} else {
+			fmt.Fprintf(&encoded, "%%%02X", c)
+		}
+	}
+	return fmt.Sprintf(`attachment; filename="%s"; filename*=UTF-8''%s`, fallback.String(), encoded.String())
+}
+
+// isAttrChar reports whether c may appear unencoded in an RFC 8187 value.
+func isAttrChar(c byte) bool {
+	switch {
+	case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		return true
+	}
+	return strings.IndexByte("!#$&+-.^_`|~", c) >= 0
+}
+
+// formatLinks renders links in one of the export formats.
+func formatLinks(links []shareLink, format string) string {
+	var b strings.Builder
+	switch format {
+	case linkFormatCSV:
+		w := csv.NewWriter(&b)
+		_ = w.Write([]string{"key", "method", "expires", "url"})
+		for _, l := range links {
+			_ = w.Write([]string{l.Key, l.Method, l.Expires.Format(time.RFC3339), l.URL})
+		}
+		w.Flush()
+	case linkFormatMarkdown:
+		for _, l := range links {
+			fmt.Fprintf(&b, "- [%s](%s)", markdownEscaper.Replace(l.Key), l.URL)
+			if l.Method == http.MethodPut {
+				b.WriteString(" (upload)")
+			}
+			fmt.Fprintf(&b, " – expires %s\n", l.Expires.Format("2006-01-02 15:04"))
+		}
+	default:
+		for _, l := range links {
+			if len(links) > 1 {
+				b.WriteString(l.Key + "\n")
+			}
+			b.WriteString(l.URL + "\n")
+		}
+	}
+	return b.String()
+}
+
+var markdownEscaper = strings.NewReplacer(`\`, `\\`, `[`, `\[`, `]`, `\]`, `*`, `\*`, `_`, `\_`, "`", "\\`")
@@ -0,0 +1,159 @@
+package windows
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	fynetest "fyne.io/fyne/v2/test"
+
+	"github.com/pteich/us3ui/s3/memstore"
+)
+
+func newTestShareDialog(t *testing.T, keys []string, uploadKey string) *ShareDialog {
+	t.Helper()
+
+	newTestApp(t)
+	sd := NewShareDialog(fynetest.NewWindow(nil), memstore.New("bucket"), context.Background(), keys, uploadKey)
+	sd.now = func() time.Time { return time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC) }
+	sd.createContent()
+	return sd
+}
+
+func TestShareDialogOptions(t *testing.T) {
+	sd := newTestShareDialog(t, []string{"a.txt"}, "a.txt")
+
+	sd.expiryEntry.SetText("7")
+	sd.unitSelect.SetSelected("days")
+	sd.typeEntry.SetText("text/csv")
+	opts, err := sd.options()
+	if err != nil || opts.Method != http.MethodGet || opts.Expires != 7*24*time.Hour || opts.ContentType != "text/csv" {
+		t.Errorf("options = %+v, %v", opts, err)
+	}
+
+	sd.expiryEntry.SetText("8")
+	if _, err := sd.options(); err == nil {
+		t.Errorf("an expiry beyond the signature limit was accepted")
+	}
+	sd.expiryEntry.SetText("soon")
+	if _, err := sd.options(); err == nil {
+		t.Errorf("a non-numeric expiry was accepted")
+	}
+
+	sd.expiryEntry.SetText("30")
+	sd.unitSelect.SetSelected("minutes")
+	sd.methodSelect.SetSelected(linkMethodPut)
+	if !sd.typeEntry.Disabled() || sd.keyEntry.Disabled() {
+		t.Errorf("upload links offer response overrides or no key")
+	}
+	opts, err = sd.options()
+	if err != nil || opts.Method != http.MethodPut || opts.ContentType != "" {
+		t.Errorf("upload options = %+v, %v", opts, err)
+	}
+	sd.keyEntry.SetText("incoming/")
+	if _, err := sd.targets(); err == nil {
+		t.Errorf("an upload link to a folder was accepted")
+	}
+	sd.keyEntry.SetText("incoming/photo.jpg")
+	if keys, err := sd.targets(); err != nil || len(keys) != 1 || keys[0] != "incoming/photo.jpg" {
+		t.Errorf("targets = %v, %v", keys, err)
+	}
+}
+
+func TestShareDialogStartsWithUploadWithoutSelection(t *testing.T) {
+	sd := newTestShareDialog(t, nil, "incoming/")
+	if sd.methodSelect.Selected != linkMethodPut || sd.keyEntry.Text != "incoming/" {
+		t.Errorf("method = %q, key = %q", sd.methodSelect.Selected, sd.keyEntry.Text)
+	}
+}
+
+func TestShareDialogGeneratesLinksForSelection(t *testing.T) {
+	sd := newTestShareDialog(t, []string{"docs/a b.pdf", "docs/c.txt"}, "")
+	sd.attachmentCheck.SetChecked(true)
+	opts, err := sd.options()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	links, failures := sd.generate(context.Background(), sd.keys, opts, true)
+	if len(links) != 2 || len(failures) != 0 {
+		t.Fatalf("links = %+v, failures %v", links, failures)
+	}
+	u, err := url.Parse(links[0].URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	q := u.Query()
+	if q.Get("response-content-disposition") != `attachment; filename="a b.pdf"; filename*=UTF-8''a%20b.pdf` || q.Get("X-Amz-Expires") != "3600" {
+		t.Errorf("link query = %v", q)
+	}
+	if want := time.Date(2026, 10, 17, 13, 0, 0, 0, time.UTC); !links[1].Expires.Equal(want) {
+		t.Errorf("expires = %v, want %v", links[1].Expires, want)
+	}
+
+	sd.setLinks(links, nil)
+	sd.formatSelect.SetSelected(linkFormatCSV)
+	lines := strings.Split(strings.TrimSpace(sd.output.Text), "\n")
+	if len(lines) != 3 || lines[0] != "key,method,expires,url" || !strings.HasPrefix(lines[2], "docs/c.txt,GET,2026-10-17T13:00:00Z,memory://") {
+		t.Errorf("CSV export = %q", sd.output.Text)
+	}
+}
+
+func TestAttachmentDisposition(t *testing.T) {
+	for name, want := range map[string]string{
+		"report.pdf":         `attachment; filename="report.pdf"; filename*=UTF-8''report.pdf`,
+		"Übersicht 2026.csv": `attachment; filename="_bersicht 2026.csv"; filename*=UTF-8''%C3%9Cbersicht%202026.csv`,
+		`say "hi"\.txt`:      `attachment; filename="say \"hi\"\\.txt"; filename*=UTF-8''say%20%22hi%22%5C.txt`,
+		"日本.txt":             `attachment; filename="__.txt"; filename*=UTF-8''%E6%97%A5%E6%9C%AC.txt`,
+	} {
+		if got := attachmentDisposition(name); got != want {
+			t.Errorf("attachmentDisposition(%q) = %s, want %s", name, got, want)
+		}
+	}
+}
+
+func TestFormatLinks(t *testing.T) {
+	expires := time.Date(2026, 10, 17, 13, 0, 0, 0, time.UTC)
+	links := []shareLink{
+		{Key: "a_[1].txt", Method: http.MethodGet, Expires: expires, URL: "https://s3/a"},
+		{Key: "up.bin", Method: http.MethodPut, Expires: expires, URL: "https://s3/up"},
+	}
+
+	want := "- [a\\_\\[1\\].txt](https://s3/a) – expires 2026-10-17 13:00\n" +
+		"- [up.bin](https://s3/up) (upload) – expires 2026-10-17 13:00\n"
+	if got := formatLinks(links, linkFormatMarkdown); got != want {
+		t.Errorf("Markdown = %q, want %q", got, want)
+	}
+	if got := formatLinks(links, linkFormatText); got != "a_[1].txt\nhttps://s3/a\nup.bin\nhttps://s3/up\n" {
+		t.Errorf("text = %q", got)
+	}
+	if got := formatLinks(links[:1], linkFormatText); got != "https://s3/a\n" {
+		t.Errorf("text of one link = %q, want only the URL", got)
+	}
+}
+
+func TestShareDialogCopiesLinks(t *testing.T) {
+	a := newTestApp(t)
+	w := fynetest.NewWindow(nil)
+	sd := NewShareDialog(w, memstore.New("bucket"), context.Background(), []string{"a.txt"}, "")
+	sd.Show()
+
+	tapButton(t, w, "Copy")
+	if got := a.Clipboard().Content(); got != "" || sd.status.Text == "Copied to the clipboard." {
+		t.Errorf("copied %q before links were generated", got)
+	}
+
+	opts, err := sd.options()
+	if err != nil {
+		t.Fatal(err)
+	}
+	links, _ := sd.generate(context.Background(), sd.keys, opts, true)
+	sd.setLinks(links, nil)
+	tapButton(t, w, "Copy")
+	if got := a.Clipboard().Content(); got == "" || got != sd.output.Text {
+		t.Errorf("clipboard = %q, want the links", got)
+	}
+}
@@ -0,0 +1,472 @@
+package windows
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/pteich/us3ui/config"
+	"github.com/pteich/us3ui/s3"
+	"github.com/pteich/us3ui/transfer"
+)
+
+var syncDirectionNames = []string{"This prefix → other side", "Other side → this prefix", "Both ways, newer wins"}
+
+// SyncWindow compares the open prefix with a local directory or a prefix of
+// any bucket, shows the differences as a dry run and applies them.
+type SyncWindow struct {
+	*locations
+	app       fyne.App
+	state     *transfer.StateStore
+	here      paneLocation // the open prefix
+	onChanged func()       // called after the open bucket was changed
+	window    fyne.Window
+
+	syncer  *transfer.Syncer // the last compare
+	items   []transfer.SyncItem
+	shown   []transfer.SyncItem
+	cancel  context.CancelFunc // stops the running compare or sync
+	running sync.WaitGroup
+	closed  bool
+
+	source       *widget.Select
+	bucket       *widget.SelectEntry
+	path         *widget.Entry
+	browseBtn    *widget.Button
+	direction    *widget.RadioGroup
+	deleteCheck  *widget.Check
+	identicalChk *widget.Check
+	table        *widget.Table
+	summary      *widget.Label
+	progress     *widget.ProgressBar
+	compareBtn   *widget.Button
+	applyBtn     *widget.Button
+	cancelBtn    *widget.Button
+}
+
+func NewSyncWindow(a fyne.App, cfg *config.Config, conn config.S3Config, store s3.ObjectStore, openStore func(config.S3Config) (s3.ObjectStore, error), prefix string, onChanged func()) *SyncWindow {
+	return &SyncWindow{
+		locations: newLocations(cfg, conn, store, openStore),
+		app:       a,
+		state:     transfer.NewStateStore(transfer.DefaultStateDir()),
+		here:      paneLocation{connection: currentConnectionLabel, bucket: conn.Bucket, dir: prefix, store: store},
+		onChanged: onChanged,
+	}
+}
+
+func (sw *SyncWindow) Show() {
+	sw.window = sw.app.NewWindow("Sync " + sw.here.String())
+	sw.window.Resize(fyne.NewSize(900, 600))
+	sw.window.SetContent(sw.createContent())
+	sw.window.SetOnClosed(sw.close)
+	sw.window.Show()
+}
+
+func (sw *SyncWindow) createContent() fyne.CanvasObject {
+	sw.bucket = widget.NewSelectEntry(nil)
+	sw.bucket.SetPlaceHolder("Bucket")
+	sw.path = widget.NewEntry()
+	sw.path.SetPlaceHolder("Local directory or prefix")
+	sw.browseBtn = widget.NewButtonWithIcon("", theme.FolderOpenIcon(), sw.browse)
+	sw.bucket.OnChanged = func(string) { sw.planChanged() }
+	sw.path.OnChanged = func(string) { sw.planChanged() }
+
+	sw.source = widget.NewSelect(sw.sources(), func(source string) {
+		sw.planChanged()
+		sw.bucket.SetText("")
+		sw.path.SetText("")
+		if source == localFilesLabel {
+			sw.bucket.Hide()
+			sw.browseBtn.Show()
+			return
+		}
+		sw.bucket.Show()
+		sw.browseBtn.Hide()
+		go sw.loadBuckets(source)
+	})
+	sw.source.SetSelected(localFilesLabel)
+
+	sw.direction = widget.NewRadioGroup(syncDirectionNames, func(string) { sw.planChanged() })
+	sw.direction.Required = true
+	sw.direction.SetSelected(syncDirectionNames[0])
+	sw.deleteCheck = widget.NewCheck("Delete files missing on the side data comes from", func(bool) { sw.planChanged() })
+	sw.identicalChk = widget.NewCheck("Show identical files", func(bool) { sw.showItems() })
+
+	form := widget.NewForm(
+		widget.NewFormItem("This prefix", widget.NewLabel(sw.here.String())),
+		widget.NewFormItem("Other side", container.NewGridWithColumns(2, sw.source, sw.bucket)),
+		widget.NewFormItem("Path", container.NewBorder(nil, nil, nil, sw.browseBtn, sw.path)),
+		widget.NewFormItem("Direction", sw.direction),
+		widget.NewFormItem("", sw.deleteCheck),
+	)
+
+	sw.table = widget.NewTableWithHeaders(
+		func() (int, int) { return len(sw.shown), 4 },
+		func() fyne.CanvasObject {
+			label := widget.NewLabel("")
+			label.Truncation = fyne.TextTruncateEllipsis
+			return label
+		},
+		func(id widget.TableCellID, o fyne.CanvasObject) {
+			label := o.(*widget.Label)
+			if id.Row >= len(sw.shown) {
+				label.SetText("")
+				return
+			}
+			label.SetText(syncCellText(sw.shown[id.Row], id.Col))
+		},
+	)
+	sw.table.ShowHeaderColumn = false
+	sw.table.UpdateHeader = func(id widget.TableCellID, o fyne.CanvasObject) {
+		label := o.(*widget.Label)
+		label.SetText([]string{"Status", "Path", "Action", "Size"}[id.Col])
+	}
+	sw.table.SetColumnWidth(0, 100)
+	sw.table.SetColumnWidth(1, 420)
+	sw.table.SetColumnWidth(2, 190)
+	sw.table.SetColumnWidth(3, sizeColumnWidth+30)
+
+	sw.summary = widget.NewLabel("Pick the other side and compare to see what a sync would change.")
+	sw.summary.Wrapping = fyne.TextWrapWord
+	sw.progress = widget.NewProgressBar()
+	sw.progress.Hide()
+
+	sw.compareBtn = widget.NewButtonWithIcon("Compare", theme.SearchIcon(), sw.handleCompare)
+	sw.applyBtn = widget.NewButtonWithIcon("Sync", theme.MediaPlayIcon(), sw.handleApply)
+	sw.applyBtn.Importance = widget.HighImportance
+	sw.applyBtn.Disable()
+	sw.cancelBtn = widget.NewButtonWithIcon("Cancel", theme.CancelIcon(), sw.stop)
+	sw.cancelBtn.Disable()
+
+	buttons := container.NewHBox(sw.identicalChk, layout.NewSpacer(), sw.cancelBtn, sw.compareBtn, sw.applyBtn)
+	bottom := container.NewVBox(sw.progress, sw.summary, buttons)
+	return container.NewBorder(form, bottom, nil, nil, sw.table)
+}
+
+// syncCellText returns the text of column col for a diff entry.
+func syncCellText(item transfer.SyncItem, col int) string {
+	switch col {
+	case 0:
+		return item.Status.String()
+	case 1:
+		return item.Rel
+	case 2:
+		return syncActionText(item.Action)
+	case 3:
+		if item.Action == transfer.SyncCopyToTarget || item.Action == transfer.SyncCopyToSource {
+			return ByteCountSI(item.Size)
+		}
+	}
+	return ""
+}
+
+// syncActionText names an action from the point of view of the open prefix,
+// which is the source of every sync started here.
+func syncActionText(a transfer.SyncAction) string {
+	switch a {
+	case transfer.SyncCopyToTarget:
+		return "Copy to other side"
+	case transfer.SyncCopyToSource:
+		return "Copy here"
+	case transfer.SyncDeleteTarget:
+		return "Delete on other side"
+	case transfer.SyncDeleteSource:
+		return "Delete here"
+	}
+	return "—"
+}
+
+func (sw *SyncWindow) loadBuckets(source string) {
+	names, configured, err := sw.bucketNames(context.Background(), source)
+	if err != nil {
+		return // the bucket can still be typed in
+	}
+	fyne.Do(func() {
+		if sw.source.Selected != source {
+			return
+		}
+		sw.bucket.SetOptions(names)
+		if sw.bucket.Text == "" {
+			sw.bucket.SetText(configured)
+		}
+	})
+}
+
+func (sw *SyncWindow) browse() {
+	d := dialog.NewFolderOpen(func(dir fyne.ListableURI, err error) {
+		if err != nil || dir == nil {
+			return
+		}
+		sw.path.SetText(dir.Path())
+	}, sw.window)
+	d.Show()
+}
+
+// options returns the sync options picked in the form.
+func (sw *SyncWindow) options() transfer.SyncOptions {
+	opts := transfer.SyncOptions{
+		Delete: sw.deleteCheck.Checked,
+		Filter: transfer.Filter{Exclude: transfer.DefaultExclude},
+	}
+	for i, name := range syncDirectionNames {
+		if sw.direction.Selected == name {
+			opts.Direction = transfer.SyncDirection(i)
+		}
+	}
+	return opts
+}
+
+// planChanged drops a compare that no longer matches the form.
+func (sw *SyncWindow) planChanged() {
+	if sw.syncer == nil {
+		return
+	}
+	sw.syncer = nil
+	sw.items = nil
+	sw.showItems()
+	sw.summary.SetText("The options changed, compare again.")
+	sw.applyBtn.Disable()
+}
+
+func (sw *SyncWindow) handleCompare() {
+	other, err := sw.resolve(sw.source.Selected, strings.TrimSpace(sw.bucket.Text), strings.TrimSpace(sw.path.Text))
+	if err != nil {
+		dialog.ShowError(err, sw.window)
+		return
+	}
+	if other.local() && strings.TrimSpace(sw.path.Text) == "" {
+		dialog.ShowError(errors.New("no local directory given"), sw.window)
+		return
+	}
+	syncer := transfer.NewSyncer(
+		transfer.SyncEndpoint{Store: sw.here.store, Path: sw.here.dir},
+		transfer.SyncEndpoint{Store: other.store, Path: other.dir},
+		sw.options(), sw.state)
+
+	ctx := sw.start("Comparing with " + other.String() + "…")
+	sw.running.Add(1)
+	go func() {
+		defer sw.running.Done()
+		sw.compare(ctx, syncer)
+	}()
+}
+
+// start disables the form while a compare or sync runs and returns its
+// context.
+func (sw *SyncWindow) start(status string) context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	sw.cancel = cancel
+	sw.summary.SetText(status)
+	sw.compareBtn.Disable()
+	sw.applyBtn.Disable()
+	sw.cancelBtn.Enable()
+	return ctx
+}
+
+// finish enables the form again.
+func (sw *SyncWindow) finish() {
+	if sw.cancel != nil {
+		sw.cancel()
+		sw.cancel = nil
+	}
+	sw.compareBtn.Enable()
+	sw.cancelBtn.Disable()
+	sw.progress.Hide()
+	if sw.syncer != nil && syncHasActions(sw.items) {
+		sw.applyBtn.Enable()
+	}
+}
+
+// stop cancels the running compare or sync.
+func (sw *SyncWindow) stop() {
+	if sw.cancel != nil {
+		sw.cancel()
+	}
+}
+
+// close stops the running compare or sync when the window is closed. It
+// does not wait for them, they see closed and end on their own.
+func (sw *SyncWindow) close() {
+	sw.closed = true
+	sw.stop()
+}
+
+// compare builds the dry-run diff and shows it.
+func (sw *SyncWindow) compare(ctx context.Context, syncer *transfer.Syncer) {
+	items, err := syncer.Plan(ctx)
+	fyne.Do(func() {
+		defer sw.finish()
+		if sw.closed {
+			return
+		}
+		if err != nil {
+			sw.summary.SetText("Compare failed.")
+			if !errors.Is(err, context.Canceled) {
+				dialog.ShowError(err, sw.window)
+			}
+			return
+		}
+		sw.syncer = syncer
+		sw.items = items
+		sw.showItems()
+		sw.summary.SetText(syncSummary(items))
+	})
+}
+
+func (sw *SyncWindow) showItems() {
+	sw.shown = sw.shown[:0]
+	for _, item := range sw.items {
+		if item.Status != transfer.SyncIdentical || sw.identicalChk.Checked {
+			sw.shown = append(sw.shown, item)
+		}
+	}
+	sw.table.Refresh()
+	sw.table.ScrollToTop()
+}
+
+func (sw *SyncWindow) handleApply() {
+	if sw.syncer == nil {
+		return
+	}
+	deletions := 0
+	for _, item := range sw.items {
+		if item.Action == transfer.SyncDeleteTarget || item.Action == transfer.SyncDeleteSource {
+			deletions++
+		}
+	}
+	if deletions == 0 {
+		sw.startApply()
+		return
+	}
+	msg := fmt.Sprintf("The sync deletes %d files. Continue?", deletions)
+	dialog.ShowConfirm("Delete Files", msg, func(ok bool) {
+		if ok {
+			sw.startApply()
+		}
+	}, sw.window)
+}
+
+func (sw *SyncWindow) startApply() {
+	ctx := sw.start("Syncing…")
+	sw.progress.SetValue(0)
+	sw.progress.Show()
+	syncer, items := sw.syncer, sw.items
+	sw.running.Add(1)
+	go func() {
+		defer sw.running.Done()
+		sw.apply(ctx, syncer, items)
+	}()
+}
+
+// apply carries out the diff, reports the outcome and compares again to
+// show what is left, unless the sync was canceled or the window closed.
+func (sw *SyncWindow) apply(ctx context.Context, syncer *transfer.Syncer, items []transfer.SyncItem) {
+	result := syncer.Apply(ctx, items, func(p transfer.SyncProgress) {
+		fyne.Do(func() {
+			if p.TotalBytes > 0 {
+				sw.progress.SetValue(float64(p.Bytes) / float64(p.TotalBytes))
+			}
+			sw.summary.SetText(fmt.Sprintf("Syncing %d of %d: %s", p.Done+1, p.Total, p.Rel))
+		})
+	})
+	canceled := ctx.Err() != nil
+
+	fyne.Do(func() {
+		sw.finish()
+		if sw.onChanged != nil && changesSource(items) && (result.Copied > 0 || result.Deleted > 0) {
+			sw.onChanged()
+		}
+		if sw.closed {
+			return
+		}
+		dialog.ShowInformation("Sync", syncResultMessage(result, canceled), sw.window)
+		if canceled {
+			sw.summary.SetText("Sync canceled, compare again to see what is left.")
+			sw.applyBtn.Disable()
+			return
+		}
+		ctx := sw.start("Comparing again…")
+		sw.running.Add(1)
+		go func() {
+			defer sw.running.Done()
+			sw.compare(ctx, syncer)
+		}()
+	})
+}
+
+func syncHasActions(items []transfer.SyncItem) bool {
+	for _, item := range items {
+		if item.Action != transfer.SyncSkip {
+			return true
+		}
+	}
+	return false
+}
+
+// changesSource reports whether items copy to or delete from the source,
+// the open prefix.
+func changesSource(items []transfer.SyncItem) bool {
+	for _, item := range items {
+		if item.Action == transfer.SyncCopyToSource || item.Action == transfer.SyncDeleteSource {
+			return true
+		}
+	}
+	return false
+}
+
+// syncSummary counts the entries of a diff by status and says what a sync
+// would do.
+func syncSummary(items []transfer.SyncItem) string {
+	var counts [4]int
+	copies, deletions := 0, 0
+	var bytes int64
+	for _, item := range items {
+		counts[item.Status]++
+		switch item.Action {
+		case transfer.SyncCopyToTarget, transfer.SyncCopyToSource:
+			copies++
+			bytes += item.Size
+		case transfer.SyncDeleteTarget, transfer.SyncDeleteSource:
+			deletions++
+		}
+	}
+	summary := fmt.Sprintf("%d new, %d changed, %d deleted, %d identical.",
+		counts[transfer.SyncNew], counts[transfer.SyncChanged], counts[transfer.SyncDeleted], counts[transfer.SyncIdentical])
+	if copies == 0 && deletions == 0 {
+		return summary + " Nothing to sync."
+	}
+	summary += fmt.Sprintf(" The sync copies %d files (%s)", copies, ByteCountSI(bytes))
+	if deletions > 0 {
+		summary += fmt.Sprintf(" and deletes %d", deletions)
+	}
+	return summary + "."
+}
+
+// syncResultMessage describes an applied sync.
+func syncResultMessage(result transfer.SyncResult, canceled bool) string {
+	msg := fmt.Sprintf("Copied %d files (%s), deleted %d.", result.Copied, ByteCountSI(result.Bytes), result.Deleted)
+	if canceled {
+		msg = "Sync canceled. " + msg
+	}
+	if len(result.Errors) > 0 {
+		msg += fmt.Sprintf("\n\n%d failed:\n%s", len(result.Errors), strings.Join(firstLines(result.Errors, 10), "\n"))
+	}
+	return msg
+}
+
+// firstLines returns at most n lines and notes how many were left out.
+func firstLines(lines []string, n int) []string {
+	if len(lines) <= n {
+		return lines
+	}
+	return append(append([]string{}, lines[:n]...), fmt.Sprintf("… and %d more", len(lines)-n))
+}
@@ -0,0 +1,123 @@
+package windows
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	fynetest "fyne.io/fyne/v2/test"
+
+	"github.com/pteich/us3ui/config"
+	"github.com/pteich/us3ui/s3"
+	"github.com/pteich/us3ui/s3/memstore"
+	"github.com/pteich/us3ui/transfer"
+)
+
+func newTestSyncWindow(t *testing.T, store *memstore.Store, prefix string) (*SyncWindow, *int) {
+	t.Helper()
+
+	onChange, changed := countCalls()
+	sw := NewSyncWindow(newTestApp(t), nil, config.S3Config{Bucket: "bucket"}, store, func(config.S3Config) (s3.ObjectStore, error) {
+		return store, nil
+	}, prefix, onChange)
+	sw.state = transfer.NewStateStore(t.TempDir())
+	sw.window = fynetest.NewWindow(sw.createContent())
+	t.Cleanup(func() {
+		sw.close()
+		sw.running.Wait()
+	})
+	return sw, changed
+}
+
+func TestSyncWindowComparesThenApplies(t *testing.T) {
+	store := memstore.New("bucket")
+	store.Put("exports/a.csv", []byte("a"))
+	store.Put("exports/sub/b.csv", []byte("b"))
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "a.csv"), "a")
+	writeFile(t, filepath.Join(dir, "stale.csv"), "old")
+
+	sw, changed := newTestSyncWindow(t, store, "exports/")
+	sw.path.SetText(dir)
+	sw.deleteCheck.SetChecked(true)
+
+	sw.handleCompare()
+	sw.running.Wait()
+	if got, want := syncSummary(sw.items), "1 new, 0 changed, 1 deleted, 1 identical."; !strings.HasPrefix(got, want) {
+		t.Errorf("summary = %q, want prefix %q", got, want)
+	}
+	if len(sw.shown) != 2 || sw.applyBtn.Disabled() {
+		t.Fatalf("shown = %+v, apply disabled %v", sw.shown, sw.applyBtn.Disabled())
+	}
+
+	sw.startApply()
+	sw.running.Wait()
+
+	if data, err := os.ReadFile(filepath.Join(dir, "sub", "b.csv")); err != nil || string(data) != "b" {
+		t.Errorf("sub/b.csv = %q, %v", data, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "stale.csv")); !os.IsNotExist(err) {
+		t.Errorf("stale.csv was not deleted: %v", err)
+	}
+	if *changed != 0 {
+		t.Errorf("onChanged called %d times although the bucket was not changed", *changed)
+	}
+	if syncHasActions(sw.items) {
+		t.Errorf("the compare after the sync still has actions: %+v", sw.items)
+	}
+}
+
+func TestSyncWindowDropsStaleCompare(t *testing.T) {
+	store := memstore.New("bucket")
+	store.Put("a.txt", []byte("a"))
+
+	sw, _ := newTestSyncWindow(t, store, "")
+	sw.path.SetText(t.TempDir())
+	sw.handleCompare()
+	sw.running.Wait()
+	if sw.applyBtn.Disabled() {
+		t.Fatal("nothing to apply after the compare")
+	}
+
+	sw.direction.SetSelected(syncDirectionNames[2])
+	if sw.syncer != nil || !sw.applyBtn.Disabled() {
+		t.Errorf("the compare was kept after the direction changed")
+	}
+}
+
+func TestSyncWindowSkipsCompareAfterCancelOrClose(t *testing.T) {
+	store := memstore.New("bucket")
+	store.Put("a.txt", []byte("a"))
+	store.Put("b.txt", []byte("b"))
+
+	sw, _ := newTestSyncWindow(t, store, "")
+	sw.path.SetText(t.TempDir())
+	sw.handleCompare()
+	sw.running.Wait()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	sw.apply(ctx, sw.syncer, sw.items)
+	sw.running.Wait()
+	if sw.summary.Text != "Sync canceled, compare again to see what is left." || !sw.applyBtn.Disabled() {
+		t.Errorf("after a canceled sync: summary %q, apply disabled %v", sw.summary.Text, sw.applyBtn.Disabled())
+	}
+
+	sw.close()
+	sw.apply(context.Background(), sw.syncer, sw.items)
+	sw.running.Wait()
+	if !strings.HasPrefix(sw.summary.Text, "Syncing") || sw.cancel != nil {
+		t.Errorf("compared again after the window was closed: %q", sw.summary.Text)
+	}
+}
+
+func TestSyncResultMessage(t *testing.T) {
+	msg := syncResultMessage(transfer.SyncResult{Copied: 2, Bytes: 2000, Errors: []string{"a.txt: denied"}}, true)
+	for _, want := range []string{"Sync canceled.", "Copied 2 files (2.0 kB)", "1 failed:\na.txt: denied"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("message %q does not contain %q", msg, want)
+		}
+	}
+}
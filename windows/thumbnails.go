@@ -0,0 +1,273 @@
+package windows
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kirsle/configdir"
+	"github.com/minio/minio-go/v7"
+	_ "golang.org/x/image/bmp"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+
+	"github.com/pteich/us3ui/config"
+	"github.com/pteich/us3ui/s3"
+)
+
+const (
+	// thumbnailSize is the longest side of a thumbnail in pixels.
+	thumbnailSize = 128
+	// thumbnailSourceLimit is the largest image thumbnails are made of.
+	thumbnailSourceLimit = 20 << 20
+	// thumbnailPixelLimit is the most pixels an image may have to be decoded.
+	thumbnailPixelLimit = 50_000_000
+	// thumbnailHeaderSize is how much of an image is fetched first to read
+	// its dimensions.
+	thumbnailHeaderSize = 64 << 10
+	// thumbnailCacheLimit is how much disk space cached thumbnails may use.
+	thumbnailCacheLimit = 200 << 20
+	// thumbnailWorkers is how many thumbnails are made at the same time.
+	thumbnailWorkers = 4
+)
+
+// thumbnailExtensions are the image types thumbnails are made of.
+var thumbnailExtensions = map[string]bool{
+	".png":  true,
+	".jpg":  true,
+	".jpeg": true,
+	".gif":  true,
+	".bmp":  true,
+	".webp": true,
+}
+
+// hasThumbnail reports whether a thumbnail can be made of obj.
+func hasThumbnail(obj minio.ObjectInfo) bool {
+	return obj.Size > 0 && obj.Size <= thumbnailSourceLimit &&
+		thumbnailExtensions[strings.ToLower(filepath.Ext(obj.Key))]
+}
+
+// DefaultThumbnailDir returns the per-user directory thumbnails are cached in.
+func DefaultThumbnailDir() string {
+	return configdir.LocalCache(config.Name, "thumbnails")
+}
+
+// ThumbnailCache keeps thumbnails as PNG files in a directory. When the files
+// grow beyond the size limit, the least recently used ones are removed.
+type ThumbnailCache struct {
+	dir      string
+	maxBytes int64
+	mu       sync.Mutex
+}
+
+func NewThumbnailCache(dir string, maxBytes int64) *ThumbnailCache {
+	return &ThumbnailCache{dir: dir, maxBytes: maxBytes}
+}
+
+// thumbnailID names the thumbnail of a version of an object. A changed object
+// gets a new ETag and so a new thumbnail.
+func thumbnailID(endpoint, bucket, key, etag string) string {
+	sum := sha256.Sum256([]byte(strings.Join([]string{endpoint, bucket, key, etag}, "\x00")))
+	return hex.EncodeToString(sum[:16])
+}
+
+func (c *ThumbnailCache) path(id string) string {
+	return filepath.Join(c.dir, id+".png")
+}
+
+// Get returns the cached thumbnail id and marks it as used.
+func (c *ThumbnailCache) Get(id string) ([]byte, bool) {
+	data, err := os.ReadFile(c.path(id))
+	if err != nil {
+		return nil, false
+	}
+	now := time.Now()
+	_ = os.Chtimes(c.path(id), now, now)
+	return data, true
+}
+
+// Put stores thumbnail id and removes old thumbnails if the cache is full.
+func (c *ThumbnailCache) Put(id string, data []byte) error {
+	if err := os.MkdirAll(c.dir, 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(c.dir, id+"-*.tmp")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), c.path(id))
+	}
+	if err != nil {
+		_ = os.Remove(tmp.Name())
+		return err
+	}
+	return c.prune()
+}
+
+// prune removes the least recently used thumbnails until the cache fits its
+// size limit.
+func (c *ThumbnailCache) prune() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entries, err := os.ReadDir(c.dir)
+	if err != nil {
+		return err
+	}
+	var files []fs.FileInfo
+	var total int64
+	for _, e := range entries {
+		if e.IsDir() || filepath.Ext(e.Name()) != ".png" {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		files = append(files, info)
+		total += info.Size()
+	}
+	sort.Slice(files, func(i, j int) bool { return files[i].ModTime().Before(files[j].ModTime()) })
+	for _, f := range files {
+		if total <= c.maxBytes {
+			break
+		}
+		if err := os.Remove(filepath.Join(c.dir, f.Name())); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+		total -= f.Size()
+	}
+	return nil
+}
+
+// Thumbnailer makes thumbnails of the images in a bucket and caches them on
+// disk. Only thumbnailWorkers thumbnails are made at the same time.
+type Thumbnailer struct {
+	store     s3.ObjectStore
+	cache     *ThumbnailCache
+	endpoint  string
+	bucket    string
+	semaphore chan struct{}
+}
+
+func NewThumbnailer(store s3.ObjectStore, cache *ThumbnailCache, endpoint, bucket string) *Thumbnailer {
+	return &Thumbnailer{
+		store:     store,
+		cache:     cache,
+		endpoint:  endpoint,
+		bucket:    bucket,
+		semaphore: make(chan struct{}, thumbnailWorkers),
+	}
+}
+
+// Thumbnail returns the PNG thumbnail of obj from the cache, or fetches the
+// image and makes it. The dimensions are read from the first bytes of the
+// image so that huge images are skipped before they are downloaded.
+func (t *Thumbnailer) Thumbnail(ctx context.Context, obj minio.ObjectInfo) ([]byte, error) {
+	id := thumbnailID(t.endpoint, t.bucket, obj.Key, obj.ETag)
+	if data, ok := t.cache.Get(id); ok {
+		return data, nil
+	}
+	if !hasThumbnail(obj) {
+		return nil, errors.New("no thumbnail for this object")
+	}
+
+	select {
+	case t.semaphore <- struct{}{}:
+		defer func() { <-t.semaphore }()
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+
+	data, err := readRange(ctx, t.store, obj.Key, obj.ETag, 0, min(obj.Size, thumbnailHeaderSize))
+	if err != nil {
+		return nil, err
+	}
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if (errors.Is(err, io.ErrUnexpectedEOF) || errors.Is(err, io.EOF)) && int64(len(data)) < obj.Size {
+		// The dimensions come after more metadata than the header holds,
+		// like a preview embedded in EXIF data.
+		if data, err = t.readRest(ctx, obj, data); err != nil {
+			return nil, err
+		}
+		cfg, _, err = image.DecodeConfig(bytes.NewReader(data))
+	}
+	if err != nil {
+		return nil, err
+	}
+	if int64(cfg.Width)*int64(cfg.Height) > thumbnailPixelLimit {
+		return nil, fmt.Errorf("image of %dx%d pixels is too large", cfg.Width, cfg.Height)
+	}
+
+	if data, err = t.readRest(ctx, obj, data); err != nil {
+		return nil, err
+	}
+	thumb, err := makeThumbnail(data, thumbnailSize)
+	if err != nil {
+		return nil, err
+	}
+	if err := t.cache.Put(id, thumb); err != nil {
+		return nil, err
+	}
+	return thumb, nil
+}
+
+// readRest returns the whole image of obj whose first bytes are data.
+func (t *Thumbnailer) readRest(ctx context.Context, obj minio.ObjectInfo, data []byte) ([]byte, error) {
+	if int64(len(data)) >= obj.Size {
+		return data, nil
+	}
+	rest, err := readRange(ctx, t.store, obj.Key, obj.ETag, int64(len(data)), obj.Size-int64(len(data)))
+	if err != nil {
+		return nil, err
+	}
+	return append(data, rest...), nil
+}
+
+// makeThumbnail scales the image in data to fit a square of size pixels and
+// encodes it as PNG. Smaller images keep their size.
+func makeThumbnail(data []byte, size int) ([]byte, error) {
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	w, h := src.Bounds().Dx(), src.Bounds().Dy()
+	if w == 0 || h == 0 {
+		return nil, errors.New("empty image")
+	}
+	if w > size || h > size {
+		if w >= h {
+			w, h = size, max(1, h*size/w)
+		} else {
+			w, h = max(1, w*size/h), size
+		}
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.ApproxBiLinear.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Src, nil)
+
+	var b bytes.Buffer
+	if err := png.Encode(&b, dst); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
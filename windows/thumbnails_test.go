@@ -0,0 +1,161 @@
+package windows
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/pteich/us3ui/s3/memstore"
+)
+
+func encodePNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	if err := png.Encode(&b, image.NewRGBA(image.Rect(0, 0, w, h))); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+func TestMakeThumbnail(t *testing.T) {
+	for _, tt := range []struct{ w, h, wantW, wantH int }{
+		{400, 200, 128, 64},
+		{100, 1000, 12, 128},
+		{10, 10, 10, 10},
+	} {
+		thumb, err := makeThumbnail(encodePNG(t, tt.w, tt.h), thumbnailSize)
+		if err != nil {
+			t.Fatal(err)
+		}
+		cfg, err := png.DecodeConfig(bytes.NewReader(thumb))
+		if err != nil || cfg.Width != tt.wantW || cfg.Height != tt.wantH {
+			t.Errorf("thumbnail of %dx%d = %dx%d, %v, want %dx%d", tt.w, tt.h, cfg.Width, cfg.Height, err, tt.wantW, tt.wantH)
+		}
+	}
+	if _, err := makeThumbnail([]byte("not an image"), thumbnailSize); err == nil {
+		t.Errorf("made a thumbnail of text")
+	}
+}
+
+func TestThumbnailerCachesOnDisk(t *testing.T) {
+	store := memstore.New("bucket")
+	store.Put("photos/cat.png", encodePNG(t, 300, 300))
+	dir := t.TempDir()
+	th := NewThumbnailer(store, NewThumbnailCache(dir, thumbnailCacheLimit), "https://s3", "bucket")
+	ctx := context.Background()
+
+	info, err := store.StatObject(ctx, "photos/cat.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	thumb, err := th.Thumbnail(ctx, info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	files, _ := filepath.Glob(filepath.Join(dir, "*.png"))
+	if len(files) != 1 {
+		t.Fatalf("cache holds %v", files)
+	}
+
+	// The cached thumbnail is used without fetching the image again.
+	if err := store.DeleteObject(ctx, "photos/cat.png"); err != nil {
+		t.Fatal(err)
+	}
+	if cached, err := th.Thumbnail(ctx, info); err != nil || !bytes.Equal(cached, thumb) {
+		t.Errorf("cached thumbnail = %d bytes, %v", len(cached), err)
+	}
+	info.ETag = "changed"
+	if _, err := th.Thumbnail(ctx, info); err == nil {
+		t.Errorf("a changed object was served from the cache")
+	}
+}
+
+func TestThumbnailerSkipsHugeImages(t *testing.T) {
+	// A PNG that declares 10000x10000 pixels is rejected from its header.
+	header := []byte("\x89PNG\r\n\x1a\n")
+	ihdr := make([]byte, 17)
+	copy(ihdr, "IHDR")
+	binary.BigEndian.PutUint32(ihdr[4:], 10000)
+	binary.BigEndian.PutUint32(ihdr[8:], 10000)
+	ihdr[12], ihdr[13] = 8, 2
+	header = binary.BigEndian.AppendUint32(header, 13)
+	header = append(header, ihdr...)
+	header = binary.BigEndian.AppendUint32(header, crc32.ChecksumIEEE(ihdr))
+
+	store := memstore.New("bucket")
+	store.Put("huge.png", header)
+	th := NewThumbnailer(store, NewThumbnailCache(t.TempDir(), thumbnailCacheLimit), "", "bucket")
+	info, err := store.StatObject(context.Background(), "huge.png")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := th.Thumbnail(context.Background(), info); err == nil {
+		t.Errorf("made a thumbnail of a 100 megapixel image")
+	}
+}
+
+func TestThumbnailCachePrunesLeastRecentlyUsed(t *testing.T) {
+	dir := t.TempDir()
+	cache := NewThumbnailCache(dir, 25)
+	old := time.Now().Add(-time.Hour)
+	for i, id := range []string{"a", "b"} {
+		if err := cache.Put(id, bytes.Repeat([]byte("x"), 10)); err != nil {
+			t.Fatal(err)
+		}
+		stamp := old.Add(time.Duration(i) * time.Minute)
+		if err := os.Chtimes(cache.path(id), stamp, stamp); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// Reading a marks it as used, so b is the oldest.
+	if _, ok := cache.Get("a"); !ok {
+		t.Fatal("a is not cached")
+	}
+	if err := cache.Put("c", bytes.Repeat([]byte("x"), 10)); err != nil {
+		t.Fatal(err)
+	}
+	for id, want := range map[string]bool{"a": true, "b": false, "c": true} {
+		if _, ok := cache.Get(id); ok != want {
+			t.Errorf("%s cached = %v, want %v", id, ok, want)
+		}
+	}
+}
+
+func TestThumbnailerReadsPastLargeMetadata(t *testing.T) {
+	var b bytes.Buffer
+	if err := jpeg.Encode(&b, image.NewRGBA(image.Rect(0, 0, 40, 20)), nil); err != nil {
+		t.Fatal(err)
+	}
+	// Two APP1 segments push the frame header past the first fetch.
+	img := append([]byte(nil), b.Bytes()[:2]...)
+	for range 2 {
+		segment := make([]byte, 4+60_000)
+		segment[0], segment[1] = 0xff, 0xe1
+		binary.BigEndian.PutUint16(segment[2:], uint16(len(segment)-2))
+		img = append(img, segment...)
+	}
+	img = append(img, b.Bytes()[2:]...)
+
+	store := memstore.New("bucket")
+	store.Put("photo.jpg", img)
+	th := NewThumbnailer(store, NewThumbnailCache(t.TempDir(), thumbnailCacheLimit), "", "bucket")
+	info, err := store.StatObject(context.Background(), "photo.jpg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	thumb, err := th.Thumbnail(context.Background(), info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg, err := png.DecodeConfig(bytes.NewReader(thumb)); err != nil || cfg.Width != 40 || cfg.Height != 20 {
+		t.Errorf("thumbnail = %dx%d, %v", cfg.Width, cfg.Height, err)
+	}
+}
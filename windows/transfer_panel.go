@@ -0,0 +1,277 @@
+package windows
+
+import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/pteich/us3ui/config"
+	"github.com/pteich/us3ui/transfer"
+)
+
+var transferWorkerOptions = []string{"1", "2", "3", "4", "6", "8"}
+
+// TransferPanel lists queued, running and finished transfers and lets the
+// user pause, resume, cancel and retry them.
+type TransferPanel struct {
+	app       fyne.App
+	cfg       *config.Config
+	transfers *transfer.Manager
+	window    fyne.Window
+
+	jobs        []transfer.JobInfo
+	list        *widget.List
+	summary     *widget.Label
+	unsubscribe func()
+}
+
+func NewTransferPanel(a fyne.App, cfg *config.Config, transfers *transfer.Manager) *TransferPanel {
+	return &TransferPanel{
+		app:       a,
+		cfg:       cfg,
+		transfers: transfers,
+	}
+}
+
+// Show opens the transfer window, or brings it to the front if it is open
+// already.
+func (tp *TransferPanel) Show() {
+	if tp.window != nil {
+		tp.window.RequestFocus()
+		return
+	}
+
+	tp.window = tp.app.NewWindow("Transfers")
+	tp.window.Resize(fyne.NewSize(700, 400))
+
+	tp.list = widget.NewList(
+		func() int { return len(tp.jobs) },
+		tp.createRow,
+		func(i widget.ListItemID, o fyne.CanvasObject) {
+			if i < len(tp.jobs) {
+				tp.updateRow(tp.jobs[i], o)
+			}
+		},
+	)
+
+	tp.summary = widget.NewLabel("")
+
+	workers := widget.NewSelect(transferWorkerOptions, nil)
+	workers.SetSelected(strconv.Itoa(tp.transfers.Workers()))
+	workers.OnChanged = tp.setWorkers
+
+	cancelAllBtn := widget.NewButtonWithIcon("Cancel All", theme.CancelIcon(), func() {
+		dialog.ShowConfirm("Cancel Transfers", "Do you really want to cancel all unfinished transfers?", func(yes bool) {
+			if yes {
+				tp.transfers.CancelAll()
+			}
+		}, tp.window)
+	})
+
+	clearBtn := widget.NewButtonWithIcon("Clear Finished", theme.ContentClearIcon(), tp.transfers.ClearFinished)
+
+	toolbar := container.NewHBox(
+		widget.NewLabel("Parallel transfers:"),
+		workers,
+		layout.NewSpacer(),
+		clearBtn,
+		cancelAllBtn,
+	)
+
+	tp.window.SetContent(container.NewBorder(toolbar, container.NewPadded(tp.summary), nil, nil, tp.list))
+
+	tp.unsubscribe = tp.transfers.Subscribe(func() {
+		fyne.Do(tp.refresh)
+	})
+	tp.window.SetOnClosed(func() {
+		tp.unsubscribe()
+		tp.window = nil
+	})
+
+	tp.refresh()
+	tp.window.Show()
+}
+
+func (tp *TransferPanel) setWorkers(value string) {
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return
+	}
+	tp.transfers.SetWorkers(n)
+
+	if tp.cfg == nil || tp.cfg.Settings.TransferWorkers == n {
+		return
+	}
+	tp.cfg.Settings.TransferWorkers = n
+	if err := tp.cfg.Save(); err != nil {
+		dialog.ShowError(err, tp.window)
+	}
+}
+
+func (tp *TransferPanel) refresh() {
+	if tp.window == nil {
+		return
+	}
+	tp.jobs = tp.transfers.Jobs()
+	tp.list.Refresh()
+
+	queued, running := tp.transfers.Counts()
+	tp.summary.SetText(fmt.Sprintf("%d running, %d queued, %d total", running, queued, len(tp.jobs)))
+}
+
+func (tp *TransferPanel) createRow() fyne.CanvasObject {
+	name := widget.NewLabel("")
+	name.Truncation = fyne.TextTruncateEllipsis
+	status := widget.NewLabel("")
+	status.Truncation = fyne.TextTruncateEllipsis
+	progress := widget.NewProgressBar()
+
+	pauseBtn := widget.NewButtonWithIcon("", theme.MediaPauseIcon(), nil)
+	retryBtn := widget.NewButtonWithIcon("", theme.ViewRefreshIcon(), nil)
+	cancelBtn := widget.NewButtonWithIcon("", theme.CancelIcon(), nil)
+
+	return container.NewBorder(nil, nil, nil,
+		container.NewHBox(pauseBtn, retryBtn, cancelBtn),
+		container.NewVBox(name, progress, status),
+	)
+}
+
+func (tp *TransferPanel) updateRow(info transfer.JobInfo, o fyne.CanvasObject) {
+	row := o.(*fyne.Container)
+	content := row.Objects[0].(*fyne.Container)
+	buttons := row.Objects[1].(*fyne.Container)
+
+	content.Objects[0].(*widget.Label).SetText(fmt.Sprintf("%s %s", info.Kind, info.Name))
+	content.Objects[1].(*widget.ProgressBar).SetValue(info.Progress.Fraction())
+	content.Objects[2].(*widget.Label).SetText(transferStatusText(info))
+
+	pauseBtn := buttons.Objects[0].(*widget.Button)
+	retryBtn := buttons.Objects[1].(*widget.Button)
+	cancelBtn := buttons.Objects[2].(*widget.Button)
+
+	id := info.ID
+	switch info.Status {
+	case transfer.StatusPaused:
+		pauseBtn.SetIcon(theme.MediaPlayIcon())
+		pauseBtn.OnTapped = func() { tp.transfers.Resume(id) }
+		pauseBtn.Enable()
+	case transfer.StatusQueued, transfer.StatusRunning:
+		pauseBtn.SetIcon(theme.MediaPauseIcon())
+		pauseBtn.OnTapped = func() { tp.transfers.Pause(id) }
+		pauseBtn.Enable()
+	default:
+		pauseBtn.SetIcon(theme.MediaPauseIcon())
+		pauseBtn.OnTapped = nil
+		pauseBtn.Disable()
+	}
+
+	retryBtn.OnTapped = func() { tp.transfers.Retry(id) }
+	if info.Status == transfer.StatusFailed || info.Status == transfer.StatusCanceled {
+		retryBtn.Enable()
+	} else {
+		retryBtn.Disable()
+	}
+
+	cancelBtn.OnTapped = func() { tp.transfers.Cancel(id) }
+	if info.Status.Finished() {
+		cancelBtn.Disable()
+	} else {
+		cancelBtn.Enable()
+	}
+}
+
+// transferStatusText describes the state of a transfer for the status line
+// below its progress bar.
+func transferStatusText(info transfer.JobInfo) string {
+	switch info.Status {
+	case transfer.StatusRunning:
+		text := progressText(fmt.Sprintf("%s of %s", ByteCountSI(info.Progress.Bytes), ByteCountSI(info.Progress.Total)), info.Progress)
+		if info.Speed > 0 {
+			text += fmt.Sprintf(", %s/s", ByteCountSI(int64(info.Speed)))
+		}
+		if info.ETA > 0 {
+			text += fmt.Sprintf(", %s left", info.ETA.Round(time.Second))
+		}
+		return text
+	case transfer.StatusPaused:
+		return fmt.Sprintf("Paused at %.1f%%", info.Progress.Fraction()*100)
+	case transfer.StatusFailed:
+		return fmt.Sprintf("Failed: %v", info.Err)
+	case transfer.StatusDone:
+		return fmt.Sprintf("Done, %s", ByteCountSI(info.Progress.Total))
+	default:
+		return info.Status.String()
+	}
+}
+
+// transferBatch collects the results of transfers queued together so one
+// summary can be shown once all of them have finished. pending must be set to
+// the number of tracked transfers before the first one is queued.
+type transferBatch struct {
+	mu        sync.Mutex
+	pending   int
+	names     []string
+	results   map[int]error
+	done      bool
+	onDone    func(completed int, failures []string)
+	onRetried func() // called when a transfer succeeds after the summary was shown
+}
+
+// track registers a transfer and returns its OnFinish hook.
+func (b *transferBatch) track(name string) func(error) {
+	b.mu.Lock()
+	i := len(b.names)
+	b.names = append(b.names, name)
+	b.mu.Unlock()
+
+	return func(err error) {
+		b.mu.Lock()
+		if b.done {
+			b.mu.Unlock()
+			if err == nil && b.onRetried != nil {
+				b.onRetried()
+			}
+			return
+		}
+
+		if b.results == nil {
+			b.results = make(map[int]error)
+		}
+		b.results[i] = err
+		if len(b.results) < b.pending {
+			b.mu.Unlock()
+			return
+		}
+
+		b.done = true
+		completed := 0
+		var failures []string
+		for j, name := range b.names {
+			if err := b.results[j]; err != nil {
+				failures = append(failures, fmt.Sprintf("%s: %v", name, err))
+				continue
+			}
+			completed++
+		}
+		b.mu.Unlock()
+
+		if b.onDone != nil {
+			b.onDone(completed, failures)
+		}
+	}
+}
+
+func retryHint(failures []string) string {
+	if len(failures) == 0 {
+		return ""
+	}
+	return "\n\nFailed transfers can be retried from the Transfers window."
+}
@@ -0,0 +1,83 @@
+package windows
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pteich/us3ui/transfer"
+)
+
+func TestTransferStatusText(t *testing.T) {
+	tests := []struct {
+		name string
+		info transfer.JobInfo
+		want string
+	}{
+		{
+			name: "running",
+			info: transfer.JobInfo{
+				Status:   transfer.StatusRunning,
+				Progress: transfer.Progress{Bytes: 500_000, Total: 2_000_000},
+				Speed:    250_000,
+				ETA:      6 * time.Second,
+			},
+			want: "500.0 kB of 2.0 MB: 25.0%, 250.0 kB/s, 6s left",
+		},
+		{
+			name: "paused",
+			info: transfer.JobInfo{Status: transfer.StatusPaused, Progress: transfer.Progress{Bytes: 1, Total: 4}},
+			want: "Paused at 25.0%",
+		},
+		{
+			name: "failed",
+			info: transfer.JobInfo{Status: transfer.StatusFailed, Err: errors.New("access denied")},
+			want: "Failed: access denied",
+		},
+		{
+			name: "queued",
+			info: transfer.JobInfo{Status: transfer.StatusQueued},
+			want: "Queued",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := transferStatusText(tt.info); got != tt.want {
+				t.Errorf("transferStatusText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTransferBatchReportsOnceAllFinished(t *testing.T) {
+	var completed int
+	var failures []string
+	calls := 0
+	retried := 0
+
+	b := &transferBatch{
+		pending: 2,
+		onDone: func(c int, f []string) {
+			calls++
+			completed, failures = c, f
+		},
+		onRetried: func() { retried++ },
+	}
+	first := b.track("a.txt")
+	second := b.track("b.txt")
+
+	second(errors.New("boom"))
+	if calls != 0 {
+		t.Fatalf("onDone called before all transfers finished")
+	}
+	first(nil)
+	if calls != 1 || completed != 1 || len(failures) != 1 || failures[0] != "b.txt: boom" {
+		t.Fatalf("onDone(%d, %v) after %d calls", completed, failures, calls)
+	}
+
+	second(nil)
+	if calls != 1 || retried != 1 {
+		t.Errorf("calls = %d, retried = %d after successful retry", calls, retried)
+	}
+}
@@ -0,0 +1,175 @@
+package windows
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"github.com/minio/minio-go/v7"
+
+	"github.com/pteich/us3ui/config"
+	"github.com/pteich/us3ui/s3"
+)
+
+const (
+	// trashUndoPeriod is how long the Undo button is offered after a delete.
+	trashUndoPeriod = time.Minute
+	// trashStampLayout names the trash subfolder of each delete.
+	trashStampLayout = "20060102-150405"
+)
+
+// trashBatch records the objects one delete moved to the trash so that the
+// delete can be undone.
+type trashBatch struct {
+	folder    string   // the trash folder of the connection
+	stamp     string   // subfolder of this delete below folder
+	versioned bool     // deleted with delete markers instead of moved
+	keys      []string // original keys of the trashed objects
+}
+
+// newTrashBatch prepares a delete to the trash of conn. Versioned buckets
+// keep deleted objects as versions, so their objects are deleted in place.
+func newTrashBatch(ctx context.Context, store s3.ObjectStore, conn config.S3Config, now time.Time) *trashBatch {
+	versioning, err := store.GetBucketVersioning(ctx, conn.Bucket)
+	return &trashBatch{
+		folder:    conn.TrashFolder(),
+		stamp:     now.UTC().Format(trashStampLayout),
+		versioned: err == nil && versioning == s3.VersioningEnabled,
+	}
+}
+
+// trashKey returns the key key is moved to.
+func (b *trashBatch) trashKey(key string) string {
+	return b.folder + b.stamp + s3.Delimiter + key
+}
+
+// inTrash reports whether key is in the trash already. Such objects are
+// deleted for good.
+func (b *trashBatch) inTrash(key string) bool {
+	return strings.HasPrefix(key, b.folder)
+}
+
+// restore puts the trashed objects back at their original keys.
+func (b *trashBatch) restore(ctx context.Context, store s3.ObjectStore) (restored int, failures []string) {
+	for _, key := range b.keys {
+		var err error
+		if b.versioned {
+			err = undeleteObject(ctx, store, key)
+		} else {
+			err = restoreTrashed(ctx, store, b.trashKey(key), key)
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			failures = append(failures, fmt.Sprintf("%s: %v", key, err))
+			continue
+		}
+		restored++
+	}
+	return restored, failures
+}
+
+// undeleteObject removes the delete marker that hides the latest version of
+// key.
+func undeleteObject(ctx context.Context, store s3.ObjectStore, key string) error {
+	versions, err := store.ListObjectVersions(ctx, key)
+	if err != nil {
+		return err
+	}
+	if len(versions) == 0 || !versions[0].IsDeleteMarker {
+		return errors.New("not deleted")
+	}
+	return store.DeleteObjectVersion(ctx, key, versions[0].VersionID)
+}
+
+// restoreTrashed moves the trashed object from back to key unless another
+// object took its place in the meantime.
+func restoreTrashed(ctx context.Context, store s3.ObjectStore, from, key string) error {
+	_, err := store.StatObject(ctx, key)
+	switch {
+	case err == nil:
+		return errors.New("another object exists at this key")
+	case minio.ToErrorResponse(err).Code != "NoSuchKey":
+		return err
+	}
+	if err := store.CopyObject(ctx, from, key); err != nil {
+		return err
+	}
+	return store.DeleteObject(ctx, from)
+}
+
+// trashEntry is an object in the trash folder.
+type trashEntry struct {
+	Key      string // key in the trash
+	Original string // key it was deleted from
+	Deleted  time.Time
+	Size     int64
+}
+
+// parseTrashEntry reads the original key and delete time from the key of obj
+// below folder.
+func parseTrashEntry(folder string, obj minio.ObjectInfo) (trashEntry, bool) {
+	stamp, original, ok := strings.Cut(strings.TrimPrefix(obj.Key, folder), s3.Delimiter)
+	if !ok || original == "" || !strings.HasPrefix(obj.Key, folder) {
+		return trashEntry{}, false
+	}
+	deleted, err := time.Parse(trashStampLayout, stamp)
+	if err != nil {
+		return trashEntry{}, false
+	}
+	return trashEntry{Key: obj.Key, Original: original, Deleted: deleted, Size: obj.Size}, true
+}
+
+// offerUndo shows the Undo button for batch until trashUndoPeriod passed or
+// another delete replaced it.
+func (fm *FileManager) offerUndo(batch *trashBatch) {
+	if batch == nil || len(batch.keys) == 0 {
+		return
+	}
+	fm.lastTrash = batch
+	fm.undoBtn.SetText(fmt.Sprintf("Undo Delete (%d)", len(batch.keys)))
+	fm.undoBtn.Show()
+	time.AfterFunc(trashUndoPeriod, func() {
+		fyne.Do(func() {
+			if fm.lastTrash == batch {
+				fm.lastTrash = nil
+				fm.undoBtn.Hide()
+			}
+		})
+	})
+}
+
+// handleUndoDelete puts back the objects of the last delete.
+func (fm *FileManager) handleUndoDelete() {
+	batch := fm.lastTrash
+	if batch == nil || fm.context == nil {
+		return
+	}
+	fm.lastTrash = nil
+	fm.undoBtn.Hide()
+
+	ctx, basePrefix := fm.context, fm.basePrefix
+	go func() {
+		restored, failures := batch.restore(ctx, fm.s3svc)
+		fyne.Do(func() {
+			if len(failures) > 0 {
+				dialog.ShowInformation("Undo Delete", transferSummaryMessage("Restored", restored, len(batch.keys), failures), fm.window)
+			}
+			fm.reload(ctx, basePrefix, batch.keys...)
+		})
+	}()
+}
+
+// handleTrash opens the trash of the connection.
+func (fm *FileManager) handleTrash() {
+	NewTrashWindow(fm.app, fm.s3svc, fm.conn, func() {
+		if fm.context != nil {
+			fm.reload(fm.context, fm.basePrefix)
+		}
+	}).Show()
+}
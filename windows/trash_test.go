@@ -0,0 +1,146 @@
+package windows
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	fynetest "fyne.io/fyne/v2/test"
+	"github.com/minio/minio-go/v7"
+
+	"github.com/pteich/us3ui/config"
+	"github.com/pteich/us3ui/s3/memstore"
+)
+
+func TestTrashModeMovesObjectsAndUndoRestoresThem(t *testing.T) {
+	store := memstore.New("bucket")
+	store.Put("docs/a.txt", []byte("a"))
+	store.Put("docs/b.txt", []byte("b"))
+	store.Put(".trash/20260101-000000/old.txt", []byte("old"))
+
+	fm := newTestFileManager(t, store)
+	fm.conn = config.S3Config{Bucket: "bucket", Trash: true}
+	loadAll(t, fm, 0, "")
+
+	now := time.Date(2026, 10, 17, 9, 30, 0, 0, time.UTC)
+	trash := newTrashBatch(context.Background(), store, fm.conn, now)
+	if trash.versioned {
+		t.Fatalf("unversioned bucket treated as versioned")
+	}
+	keys := []string{"docs/a.txt", "docs/b.txt", ".trash/20260101-000000/old.txt"}
+	result := fm.deleteObjects(context.Background(), keys, trash, nil)
+	if result.deleted != 3 || len(result.failures) != 0 {
+		t.Fatalf("result = %+v", result)
+	}
+
+	if data, ok := store.Get(".trash/20261017-093000/docs/a.txt"); !ok || string(data) != "a" {
+		t.Errorf("trashed docs/a.txt = %q, %v", data, ok)
+	}
+	if _, ok := store.Get(".trash/20261017-093000/.trash/20260101-000000/old.txt"); ok {
+		t.Errorf("an object in the trash was trashed again")
+	}
+	if _, ok := store.Get(".trash/20260101-000000/old.txt"); ok {
+		t.Errorf("deleting from the trash kept the object")
+	}
+	assertStringSet(t, trash.keys, []string{"docs/a.txt", "docs/b.txt"})
+
+	fm.offerUndo(trash)
+	if fm.undoBtn.Hidden || fm.lastTrash != trash {
+		t.Fatalf("Undo is not offered after the delete")
+	}
+	restored, failures := trash.restore(context.Background(), store)
+	if restored != 2 || len(failures) != 0 {
+		t.Fatalf("restored %d, failures %v", restored, failures)
+	}
+	if data, ok := store.Get("docs/b.txt"); !ok || string(data) != "b" {
+		t.Errorf("restored docs/b.txt = %q, %v", data, ok)
+	}
+	if _, ok := store.Get(".trash/20261017-093000/docs/b.txt"); ok {
+		t.Errorf("restored object is still in the trash")
+	}
+}
+
+func TestTrashModeUsesDeleteMarkersInVersionedBuckets(t *testing.T) {
+	store := memstore.New("bucket")
+	if err := store.SetBucketVersioning(context.Background(), "bucket", true); err != nil {
+		t.Fatal(err)
+	}
+	store.Put("report.csv", []byte("1,2"))
+
+	fm := newTestFileManager(t, store)
+	fm.conn = config.S3Config{Bucket: "bucket", Trash: true}
+	loadAll(t, fm, 0, "")
+
+	trash := newTrashBatch(context.Background(), store, fm.conn, time.Now())
+	if !trash.versioned {
+		t.Fatalf("versioned bucket not detected")
+	}
+	fm.deleteObjects(context.Background(), []string{"report.csv"}, trash, nil)
+	if _, ok := store.Get("report.csv"); ok {
+		t.Fatalf("report.csv still visible after the delete")
+	}
+	if objects, _ := listAllObjects(context.Background(), store, ""); len(objects) != 0 {
+		t.Errorf("versioned delete wrote to the trash folder: %v", keysOf(objects))
+	}
+
+	if restored, failures := trash.restore(context.Background(), store); restored != 1 || len(failures) != 0 {
+		t.Fatalf("restored %d, failures %v", restored, failures)
+	}
+	if data, ok := store.Get("report.csv"); !ok || string(data) != "1,2" {
+		t.Errorf("report.csv after undo = %q, %v", data, ok)
+	}
+}
+
+func TestRestoreTrashedKeepsNewerObject(t *testing.T) {
+	store := memstore.New("bucket")
+	store.Put(".trash/20260101-000000/a.txt", []byte("old"))
+	store.Put("a.txt", []byte("new"))
+
+	if err := restoreTrashed(context.Background(), store, ".trash/20260101-000000/a.txt", "a.txt"); err == nil {
+		t.Errorf("restore replaced an existing object")
+	}
+	if data, _ := store.Get("a.txt"); string(data) != "new" {
+		t.Errorf("a.txt = %q", data)
+	}
+}
+
+func TestParseTrashEntry(t *testing.T) {
+	e, ok := parseTrashEntry(".trash/", minio.ObjectInfo{Key: ".trash/20261017-093000/docs/a.txt", Size: 3})
+	want := time.Date(2026, 10, 17, 9, 30, 0, 0, time.UTC)
+	if !ok || e.Original != "docs/a.txt" || !e.Deleted.Equal(want) || e.Size != 3 {
+		t.Errorf("parseTrashEntry = %+v, %v", e, ok)
+	}
+	for _, key := range []string{".trash/notes.txt", ".trash/yesterday/a.txt", ".trash/20261017-093000/", "other/20261017-093000/a.txt"} {
+		if _, ok := parseTrashEntry(".trash/", minio.ObjectInfo{Key: key}); ok {
+			t.Errorf("parseTrashEntry accepted %s", key)
+		}
+	}
+}
+
+func TestTrashWindowRestoresAndEmpties(t *testing.T) {
+	store := memstore.New("bucket")
+	store.Put("bin/20261016-080000/a.txt", []byte("a"))
+	store.Put("bin/20261017-080000/b.txt", []byte("b"))
+
+	onChange, changed := countCalls()
+	tw := NewTrashWindow(newTestApp(t), store, config.S3Config{Bucket: "bucket", TrashPrefix: "bin"}, onChange)
+	tw.window = fynetest.NewWindow(tw.createContent())
+
+	tw.loadEntries(context.Background())
+	if len(tw.entries) != 2 || tw.entries[0].Original != "b.txt" {
+		t.Fatalf("entries = %+v, want newest first", tw.entries)
+	}
+
+	tw.restore(context.Background(), tw.entries[1])
+	if _, ok := store.Get("a.txt"); !ok || *changed != 1 || len(tw.entries) != 1 {
+		t.Errorf("a.txt was not restored, %d entries left", len(tw.entries))
+	}
+
+	tw.purge(context.Background(), []string{tw.entries[0].Key})
+	if len(tw.entries) != 0 || !tw.emptyBtn.Disabled() {
+		t.Errorf("entries after purge = %+v", tw.entries)
+	}
+	if _, ok := store.Get("bin/20261017-080000/b.txt"); ok {
+		t.Errorf("purged object is still stored")
+	}
+}
@@ -0,0 +1,237 @@
+package windows
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/pteich/us3ui/config"
+	"github.com/pteich/us3ui/s3"
+)
+
+// TrashWindow lists the objects in the trash folder of a connection and lets
+// the user restore them or delete them for good.
+type TrashWindow struct {
+	app       fyne.App
+	s3Service s3.ObjectStore
+	conn      config.S3Config
+	onChanged func() // called after objects were restored
+	window    fyne.Window
+
+	entries    []trashEntry
+	selectedID int
+	table      *widget.Table
+	status     *widget.Label
+	restoreBtn *widget.Button
+	purgeBtn   *widget.Button
+	emptyBtn   *widget.Button
+}
+
+func NewTrashWindow(a fyne.App, service s3.ObjectStore, conn config.S3Config, onChanged func()) *TrashWindow {
+	return &TrashWindow{
+		app:        a,
+		s3Service:  service,
+		conn:       conn,
+		onChanged:  onChanged,
+		selectedID: -1,
+	}
+}
+
+func (tw *TrashWindow) Show() {
+	tw.window = tw.app.NewWindow("Trash of " + tw.conn.Bucket)
+	tw.window.Resize(fyne.NewSize(800, 450))
+	tw.window.SetContent(tw.createContent())
+	tw.window.Show()
+
+	go tw.loadEntries(context.Background())
+}
+
+func (tw *TrashWindow) createContent() fyne.CanvasObject {
+	tw.table = widget.NewTableWithHeaders(
+		func() (int, int) { return len(tw.entries), 3 },
+		newTruncatedLabel,
+		func(id widget.TableCellID, o fyne.CanvasObject) {
+			label := o.(*widget.Label)
+			if id.Row >= len(tw.entries) {
+				label.SetText("")
+				return
+			}
+			label.SetText(trashCellText(tw.entries[id.Row], id.Col))
+		},
+	)
+	tw.table.ShowHeaderColumn = false
+	tw.table.UpdateHeader = func(id widget.TableCellID, o fyne.CanvasObject) {
+		o.(*widget.Label).SetText([]string{"Original Key", "Deleted", "Size"}[id.Col])
+	}
+	tw.table.SetColumnWidth(0, 450)
+	tw.table.SetColumnWidth(1, dateColumnWidth)
+	tw.table.SetColumnWidth(2, sizeColumnWidth+30)
+	tw.table.OnSelected = func(id widget.TableCellID) {
+		tw.selectedID = id.Row
+		tw.updateButtons()
+	}
+	tw.table.OnUnselected = func(id widget.TableCellID) {
+		tw.selectedID = -1
+		tw.updateButtons()
+	}
+
+	tw.restoreBtn = widget.NewButtonWithIcon("Restore", theme.ContentUndoIcon(), tw.handleRestore)
+	tw.purgeBtn = widget.NewButtonWithIcon("Delete Permanently", theme.DeleteIcon(), tw.handlePurge)
+	tw.purgeBtn.Importance = widget.DangerImportance
+	tw.emptyBtn = widget.NewButtonWithIcon("Empty Trash", theme.ContentClearIcon(), tw.handleEmpty)
+	refreshBtn := widget.NewButtonWithIcon("Refresh", theme.ViewRefreshIcon(), func() {
+		go tw.loadEntries(context.Background())
+	})
+
+	toolbar := container.NewHBox(refreshBtn, layout.NewSpacer(), tw.restoreBtn, tw.purgeBtn, tw.emptyBtn)
+	tw.status = widget.NewLabel("Loading trash…")
+	tw.status.Wrapping = fyne.TextWrapWord
+
+	tw.updateButtons()
+	return container.NewBorder(toolbar, container.NewPadded(tw.status), nil, nil, tw.table)
+}
+
+// trashCellText returns the text of column col for a trashed object.
+func trashCellText(e trashEntry, col int) string {
+	switch col {
+	case 0:
+		return e.Original
+	case 1:
+		return e.Deleted.Local().Format("2006-01-02 15:04:05")
+	case 2:
+		return ByteCountSI(e.Size)
+	}
+	return ""
+}
+
+// loadEntries lists the trash folder, newest deletes first.
+func (tw *TrashWindow) loadEntries(ctx context.Context) {
+	folder := tw.conn.TrashFolder()
+	objects, err := listAllObjects(ctx, tw.s3Service, folder)
+	versioning, _ := tw.s3Service.GetBucketVersioning(ctx, tw.conn.Bucket)
+
+	entries := make([]trashEntry, 0, len(objects))
+	for _, obj := range objects {
+		if e, ok := parseTrashEntry(folder, obj); ok {
+			entries = append(entries, e)
+		}
+	}
+	sort.SliceStable(entries, func(i, j int) bool { return entries[i].Deleted.After(entries[j].Deleted) })
+
+	fyne.Do(func() {
+		if err != nil {
+			tw.status.SetText("Failed to load the trash")
+			dialog.ShowError(err, tw.window)
+			return
+		}
+		tw.entries = entries
+		tw.selectedID = -1
+		tw.table.UnselectAll()
+		tw.table.Refresh()
+		tw.updateButtons()
+		tw.status.SetText(trashSummary(entries, folder, versioning == s3.VersioningEnabled))
+	})
+}
+
+func trashSummary(entries []trashEntry, folder string, versioned bool) string {
+	var size int64
+	for _, e := range entries {
+		size += e.Size
+	}
+	summary := fmt.Sprintf("%d objects, %s in %s", len(entries), ByteCountSI(size), folder)
+	if versioned {
+		summary += "\nThis bucket keeps versions, so deletes leave delete markers instead of filling the trash. Restore those objects from the Versions window."
+	}
+	return summary
+}
+
+func (tw *TrashWindow) selected() (trashEntry, bool) {
+	if tw.selectedID < 0 || tw.selectedID >= len(tw.entries) {
+		return trashEntry{}, false
+	}
+	return tw.entries[tw.selectedID], true
+}
+
+func (tw *TrashWindow) updateButtons() {
+	_, ok := tw.selected()
+	for _, btn := range []*widget.Button{tw.restoreBtn, tw.purgeBtn} {
+		if ok {
+			btn.Enable()
+		} else {
+			btn.Disable()
+		}
+	}
+	if len(tw.entries) > 0 {
+		tw.emptyBtn.Enable()
+	} else {
+		tw.emptyBtn.Disable()
+	}
+}
+
+func (tw *TrashWindow) handleRestore() {
+	e, ok := tw.selected()
+	if ok {
+		go tw.restore(context.Background(), e)
+	}
+}
+
+// restore moves e back to its original key.
+func (tw *TrashWindow) restore(ctx context.Context, e trashEntry) {
+	if err := restoreTrashed(ctx, tw.s3Service, e.Key, e.Original); err != nil {
+		fyne.Do(func() { dialog.ShowError(fmt.Errorf("%s: %w", e.Original, err), tw.window) })
+		return
+	}
+	tw.loadEntries(ctx)
+	if tw.onChanged != nil {
+		fyne.Do(tw.onChanged)
+	}
+}
+
+func (tw *TrashWindow) handlePurge() {
+	e, ok := tw.selected()
+	if !ok {
+		return
+	}
+	msg := fmt.Sprintf("Permanently delete %s from the trash?\nThis action cannot be undone.", e.Original)
+	dialog.ShowConfirm("Delete Permanently", msg, func(yes bool) {
+		if yes {
+			go tw.purge(context.Background(), []string{e.Key})
+		}
+	}, tw.window)
+}
+
+func (tw *TrashWindow) handleEmpty() {
+	keys := make([]string, len(tw.entries))
+	for i, e := range tw.entries {
+		keys[i] = e.Key
+	}
+	msg := fmt.Sprintf("Permanently delete all %d objects in the trash?\nThis action cannot be undone.", len(keys))
+	dialog.ShowConfirm("Empty Trash", msg, func(yes bool) {
+		if yes {
+			go tw.purge(context.Background(), keys)
+		}
+	}, tw.window)
+}
+
+// purge deletes keys from the trash for good.
+func (tw *TrashWindow) purge(ctx context.Context, keys []string) {
+	var failures []string
+	for start := 0; start < len(keys); start += s3.MaxDeleteBatch {
+		for _, e := range tw.s3Service.DeleteObjects(ctx, keys[start:min(start+s3.MaxDeleteBatch, len(keys))]) {
+			failures = append(failures, e.Error())
+		}
+	}
+	if len(failures) > 0 {
+		fyne.Do(func() {
+			dialog.ShowInformation("Delete", transferSummaryMessage("Deleted", len(keys)-len(failures), len(keys), failures), tw.window)
+		})
+	}
+	tw.loadEntries(ctx)
+}
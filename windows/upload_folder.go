@@ -0,0 +1,108 @@
+package windows
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/pteich/us3ui/config"
+	"github.com/pteich/us3ui/transfer"
+)
+
+// uploadFilter returns the folder upload patterns saved in the settings or
+// the defaults.
+func (fm *FileManager) uploadFilter() transfer.Filter {
+	if fm.cfg != nil && fm.cfg.Settings.UploadFilters != nil {
+		return transfer.Filter{
+			Include: fm.cfg.Settings.UploadFilters.Include,
+			Exclude: fm.cfg.Settings.UploadFilters.Exclude,
+		}
+	}
+	return transfer.Filter{Exclude: transfer.DefaultExclude}
+}
+
+func (fm *FileManager) saveUploadFilter(filter transfer.Filter) {
+	if fm.cfg == nil {
+		return
+	}
+	fm.cfg.Settings.UploadFilters = &config.UploadFilters{
+		Include: filter.Include,
+		Exclude: filter.Exclude,
+	}
+	if err := fm.cfg.Save(); err != nil {
+		dialog.ShowError(err, fm.window)
+	}
+}
+
+// showUploadFilters asks for the include and exclude patterns before paths,
+// which contain at least one folder, are uploaded.
+func (fm *FileManager) showUploadFilters(paths []string) {
+	filter := fm.uploadFilter()
+
+	include := widget.NewEntry()
+	include.SetPlaceHolder("All files")
+	include.SetText(strings.Join(filter.Include, ", "))
+	exclude := widget.NewEntry()
+	exclude.SetPlaceHolder("Nothing")
+	exclude.SetText(strings.Join(filter.Exclude, ", "))
+
+	what := fmt.Sprintf("%d items", len(paths))
+	if len(paths) == 1 {
+		what = paths[0]
+	}
+	target := uploadKeyPrefix(fm.uploadPrefix())
+	if target == "" {
+		target = "Bucket root"
+	}
+
+	hint := widget.NewLabel("Comma separated glob patterns matched against names and relative paths, e.g. *.jpg, node_modules, build/*")
+	hint.Wrapping = fyne.TextWrapWord
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Upload", widget.NewLabel(what)),
+		widget.NewFormItem("Into", widget.NewLabel(target)),
+		widget.NewFormItem("Include", include),
+		widget.NewFormItem("Exclude", exclude),
+		widget.NewFormItem("", hint),
+	}
+	d := dialog.NewForm("Upload Folder", "Upload", "Cancel", items, func(ok bool) {
+		if !ok {
+			return
+		}
+		filter := transfer.Filter{
+			Include: transfer.ParsePatterns(include.Text),
+			Exclude: transfer.ParsePatterns(exclude.Text),
+		}
+		if err := filter.Validate(); err != nil {
+			dialog.ShowError(err, fm.window)
+			return
+		}
+		fm.saveUploadFilter(filter)
+		fm.uploadPaths(paths, filter)
+	}, fm.window)
+	d.Resize(fyne.NewSize(500, d.MinSize().Height))
+	d.Show()
+}
+
+// collectUploads walks paths and queues the files it finds below keyPrefix.
+func (fm *FileManager) collectUploads(ctx context.Context, paths []string, keyPrefix, basePrefix string, filter transfer.Filter) {
+	files, err := transfer.CollectUploads(paths, keyPrefix, filter)
+	fyne.Do(func() {
+		if err != nil {
+			// Unreadable paths are reported; whatever could be read is
+			// still uploaded.
+			dialog.ShowError(err, fm.window)
+		}
+		if len(files) == 0 {
+			if err == nil {
+				dialog.ShowInformation("Upload", "No files to upload.", fm.window)
+			}
+			return
+		}
+		fm.uploadFiles(ctx, files, basePrefix)
+	})
+}
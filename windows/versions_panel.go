@@ -0,0 +1,301 @@
+package windows
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/theme"
+	"fyne.io/fyne/v2/widget"
+	"github.com/minio/minio-go/v7"
+
+	"github.com/pteich/us3ui/s3"
+	"github.com/pteich/us3ui/transfer"
+)
+
+const versionIDColumnWidth = 300
+
+// VersionsPanel lists the versions and delete markers of one object and lets
+// the user download, restore or permanently delete them.
+type VersionsPanel struct {
+	app        fyne.App
+	s3Service  s3.ObjectStore
+	transfers  *transfer.Manager
+	downloader *transfer.Downloader
+	key        string
+	onChanged  func() // called after the latest version changed
+	window     fyne.Window
+
+	versions    []minio.ObjectInfo
+	selectedID  int
+	table       *widget.Table
+	status      *widget.Label
+	downloadBtn *widget.Button
+	restoreBtn  *widget.Button
+	deleteBtn   *widget.Button
+}
+
+func NewVersionsPanel(a fyne.App, service s3.ObjectStore, transfers *transfer.Manager, downloader *transfer.Downloader, key string, onChanged func()) *VersionsPanel {
+	return &VersionsPanel{
+		app:        a,
+		s3Service:  service,
+		transfers:  transfers,
+		downloader: downloader,
+		key:        key,
+		onChanged:  onChanged,
+		selectedID: -1,
+	}
+}
+
+func (vp *VersionsPanel) Show() {
+	vp.window = vp.app.NewWindow("Versions of " + vp.key)
+	vp.window.Resize(fyne.NewSize(800, 400))
+	vp.window.SetContent(vp.createContent())
+	vp.window.Show()
+
+	go vp.loadVersions(context.Background())
+}
+
+func (vp *VersionsPanel) createContent() fyne.CanvasObject {
+	vp.table = widget.NewTableWithHeaders(
+		func() (int, int) { return len(vp.versions), 4 },
+		func() fyne.CanvasObject {
+			label := widget.NewLabel("")
+			label.Truncation = fyne.TextTruncateEllipsis
+			return label
+		},
+		func(id widget.TableCellID, o fyne.CanvasObject) {
+			label := o.(*widget.Label)
+			if id.Row >= len(vp.versions) {
+				label.SetText("")
+				return
+			}
+			label.SetText(versionCellText(vp.versions[id.Row], id.Col))
+		},
+	)
+	vp.table.ShowHeaderColumn = false
+	vp.table.UpdateHeader = func(id widget.TableCellID, o fyne.CanvasObject) {
+		label := o.(*widget.Label)
+		switch id.Col {
+		case 0:
+			label.SetText("Version ID")
+		case 1:
+			label.SetText("Size")
+		case 2:
+			label.SetText("Last Modified")
+		case 3:
+			label.SetText("")
+		}
+	}
+	vp.table.SetColumnWidth(0, versionIDColumnWidth)
+	vp.table.SetColumnWidth(1, sizeColumnWidth+30)
+	vp.table.SetColumnWidth(2, dateColumnWidth)
+	vp.table.SetColumnWidth(3, 80)
+	vp.table.OnSelected = func(id widget.TableCellID) {
+		vp.selectedID = id.Row
+		vp.updateButtons()
+	}
+	vp.table.OnUnselected = func(id widget.TableCellID) {
+		vp.selectedID = -1
+		vp.updateButtons()
+	}
+
+	vp.downloadBtn = widget.NewButtonWithIcon("Download", theme.DownloadIcon(), vp.handleDownload)
+	vp.restoreBtn = widget.NewButtonWithIcon("Restore as Latest", theme.HistoryIcon(), vp.handleRestore)
+	vp.deleteBtn = widget.NewButtonWithIcon("Delete Permanently", theme.DeleteIcon(), vp.handleDelete)
+	vp.deleteBtn.Importance = widget.DangerImportance
+	refreshBtn := widget.NewButtonWithIcon("Refresh", theme.ViewRefreshIcon(), func() {
+		go vp.loadVersions(context.Background())
+	})
+
+	toolbar := container.NewHBox(refreshBtn, layout.NewSpacer(), vp.downloadBtn, vp.restoreBtn, vp.deleteBtn)
+	vp.status = widget.NewLabel("Loading versions…")
+
+	vp.updateButtons()
+	return container.NewBorder(toolbar, container.NewPadded(vp.status), nil, nil, vp.table)
+}
+
+// versionCellText returns the text of column col for a version.
+func versionCellText(v minio.ObjectInfo, col int) string {
+	switch col {
+	case 0:
+		return v.VersionID
+	case 1:
+		if v.IsDeleteMarker {
+			return "Delete marker"
+		}
+		return ByteCountSI(v.Size)
+	case 2:
+		return v.LastModified.Format("2006-01-02 15:04:05")
+	case 3:
+		if v.IsLatest {
+			return "Latest"
+		}
+	}
+	return ""
+}
+
+func (vp *VersionsPanel) loadVersions(ctx context.Context) {
+	versions, err := vp.s3Service.ListObjectVersions(ctx, vp.key)
+	fyne.Do(func() {
+		if err != nil {
+			vp.status.SetText("Failed to load versions")
+			dialog.ShowError(err, vp.window)
+			return
+		}
+		vp.versions = versions
+		vp.selectedID = -1
+		vp.table.UnselectAll()
+		vp.table.Refresh()
+		vp.updateButtons()
+		vp.status.SetText(versionsSummary(versions))
+	})
+}
+
+func versionsSummary(versions []minio.ObjectInfo) string {
+	markers := 0
+	for _, v := range versions {
+		if v.IsDeleteMarker {
+			markers++
+		}
+	}
+	summary := fmt.Sprintf("%d versions", len(versions)-markers)
+	if markers > 0 {
+		summary += fmt.Sprintf(", %d delete markers", markers)
+	}
+	return summary
+}
+
+func (vp *VersionsPanel) selected() (minio.ObjectInfo, bool) {
+	if vp.selectedID < 0 || vp.selectedID >= len(vp.versions) {
+		return minio.ObjectInfo{}, false
+	}
+	return vp.versions[vp.selectedID], true
+}
+
+func (vp *VersionsPanel) updateButtons() {
+	v, ok := vp.selected()
+	for _, btn := range []*widget.Button{vp.downloadBtn, vp.restoreBtn, vp.deleteBtn} {
+		btn.Disable()
+	}
+	if !ok {
+		return
+	}
+	// A delete marker has no content; removing it brings the object back.
+	vp.deleteBtn.Enable()
+	if v.IsDeleteMarker {
+		return
+	}
+	vp.downloadBtn.Enable()
+	if !v.IsLatest {
+		vp.restoreBtn.Enable()
+	}
+}
+
+func (vp *VersionsPanel) handleDownload() {
+	v, ok := vp.selected()
+	if !ok || v.IsDeleteMarker {
+		return
+	}
+
+	fd := dialog.NewFileSave(func(writer fyne.URIWriteCloser, err error) {
+		if err != nil || writer == nil {
+			return
+		}
+		// The download writes the file itself via a ".part" file.
+		writer.Close()
+		vp.downloadVersion(v, writer.URI().Path())
+	}, vp.window)
+	fd.SetFileName(versionFileName(vp.key, v))
+	fd.Show()
+}
+
+// downloadVersion queues the download of version v into filePath.
+func (vp *VersionsPanel) downloadVersion(v minio.ObjectInfo, filePath string) {
+	downloader := vp.downloader
+	key := vp.key
+	vp.transfers.Add(transfer.Task{
+		Kind:   transfer.KindDownload,
+		Name:   fmt.Sprintf("%s (%s)", key, v.VersionID),
+		Source: key,
+		Target: filePath,
+		Size:   v.Size,
+		Run: func(ctx context.Context, onProgress func(transfer.Progress)) error {
+			return downloader.DownloadVersion(ctx, key, v.VersionID, v.Size, filePath, onProgress)
+		},
+		OnFinish: func(err error) {
+			if err != nil {
+				fyne.Do(func() { dialog.ShowError(err, vp.window) })
+			}
+		},
+	})
+}
+
+// versionFileName suggests a local name for a version: the latest version
+// keeps the object name, older ones get the version ID appended.
+func versionFileName(key string, v minio.ObjectInfo) string {
+	name := path.Base(key)
+	if v.IsLatest || v.VersionID == "" {
+		return name
+	}
+	ext := path.Ext(name)
+	return fmt.Sprintf("%s (%s)%s", strings.TrimSuffix(name, ext), v.VersionID, ext)
+}
+
+func (vp *VersionsPanel) handleRestore() {
+	v, ok := vp.selected()
+	if !ok {
+		return
+	}
+	msg := fmt.Sprintf("Restore version %s as the latest version of %s?\nA copy of it becomes the current version; no version is removed.", v.VersionID, vp.key)
+	dialog.ShowConfirm("Restore Version", msg, func(yes bool) {
+		if yes {
+			go vp.restoreVersion(context.Background(), v.VersionID)
+		}
+	}, vp.window)
+}
+
+func (vp *VersionsPanel) restoreVersion(ctx context.Context, versionID string) {
+	if err := vp.s3Service.RestoreObjectVersion(ctx, vp.key, versionID); err != nil {
+		fyne.Do(func() { dialog.ShowError(err, vp.window) })
+		return
+	}
+	vp.changed(ctx)
+}
+
+func (vp *VersionsPanel) handleDelete() {
+	v, ok := vp.selected()
+	if !ok {
+		return
+	}
+	what := "version " + v.VersionID
+	if v.IsDeleteMarker {
+		what = "delete marker " + v.VersionID
+	}
+	msg := fmt.Sprintf("Permanently delete %s of %s?\nThis action cannot be undone.", what, vp.key)
+	dialog.ShowConfirm("Delete Version", msg, func(yes bool) {
+		if yes {
+			go vp.deleteVersion(context.Background(), v.VersionID)
+		}
+	}, vp.window)
+}
+
+func (vp *VersionsPanel) deleteVersion(ctx context.Context, versionID string) {
+	if err := vp.s3Service.DeleteObjectVersion(ctx, vp.key, versionID); err != nil {
+		fyne.Do(func() { dialog.ShowError(err, vp.window) })
+		return
+	}
+	vp.changed(ctx)
+}
+
+func (vp *VersionsPanel) changed(ctx context.Context) {
+	vp.loadVersions(ctx)
+	if vp.onChanged != nil {
+		fyne.Do(vp.onChanged)
+	}
+}
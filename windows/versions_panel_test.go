@@ -0,0 +1,114 @@
+package windows
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	fynetest "fyne.io/fyne/v2/test"
+	"github.com/minio/minio-go/v7"
+
+	"github.com/pteich/us3ui/s3/memstore"
+	"github.com/pteich/us3ui/transfer"
+)
+
+func newTestVersionsPanel(t *testing.T, store *memstore.Store, key string) (*VersionsPanel, *int) {
+	t.Helper()
+
+	onChange, changed := countCalls()
+	transfers := transfer.NewManager(1)
+	vp := NewVersionsPanel(newTestApp(t), store, transfers, transfer.NewDownloader(store, transfer.NewStateStore(t.TempDir())), key, onChange)
+	vp.window = fynetest.NewWindow(vp.createContent())
+	vp.loadVersions(context.Background())
+	return vp, changed
+}
+
+func TestVersionsPanelRestoreAndDelete(t *testing.T) {
+	store := memstore.New("bucket")
+	ctx := context.Background()
+	if err := store.SetBucketVersioning(ctx, "bucket", true); err != nil {
+		t.Fatal(err)
+	}
+	store.Put("report.csv", []byte("old"))
+	store.Put("report.csv", []byte("new"))
+
+	vp, changed := newTestVersionsPanel(t, store, "report.csv")
+	if len(vp.versions) != 2 || vp.status.Text != "2 versions" {
+		t.Fatalf("versions = %v, status %q", vp.versions, vp.status.Text)
+	}
+
+	vp.restoreVersion(ctx, vp.versions[1].VersionID)
+	if data, _ := store.Get("report.csv"); string(data) != "old" {
+		t.Errorf("latest content after restore = %q, want old", data)
+	}
+	if len(vp.versions) != 3 || *changed != 1 {
+		t.Errorf("after restore: %d versions, %d change notifications", len(vp.versions), *changed)
+	}
+
+	vp.deleteVersion(ctx, vp.versions[0].VersionID)
+	if data, _ := store.Get("report.csv"); string(data) != "new" {
+		t.Errorf("latest content after deleting the restored copy = %q, want new", data)
+	}
+	if len(vp.versions) != 2 {
+		t.Errorf("versions after delete = %d, want 2", len(vp.versions))
+	}
+}
+
+func TestVersionsPanelButtons(t *testing.T) {
+	store := memstore.New("bucket")
+	ctx := context.Background()
+	if err := store.SetBucketVersioning(ctx, "bucket", true); err != nil {
+		t.Fatal(err)
+	}
+	store.Put("a.txt", []byte("a"))
+	if err := store.DeleteObject(ctx, "a.txt"); err != nil {
+		t.Fatal(err)
+	}
+
+	vp, _ := newTestVersionsPanel(t, store, "a.txt")
+	if vp.status.Text != "1 versions, 1 delete markers" {
+		t.Errorf("status = %q", vp.status.Text)
+	}
+
+	vp.selectedID = 0 // the delete marker
+	vp.updateButtons()
+	if !vp.downloadBtn.Disabled() || !vp.restoreBtn.Disabled() || vp.deleteBtn.Disabled() {
+		t.Errorf("delete marker: only delete should be enabled")
+	}
+
+	vp.selectedID = 1
+	vp.updateButtons()
+	if vp.downloadBtn.Disabled() || vp.restoreBtn.Disabled() {
+		t.Errorf("older version: download and restore should be enabled")
+	}
+}
+
+func TestDownloadVersionQueuesTransfer(t *testing.T) {
+	store := memstore.New("bucket")
+	store.Put("notes.txt", []byte("hello"))
+
+	vp, _ := newTestVersionsPanel(t, store, "notes.txt")
+	dest := filepath.Join(t.TempDir(), versionFileName("notes.txt", vp.versions[0]))
+	vp.downloadVersion(vp.versions[0], dest)
+	vp.transfers.Wait()
+
+	data, err := os.ReadFile(dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "hello" || filepath.Base(dest) != "notes.txt" {
+		t.Errorf("downloaded %q to %s", data, dest)
+	}
+}
+
+func TestVersionFileName(t *testing.T) {
+	old := minio.ObjectInfo{VersionID: "v2"}
+	if got := versionFileName("docs/report.csv", old); got != "report (v2).csv" {
+		t.Errorf("versionFileName = %q", got)
+	}
+	latest := minio.ObjectInfo{VersionID: "v3", IsLatest: true}
+	if got := versionFileName("docs/report.csv", latest); got != "report.csv" {
+		t.Errorf("versionFileName(latest) = %q", got)
+	}
+}